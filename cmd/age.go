@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/chamber/v2/store"
+)
+
+// parseAge parses a duration like time.ParseDuration, additionally accepting
+// whole days, e.g. 90d
+func parseAge(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil || days < 0 {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return d, nil
+}
+
+// staleSecret is a secret that wasn't rotated within the maximum age
+type staleSecret struct {
+	service string
+	key     string
+	age     time.Duration
+}
+
+func (s staleSecret) String() string {
+	return fmt.Sprintf("%s/%s was last rotated %d days ago", s.service, s.key, int(s.age/(24*time.Hour)))
+}
+
+// findStaleSecrets returns the secrets of services last written more than
+// maxAge before now. Secrets of backends that don't record when they were
+// written are never stale.
+func findStaleSecrets(secretStore store.Store, services []string, maxAge time.Duration, now time.Time) ([]staleSecret, error) {
+	var stale []staleSecret
+	for _, service := range services {
+		if _, pin, _ := store.SplitVersionPin(service); pin != nil {
+			// an older version was asked for, however old it is
+			continue
+		}
+		secrets, err := secretStore.List(service, false)
+		if err != nil {
+			return nil, err
+		}
+		for _, secret := range secrets {
+			if secret.Meta.Created.IsZero() {
+				// the backend doesn't know when the secret was written
+				continue
+			}
+			if age := now.Sub(secret.Meta.Created); age > maxAge {
+				stale = append(stale, staleSecret{service: service, key: key(secret.Meta.Key), age: age})
+			}
+		}
+	}
+	return stale, nil
+}
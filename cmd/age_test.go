@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAge(t *testing.T) {
+	t.Run("Should accept days", func(t *testing.T) {
+		d, err := parseAge("90d")
+		assert.Nil(t, err)
+		assert.Equal(t, 90*24*time.Hour, d)
+	})
+
+	t.Run("Should accept Go durations", func(t *testing.T) {
+		d, err := parseAge("36h")
+		assert.Nil(t, err)
+		assert.Equal(t, 36*time.Hour, d)
+	})
+
+	t.Run("Should reject invalid ages", func(t *testing.T) {
+		for _, s := range []string{"d", "-1d", "ninety days", "-5h"} {
+			_, err := parseAge(s)
+			assert.Error(t, err, s)
+		}
+	})
+}
+
+func TestFindStaleSecrets(t *testing.T) {
+	created := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	s := newFakeStore()
+	s.versions[store.SecretId{Service: "app", Key: "db_password"}] = []fakeVersion{{value: "hunter2", created: created}}
+	s.versions[store.SecretId{Service: "app", Key: "static"}] = []fakeVersion{{value: "value"}}
+
+	t.Run("Should report secrets older than the maximum age", func(t *testing.T) {
+		stale, err := findStaleSecrets(s, []string{"app"}, 24*time.Hour, created.Add(48*time.Hour))
+		assert.Nil(t, err)
+		assert.Len(t, stale, 1)
+		assert.Equal(t, "app/db_password was last rotated 2 days ago", stale[0].String())
+	})
+
+	t.Run("Should accept recently rotated secrets", func(t *testing.T) {
+		stale, err := findStaleSecrets(s, []string{"app"}, 24*time.Hour, created.Add(time.Hour))
+		assert.Nil(t, err)
+		assert.Empty(t, stale)
+	})
+}
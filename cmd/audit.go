@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+)
+
+var (
+	auditUnused   bool
+	auditSince    string
+	auditInsecure bool
+
+	// auditCmd represents the audit command
+	auditCmd = &cobra.Command{
+		Use:   "audit <service...>",
+		Short: "Show when workloads last consumed the keys of services",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  audit,
+		Example: `
+	$ chamber --usage-sink s3://audit-bucket/chamber audit --unused --since 90d app/prod
+	Service   Key
+	app/prod  legacy_api_token
+
+	$ chamber audit --insecure-params app/prod
+	Service   Key        Type
+	app/prod  log_level  String
+`,
+	}
+)
+
+func init() {
+	auditCmd.Flags().BoolVarP(&auditUnused, "unused", "", false, "Only list keys no workload consumed")
+	auditCmd.Flags().StringVarP(&auditSince, "since", "", "90d", "How far back to look for usage, e.g. 90d")
+	auditCmd.Flags().BoolVarP(&auditInsecure, "insecure-params", "", false, "Instead of usage, list the SSM parameters that are String instead of SecureString, apart from --plaintext-keys")
+	RootCmd.AddCommand(auditCmd)
+}
+
+// getUsageSink returns the sink configured with --usage-sink, or nil
+func getUsageSink() (*store.S3UsageSink, error) {
+	location := usageSinkFlag
+	if usageSinkEnvVarValue := os.Getenv(UsageSinkEnvVar); !RootCmd.PersistentFlags().Changed("usage-sink") && usageSinkEnvVarValue != "" {
+		location = usageSinkEnvVarValue
+	}
+	if location == "" {
+		return nil, nil
+	}
+	if err := configureSessions(); err != nil {
+		return nil, err
+	}
+	return store.NewS3UsageSink(numRetries, location)
+}
+
+// usageRecorder remembers the keys of every service listed for injection
+type usageRecorder struct {
+	store.Store
+	keys map[string][]string
+}
+
+func newUsageRecorder(s store.Store) *usageRecorder {
+	return &usageRecorder{Store: s, keys: map[string][]string{}}
+}
+
+func (r *usageRecorder) ListRaw(service string) ([]store.RawSecret, error) {
+	rawSecrets, err := r.Store.ListRaw(service)
+	// a key with a version pin is used from its own service
+	if name, pin, _ := store.SplitVersionPin(service); pin != nil {
+		if id, err := pinnedId(name); err == nil {
+			service = id.Service
+		}
+	}
+	for _, rawSecret := range rawSecrets {
+		r.keys[service] = append(r.keys[service], key(rawSecret.Key))
+	}
+	return rawSecrets, err
+}
+
+// record stores what was consumed, warning instead of failing so that an
+// unavailable sink never keeps a workload from starting
+func (r *usageRecorder) record(sink *store.S3UsageSink) {
+	host, _ := os.Hostname()
+	now := time.Now()
+	for service, keys := range r.keys {
+		err := sink.Record(store.UsageRecord{Service: service, Keys: keys, Time: now, Host: host})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: unable to record usage of %s: %s\n", service, err)
+		}
+	}
+}
+
+// lastUsed returns when each key of service was last consumed
+func lastUsed(records []store.UsageRecord, service string) map[string]time.Time {
+	used := map[string]time.Time{}
+	for _, r := range records {
+		if r.Service != service {
+			continue
+		}
+		for _, k := range r.Keys {
+			if r.Time.After(used[k]) {
+				used[k] = r.Time
+			}
+		}
+	}
+	return used
+}
+
+// insecureParams returns the native attributes of the keys of service stored
+// as String parameters, apart from the ones --plaintext-keys meant to be
+func insecureParams(secretStore store.Store, service string) (map[string]store.NativeAttributes, error) {
+	native, err := store.ListNative(secretStore, service)
+	if err != nil {
+		return nil, err
+	}
+	insecure := map[string]store.NativeAttributes{}
+	for k, a := range native {
+		if a.Type == store.ParameterTypePlaintext && !store.IsPlaintextKey(plaintextKeys, store.SecretId{Service: service, Key: k}) {
+			insecure[k] = a
+		}
+	}
+	return insecure, nil
+}
+
+// nativeKeys returns the keys of attributes, sorted
+func nativeKeys(attributes map[string]store.NativeAttributes) []string {
+	keys := make([]string, 0, len(attributes))
+	for k := range attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// auditInsecureParams lists the String parameters of services, failing if
+// there are any so CI can enforce SecureString
+func auditInsecureParams(out io.Writer, secretStore store.Store, services []string) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, '\t', 0)
+	fmt.Fprintln(w, "Service\tKey\tType")
+	found := 0
+	for _, service := range services {
+		insecure, err := insecureParams(secretStore, service)
+		if err != nil {
+			w.Flush()
+			return errors.Wrap(err, "Failed to describe parameters")
+		}
+		keys := nativeKeys(insecure)
+		for _, k := range keys {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", service, k, store.ParameterTypePlaintext)
+		}
+		found += len(keys)
+	}
+	w.Flush()
+	if found > 0 {
+		return fmt.Errorf("%d parameters aren't SecureString; convert them with chamber fix --reencrypt", found)
+	}
+	return nil
+}
+
+func audit(cmd *cobra.Command, args []string) error {
+	services, err := expandServices(args)
+	if err != nil {
+		return errors.Wrap(err, "Failed to expand service")
+	}
+	for i, service := range services {
+		services[i] = strings.ToLower(service)
+		if err := validateService(services[i]); err != nil {
+			return errors.Wrap(err, "Failed to validate service")
+		}
+	}
+	since, err := parseAge(auditSince)
+	if err != nil {
+		return errors.Wrap(err, "Failed to parse --since")
+	}
+
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", "audit").
+				Set("chamber-version", chamberVersion).
+				Set("services", services).
+				Set("backend", backend).
+				Set("unused", auditUnused).
+				Set("insecure-params", auditInsecure),
+		})
+	}
+
+	if auditInsecure {
+		secretStore, err := getSecretStore()
+		if err != nil {
+			return errors.Wrap(err, "Failed to get secret store")
+		}
+		return auditInsecureParams(os.Stdout, secretStore, services)
+	}
+
+	sink, err := getUsageSink()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get usage sink")
+	}
+	if sink == nil {
+		return errors.New("Must set --usage-sink or $CHAMBER_USAGE_SINK to audit usage")
+	}
+	secretStore, err := getSecretStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get secret store")
+	}
+
+	now := time.Now()
+	records, err := sink.Since(now.Add(-since), now)
+	if err != nil {
+		return errors.Wrap(err, "Failed to read usage records")
+	}
+
+	owners := serviceOwners(secretStore)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, '\t', 0)
+	defer w.Flush()
+	if auditUnused {
+		fmt.Fprintln(w, "Service\tKey\tOwner")
+	} else {
+		fmt.Fprintln(w, "Service\tKey\tLastUsed\tOwner")
+	}
+	for _, service := range services {
+		secrets, err := secretStore.List(service, false)
+		if err != nil {
+			return errors.Wrap(err, "Failed to list store contents")
+		}
+		metadata, err := store.ListKeyMetadata(secretStore, service)
+		if err != nil {
+			return errors.Wrap(err, "Failed to list metadata")
+		}
+		deprecated := []string{}
+		for k, m := range metadata {
+			if m.Removable(now) {
+				deprecated = append(deprecated, k)
+			}
+		}
+		sort.Strings(deprecated)
+		for _, k := range deprecated {
+			fmt.Fprintf(os.Stderr, "warning: %s/%s is past the removal date of its deprecation, %s; gc deletes it\n", service, k, metadata[k].Deprecation.RemoveAfter.Format("2006-01-02"))
+		}
+		used := lastUsed(records, service)
+		owner := ownerName(owners, service)
+		keys := make([]string, 0, len(secrets))
+		for _, secret := range secrets {
+			keys = append(keys, key(secret.Meta.Key))
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			t, ok := used[k]
+			switch {
+			case auditUnused && !ok:
+				fmt.Fprintf(w, "%s\t%s\t%s\n", service, k, owner)
+			case !auditUnused && ok:
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", service, k, t.Local().Format(ShortTimeFormat), owner)
+			case !auditUnused:
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", service, k, "never", owner)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLastUsed(t *testing.T) {
+	day := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	records := []store.UsageRecord{
+		{Service: "app", Keys: []string{"db_url", "api_token"}, Time: day},
+		{Service: "app", Keys: []string{"db_url"}, Time: day.Add(24 * time.Hour)},
+		{Service: "other", Keys: []string{"smtp_password"}, Time: day},
+	}
+
+	used := lastUsed(records, "app")
+	assert.Equal(t, map[string]time.Time{"db_url": day.Add(24 * time.Hour), "api_token": day}, used)
+}
+
+func TestUsageRecorder(t *testing.T) {
+	s := newUsageRecorder(store.NewStaticStore(map[string]map[string]string{
+		"app": {"db_url": "postgres://db", "api_token": "token"},
+	}))
+
+	_, err := s.ListRaw("app")
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"db_url", "api_token"}, s.keys["app"])
+}
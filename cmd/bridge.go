@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"github.com/segmentio/chamber/v2/store"
+)
+
+// bridgeFilter replaces the values of listings that are ssm:// or
+// secretsmanager:// URIs with the values they name, so exec --bridge can
+// inject secrets kept in the other backend without duplicating them
+type bridgeFilter struct {
+	store.Store
+	resolve func(uris []string) (map[string]string, error)
+}
+
+func newBridgeFilter(s store.Store, resolve func(uris []string) (map[string]string, error)) *bridgeFilter {
+	return &bridgeFilter{Store: s, resolve: resolve}
+}
+
+func (f *bridgeFilter) ListRaw(service string) ([]store.RawSecret, error) {
+	rawSecrets, err := f.Store.ListRaw(service)
+	if err != nil {
+		return nil, err
+	}
+
+	var uris []string
+	for _, rawSecret := range rawSecrets {
+		if _, ok, err := store.BridgedParameter(rawSecret.Value); err != nil {
+			return nil, err
+		} else if ok {
+			uris = append(uris, rawSecret.Value)
+		}
+	}
+	if len(uris) == 0 {
+		return rawSecrets, nil
+	}
+	values, err := f.resolve(uris)
+	if err != nil {
+		return nil, err
+	}
+	for i, rawSecret := range rawSecrets {
+		if value, ok := values[rawSecret.Value]; ok {
+			rawSecrets[i].Value = value
+			store.RegisterSecretValue(value)
+		}
+	}
+	return rawSecrets, nil
+}
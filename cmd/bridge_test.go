@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBridgeFilter(t *testing.T) {
+	backing := store.NewStaticStore(map[string]map[string]string{"app": {
+		"db_password": "ssm:///shared/db_password",
+		"api_key":     "secretsmanager://prod/api",
+		"log_level":   "debug",
+	}})
+
+	t.Run("Should replace URIs with the values they name", func(t *testing.T) {
+		var asked []string
+		f := newBridgeFilter(backing, func(uris []string) (map[string]string, error) {
+			asked = uris
+			return map[string]string{"ssm:///shared/db_password": "hunter22", "secretsmanager://prod/api": "key"}, nil
+		})
+		rawSecrets, err := f.ListRaw("app")
+		assert.Nil(t, err)
+		values := map[string]string{}
+		for _, rawSecret := range rawSecrets {
+			values[key(rawSecret.Key)] = rawSecret.Value
+		}
+		assert.Equal(t, map[string]string{"db_password": "hunter22", "api_key": "key", "log_level": "debug"}, values)
+		assert.ElementsMatch(t, []string{"ssm:///shared/db_password", "secretsmanager://prod/api"}, asked)
+	})
+
+	t.Run("Should fail when URIs can't be resolved", func(t *testing.T) {
+		f := newBridgeFilter(backing, func(uris []string) (map[string]string, error) {
+			return nil, errors.New("access denied")
+		})
+		_, err := f.ListRaw("app")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+
+	// ColorsEnvVar overrides the colors of the default theme, like
+	// added=green,expired=red+bold
+	ColorsEnvVar = "CHAMBER_COLORS"
+)
+
+// The roles colored rows play in tables
+const (
+	headerRole     = "header"
+	addedRole      = "added"
+	changedRole    = "changed"
+	expiredRole    = "expired"
+	deprecatedRole = "deprecated"
+)
+
+var colorFlag string
+
+// theme maps the roles of rows to the SGR parameters coloring them
+type theme map[string]string
+
+var defaultTheme = theme{
+	headerRole:     "1",
+	addedRole:      "32",
+	changedRole:    "33",
+	expiredRole:    "31",
+	deprecatedRole: "35",
+}
+
+// sgrAttributes are the names CHAMBER_COLORS accepts for SGR parameters
+var sgrAttributes = map[string]string{
+	"bold":      "1",
+	"dim":       "2",
+	"underline": "4",
+	"black":     "30",
+	"red":       "31",
+	"green":     "32",
+	"yellow":    "33",
+	"blue":      "34",
+	"magenta":   "35",
+	"cyan":      "36",
+	"white":     "37",
+}
+
+func init() {
+	RootCmd.PersistentFlags().StringVarP(&colorFlag, "color", "", ColorAuto, "When to color tables: auto (only on terminals and without $NO_COLOR), always or never; the colors are set by $CHAMBER_COLORS")
+}
+
+// parseTheme overrides the roles of defaultTheme listed in spec, like
+// added=green,expired=red+bold,header=none. Attributes are names of
+// sgrAttributes or SGR parameters.
+func parseTheme(spec string) (theme, error) {
+	t := theme{}
+	for role, sgr := range defaultTheme {
+		t[role] = sgr
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		role := strings.TrimSpace(parts[0])
+		if _, ok := defaultTheme[role]; !ok {
+			return nil, fmt.Errorf("unknown role %q, expected one of %s", role, strings.Join(themeRoles(), ", "))
+		}
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("missing color of %s", role)
+		}
+		value := strings.TrimSpace(parts[1])
+		if value == "none" {
+			t[role] = ""
+			continue
+		}
+		var params []string
+		for _, attr := range strings.Split(value, "+") {
+			if sgr, ok := sgrAttributes[attr]; ok {
+				params = append(params, sgr)
+			} else if n, err := strconv.Atoi(attr); err == nil && n >= 0 && n < 256 {
+				params = append(params, attr)
+			} else {
+				return nil, fmt.Errorf("unknown color %q of %s", attr, role)
+			}
+		}
+		t[role] = strings.Join(params, ";")
+	}
+	return t, nil
+}
+
+func themeRoles() []string {
+	roles := make([]string, 0, len(defaultTheme))
+	for role := range defaultTheme {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// colorTheme returns the theme to color tables written to f with, or nil to
+// leave them uncolored
+func colorTheme(f *os.File) theme {
+	if !useColor(f) {
+		return nil
+	}
+	t, err := parseTheme(os.Getenv(ColorsEnvVar))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: ignoring $%s: %s\n", ColorsEnvVar, err)
+		return defaultTheme
+	}
+	return t
+}
+
+func useColor(f *os.File) bool {
+	if porcelain != "" {
+		return false
+	}
+	switch colorFlag {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	// https://no-color.org
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return isTerminal(f)
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorize wraps line in the SGR parameters of role, if any
+func (t theme) colorize(role, line string) string {
+	sgr := t[role]
+	if sgr == "" {
+		return line
+	}
+	return "\x1b[" + sgr + "m" + line + "\x1b[0m"
+}
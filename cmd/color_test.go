@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTheme(t *testing.T) {
+	t.Run("Should keep the default theme without overrides", func(t *testing.T) {
+		th, err := parseTheme("")
+		assert.Nil(t, err)
+		assert.Equal(t, defaultTheme, th)
+	})
+
+	t.Run("Should override roles by name or SGR parameter", func(t *testing.T) {
+		th, err := parseTheme("added=blue, expired=red+bold,changed=93,header=none")
+		assert.Nil(t, err)
+		assert.Equal(t, "34", th[addedRole])
+		assert.Equal(t, "31;1", th[expiredRole])
+		assert.Equal(t, "93", th[changedRole])
+		assert.Equal(t, "", th[headerRole])
+		assert.Equal(t, defaultTheme[deprecatedRole], th[deprecatedRole])
+	})
+
+	t.Run("Should reject unknown roles and colors", func(t *testing.T) {
+		_, err := parseTheme("removed=red")
+		assert.NotNil(t, err)
+		_, err = parseTheme("added=pink")
+		assert.NotNil(t, err)
+		_, err = parseTheme("added")
+		assert.NotNil(t, err)
+	})
+}
+
+func TestColoredTable(t *testing.T) {
+	var buf bytes.Buffer
+	w := newTableWriter(&buf)
+	w.theme = theme{headerRole: "1", expiredRole: "31"}
+	w.Header("Key", "Version")
+	fmt.Fprintf(w, "%s\t%d\n", "a_long_key", 1)
+	w.Role(expiredRole)
+	fmt.Fprintf(w, "%s\t%d\n", "old", 2)
+	w.Flush()
+
+	t.Run("Should color whole rows after aligning them", func(t *testing.T) {
+		assert.Equal(t, "\x1b[1mKey\t\tVersion\x1b[0m\na_long_key\t1\n\x1b[31mold\t\t2\x1b[0m\n", buf.String())
+	})
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// completionCmd represents the completion command
+var completionCmd = &cobra.Command{
+	Use:       "completion <bash|zsh>",
+	Short:     "Print a shell completion script",
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"bash", "zsh"},
+	RunE:      completion,
+	Example: `
+	$ source <(chamber completion bash)
+`,
+}
+
+// bashCompletionFunction completes the value of chamber write with the values
+// allowed by chamber enum
+const bashCompletionFunction = `
+__custom_func() {
+    case ${last_command} in
+        chamber_write)
+            if [[ ${#nouns[@]} -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "$(chamber enum "${nouns[0]}" "${nouns[1]}" 2>/dev/null)" -- "$cur") )
+            fi
+            ;;
+    esac
+}
+`
+
+func init() {
+	RootCmd.BashCompletionFunction = bashCompletionFunction
+	RootCmd.AddCommand(completionCmd)
+}
+
+func completion(cmd *cobra.Command, args []string) error {
+	switch args[0] {
+	case "bash":
+		return RootCmd.GenBashCompletion(os.Stdout)
+	case "zsh":
+		return RootCmd.GenZshCompletion(os.Stdout)
+	default:
+		return fmt.Errorf("unsupported shell %s", args[0])
+	}
+}
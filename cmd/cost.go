@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+)
+
+// Monthly list prices in USD, as of writing, in us-east-1
+const (
+	ssmAdvancedParameterPrice = 0.05
+	ssmAdvancedAPIPrice       = 0.05 / 10000
+	kmsRequestPrice           = 0.03 / 10000
+	secretsManagerSecretPrice = 0.40
+	secretsManagerAPIPrice    = 0.05 / 10000
+)
+
+const (
+	// ssmStandardParameterMaxSize is the largest value of a standard parameter
+	ssmStandardParameterMaxSize = 4096
+
+	// ssmParametersPerPage is how many parameters one call reads by path
+	ssmParametersPerPage = 10
+)
+
+var (
+	costReads int
+
+	// costCmd represents the cost command
+	costCmd = &cobra.Command{
+		Use:   "cost <service...>",
+		Short: "Estimate the monthly cost of services in SSM and in Secrets Manager",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  cost,
+		Example: `
+	$ chamber --usage-sink s3://audit-bucket/chamber cost app/prod
+	Service   Keys  Advanced  Reads  SSM    SecretsManager  SecretsManagerJSON
+	app/prod  42    1         8640   $1.35  $18.61          $0.44
+`,
+	}
+)
+
+func init() {
+	costCmd.Flags().IntVarP(&costReads, "reads", "", -1, "Reads of each service per month; default is the reads recorded by --usage-sink over the last 30 days")
+	RootCmd.AddCommand(costCmd)
+}
+
+// costInput describes the size and use of a service
+type costInput struct {
+	keys      int
+	advanced  int
+	encrypted int
+	reads     int
+}
+
+// costEstimate is the monthly cost of a service in each layout
+type costEstimate struct {
+	// ssm is the cost of the service as SSM parameters, as chamber stores it
+	ssm float64
+
+	// secretsManager is the cost of one Secrets Manager secret per key
+	secretsManager float64
+
+	// secretsManagerJSON is the cost of one Secrets Manager secret holding
+	// all the keys of the service as JSON
+	secretsManagerJSON float64
+}
+
+func (e *costEstimate) add(o costEstimate) {
+	e.ssm += o.ssm
+	e.secretsManager += o.secretsManager
+	e.secretsManagerJSON += o.secretsManagerJSON
+}
+
+// estimateCost prices reading every key of a service c.reads times. Standard
+// SSM parameters and their API calls are free, but advanced ones (over 4 KB)
+// aren't, and each SecureString is decrypted with a KMS request.
+func estimateCost(c costInput) costEstimate {
+	reads := float64(c.reads)
+	pages := float64((c.keys + ssmParametersPerPage - 1) / ssmParametersPerPage)
+
+	var e costEstimate
+	e.ssm = float64(c.advanced)*ssmAdvancedParameterPrice + reads*float64(c.encrypted)*kmsRequestPrice
+	if c.advanced > 0 {
+		e.ssm += reads * pages * ssmAdvancedAPIPrice
+	}
+	e.secretsManager = float64(c.keys)*secretsManagerSecretPrice + reads*float64(c.keys)*secretsManagerAPIPrice
+	if c.keys > 0 {
+		e.secretsManagerJSON = secretsManagerSecretPrice + reads*secretsManagerAPIPrice
+	}
+	return e
+}
+
+func cost(cmd *cobra.Command, args []string) error {
+	services, err := expandServices(args)
+	if err != nil {
+		return errors.Wrap(err, "Failed to expand service")
+	}
+	for i, service := range services {
+		services[i] = strings.ToLower(service)
+		if err := validateService(services[i]); err != nil {
+			return errors.Wrap(err, "Failed to validate service")
+		}
+	}
+
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", "cost").
+				Set("chamber-version", chamberVersion).
+				Set("services", services).
+				Set("backend", backend),
+		})
+	}
+
+	secretStore, err := getSecretStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get secret store")
+	}
+
+	var records []store.UsageRecord
+	if costReads < 0 {
+		sink, err := getUsageSink()
+		if err != nil {
+			return errors.Wrap(err, "Failed to get usage sink")
+		}
+		if sink == nil {
+			fmt.Fprintln(os.Stderr, "warning: without --reads or --usage-sink, only storage is priced")
+		} else {
+			now := time.Now()
+			if records, err = sink.Since(now.AddDate(0, 0, -30), now); err != nil {
+				return errors.Wrap(err, "Failed to read usage records")
+			}
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, '\t', 0)
+	defer w.Flush()
+	fmt.Fprintln(w, "Service\tKeys\tAdvanced\tReads\tSSM\tSecretsManager\tSecretsManagerJSON")
+
+	var total costEstimate
+	for _, service := range services {
+		secrets, err := secretStore.ListRaw(service)
+		if err != nil {
+			return errors.Wrap(err, "Failed to list store contents")
+		}
+
+		c := costInput{keys: len(secrets), reads: costReads}
+		if c.reads < 0 {
+			c.reads = 0
+			for _, r := range records {
+				if r.Service == service {
+					c.reads++
+				}
+			}
+		}
+		for _, secret := range secrets {
+			if len(secret.Value) > ssmStandardParameterMaxSize {
+				c.advanced++
+			}
+			if !store.IsPlaintextKey(plaintextKeys, store.SecretId{Service: service, Key: key(secret.Key)}) {
+				c.encrypted++
+			}
+		}
+
+		e := estimateCost(c)
+		total.add(e)
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t$%.2f\t$%.2f\t$%.2f\n", service, c.keys, c.advanced, c.reads, e.ssm, e.secretsManager, e.secretsManagerJSON)
+	}
+	if len(services) > 1 {
+		fmt.Fprintf(w, "Total\t\t\t\t$%.2f\t$%.2f\t$%.2f\n", total.ssm, total.secretsManager, total.secretsManagerJSON)
+	}
+	return nil
+}
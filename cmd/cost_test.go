@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateCost(t *testing.T) {
+	t.Run("Should price standard parameters by their KMS requests", func(t *testing.T) {
+		e := estimateCost(costInput{keys: 10, encrypted: 10, reads: 10000})
+		assert.InDelta(t, 0.30, e.ssm, 1e-9)
+		assert.InDelta(t, 4.50, e.secretsManager, 1e-9)
+		assert.InDelta(t, 0.45, e.secretsManagerJSON, 1e-9)
+	})
+
+	t.Run("Should price advanced parameters and their API calls", func(t *testing.T) {
+		e := estimateCost(costInput{keys: 11, advanced: 1, reads: 10000})
+		assert.InDelta(t, 0.05+0.10, e.ssm, 1e-9)
+	})
+
+	t.Run("Should cost nothing without keys", func(t *testing.T) {
+		assert.Equal(t, costEstimate{}, estimateCost(costInput{reads: 100}))
+	})
+}
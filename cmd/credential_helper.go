@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+)
+
+// credentialHelperCmd represents the credential-helper command
+var credentialHelperCmd = &cobra.Command{
+	Use:   "credential-helper",
+	Short: "Print the credentials chamber uses, for use as a credential_process of other AWS tools",
+	Args:  cobra.NoArgs,
+	RunE:  credentialHelper,
+	Example: `
+In ~/.aws/config:
+
+	[profile deploy]
+	credential_process = chamber credential-helper --role-arn arn:aws:iam::123456789012:role/deploy
+`,
+}
+
+func init() {
+	RootCmd.AddCommand(credentialHelperCmd)
+}
+
+// processCredentials is the output format of a credential_process, see
+// https://docs.aws.amazon.com/cli/latest/userguide/cli-configure-sourcing-external.html
+type processCredentials struct {
+	Version         int
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string
+	SessionToken    string `json:",omitempty"`
+	Expiration      string `json:",omitempty"`
+}
+
+func newProcessCredentials(value credentials.Value, expires time.Time) processCredentials {
+	creds := processCredentials{
+		Version:         1,
+		AccessKeyID:     value.AccessKeyID,
+		SecretAccessKey: value.SecretAccessKey,
+		SessionToken:    value.SessionToken,
+	}
+	if !expires.IsZero() {
+		creds.Expiration = expires.UTC().Format(time.RFC3339)
+	}
+	return creds
+}
+
+func credentialHelper(cmd *cobra.Command, args []string) error {
+	if err := configureSessions(); err != nil {
+		return err
+	}
+
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", "credential-helper").
+				Set("chamber-version", chamberVersion),
+		})
+	}
+
+	value, expires, err := store.SessionCredentials(numRetries)
+	if err != nil {
+		return errors.Wrap(err, "Failed to get credentials")
+	}
+	return json.NewEncoder(os.Stdout).Encode(newProcessCredentials(value, expires))
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProcessCredentials(t *testing.T) {
+	value := credentials.Value{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "token"}
+
+	t.Run("Should include the expiration of temporary credentials", func(t *testing.T) {
+		expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+		out, err := json.Marshal(newProcessCredentials(value, expires))
+		assert.Nil(t, err)
+		assert.JSONEq(t, `{"Version":1,"AccessKeyId":"AKID","SecretAccessKey":"secret","SessionToken":"token","Expiration":"2030-01-02T03:04:05Z"}`, string(out))
+	})
+
+	t.Run("Should omit the expiration of long-lived credentials", func(t *testing.T) {
+		out, err := json.Marshal(newProcessCredentials(credentials.Value{AccessKeyID: "AKID", SecretAccessKey: "secret"}, time.Time{}))
+		assert.Nil(t, err)
+		assert.JSONEq(t, `{"Version":1,"AccessKeyId":"AKID","SecretAccessKey":"secret"}`, string(out))
+	})
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/segmentio/chamber/v2/store"
@@ -22,7 +23,11 @@ func init() {
 }
 
 func delete(cmd *cobra.Command, args []string) error {
-	service := strings.ToLower(args[0])
+	service, err := expandService(args[0])
+	if err != nil {
+		return errors.Wrap(err, "Failed to expand service")
+	}
+	service = strings.ToLower(service)
 	if err := validateService(service); err != nil {
 		return errors.Wrap(err, "Failed to validate service")
 	}
@@ -48,10 +53,24 @@ func delete(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return errors.Wrap(err, "Failed to get secret store")
 	}
+	if err := requireCapabilities(secretStore, store.Capabilities{Write: true}); err != nil {
+		return err
+	}
 	secretId := store.SecretId{
 		Service: service,
 		Key:     key,
 	}
 
-	return secretStore.Delete(secretId)
+	event := HookEvent{Command: "delete", Services: []string{service}, Keys: []string{key}}
+	if err := runHooks(withEvent(event, PreWriteHook)); err != nil {
+		return err
+	}
+	if err := secretStore.Delete(secretId); err != nil {
+		return err
+	}
+	if err := store.MarkKeyDeleted(secretStore, service, key, time.Now()); err != nil {
+		return errors.Wrap(err, "Failed to mark metadata of deleted key")
+	}
+	runPostHooks(withEvent(event, PostWriteHook))
+	return nil
 }
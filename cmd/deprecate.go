@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+)
+
+var (
+	deprecateReplacement string
+	deprecateRemoveAfter string
+	deprecateUndo        bool
+
+	// deprecateCmd represents the deprecate command
+	deprecateCmd = &cobra.Command{
+		Use:   "deprecate <service> <key>",
+		Short: "Mark a key as deprecated, so consumers are warned until it's removed",
+		Args:  cobra.ExactArgs(2),
+		RunE:  deprecate,
+		Example: `
+	$ chamber deprecate app db_pass --replacement db_password --remove-after 2025-03-01
+	$ chamber exec app -- ./server
+	warning: injecting app/db_pass, which is deprecated, use db_password instead; it will be removed after 2025-03-01
+`,
+	}
+)
+
+func init() {
+	deprecateCmd.Flags().StringVarP(&deprecateReplacement, "replacement", "", "", "The key consumers should use instead")
+	deprecateCmd.Flags().StringVarP(&deprecateRemoveAfter, "remove-after", "", "", "Date, like 2025-03-01, after which gc deletes the key; default is never")
+	deprecateCmd.Flags().BoolVarP(&deprecateUndo, "undo", "", false, "Remove the deprecation of the key")
+	RootCmd.AddCommand(deprecateCmd)
+}
+
+func deprecate(cmd *cobra.Command, args []string) error {
+	service, err := expandService(args[0])
+	if err != nil {
+		return errors.Wrap(err, "Failed to expand service")
+	}
+	service = strings.ToLower(service)
+	if err := validateService(service); err != nil {
+		return errors.Wrap(err, "Failed to validate service")
+	}
+
+	key := strings.ToLower(args[1])
+	if err := validateKey(key); err != nil {
+		return errors.Wrap(err, "Failed to validate key")
+	}
+
+	d := store.Deprecation{Replacement: strings.ToLower(deprecateReplacement)}
+	if d.Replacement != "" {
+		if err := validateKey(d.Replacement); err != nil {
+			return errors.Wrap(err, "Failed to validate replacement")
+		}
+		if d.Replacement == key {
+			return errors.New("A key can't be its own replacement")
+		}
+	}
+	if deprecateRemoveAfter != "" {
+		if d.RemoveAfter, err = time.Parse("2006-01-02", deprecateRemoveAfter); err != nil {
+			return errors.Wrap(err, "Failed to parse --remove-after")
+		}
+	}
+	if deprecateUndo && (d.Replacement != "" || !d.RemoveAfter.IsZero()) {
+		return errors.New("Cannot both deprecate a key and undo its deprecation")
+	}
+
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", "deprecate").
+				Set("chamber-version", chamberVersion).
+				Set("service", service).
+				Set("backend", backend).
+				Set("key", key).
+				Set("undo", deprecateUndo),
+		})
+	}
+
+	secretStore, err := getSecretStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get secret store")
+	}
+
+	if _, err := secretStore.Read(store.SecretId{Service: service, Key: key}, -1); err != nil {
+		return errors.Wrapf(err, "Failed to read %s", key)
+	}
+	if d.Replacement != "" {
+		if _, err := secretStore.Read(store.SecretId{Service: service, Key: d.Replacement}, -1); err == store.ErrSecretNotFound {
+			fmt.Fprintf(os.Stderr, "warning: replacement %s/%s doesn't exist yet\n", service, d.Replacement)
+		} else if err != nil {
+			return errors.Wrapf(err, "Failed to read %s", d.Replacement)
+		}
+	}
+
+	m, err := store.ReadKeyMetadata(secretStore, service, key)
+	if err != nil {
+		return errors.Wrap(err, "Failed to read metadata")
+	}
+	if m == nil {
+		m = &store.KeyMetadata{}
+	}
+	if deprecateUndo {
+		m.Deprecation = nil
+	} else {
+		m.Deprecation = &d
+	}
+	if err := store.WriteKeyMetadata(secretStore, service, key, *m); err != nil {
+		return errors.Wrap(err, "Failed to write metadata")
+	}
+	return nil
+}
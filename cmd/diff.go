@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+)
+
+// The ways a key can differ between two services
+const (
+	diffAdded   = "added"
+	diffRemoved = "removed"
+	diffChanged = "changed"
+)
+
+var (
+	diffBackendA string
+	diffBackendB string
+	diffProfileA string
+	diffProfileB string
+	diffValues   bool
+	diffExitCode bool
+
+	// diffCmd represents the diff command
+	diffCmd = &cobra.Command{
+		Use:   "diff <service-a> <service-b>",
+		Short: "Print the keys added, removed or changed from one service to another",
+		Args:  cobra.ExactArgs(2),
+		RunE:  diffRun,
+		Example: `
+	$ chamber diff app/staging app/prod
+	Key          Change
+	db_host      changed
+	new_flag     removed
+	$ chamber diff --backend-b s3-kms --exit-code app/prod app/prod
+`,
+	}
+)
+
+func init() {
+	diffCmd.Flags().StringVarP(&diffBackendA, "backend-a", "", "", "Backend of the first service, like --backend (default is --backend)")
+	diffCmd.Flags().StringVarP(&diffBackendB, "backend-b", "", "", "Backend of the second service, like --backend (default is --backend)")
+	diffCmd.Flags().StringVarP(&diffProfileA, "profile-a", "", "", "Profile of the first service, like --profile (default is --profile)")
+	diffCmd.Flags().StringVarP(&diffProfileB, "profile-b", "", "", "Profile of the second service, like --profile (default is --profile)")
+	diffCmd.Flags().BoolVarP(&diffValues, "values", "", false, "Print the values of the keys that differ")
+	diffCmd.Flags().BoolVarP(&diffExitCode, "exit-code", "", false, "Exit with 1 if the services differ, e.g. to gate CI on them matching")
+	RootCmd.AddCommand(diffCmd)
+}
+
+// diffEntry is a key that differs between two services, with its value in
+// each, empty where it is missing
+type diffEntry struct {
+	key    string
+	change string
+	a      string
+	b      string
+}
+
+func diffRun(cmd *cobra.Command, args []string) error {
+	services := make([]string, 0, len(args))
+	for _, arg := range args {
+		service, err := expandService(arg)
+		if err != nil {
+			return errors.Wrap(err, "Failed to expand service")
+		}
+		service = strings.ToLower(service)
+		if err := validateServiceWithLabel(service); err != nil {
+			return errors.Wrap(err, "Failed to validate service")
+		}
+		services = append(services, service)
+	}
+
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", "diff").
+				Set("chamber-version", chamberVersion).
+				Set("services", services).
+				Set("backend", backend).
+				Set("backends", diffBackendA != "" || diffBackendB != "").
+				Set("profiles", diffProfileA != "" || diffProfileB != "").
+				Set("values", diffValues).
+				Set("exit-code", diffExitCode),
+		})
+	}
+
+	// Each side's store is built like that of --backend and --profile, with
+	// whichever of them the side overrides
+	rootPflags := RootCmd.PersistentFlags()
+	defaultBackend := backendFlag
+	if backendEnvVarValue := os.Getenv(BackendEnvVar); !rootPflags.Changed("backend") && backendEnvVarValue != "" {
+		defaultBackend = backendEnvVarValue
+	}
+	defaultProfile := profileFlag
+	if profileEnvVarValue := os.Getenv(ProfileEnvVar); !rootPflags.Changed("profile") && profileEnvVarValue != "" {
+		defaultProfile = profileEnvVarValue
+	}
+	sides := []struct{ backend, profile string }{
+		{diffBackendA, diffProfileA},
+		{diffBackendB, diffProfileB},
+	}
+	values := make([]map[string]string, len(sides))
+	for i, side := range sides {
+		if side.backend == "" {
+			side.backend = defaultBackend
+		}
+		if side.profile == "" {
+			side.profile = defaultProfile
+		}
+		if err := rootPflags.Set("backend", side.backend); err != nil {
+			return errors.Wrap(err, "Failed to set backend")
+		}
+		if err := rootPflags.Set("profile", side.profile); err != nil {
+			return errors.Wrap(err, "Failed to set profile")
+		}
+		secretStore, err := getSecretStore()
+		if err != nil {
+			return errors.Wrapf(err, "Failed to get secret store of %s", services[i])
+		}
+		rawSecrets, err := secretStore.ListRaw(services[i])
+		if err != nil {
+			return errors.Wrapf(err, "Failed to list store contents of %s", services[i])
+		}
+		values[i] = make(map[string]string, len(rawSecrets))
+		for _, rawSecret := range rawSecrets {
+			values[i][key(rawSecret.Key)] = rawSecret.Value
+		}
+	}
+
+	entries := diffSecrets(values[0], values[1])
+	if len(entries) > 0 {
+		w := newTableWriter(os.Stdout)
+		if diffValues {
+			w.Header("Key", "Change", args[0], args[1])
+		} else {
+			w.Header("Key", "Change")
+		}
+		for _, entry := range entries {
+			switch entry.change {
+			case diffAdded:
+				w.Role(addedRole)
+			case diffChanged:
+				w.Role(changedRole)
+			case diffRemoved:
+				w.Role(expiredRole)
+			}
+			fmt.Fprintf(w, "%s\t%s", entry.key, entry.change)
+			if diffValues {
+				fmt.Fprintf(w, "\t%s\t%s", entry.a, entry.b)
+			}
+			fmt.Fprintln(w)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if diffExitCode && len(entries) > 0 {
+		return fmt.Errorf("%s and %s differ in %d keys", args[0], args[1], len(entries))
+	}
+	return nil
+}
+
+// diffSecrets returns the keys that differ from a to b, by key: those only b
+// has are added, those only a has are removed, and those with other values
+// are changed
+func diffSecrets(a, b map[string]string) []diffEntry {
+	var entries []diffEntry
+	for k, valueA := range a {
+		valueB, ok := b[k]
+		switch {
+		case !ok:
+			entries = append(entries, diffEntry{key: k, change: diffRemoved, a: valueA})
+		case valueA != valueB:
+			entries = append(entries, diffEntry{key: k, change: diffChanged, a: valueA, b: valueB})
+		}
+	}
+	for k, valueB := range b {
+		if _, ok := a[k]; !ok {
+			entries = append(entries, diffEntry{key: k, change: diffAdded, b: valueB})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	return entries
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffSecrets(t *testing.T) {
+	t.Run("Should list added, removed and changed keys in key order", func(t *testing.T) {
+		a := map[string]string{"db_host": "staging.db", "log_level": "debug", "old_flag": "on"}
+		b := map[string]string{"db_host": "prod.db", "log_level": "debug", "new_flag": "off"}
+		assert.Equal(t, []diffEntry{
+			{key: "db_host", change: diffChanged, a: "staging.db", b: "prod.db"},
+			{key: "new_flag", change: diffAdded, b: "off"},
+			{key: "old_flag", change: diffRemoved, a: "on"},
+		}, diffSecrets(a, b))
+	})
+
+	t.Run("Should find no differences between equal services", func(t *testing.T) {
+		a := map[string]string{"db_host": "prod.db"}
+		assert.Empty(t, diffSecrets(a, map[string]string{"db_host": "prod.db"}))
+	})
+}
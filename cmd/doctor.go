@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+)
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check that AWS is reachable with the current proxy and TLS settings",
+	Args:  cobra.NoArgs,
+	RunE:  doctor,
+}
+
+func init() {
+	RootCmd.AddCommand(doctorCmd)
+}
+
+func doctor(cmd *cobra.Command, args []string) error {
+	if err := configureSessions(); err != nil {
+		return err
+	}
+
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", "doctor").
+				Set("chamber-version", chamberVersion),
+		})
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, '\t', 0)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "Version\t%s\n", chamberVersion)
+	caBundle := caBundleFlag
+	if caBundle == "" {
+		caBundle = "system"
+	}
+	fmt.Fprintf(w, "CA bundle\t%s\n", caBundle)
+	tlsVersion := tlsMinVersion
+	if tlsVersion == "" {
+		tlsVersion = "default"
+	}
+	fmt.Fprintf(w, "TLS min version\t%s\n", tlsVersion)
+	if secretStore, err := getSecretStore(); err == nil {
+		fmt.Fprintf(w, "Backend\t%s (%s)\n", backend, store.CapabilitiesOf(secretStore))
+	}
+
+	arn, endpoint, err := store.CallerIdentity(numRetries)
+	if endpoint != "" {
+		fmt.Fprintf(w, "Endpoint\t%s\n", endpoint)
+		proxy, perr := store.ProxyFor(endpoint)
+		if perr != nil {
+			return errors.Wrap(perr, "Failed to determine proxy")
+		}
+		if proxy != nil {
+			fmt.Fprintf(w, "Proxy\t%s://%s\n", proxy.Scheme, proxy.Host)
+		} else {
+			fmt.Fprintf(w, "Proxy\tnone\n")
+		}
+	}
+	if err != nil {
+		w.Flush()
+		return errors.Wrap(err, "Failed to reach AWS")
+	}
+	fmt.Fprintf(w, "Identity\t%s\n", arn)
+	return nil
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+)
+
+var (
+	enumDelete bool
+
+	// enumCmd represents the enum command
+	enumCmd = &cobra.Command{
+		Use:   "enum <service> <key> [value...]",
+		Short: "Restrict the values a key may be written with, or print them",
+		Args:  cobra.MinimumNArgs(2),
+		RunE:  enum,
+		Example: `
+	$ chamber enum app log_level debug info warn error
+	$ chamber write app log_level verbose
+	Error: Failed to validate value: log_level must be one of debug, info, warn, error
+	$ chamber enum app log_level
+	debug
+	info
+	warn
+	error
+`,
+	}
+)
+
+func init() {
+	enumCmd.Flags().BoolVarP(&enumDelete, "delete", "d", false, "Allow any value again")
+	RootCmd.AddCommand(enumCmd)
+}
+
+func enum(cmd *cobra.Command, args []string) error {
+	service, err := expandService(args[0])
+	if err != nil {
+		return errors.Wrap(err, "Failed to expand service")
+	}
+	service = strings.ToLower(service)
+	if err := validateService(service); err != nil {
+		return errors.Wrap(err, "Failed to validate service")
+	}
+
+	key := strings.ToLower(args[1])
+	if err := validateKey(key); err != nil {
+		return errors.Wrap(err, "Failed to validate key")
+	}
+	values := args[2:]
+	if enumDelete && len(values) > 0 {
+		return errors.New("Cannot both set and delete the values of a key")
+	}
+
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", "enum").
+				Set("chamber-version", chamberVersion).
+				Set("service", service).
+				Set("backend", backend).
+				Set("key", key).
+				Set("delete", enumDelete),
+		})
+	}
+
+	secretStore, err := getSecretStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get secret store")
+	}
+
+	m, err := store.ReadKeyMetadata(secretStore, service, key)
+	if err != nil {
+		return errors.Wrap(err, "Failed to read values")
+	}
+
+	switch {
+	case enumDelete && m == nil:
+		return nil
+	case enumDelete || len(values) > 0:
+		// Keep the rest of the metadata, such as the expiry, deprecation and
+		// imported history
+		if m == nil {
+			m = &store.KeyMetadata{}
+		}
+		m.Enum = values
+		if m.Empty() {
+			if err := store.DeleteKeyMetadata(secretStore, service, key); err != nil && err != store.ErrSecretNotFound {
+				return errors.Wrap(err, "Failed to delete values")
+			}
+			return nil
+		}
+		return store.WriteKeyMetadata(secretStore, service, key, *m)
+	}
+
+	if m != nil {
+		for _, value := range m.Enum {
+			fmt.Fprintln(os.Stdout, value)
+		}
+	}
+	return nil
+}
+
+// validateEnum checks value against the values allowed for key, if any
+func validateEnum(secretStore store.Store, service, key, value string) error {
+	m, err := store.ReadKeyMetadata(secretStore, service, key)
+	if err != nil {
+		return err
+	}
+	if m != nil && !m.Allows(value) {
+		return fmt.Errorf("%s must be one of %s", key, strings.Join(m.Enum, ", "))
+	}
+	return nil
+}
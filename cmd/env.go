@@ -2,10 +2,16 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"regexp"
+	"runtime"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/store"
 	"github.com/spf13/cobra"
 	analytics "gopkg.in/segmentio/analytics-go.v3"
 )
@@ -19,24 +25,63 @@ var (
 		RunE:  env,
 	}
 	pattern *regexp.Regexp
+
+	watchEnv         bool
+	watchInterval    time.Duration
+	watchMaxInterval time.Duration
+	envShell         string
+)
+
+const (
+	shellPOSIX      = "sh"
+	shellPowerShell = "powershell"
+	shellCmd        = "cmd"
 )
 
 func init() {
+	envCmd.Flags().BoolVarP(&watchEnv, "watch", "w", false, "Keep running and print export/unset statements whenever secrets change")
+	envCmd.Flags().DurationVarP(&watchInterval, "interval", "", 30*time.Second, "How often to poll for changes in --watch mode")
+	envCmd.Flags().DurationVarP(&watchMaxInterval, "max-interval", "", 0, "In --watch mode, back off polling exponentially up to this interval while nothing changes; 0 always polls every --interval")
+	envCmd.Flags().StringVarP(&envShell, "shell", "", defaultEnvShell(), "Syntax of the printed statements; one of sh, powershell or cmd")
 	RootCmd.AddCommand(envCmd)
 	pattern = regexp.MustCompile(`[^\w@%+=:,./-]`)
 }
 
 func env(cmd *cobra.Command, args []string) error {
-	service := strings.ToLower(args[0])
+	service, err := expandService(args[0])
+	if err != nil {
+		return errors.Wrap(err, "Failed to expand service")
+	}
+	service = strings.ToLower(service)
 	if err := validateService(service); err != nil {
 		return errors.Wrap(err, "Failed to validate service")
 	}
 
+	if watchEnv && watchInterval <= 0 {
+		return errors.New("--interval must be positive")
+	}
+	if watchMaxInterval != 0 && watchMaxInterval < watchInterval {
+		return errors.New("--max-interval must not be shorter than --interval")
+	}
+	switch envShell {
+	case shellPOSIX, shellPowerShell, shellCmd:
+	default:
+		return fmt.Errorf("invalid shell `%s`", envShell)
+	}
+
 	secretStore, err := getSecretStore()
 	if err != nil {
 		return errors.Wrap(err, "Failed to get secret store")
 	}
-	secrets, err := secretStore.List(service, true)
+	// Taken before reading the values, so a write in between shows up as a
+	// change on the next poll
+	var hint string
+	if watchEnv {
+		if hint, err = envChangeHint(secretStore, service); err != nil {
+			return errors.Wrap(err, "Failed to list store contents")
+		}
+	}
+	vars, err := envVars(newExpiryFilter(secretStore, time.Now()), service)
 	if err != nil {
 		return errors.Wrap(err, "Failed to list store contents")
 	}
@@ -49,19 +94,154 @@ func env(cmd *cobra.Command, args []string) error {
 				Set("command", "env").
 				Set("chamber-version", chamberVersion).
 				Set("service", service).
-				Set("backend", backend),
+				Set("backend", backend).
+				Set("watch", watchEnv).
+				Set("shell", envShell),
 		})
 	}
 
+	if err := printEnvChanges(os.Stdout, envShell, map[string]string{}, vars); err != nil {
+		return err
+	}
+	if !watchEnv {
+		return nil
+	}
+
+	interval := watchInterval
+	for {
+		time.Sleep(interval)
+		// Values are only read, and decrypted, once their metadata changed
+		nextHint, err := envChangeHint(secretStore, service)
+		if err != nil {
+			// A single failed poll shouldn't end a long-lived session; keep the
+			// last known values and try again on the next tick
+			fmt.Fprintf(os.Stderr, "warning: failed to list store contents: %s\n", err)
+			continue
+		}
+		if nextHint == hint {
+			interval = nextWatchInterval(interval, watchInterval, watchMaxInterval, false)
+			continue
+		}
+		next, err := envVars(newExpiryFilter(secretStore, time.Now()), service)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to list store contents: %s\n", err)
+			continue
+		}
+		if err := printEnvChanges(os.Stdout, envShell, vars, next); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", err)
+			continue
+		}
+		vars, hint = next, nextHint
+		interval = nextWatchInterval(interval, watchInterval, watchMaxInterval, true)
+	}
+}
+
+// envChangeHint summarizes the metadata of the secrets of service, which
+// changes whenever one of them is written or deleted. Listing metadata
+// doesn't read values, which for SSM saves fetching and decrypting them on
+// every poll.
+func envChangeHint(secretStore store.Store, service string) (string, error) {
+	secrets, err := secretStore.List(service, false)
+	if err != nil {
+		return "", err
+	}
+	sort.Sort(ByName(secrets))
+
+	var hint strings.Builder
 	for _, secret := range secrets {
-		fmt.Printf("export %s=%s\n",
-			strings.ToUpper(key(secret.Meta.Key)),
-			shellescape(*secret.Value))
+		fmt.Fprintf(&hint, "%s\t%d\t%s\n", secret.Meta.Key, secret.Meta.Version, secret.Meta.Created.UTC().Format(time.RFC3339Nano))
 	}
+	return hint.String(), nil
+}
 
+// nextWatchInterval doubles the polling interval of --watch after a poll
+// without changes, up to max, and starts over from base after a change
+func nextWatchInterval(current, base, max time.Duration, changed bool) time.Duration {
+	if changed || max <= base {
+		return base
+	}
+	if current *= 2; current > max {
+		return max
+	}
+	return current
+}
+
+// envVars returns the secrets of service keyed by their environment variable
+// name.
+func envVars(secretStore store.Store, service string) (map[string]string, error) {
+	secrets, err := secretStore.List(service, true)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := make(map[string]string, len(secrets))
+	for _, secret := range secrets {
+		vars[strings.ToUpper(key(secret.Meta.Key))] = *secret.Value
+	}
+	return vars, nil
+}
+
+// defaultEnvShell returns the shell whose syntax env prints unless told
+// otherwise: PowerShell on Windows, POSIX sh everywhere else.
+func defaultEnvShell() string {
+	if runtime.GOOS == "windows" {
+		return shellPowerShell
+	}
+	return shellPOSIX
+}
+
+// printEnvChanges writes the statements needed to move a shell from the
+// variables in prev to the variables in next.
+func printEnvChanges(w io.Writer, shell string, prev, next map[string]string) error {
+	for _, k := range sortedKeys(next) {
+		if v, ok := prev[k]; ok && v == next[k] {
+			continue
+		}
+		switch shell {
+		case shellPowerShell:
+			fmt.Fprintf(w, "$env:%s = %s\n", k, powershellEscape(next[k]))
+		case shellCmd:
+			v, err := cmdEscape(next[k])
+			if err != nil {
+				return errors.Wrapf(err, "Failed to print %s", k)
+			}
+			fmt.Fprintf(w, "set \"%s=%s\"\n", k, v)
+		default:
+			fmt.Fprintf(w, "export %s=%s\n", k, shellescape(next[k]))
+		}
+	}
+	for _, k := range sortedKeys(prev) {
+		if _, ok := next[k]; ok {
+			continue
+		}
+		switch shell {
+		case shellPowerShell:
+			fmt.Fprintf(w, "Remove-Item Env:%s -ErrorAction SilentlyContinue\n", k)
+		case shellCmd:
+			fmt.Fprintf(w, "set %s=\n", k)
+		default:
+			fmt.Fprintf(w, "unset %s\n", k)
+		}
+	}
 	return nil
 }
 
+// powershellEscape returns s as a PowerShell single-quoted string, in which
+// nothing but the quote itself needs escaping.
+func powershellEscape(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// cmdEscape returns s escaped for use inside `set "KEY=..."` in a batch
+// file. cmd has no way of quoting line breaks, so values containing them are
+// rejected.
+func cmdEscape(s string) (string, error) {
+	if strings.ContainsAny(s, "\r\n") {
+		return "", errors.New("cmd cannot represent values containing line breaks")
+	}
+	return strings.Replace(s, "%", "%%", -1), nil
+}
+
 // shellescape returns a shell-escaped version of the string s. The returned value
 // is a string that can safely be used as one token in a shell command line.
 func shellescape(s string) string {
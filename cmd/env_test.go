@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/stretchr/testify/assert"
+)
+
+type listOnlyStore struct {
+	store.Store
+	secrets []store.Secret
+}
+
+func (s *listOnlyStore) List(service string, includeValues bool) ([]store.Secret, error) {
+	return s.secrets, nil
+}
+
+func TestPrintEnvChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		prev   map[string]string
+		next   map[string]string
+		output string
+	}{
+		{
+			"added keys are exported",
+			map[string]string{},
+			map[string]string{"FOO": "bar", "BAZ": "qux quux"},
+			"export BAZ='qux quux'\nexport FOO=bar\n",
+		},
+		{
+			"changed keys are exported again",
+			map[string]string{"FOO": "bar"},
+			map[string]string{"FOO": "baz"},
+			"export FOO=baz\n",
+		},
+		{
+			"unchanged keys produce no output",
+			map[string]string{"FOO": "bar"},
+			map[string]string{"FOO": "bar"},
+			"",
+		},
+		{
+			"removed keys are unset",
+			map[string]string{"FOO": "bar", "BAZ": "qux"},
+			map[string]string{"FOO": "bar"},
+			"unset BAZ\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			err := printEnvChanges(buf, shellPOSIX, test.prev, test.next)
+			assert.Nil(t, err)
+			assert.Equal(t, test.output, buf.String())
+		})
+	}
+}
+
+func TestPrintEnvChangesWindowsShells(t *testing.T) {
+	prev := map[string]string{"OLD": "gone"}
+	next := map[string]string{"PASSWORD": "it's 100%"}
+
+	t.Run("PowerShell should get single-quoted assignments", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		assert.Nil(t, printEnvChanges(buf, shellPowerShell, prev, next))
+		assert.Equal(t, "$env:PASSWORD = 'it''s 100%'\nRemove-Item Env:OLD -ErrorAction SilentlyContinue\n", buf.String())
+	})
+
+	t.Run("cmd should get quoted set statements", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		assert.Nil(t, printEnvChanges(buf, shellCmd, prev, next))
+		assert.Equal(t, "set \"PASSWORD=it's 100%%\"\nset OLD=\n", buf.String())
+	})
+
+	t.Run("cmd should reject multi-line values", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		assert.Error(t, printEnvChanges(buf, shellCmd, nil, map[string]string{"KEY": "a\nb"}))
+	})
+}
+
+func TestEnvVars(t *testing.T) {
+	value := "hunter22"
+	s := &listOnlyStore{secrets: []store.Secret{
+		{Value: &value, Meta: store.SecretMetadata{Key: "/service/db_password"}},
+	}}
+
+	vars, err := envVars(s, "service")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"DB_PASSWORD": "hunter22"}, vars)
+}
+
+func TestEnvChangeHint(t *testing.T) {
+	s := &listOnlyStore{secrets: []store.Secret{
+		{Meta: store.SecretMetadata{Key: "/service/b", Version: 1}},
+		{Meta: store.SecretMetadata{Key: "/service/a", Version: 3}},
+	}}
+	hint, err := envChangeHint(s, "service")
+	assert.Nil(t, err)
+
+	t.Run("Should not depend on the order of listings", func(t *testing.T) {
+		s.secrets[0], s.secrets[1] = s.secrets[1], s.secrets[0]
+		reordered, err := envChangeHint(s, "service")
+		assert.Nil(t, err)
+		assert.Equal(t, hint, reordered)
+	})
+
+	t.Run("Should change with new versions", func(t *testing.T) {
+		s.secrets[0].Meta.Version = 4
+		changed, err := envChangeHint(s, "service")
+		assert.Nil(t, err)
+		assert.NotEqual(t, hint, changed)
+	})
+}
+
+func TestNextWatchInterval(t *testing.T) {
+	t.Run("Should back off while nothing changes", func(t *testing.T) {
+		assert.Equal(t, time.Minute, nextWatchInterval(30*time.Second, 30*time.Second, 5*time.Minute, false))
+		assert.Equal(t, 5*time.Minute, nextWatchInterval(4*time.Minute, 30*time.Second, 5*time.Minute, false))
+	})
+
+	t.Run("Should start over after a change", func(t *testing.T) {
+		assert.Equal(t, 30*time.Second, nextWatchInterval(4*time.Minute, 30*time.Second, 5*time.Minute, true))
+	})
+
+	t.Run("Should not back off without a maximum", func(t *testing.T) {
+		assert.Equal(t, 30*time.Second, nextWatchInterval(30*time.Second, 30*time.Second, 0, false))
+	})
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+)
+
+// pointerSize is the size of the pointer to each argument and variable
+const pointerSize = int(unsafe.Sizeof(uintptr(0)))
+
+// envLimits are the limits the platform puts on the arguments and environment
+// of a new process; zero means unlimited
+type envLimits struct {
+	// total is the size in bytes of the arguments and environment together,
+	// including the terminating NUL and pointer of each string
+	total int
+
+	// perString is the size in bytes of a single argument or variable,
+	// including its terminating NUL
+	perString int
+}
+
+// envLimitSuggestion tells how to pass secrets that don't fit the environment
+const envLimitSuggestion = "pass the secrets with --handoff fd, --handoff socket or --isolate instead of the environment"
+
+// checkEnvLimits returns an error naming the argument or variable that would
+// make starting the command fail, rather than leaving the platform's error
+func checkEnvLimits(argv []string, env []string, limits envLimits) error {
+	total := 0
+	for _, strs := range [][]string{argv, env} {
+		for _, s := range strs {
+			size := len(s) + 1
+			if limits.perString > 0 && size > limits.perString {
+				name := strings.SplitN(s, "=", 2)[0]
+				return fmt.Errorf("%s is %d bytes, over the limit of %d bytes for a single variable or argument; %s", name, size, limits.perString, envLimitSuggestion)
+			}
+			total += size + pointerSize
+		}
+	}
+	if limits.total > 0 && total > limits.total {
+		return fmt.Errorf("the %d environment variables and arguments of the command take %d bytes, over the limit of %d bytes; %s", len(env)+len(argv), total, limits.total, envLimitSuggestion)
+	}
+	return nil
+}
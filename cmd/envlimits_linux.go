@@ -0,0 +1,14 @@
+package cmd
+
+import "syscall"
+
+// platformEnvLimits returns the limits of execve(2): a quarter of the stack
+// size in total, and MAX_ARG_STRLEN, 32 pages, per string
+func platformEnvLimits() envLimits {
+	limits := envLimits{perString: 32 * 4096}
+	var stack syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_STACK, &stack); err == nil && stack.Cur != ^uint64(0) {
+		limits.total = int(stack.Cur / 4)
+	}
+	return limits
+}
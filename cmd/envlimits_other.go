@@ -0,0 +1,9 @@
+// +build !linux,!windows
+
+package cmd
+
+// platformEnvLimits returns ARG_MAX of macOS, which other Unix systems are
+// close to
+func platformEnvLimits() envLimits {
+	return envLimits{total: 1024 * 1024}
+}
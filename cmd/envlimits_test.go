@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckEnvLimits(t *testing.T) {
+	argv := []string{"app", "--serve"}
+
+	t.Run("Should accept an environment within the limits", func(t *testing.T) {
+		env := []string{"DB_PASSWORD=hunter2", "API_KEY=key"}
+		assert.Nil(t, checkEnvLimits(argv, env, envLimits{total: 1024, perString: 64}))
+		assert.Nil(t, checkEnvLimits(argv, env, envLimits{}))
+	})
+
+	t.Run("Should name a variable over the per-variable limit", func(t *testing.T) {
+		env := []string{"SMALL=x", "CERTIFICATE=" + strings.Repeat("x", 100)}
+		err := checkEnvLimits(argv, env, envLimits{perString: 64})
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "CERTIFICATE is 113 bytes")
+		assert.Contains(t, err.Error(), "--handoff")
+	})
+
+	t.Run("Should fail when the environment is over the total limit", func(t *testing.T) {
+		env := make([]string, 10)
+		for i := range env {
+			env[i] = "KEY=" + strings.Repeat("x", 50)
+		}
+		err := checkEnvLimits(argv, env, envLimits{total: 500, perString: 64})
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "over the limit of 500 bytes")
+	})
+}
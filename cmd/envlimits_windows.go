@@ -0,0 +1,7 @@
+package cmd
+
+// platformEnvLimits returns the limit Windows puts on a single environment
+// variable, 32767 characters
+func platformEnvLimits() envLimits {
+	return envLimits{perString: 32767}
+}
@@ -1,12 +1,16 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/segmentio/chamber/v2/environ"
+	"github.com/segmentio/chamber/v2/store"
 	"github.com/spf13/cobra"
 	analytics "gopkg.in/segmentio/analytics-go.v3"
 )
@@ -23,6 +27,55 @@ var strictValue string
 // Default value to expect in strict mode
 const strictValueDefault = "chamberme"
 
+// How secrets are handed to the command: in its environment, or over an
+// inherited file descriptor or a unix socket
+var handoff string
+
+// A local .env file merged into the environment of the command, and whether
+// its values go above or below those of the store
+var envFile string
+var envFilePrecedence string
+
+const (
+	envFileAbove = "above"
+	envFileBelow = "below"
+)
+
+// VAR=KEY#jsonpath mappings that set VAR to a field of the JSON value of KEY
+var extract []string
+
+// When true, run the command in private namespaces with secrets as files on
+// a private tmpfs
+var isolate bool
+
+// When true, values that are ssm:// or secretsmanager:// URIs are replaced
+// with the values they name
+var bridge bool
+
+const (
+	handoffEnv    = "env"
+	handoffFD     = "fd"
+	handoffSocket = "socket"
+
+	// HandoffFDEnvVar tells the command which file descriptor to read its
+	// secrets from with --handoff fd
+	HandoffFDEnvVar = "CHAMBER_HANDOFF_FD"
+
+	// HandoffSocketEnvVar tells the command which unix socket to read its
+	// secrets from with --handoff socket
+	HandoffSocketEnvVar = "CHAMBER_HANDOFF_SOCKET"
+)
+
+// The user and group to run the command as, by name or id
+var execUser, execGroup string
+
+// The working directory of the command, and its umask in octal
+var execChdir, execUmask string
+
+// Maximum age of injected secrets, and whether to "warn" or "fail" when any
+// is older
+var maxSecretAge, staleSecretsAction string
+
 // execCmd represents the exec command
 var execCmd = &cobra.Command{
 	Use:   "exec <service...> -- <command> [<arg...>]",
@@ -67,12 +120,33 @@ only inject secrets for which there is a corresponding env var with value
 <strict-value>, and fail if there are any env vars with that value missing
 from secrets`)
 	execCmd.Flags().StringVar(&strictValue, "strict-value", strictValueDefault, "value to expect in --strict mode")
+	execCmd.Flags().StringVar(&maxSecretAge, "max-secret-age", "", "warn about secrets that haven't been rotated for this long, e.g. 90d")
+	execCmd.Flags().StringVar(&staleSecretsAction, "stale-secrets", "warn", "whether to warn or fail when secrets exceed --max-secret-age")
+	execCmd.Flags().StringVar(&handoff, "handoff", handoffEnv, `how to pass secrets to the command:
+env: as environment variables
+fd: in dotenv format on an inherited file descriptor, named by $`+HandoffFDEnvVar+`
+socket: in dotenv format from a unix socket, named by $`+HandoffSocketEnvVar)
+	execCmd.Flags().StringVar(&envFile, "env-file", "", "local .env file to merge into the environment of the command, e.g. to override a few values during development")
+	execCmd.Flags().StringVar(&envFilePrecedence, "env-file-precedence", envFileAbove, `how --env-file merges:
+	above: its values override those of the store and the environment
+	below: its values only set variables that the store and the environment don't`)
+	execCmd.Flags().StringArrayVar(&extract, "extract", nil, "set a variable to a field of a JSON-valued secret, as VAR=KEY#jsonpath, e.g. DB_PASSWORD=DB_CREDENTIALS#$.password; may be repeated")
+	execCmd.Flags().BoolVar(&bridge, "bridge", false, "replace values that are ssm://<parameter> or secretsmanager://<secret> URIs with the values they name, read through SSM")
+	execCmd.Flags().BoolVar(&isolate, "isolate", false, "on Linux, run the command in private namespaces, with secrets as files in $"+SecretsDirEnvVar+" instead of the environment, and chamber's AWS credentials hidden")
+	execCmd.Flags().StringVar(&execUser, "user", "", "run the command as this user, by name or uid, with its groups, after reading secrets as chamber's user, e.g. when chamber starts as root")
+	execCmd.Flags().StringVar(&execGroup, "group", "", "run the command with this group, by name or gid; defaults to the primary group of --user")
+	execCmd.Flags().StringVar(&execChdir, "chdir", "", "run the command in this directory")
+	execCmd.Flags().StringVar(&execUmask, "umask", "", "run the command with this umask, in octal, e.g. 027")
 	RootCmd.AddCommand(execCmd)
 }
 
 func execRun(cmd *cobra.Command, args []string) error {
 	dashIx := cmd.ArgsLenAtDash()
 	services, command, commandArgs := args[:dashIx], args[dashIx], args[dashIx+1:]
+	services, err := expandServices(services)
+	if err != nil {
+		return errors.Wrap(err, "Failed to expand service")
+	}
 
 	if analyticsEnabled && analyticsClient != nil {
 		analyticsClient.Enqueue(analytics.Track{
@@ -82,20 +156,96 @@ func execRun(cmd *cobra.Command, args []string) error {
 				Set("command", "exec").
 				Set("chamber-version", chamberVersion).
 				Set("services", services).
-				Set("backend", backend),
+				Set("backend", backend).
+				Set("handoff", handoff).
+				Set("isolate", isolate).
+				Set("bridge", bridge).
+				Set("user", execUser != "" || execGroup != "").
+				Set("env-file", envFile != "").
+				Set("extract", len(extract)).
+				Set("version-pin", hasPin(services)),
 		})
 	}
 
 	for _, service := range services {
-		if err := validateServiceWithLabel(service); err != nil {
+		if err := validateServiceOrPin(service, validateServiceWithLabel); err != nil {
 			return errors.Wrap(err, "Failed to validate service")
 		}
 	}
 
+	var maxAge time.Duration
+	if maxSecretAge != "" {
+		if maxAge, err = parseAge(maxSecretAge); err != nil {
+			return errors.Wrap(err, "Failed to parse --max-secret-age")
+		}
+	}
+	if staleSecretsAction != "warn" && staleSecretsAction != "fail" {
+		return fmt.Errorf("invalid --stale-secrets %s; must be warn or fail", staleSecretsAction)
+	}
+	switch handoff {
+	case handoffEnv:
+	case handoffFD, handoffSocket:
+		if strict {
+			return fmt.Errorf("--strict checks the environment, so it requires --handoff %s", handoffEnv)
+		}
+	default:
+		return fmt.Errorf("invalid --handoff %s; must be env, fd or socket", handoff)
+	}
+	if envFilePrecedence != envFileAbove && envFilePrecedence != envFileBelow {
+		return fmt.Errorf("invalid --env-file-precedence %s; must be above or below", envFilePrecedence)
+	}
+	extractions, err := parseExtractions(extract)
+	if err != nil {
+		return errors.Wrap(err, "Failed to parse --extract")
+	}
+	var envFileValues map[string]string
+	if envFile != "" {
+		if envFileValues, err = readEnvFile(envFile); err != nil {
+			return errors.Wrap(err, "Failed to read --env-file")
+		}
+	}
+	if isolate && (strict || handoff != handoffEnv) {
+		return errors.New("--isolate hands secrets off as files, so it can't be combined with --strict or --handoff")
+	}
+
+	umask := -1
+	if execUmask != "" {
+		if umask, err = parseUmask(execUmask); err != nil {
+			return errors.Wrap(err, "Failed to parse --umask")
+		}
+	}
+
+	var identity *execIdentity
+	if execUser != "" || execGroup != "" {
+		if identity, err = resolveExecIdentity(execUser, execGroup); err != nil {
+			return errors.Wrap(err, "Failed to resolve --user and --group")
+		}
+	}
+
 	secretStore, err := getSecretStore()
 	if err != nil {
 		return errors.Wrap(err, "Failed to get secret store")
 	}
+	if err := runHooks(HookEvent{Event: PreExecHook, Command: "exec", Services: services, Args: append([]string{command}, commandArgs...)}); err != nil {
+		return err
+	}
+	sink, err := getUsageSink()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get usage sink")
+	}
+	secretStore = newPinFilter(newExpiryFilter(secretStore, time.Now()), time.Now())
+	if bridge {
+		ssmStore, err := store.NewSSMStoreWithMinThrottleDelay(numRetries, minThrottleDelay)
+		if err != nil {
+			return errors.Wrap(err, "Failed to get SSM store for --bridge")
+		}
+		secretStore = newBridgeFilter(secretStore, ssmStore.ResolveBridged)
+	}
+	var recorder *usageRecorder
+	if sink != nil {
+		recorder = newUsageRecorder(secretStore)
+		secretStore = recorder
+	}
 	_, noPaths := os.LookupEnv("CHAMBER_NO_PATHS")
 
 	if pristine && verbose {
@@ -118,7 +268,8 @@ func execRun(cmd *cobra.Command, args []string) error {
 			return err
 		}
 	} else {
-		if !pristine {
+		// Secrets handed off otherwise are loaded on their own
+		if !pristine && handoff == handoffEnv && !isolate {
 			env = environ.Environ(os.Environ())
 		}
 		for _, service := range services {
@@ -140,9 +291,157 @@ func execRun(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if err := applyExtractions(&env, extractions); err != nil {
+		return err
+	}
+	mergeEnvFile(&env, envFileValues, envFilePrecedence == envFileAbove)
+
+	if recorder != nil {
+		recorder.record(sink)
+	}
+
+	if maxAge > 0 {
+		stale, err := findStaleSecrets(secretStore, services, maxAge, time.Now())
+		if err != nil {
+			return errors.Wrap(err, "Failed to check secret ages")
+		}
+		for _, s := range stale {
+			fmt.Fprintf(os.Stderr, "warning: %s (max %s)\n", s, maxSecretAge)
+		}
+		if len(stale) > 0 && staleSecretsAction == "fail" {
+			return fmt.Errorf("%d secrets exceed --max-secret-age %s", len(stale), maxSecretAge)
+		}
+	}
+
+	// the command replaces chamber, so report before starting it
+	recordStats(cmd, nil)
+	reportAPICalls(os.Stderr)
+
+	if identity != nil {
+		if err := dropPrivileges(identity); err != nil {
+			return err
+		}
+	}
+	// after dropping privileges, so the command only ends up where its user
+	// may go
+	if execChdir != "" {
+		if err := os.Chdir(execChdir); err != nil {
+			return errors.Wrap(err, "Failed to change directory")
+		}
+	}
+	if umask != -1 {
+		if err := setUmask(umask); err != nil {
+			return err
+		}
+	}
+
+	if isolate {
+		var childEnv []string
+		if !pristine {
+			childEnv = os.Environ()
+		}
+		if verbose {
+			fmt.Fprintf(os.Stderr, "chamber: isolating command with %d secrets\n", len(env))
+		}
+		return execIsolated(command, commandArgs, childEnv, env.Map())
+	}
+
+	if handoff != handoffEnv {
+		var childEnv []string
+		if !pristine {
+			childEnv = os.Environ()
+		}
+		var payload bytes.Buffer
+		if err := exportAsEnvFile(env.Map(), &payload); err != nil {
+			return err
+		}
+		if verbose {
+			fmt.Fprintf(os.Stderr, "chamber: handing off %d secrets over %s\n", len(env), handoff)
+		}
+		return execHandoff(command, commandArgs, childEnv, handoff, payload.Bytes())
+	}
+
 	if verbose {
-		fmt.Fprintf(os.Stdout, "info: With environment %s\n", strings.Join(env, ","))
+		fmt.Fprintf(os.Stderr, "info: With environment %s\n", strings.Join(env, ","))
+	}
+
+	if err := checkEnvLimits(append([]string{command}, commandArgs...), env, platformEnvLimits()); err != nil {
+		return err
 	}
 
 	return exec(command, commandArgs, env)
 }
+
+// parseUmask parses an octal umask like 027 or 0027
+func parseUmask(s string) (int, error) {
+	umask, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || umask > 0777 {
+		return 0, fmt.Errorf("invalid umask %s; must be octal, from 000 to 777", s)
+	}
+	return int(umask), nil
+}
+
+// readEnvFile reads the KEY=value lines of path
+func readEnvFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return store.ParseEnvFile(f)
+}
+
+// mergeEnvFile sets the values of an --env-file in env, replacing the values
+// already set if above, or only setting the variables that aren't otherwise
+func mergeEnvFile(env *environ.Environ, values map[string]string, above bool) {
+	for k, v := range values {
+		if above || !env.IsSet(k) {
+			env.Set(k, v)
+		}
+	}
+}
+
+// extraction sets variable to the field at path of the JSON value of source
+type extraction struct {
+	variable string
+	source   string
+	path     string
+}
+
+// parseExtractions parses VAR=KEY#jsonpath mappings, where KEY is the
+// variable a JSON-valued secret is loaded as
+func parseExtractions(mappings []string) ([]extraction, error) {
+	extractions := make([]extraction, 0, len(mappings))
+	for _, mapping := range mappings {
+		parts := strings.SplitN(mapping, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid mapping %s; must be VAR=KEY#jsonpath", mapping)
+		}
+		source := strings.SplitN(parts[1], "#", 2)
+		if len(source) != 2 || source[0] == "" {
+			return nil, fmt.Errorf("invalid mapping %s; must be VAR=KEY#jsonpath", mapping)
+		}
+		if _, err := parseJSONPath(source[1]); err != nil {
+			return nil, err
+		}
+		extractions = append(extractions, extraction{variable: parts[0], source: source[0], path: source[1]})
+	}
+	return extractions, nil
+}
+
+// applyExtractions sets the variables of extractions in env
+func applyExtractions(env *environ.Environ, extractions []extraction) error {
+	values := env.Map()
+	for _, e := range extractions {
+		value, ok := values[e.source]
+		if !ok {
+			return fmt.Errorf("cannot extract %s: %s is not set", e.variable, e.source)
+		}
+		field, err := extractJSONPath(value, e.path)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to extract %s from %s", e.variable, e.source)
+		}
+		env.Set(e.variable, field)
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+// +build !linux,!darwin
+
+package cmd
+
+import "fmt"
+
+// execHandoff is only supported where secrets can be passed over inherited
+// file descriptors or unix sockets
+func execHandoff(command string, args []string, env []string, mode string, payload []byte) error {
+	return fmt.Errorf("--handoff %s is not supported on this platform", mode)
+}
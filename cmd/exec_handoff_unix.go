@@ -0,0 +1,134 @@
+// +build linux darwin
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	osexec "os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// execHandoff runs the command with env, handing it payload over a file
+// descriptor or a unix socket rather than in its environment, where it would
+// show up in /proc/<pid>/environ.
+func execHandoff(command string, args []string, env []string, mode string, payload []byte) error {
+	switch mode {
+	case handoffFD:
+		r, err := handoffPipe(payload)
+		if err != nil {
+			return err
+		}
+		env = append(env, fmt.Sprintf("%s=%d", HandoffFDEnvVar, r.Fd()))
+		err = exec(command, args, env)
+		// The pipe must stay open until the command has replaced chamber
+		runtime.KeepAlive(r)
+		return err
+	case handoffSocket:
+		return execWithSocket(command, args, env, payload)
+	default:
+		return fmt.Errorf("unsupported handoff %s", mode)
+	}
+}
+
+// handoffPipe returns the read end of a pipe holding payload, to be inherited
+// by the command. The whole payload has to fit in the pipe's buffer, since
+// chamber is gone by the time the command reads it.
+func handoffPipe(payload []byte) (*os.File, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer w.Close()
+
+	wfd := int(w.Fd())
+	if err := syscall.SetNonblock(wfd, true); err != nil {
+		r.Close()
+		return nil, err
+	}
+	for written := 0; written < len(payload); {
+		n, err := syscall.Write(wfd, payload[written:])
+		if err == syscall.EAGAIN {
+			r.Close()
+			return nil, errors.New("secrets don't fit in a pipe; use --handoff socket")
+		}
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+		written += n
+	}
+
+	// os.Pipe marks both ends close-on-exec
+	if _, _, errno := syscall.Syscall(syscall.SYS_FCNTL, r.Fd(), syscall.F_SETFD, 0); errno != 0 {
+		r.Close()
+		return nil, errno
+	}
+	return r, nil
+}
+
+// execWithSocket serves payload to every connection to a unix socket in a
+// directory only the current user can access, while running the command as a
+// child, and exits with its exit code.
+func execWithSocket(command string, args []string, env []string, payload []byte) error {
+	dir, err := ioutil.TempDir("", "chamber-handoff")
+	if err != nil {
+		return errors.Wrap(err, "Failed to create socket directory")
+	}
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "secrets.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		return errors.Wrap(err, "Failed to listen on socket")
+	}
+	defer l.Close()
+	go serveHandoff(l, payload)
+
+	ecmd := osexec.Command(command, args...)
+	ecmd.Stdin = os.Stdin
+	ecmd.Stdout = os.Stdout
+	ecmd.Stderr = os.Stderr
+	ecmd.Env = append(env, HandoffSocketEnvVar+"="+socket)
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan)
+	if err := ecmd.Start(); err != nil {
+		return errors.Wrap(err, "Failed to start command")
+	}
+	go func() {
+		for sig := range sigChan {
+			ecmd.Process.Signal(sig)
+		}
+	}()
+
+	err = ecmd.Wait()
+	l.Close()
+	os.RemoveAll(dir)
+	if exitErr, ok := err.(*osexec.ExitError); ok {
+		os.Exit(exitErr.Sys().(syscall.WaitStatus).ExitStatus())
+	}
+	if err != nil {
+		return errors.Wrap(err, "Failed to wait for command termination")
+	}
+	os.Exit(0)
+	return nil // unreachable but Go doesn't know about it
+}
+
+func serveHandoff(l net.Listener, payload []byte) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		conn.Write(payload)
+		conn.Close()
+	}
+}
@@ -0,0 +1,49 @@
+// +build linux darwin
+
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandoffPipe(t *testing.T) {
+	t.Run("Should hold the whole payload", func(t *testing.T) {
+		r, err := handoffPipe([]byte("DB_PASSWORD=\"hunter2\"\n"))
+		assert.Nil(t, err)
+		defer r.Close()
+		payload, err := ioutil.ReadAll(r)
+		assert.Nil(t, err)
+		assert.Equal(t, "DB_PASSWORD=\"hunter2\"\n", string(payload))
+	})
+
+	t.Run("Should refuse payloads larger than the pipe", func(t *testing.T) {
+		_, err := handoffPipe(bytes.Repeat([]byte("x"), 4<<20))
+		assert.Error(t, err)
+	})
+}
+
+func TestServeHandoff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chamber-handoff-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	l, err := net.Listen("unix", filepath.Join(dir, "secrets.sock"))
+	assert.Nil(t, err)
+	defer l.Close()
+	go serveHandoff(l, []byte("API=\"x\"\n"))
+
+	for i := 0; i < 2; i++ {
+		conn, err := net.Dial("unix", l.Addr().String())
+		assert.Nil(t, err)
+		payload, err := ioutil.ReadAll(conn)
+		assert.Nil(t, err)
+		assert.Equal(t, "API=\"x\"\n", string(payload))
+		conn.Close()
+	}
+}
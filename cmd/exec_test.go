@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/segmentio/chamber/v2/environ"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeEnvFile(t *testing.T) {
+	values := map[string]string{"DB_HOST": "localhost", "DEBUG": "1"}
+
+	t.Run("Should override the store above it", func(t *testing.T) {
+		env := environ.Environ{"DB_HOST=db.internal", "API_KEY=key"}
+		mergeEnvFile(&env, values, true)
+		assert.Equal(t, map[string]string{"DB_HOST": "localhost", "API_KEY": "key", "DEBUG": "1"}, env.Map())
+	})
+
+	t.Run("Should only fill in variables below it", func(t *testing.T) {
+		env := environ.Environ{"DB_HOST=db.internal", "API_KEY=key"}
+		mergeEnvFile(&env, values, false)
+		assert.Equal(t, map[string]string{"DB_HOST": "db.internal", "API_KEY": "key", "DEBUG": "1"}, env.Map())
+	})
+}
+
+func TestParseUmask(t *testing.T) {
+	t.Run("Should parse octal umasks", func(t *testing.T) {
+		for s, expected := range map[string]int{"027": 027, "0077": 077, "0": 0, "777": 0777} {
+			umask, err := parseUmask(s)
+			assert.Nil(t, err)
+			assert.Equal(t, expected, umask, s)
+		}
+	})
+
+	t.Run("Should reject invalid umasks", func(t *testing.T) {
+		for _, s := range []string{"", "8", "1000", "rwx", "-1"} {
+			_, err := parseUmask(s)
+			assert.NotNil(t, err, s)
+		}
+	})
+}
@@ -0,0 +1,136 @@
+// +build windows
+
+package cmd
+
+import (
+	"os"
+	osexec "os/exec"
+	"os/signal"
+	"syscall"
+	"unsafe"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	kernel32                     = syscall.NewLazyDLL("kernel32.dll")
+	procCreateJobObjectW         = kernel32.NewProc("CreateJobObjectW")
+	procSetInformationJobObject  = kernel32.NewProc("SetInformationJobObject")
+	procAssignProcessToJobObject = kernel32.NewProc("AssignProcessToJobObject")
+)
+
+const (
+	jobObjectExtendedLimitInformationClass = 9
+	jobObjectLimitKillOnJobClose           = 0x2000
+
+	processSetQuota  = 0x0100
+	processTerminate = 0x0001
+)
+
+type jobObjectBasicLimitInformation struct {
+	PerProcessUserTimeLimit int64
+	PerJobUserTimeLimit     int64
+	LimitFlags              uint32
+	MinimumWorkingSetSize   uintptr
+	MaximumWorkingSetSize   uintptr
+	ActiveProcessLimit      uint32
+	Affinity                uintptr
+	PriorityClass           uint32
+	SchedulingClass         uint32
+}
+
+type ioCounters struct {
+	ReadOperationCount  uint64
+	WriteOperationCount uint64
+	OtherOperationCount uint64
+	ReadTransferCount   uint64
+	WriteTransferCount  uint64
+	OtherTransferCount  uint64
+}
+
+type jobObjectExtendedLimitInformation struct {
+	BasicLimitInformation jobObjectBasicLimitInformation
+	IoInfo                ioCounters
+	ProcessMemoryLimit    uintptr
+	JobMemoryLimit        uintptr
+	PeakProcessMemoryUsed uintptr
+	PeakJobMemoryUsed     uintptr
+}
+
+// exec runs the given command as a child process, since Windows has no
+// execve. The child is placed in a job object that is killed together with
+// chamber, and chamber exits with the child's exit code.
+// The exec function is allowed to never return and cause the program to exit.
+func exec(command string, args []string, env []string) error {
+	ecmd := osexec.Command(command, args...)
+	ecmd.Stdin = os.Stdin
+	ecmd.Stdout = os.Stdout
+	ecmd.Stderr = os.Stderr
+	ecmd.Env = env
+
+	// Ctrl+C and Ctrl+Break are delivered to every process attached to the
+	// console, so the child sees them on its own; chamber just has to stay
+	// alive until the child has exited.
+	signal.Notify(make(chan os.Signal, 1), os.Interrupt)
+
+	job, err := newKillOnCloseJob()
+	if err != nil {
+		return errors.Wrap(err, "Failed to create job object")
+	}
+	defer syscall.CloseHandle(job)
+
+	if err := ecmd.Start(); err != nil {
+		return errors.Wrap(err, "Failed to start command")
+	}
+	if err := assignToJob(job, ecmd.Process.Pid); err != nil {
+		ecmd.Process.Kill()
+		return errors.Wrap(err, "Failed to assign command to job object")
+	}
+
+	if err := ecmd.Wait(); err != nil {
+		if exitErr, ok := err.(*osexec.ExitError); ok {
+			os.Exit(exitErr.ExitCode())
+		}
+		return errors.Wrap(err, "Failed to wait for command termination")
+	}
+	os.Exit(0)
+	return nil // unreachable but Go doesn't know about it
+}
+
+// newKillOnCloseJob creates a job object whose processes are terminated when
+// its last handle is closed, which happens at the latest when chamber exits.
+func newKillOnCloseJob() (syscall.Handle, error) {
+	h, _, err := procCreateJobObjectW.Call(0, 0)
+	if h == 0 {
+		return 0, err
+	}
+	job := syscall.Handle(h)
+
+	info := jobObjectExtendedLimitInformation{}
+	info.BasicLimitInformation.LimitFlags = jobObjectLimitKillOnJobClose
+	ok, _, err := procSetInformationJobObject.Call(
+		uintptr(job),
+		jobObjectExtendedLimitInformationClass,
+		uintptr(unsafe.Pointer(&info)),
+		unsafe.Sizeof(info),
+	)
+	if ok == 0 {
+		syscall.CloseHandle(job)
+		return 0, err
+	}
+	return job, nil
+}
+
+func assignToJob(job syscall.Handle, pid int) error {
+	process, err := syscall.OpenProcess(processSetQuota|processTerminate, false, uint32(pid))
+	if err != nil {
+		return err
+	}
+	defer syscall.CloseHandle(process)
+
+	ok, _, err := procAssignProcessToJobObject.Call(uintptr(job), uintptr(process))
+	if ok == 0 {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/segmentio/chamber/v2/store"
+)
+
+// expiryFilter leaves the keys that have expired out of listings and refuses
+// to read them, so the commands serving values (env, exec, export, get and
+// share) stop serving them, and warns about the deprecated keys it still
+// serves
+type expiryFilter struct {
+	store.Store
+	now time.Time
+}
+
+func newExpiryFilter(s store.Store, now time.Time) *expiryFilter {
+	return &expiryFilter{Store: s, now: now}
+}
+
+func (f *expiryFilter) Read(id store.SecretId, version int) (store.Secret, error) {
+	if err := store.CheckExpiry(f.Store, id.Service, id.Key, f.now); err != nil {
+		return store.Secret{}, err
+	}
+	return f.Store.Read(id, version)
+}
+
+func (f *expiryFilter) List(service string, includeValues bool) ([]store.Secret, error) {
+	secrets, err := f.Store.List(service, includeValues)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := store.ListKeyMetadata(f.Store, service)
+	if err != nil {
+		return nil, err
+	}
+
+	fresh := make([]store.Secret, 0, len(secrets))
+	for _, secret := range secrets {
+		if f.serves(service, key(secret.Meta.Key), metadata) {
+			fresh = append(fresh, secret)
+		}
+	}
+	return fresh, nil
+}
+
+func (f *expiryFilter) ListRaw(service string) ([]store.RawSecret, error) {
+	rawSecrets, err := f.Store.ListRaw(service)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := store.ListKeyMetadata(f.Store, service)
+	if err != nil {
+		return nil, err
+	}
+
+	fresh := make([]store.RawSecret, 0, len(rawSecrets))
+	for _, rawSecret := range rawSecrets {
+		if f.serves(service, key(rawSecret.Key), metadata) {
+			fresh = append(fresh, rawSecret)
+		}
+	}
+	return fresh, nil
+}
+
+// serves reports whether key k of service is still served, warning about the
+// expired keys left out and the deprecated keys served
+func (f *expiryFilter) serves(service, k string, metadata map[string]store.KeyMetadata) bool {
+	m, ok := metadata[k]
+	if !ok {
+		return true
+	}
+	if m.Expired(f.now) {
+		fmt.Fprintf(os.Stderr, "warning: not serving %s/%s, which expired at %s\n", service, k, m.Expires.Local().Format(ShortTimeFormat))
+		return false
+	}
+	if m.Deprecation != nil {
+		fmt.Fprintf(os.Stderr, "warning: serving %s/%s, which is %s\n", service, k, m.Deprecation)
+	}
+	return true
+}
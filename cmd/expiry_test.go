@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpiryFilter(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	s := newFakeStore()
+	s.set(store.SecretId{Service: "app", Key: "ci_token"}, "token")
+	s.set(store.SecretId{Service: "app", Key: "db_password"}, "hunter2")
+	s.set(store.SecretId{Service: "_chamber-meta/app", Key: "ci_token"}, `{"expires":"2024-06-01T00:00:00Z"}`)
+
+	t.Run("Should leave expired keys out", func(t *testing.T) {
+		rawSecrets, err := newExpiryFilter(s, now).ListRaw("app")
+		assert.Nil(t, err)
+		assert.Equal(t, []store.RawSecret{{Key: "/app/db_password", Value: "hunter2"}}, rawSecrets)
+	})
+
+	t.Run("Should serve keys until they expire", func(t *testing.T) {
+		rawSecrets, err := newExpiryFilter(s, now.Add(-time.Second)).ListRaw("app")
+		assert.Nil(t, err)
+		assert.Len(t, rawSecrets, 2)
+	})
+
+	t.Run("Should leave expired keys out of listings with values", func(t *testing.T) {
+		s := store.NewStaticStore(map[string]map[string]string{
+			"app":               {"ci_token": "token", "db_password": "hunter2"},
+			"_chamber-meta/app": {"ci_token": `{"expires":"2024-06-01T00:00:00Z"}`},
+		})
+		secrets, err := newExpiryFilter(s, now).List("app", true)
+		assert.Nil(t, err)
+		assert.Len(t, secrets, 1)
+		assert.Equal(t, "hunter2", *secrets[0].Value)
+
+		_, err = newExpiryFilter(s, now).Read(store.SecretId{Service: "app", Key: "ci_token"}, -1)
+		assert.IsType(t, &store.ExpiredError{}, err)
+		secret, err := newExpiryFilter(s, now.Add(-time.Second)).Read(store.SecretId{Service: "app", Key: "ci_token"}, -1)
+		assert.Nil(t, err)
+		assert.Equal(t, "token", *secret.Value)
+	})
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/segmentio/chamber/v2/store"
+)
+
+// explainSlowest is how many of the slowest AWS requests --explain lists
+const explainSlowest = 5
+
+var apiCallsReported bool
+
+// reportAPICalls prints the AWS requests made so far for --explain, by
+// operation and then the slowest ones, at most once per process
+func reportAPICalls(w io.Writer) {
+	if !explain || apiCallsReported {
+		return
+	}
+	apiCallsReported = true
+	calls := store.APICalls()
+	fmt.Fprintf(w, "chamber: %d AWS API calls\n", len(calls))
+	if len(calls) == 0 {
+		return
+	}
+
+	type operationStats struct {
+		service, operation string
+		calls, failed      int
+		total              time.Duration
+	}
+	byOperation := map[string]*operationStats{}
+	for _, c := range calls {
+		id := c.Service + " " + c.Operation
+		stats, ok := byOperation[id]
+		if !ok {
+			stats = &operationStats{service: c.Service, operation: c.Operation}
+			byOperation[id] = stats
+		}
+		stats.calls++
+		stats.total += c.Duration
+		if c.Failed {
+			stats.failed++
+		}
+	}
+	operations := make([]*operationStats, 0, len(byOperation))
+	for _, stats := range byOperation {
+		operations = append(operations, stats)
+	}
+	sort.Slice(operations, func(i, j int) bool {
+		if operations[i].calls != operations[j].calls {
+			return operations[i].calls > operations[j].calls
+		}
+		return operations[i].service+operations[i].operation < operations[j].service+operations[j].operation
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, '\t', 0)
+	fmt.Fprintln(tw, "Service\tOperation\tCalls\tFailed\tTotal")
+	for _, stats := range operations {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\n", stats.service, stats.operation, stats.calls, stats.failed, stats.total.Round(time.Millisecond))
+	}
+	tw.Flush()
+
+	sort.SliceStable(calls, func(i, j int) bool { return calls[i].Duration > calls[j].Duration })
+	if len(calls) > explainSlowest {
+		calls = calls[:explainSlowest]
+	}
+	fmt.Fprintln(w, "Slowest:")
+	tw = tabwriter.NewWriter(w, 0, 8, 2, '\t', 0)
+	for _, c := range calls {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Service, c.Operation, c.Duration.Round(time.Millisecond))
+	}
+	tw.Flush()
+}
+
+// reportMaxAPICalls explains a failure caused by reaching --max-api-calls
+func reportMaxAPICalls(w io.Writer) {
+	if store.MaxAPICallsExceeded() {
+		fmt.Fprintf(w, "chamber: gave up after making --max-api-calls %d AWS API calls\n", maxAPICalls)
+	}
+}
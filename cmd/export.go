@@ -2,16 +2,22 @@ package cmd
 
 import (
 	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/magiconair/properties"
 	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/store"
 	"github.com/spf13/cobra"
 	analytics "gopkg.in/segmentio/analytics-go.v3"
 	"gopkg.in/yaml.v3"
@@ -23,23 +29,68 @@ const doubleQuoteSpecialChars = "\\\n\r\"!$`"
 var (
 	exportFormat string
 	exportOutput string
+	exportAsOf   string
+	exportRefs   bool
+
+	exportProvenanceHeader bool
+	exportSignKey          string
+	exportVerify           string
+	exportPublicKey        string
 
 	exportCmd = &cobra.Command{
 		Use:   "export <service...>",
 		Short: "Exports parameters in the specified format",
-		Args:  cobra.MinimumNArgs(1),
 		RunE:  runExport,
 	}
 )
 
 func init() {
-	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "json", "Output format (json, yaml, java-properties, csv, tsv, dotenv, tfvars)")
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "json", "Output format (json, yaml, java-properties, csv, tsv, dotenv, tfvars, 1password-csv, bitwarden-json)")
 	exportCmd.Flags().StringVarP(&exportOutput, "output-file", "o", "", "Output file (default is standard output)")
+	exportCmd.Flags().StringVarP(&exportAsOf, "as-of", "", "", "Export the values that were current at this RFC 3339 time, e.g. 2024-06-01T00:00:00Z")
+	exportCmd.Flags().BoolVarP(&exportRefs, "refs", "", false, "Export the ARN and version of each secret instead of its value, for systems that resolve secrets themselves")
+	exportCmd.Flags().BoolVarP(&exportProvenanceHeader, "provenance", "", false, "Head the export with where it came from and its digest; only for formats with # comments")
+	exportCmd.Flags().StringVarP(&exportSignKey, "sign-key", "", "", "Ed25519 private key (PEM) to sign the provenance header with; implies --provenance")
+	exportCmd.Flags().StringVarP(&exportVerify, "verify", "", "", "Instead of exporting, check that this exported file is unchanged")
+	exportCmd.Flags().StringVarP(&exportPublicKey, "public-key", "", "", "Ed25519 public key (PEM) that must have signed the file checked by --verify")
 	RootCmd.AddCommand(exportCmd)
 }
 
 func runExport(cmd *cobra.Command, args []string) error {
-	var err error
+	if exportVerify != "" {
+		return verifyExport(exportVerify, exportPublicKey)
+	}
+	if len(args) == 0 {
+		return errors.New("Must specify at least one service")
+	}
+	args, err := expandServices(args)
+	if err != nil {
+		return errors.Wrap(err, "Failed to expand service")
+	}
+
+	var asOf time.Time
+	if exportAsOf != "" {
+		if asOf, err = time.Parse(time.RFC3339, exportAsOf); err != nil {
+			return errors.Wrap(err, "Failed to parse --as-of")
+		}
+		if exportRefs {
+			return errors.New("Unable to use --as-of with --refs, which point at the current versions")
+		}
+	}
+
+	var signKey ed25519.PrivateKey
+	if exportSignKey != "" {
+		if signKey, err = readSigningKey(exportSignKey); err != nil {
+			return errors.Wrap(err, "Failed to read --sign-key")
+		}
+		exportProvenanceHeader = true
+	}
+	if hasPin(args) && (exportAsOf != "" || exportRefs || exportProvenanceHeader) {
+		return errors.New("version pins can't be combined with --as-of, --refs or --provenance")
+	}
+	if exportProvenanceHeader && !provenanceFormats[strings.ToLower(exportFormat)] {
+		return errors.Errorf("Unable to add provenance to %s; use a format with # comments, like dotenv or yaml", exportFormat)
+	}
 
 	if analyticsEnabled && analyticsClient != nil {
 		analyticsClient.Enqueue(analytics.Track{
@@ -49,7 +100,12 @@ func runExport(cmd *cobra.Command, args []string) error {
 				Set("command", "export").
 				Set("chamber-version", chamberVersion).
 				Set("services", args).
-				Set("backend", backend),
+				Set("backend", backend).
+				Set("as-of", exportAsOf != "").
+				Set("refs", exportRefs).
+				Set("provenance", exportProvenanceHeader).
+				Set("signed", signKey != nil).
+				Set("version-pin", hasPin(args)),
 		})
 	}
 
@@ -57,16 +113,41 @@ func runExport(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	if exportRefs {
+		if err := requireCapabilities(secretStore, store.Capabilities{References: true}); err != nil {
+			return err
+		}
+	}
+	if asOf.IsZero() {
+		secretStore = newExpiryFilter(secretStore, time.Now())
+	}
+	secretStore = newPinFilter(secretStore, time.Now())
 	params := make(map[string]string)
+	var provenance exportProvenance
 	for _, service := range args {
-		if err := validateService(service); err != nil {
+		if err := validateServiceOrPin(service, validateService); err != nil {
 			return errors.Wrapf(err, "Failed to validate service %s", service)
 		}
 
-		rawSecrets, err := secretStore.ListRaw(strings.ToLower(service))
+		var rawSecrets []store.RawSecret
+		var versions map[string]int
+		if exportRefs {
+			rawSecrets, err = listRawRefs(secretStore, strings.ToLower(service))
+			if err == nil && exportProvenanceHeader {
+				versions, err = currentVersions(secretStore, strings.ToLower(service))
+			}
+		} else if asOf.IsZero() {
+			rawSecrets, err = secretStore.ListRaw(strings.ToLower(service))
+			if err == nil && exportProvenanceHeader {
+				versions, err = currentVersions(secretStore, strings.ToLower(service))
+			}
+		} else {
+			rawSecrets, versions, err = listRawAsOf(secretStore, strings.ToLower(service), asOf)
+		}
 		if err != nil {
 			return errors.Wrapf(err, "Failed to list store contents for service %s", service)
 		}
+		provenance.Services = append(provenance.Services, provenanceService{Service: strings.ToLower(service), Versions: versions})
 		for _, rawSecret := range rawSecrets {
 			k := key(rawSecret.Key)
 			if _, ok := params[k]; ok {
@@ -87,30 +168,171 @@ func runExport(cmd *cobra.Command, args []string) error {
 	w := bufio.NewWriter(file)
 	defer w.Flush()
 
-	switch strings.ToLower(exportFormat) {
+	if !exportProvenanceHeader {
+		if err := exportParams(params, exportFormat, w); err != nil {
+			return errors.Wrap(err, "Unable to export parameters")
+		}
+		return nil
+	}
+
+	var body bytes.Buffer
+	if err := exportParams(params, exportFormat, &body); err != nil {
+		return errors.Wrap(err, "Unable to export parameters")
+	}
+	if !asOf.IsZero() {
+		provenance.AsOf = &asOf
+	}
+	provenance.Exported = time.Now().UTC()
+	provenance.ChamberVersion = chamberVersion
+	provenance.Format = strings.ToLower(exportFormat)
+	data, err := withProvenance(provenance, body.Bytes(), signKey)
+	if err != nil {
+		return errors.Wrap(err, "Unable to add provenance")
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// verifyExport checks the exported file against its provenance header
+func verifyExport(file, publicKey string) error {
+	var key ed25519.PublicKey
+	if publicKey != "" {
+		var err error
+		if key, err = readVerifyingKey(publicKey); err != nil {
+			return errors.Wrap(err, "Failed to read --public-key")
+		}
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return errors.Wrap(err, "Failed to read file")
+	}
+	p, signed, err := verifyProvenance(data, key)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to verify %s", file)
+	}
+
+	services := make([]string, 0, len(p.Services))
+	for _, s := range p.Services {
+		services = append(services, s.Service)
+	}
+	infof("%s is unchanged since chamber %s exported %s at %s\n",
+		file, p.ChamberVersion, strings.Join(services, ", "), p.Exported.Local().Format(ShortTimeFormat))
+	switch {
+	case key != nil:
+		infof("The signature matches the public key\n")
+	case signed:
+		fmt.Fprintln(os.Stderr, "warning: the file is signed, but no --public-key was given to check the signature")
+	}
+	return nil
+}
+
+// listRawRefs lists references to the secrets of service in place of their
+// values, so they export like values
+func listRawRefs(secretStore store.Store, service string) ([]store.RawSecret, error) {
+	refs, err := store.ListRefs(secretStore, service)
+	if err != nil {
+		return nil, err
+	}
+	rawSecrets := make([]store.RawSecret, 0, len(refs))
+	for _, ref := range refs {
+		rawSecrets = append(rawSecrets, store.RawSecret{Key: ref.Key, Value: ref.String()})
+	}
+	return rawSecrets, nil
+}
+
+// currentVersions returns the current version of each key of service
+func currentVersions(secretStore store.Store, service string) (map[string]int, error) {
+	secrets, err := secretStore.List(service, false)
+	if err != nil {
+		return nil, err
+	}
+	versions := make(map[string]int, len(secrets))
+	for _, secret := range secrets {
+		versions[key(secret.Meta.Key)] = secret.Meta.Version
+	}
+	return versions, nil
+}
+
+// listRawAsOf returns the values of the secrets of service that were current
+// at asOf, reconstructed from their history, along with their versions.
+// Secrets deleted since then are gone along with their history, and are
+// missing.
+func listRawAsOf(secretStore store.Store, service string, asOf time.Time) ([]store.RawSecret, map[string]int, error) {
+	secrets, err := secretStore.List(service, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rawSecrets := []store.RawSecret{}
+	versions := map[string]int{}
+	for _, secret := range secrets {
+		secretId := store.SecretId{Service: service, Key: key(secret.Meta.Key)}
+		events, err := secretStore.History(secretId)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "Failed to get history of %s", secretId.Key)
+		}
+		event, ok := versionAsOf(events, asOf)
+		if !ok {
+			continue
+		}
+		var versioned store.Secret
+		if event.Version == 0 && event.NativeVersion != 0 {
+			// parameters adopted from outside of chamber only have versions
+			// of the backend's own
+			versioned, err = store.ReadNativeVersion(secretStore, secretId, event.NativeVersion)
+		} else {
+			versioned, err = secretStore.Read(secretId, event.Version)
+		}
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "Failed to read version %s of %s", historyVersion(event), secretId.Key)
+		}
+		rawSecrets = append(rawSecrets, store.RawSecret{Key: secret.Meta.Key, Value: *versioned.Value})
+		versions[secretId.Key] = event.Version
+	}
+	return rawSecrets, versions, nil
+}
+
+// versionAsOf returns the event of the version that was current at asOf, or
+// false if the secret didn't exist yet. Versions written outside of chamber,
+// which have no version of chamber's, are ordered by the backend's own.
+func versionAsOf(events []store.ChangeEvent, asOf time.Time) (store.ChangeEvent, bool) {
+	var current store.ChangeEvent
+	found := false
+	for _, event := range events {
+		if event.Time.After(asOf) {
+			continue
+		}
+		if !found || event.Version > current.Version || (event.Version == current.Version && event.NativeVersion > current.NativeVersion) {
+			current, found = event, true
+		}
+	}
+	return current, found
+}
+
+// exportParams writes params to w in format
+func exportParams(params map[string]string, format string, w io.Writer) error {
+	switch strings.ToLower(format) {
 	case "json":
-		err = exportAsJson(params, w)
+		return exportAsJson(params, w)
 	case "yaml":
-		err = exportAsYaml(params, w)
+		return exportAsYaml(params, w)
 	case "java-properties", "properties":
-		err = exportAsJavaProperties(params, w)
+		return exportAsJavaProperties(params, w)
 	case "csv":
-		err = exportAsCsv(params, w)
+		return exportAsCsv(params, w)
 	case "tsv":
-		err = exportAsTsv(params, w)
+		return exportAsTsv(params, w)
 	case "dotenv":
-		err = exportAsEnvFile(params, w)
+		return exportAsEnvFile(params, w)
 	case "tfvars":
-		err = exportAsTfvars(params, w)
+		return exportAsTfvars(params, w)
+	case "1password-csv":
+		return exportAs1PasswordCsv(params, w)
+	case "bitwarden-json":
+		return exportAsBitwardenJson(params, w)
 	default:
-		err = errors.Errorf("Unsupported export format: %s", exportFormat)
+		return errors.Errorf("Unsupported export format: %s", format)
 	}
-
-	if err != nil {
-		return errors.Wrap(err, "Unable to export parameters")
-	}
-
-	return nil
 }
 
 func exportAsEnvFile(params map[string]string, w io.Writer) error {
@@ -187,6 +409,107 @@ func exportAsTsv(params map[string]string, w io.Writer) error {
 	return nil
 }
 
+func exportAs1PasswordCsv(params map[string]string, w io.Writer) error {
+	// 1Password's CSV import, one item per param titled by its key, like:
+	// Title,Website,Username,Password,Notes
+	// param1,,,value1,
+	// Values spanning lines are kept in the notes, which keep newlines
+	csvWriter := csv.NewWriter(w)
+	defer csvWriter.Flush()
+	if err := csvWriter.Write([]string{"Title", "Website", "Username", "Password", "Notes"}); err != nil {
+		return err
+	}
+	for _, k := range sortedKeys(params) {
+		record := []string{k, "", "", params[k], ""}
+		if strings.ContainsAny(params[k], "\r\n") {
+			record[3], record[4] = "", params[k]
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return errors.Wrapf(err, "Failed to write param %s to CSV file", k)
+		}
+	}
+	return nil
+}
+
+// bitwardenExport is Bitwarden's unencrypted JSON export, which its
+// importer reads
+type bitwardenExport struct {
+	Encrypted bool              `json:"encrypted"`
+	Folders   []bitwardenFolder `json:"folders"`
+	Items     []bitwardenItem   `json:"items"`
+}
+
+type bitwardenFolder struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// bitwardenItem is a login, or a secure note for values spanning lines
+type bitwardenItem struct {
+	FolderID   string               `json:"folderId"`
+	Type       int                  `json:"type"`
+	Name       string               `json:"name"`
+	Notes      *string              `json:"notes"`
+	Favorite   bool                 `json:"favorite"`
+	Login      *bitwardenLogin      `json:"login,omitempty"`
+	SecureNote *bitwardenSecureNote `json:"secureNote,omitempty"`
+}
+
+type bitwardenLogin struct {
+	URIs     []string `json:"uris"`
+	Username *string  `json:"username"`
+	Password string   `json:"password"`
+	TOTP     *string  `json:"totp"`
+}
+
+type bitwardenSecureNote struct {
+	Type int `json:"type"`
+}
+
+const (
+	bitwardenLoginType      = 1
+	bitwardenSecureNoteType = 2
+)
+
+func exportAsBitwardenJson(params map[string]string, w io.Writer) error {
+	// Bitwarden's JSON, one item per param named by its key, in a folder
+	// named chamber
+	folderID, err := newUUID()
+	if err != nil {
+		return err
+	}
+	export := bitwardenExport{
+		Folders: []bitwardenFolder{{ID: folderID, Name: "chamber"}},
+		Items:   []bitwardenItem{},
+	}
+	for _, k := range sortedKeys(params) {
+		value := params[k]
+		item := bitwardenItem{FolderID: folderID, Type: bitwardenLoginType, Name: k}
+		if strings.ContainsAny(value, "\r\n") {
+			item.Type = bitwardenSecureNoteType
+			item.Notes = &value
+			item.SecureNote = &bitwardenSecureNote{}
+		} else {
+			item.Login = &bitwardenLogin{URIs: []string{}, Password: value}
+		}
+		export.Items = append(export.Items, item)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(export)
+}
+
+// newUUID returns a random (version 4) UUID
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
 func sortedKeys(params map[string]string) []string {
 	keys := make([]string, len(params))
 	i := 0
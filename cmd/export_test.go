@@ -2,9 +2,12 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/segmentio/chamber/v2/store"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -39,3 +42,79 @@ func TestExportDotenv(t *testing.T) {
 		})
 	}
 }
+
+func TestVersionAsOf(t *testing.T) {
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	events := []store.ChangeEvent{
+		{Type: store.Created, Version: 1, Time: start},
+		{Type: store.Updated, Version: 2, Time: start.Add(24 * time.Hour)},
+		{Type: store.Updated, Version: 3, Time: start.Add(48 * time.Hour)},
+	}
+
+	t.Run("Should pick the version current at the time", func(t *testing.T) {
+		event, ok := versionAsOf(events, start.Add(36*time.Hour))
+		assert.True(t, ok)
+		assert.Equal(t, 2, event.Version)
+	})
+
+	t.Run("Should include versions written at exactly the time", func(t *testing.T) {
+		event, ok := versionAsOf(events, start.Add(48*time.Hour))
+		assert.True(t, ok)
+		assert.Equal(t, 3, event.Version)
+	})
+
+	t.Run("Should pick versions written outside of chamber by their own version", func(t *testing.T) {
+		adopted := []store.ChangeEvent{
+			{Type: store.Created, NativeVersion: 1, Time: start},
+			{Type: store.Updated, NativeVersion: 2, Time: start.Add(24 * time.Hour)},
+			{Type: store.Created, Version: 1, NativeVersion: 3, Time: start.Add(48 * time.Hour)},
+		}
+		event, ok := versionAsOf(adopted, start.Add(36*time.Hour))
+		assert.True(t, ok)
+		assert.Equal(t, int64(2), event.NativeVersion)
+
+		event, ok = versionAsOf(adopted, start.Add(48*time.Hour))
+		assert.True(t, ok)
+		assert.Equal(t, 1, event.Version)
+	})
+
+	t.Run("Should skip secrets created later", func(t *testing.T) {
+		_, ok := versionAsOf(events, start.Add(-time.Hour))
+		assert.False(t, ok)
+	})
+}
+
+func TestExport1PasswordCsv(t *testing.T) {
+	t.Run("Should write an item per param, with multiline values in the notes", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		err := exportAs1PasswordCsv(map[string]string{"db_password": "hunter22", "tls_key": "line1\nline2"}, buf)
+		assert.Nil(t, err)
+		assert.Equal(t, "Title,Website,Username,Password,Notes\ndb_password,,,hunter22,\ntls_key,,,,\"line1\nline2\"\n", buf.String())
+	})
+}
+
+func TestExportBitwardenJson(t *testing.T) {
+	t.Run("Should write logins, and secure notes for multiline values, in one folder", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		err := exportAsBitwardenJson(map[string]string{"db_password": "hunter22", "tls_key": "line1\nline2"}, buf)
+		assert.Nil(t, err)
+
+		var export bitwardenExport
+		assert.Nil(t, json.Unmarshal(buf.Bytes(), &export))
+		assert.False(t, export.Encrypted)
+		assert.Len(t, export.Folders, 1)
+		assert.Len(t, export.Items, 2)
+		for _, item := range export.Items {
+			assert.Equal(t, export.Folders[0].ID, item.FolderID)
+		}
+
+		assert.Equal(t, "db_password", export.Items[0].Name)
+		assert.Equal(t, bitwardenLoginType, export.Items[0].Type)
+		assert.Equal(t, "hunter22", export.Items[0].Login.Password)
+
+		assert.Equal(t, "tls_key", export.Items[1].Name)
+		assert.Equal(t, bitwardenSecureNoteType, export.Items[1].Type)
+		assert.Nil(t, export.Items[1].Login)
+		assert.Equal(t, "line1\nline2", *export.Items[1].Notes)
+	})
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/segmentio/chamber/v2/store"
+)
+
+// fakeStore keeps every version of each key in memory, numbered from 1, for
+// the tests of commands that need more of a store than a StaticStore has
+type fakeStore struct {
+	store.NullStore
+	versions map[store.SecretId][]fakeVersion
+	// native holds the parameter attributes of ListNative and the
+	// ParameterVersion ReadNativeVersion finds the latest value at
+	native map[store.SecretId]store.NativeAttributes
+	// writes of failKey fail
+	failKey string
+}
+
+type fakeVersion struct {
+	value   string
+	created time.Time
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		versions: map[store.SecretId][]fakeVersion{},
+		native:   map[store.SecretId]store.NativeAttributes{},
+	}
+}
+
+// set replaces the versions of id by values, the i-th created at Unix time i
+func (s *fakeStore) set(id store.SecretId, values ...string) {
+	s.versions[id] = nil
+	for _, value := range values {
+		s.versions[id] = append(s.versions[id], fakeVersion{value: value, created: time.Unix(int64(len(s.versions[id])), 0).UTC()})
+	}
+}
+
+// values returns every version of id, oldest first
+func (s *fakeStore) values(id store.SecretId) []string {
+	var values []string
+	for _, v := range s.versions[id] {
+		values = append(values, v.value)
+	}
+	return values
+}
+
+// latest returns the latest value of each key of service
+func (s *fakeStore) latest(service string) map[string]string {
+	values := map[string]string{}
+	for id, versions := range s.versions {
+		if id.Service == service {
+			values[id.Key] = versions[len(versions)-1].value
+		}
+	}
+	return values
+}
+
+func (s *fakeStore) secret(id store.SecretId, version int) store.Secret {
+	v := s.versions[id][version-1]
+	value := v.value
+	return store.Secret{
+		Value: &value,
+		Meta:  store.SecretMetadata{Key: "/" + id.Service + "/" + id.Key, Version: version, Created: v.created},
+	}
+}
+
+func (s *fakeStore) Write(id store.SecretId, value string) error {
+	if id.Key == s.failKey {
+		return errors.New("write failed")
+	}
+	s.versions[id] = append(s.versions[id], fakeVersion{value: value, created: time.Unix(int64(len(s.versions[id])), 0).UTC()})
+	return nil
+}
+
+func (s *fakeStore) Read(id store.SecretId, version int) (store.Secret, error) {
+	if version == -1 {
+		version = len(s.versions[id])
+	}
+	if version < 1 || version > len(s.versions[id]) {
+		return store.Secret{}, store.ErrSecretNotFound
+	}
+	return s.secret(id, version), nil
+}
+
+func (s *fakeStore) Delete(id store.SecretId) error {
+	if _, ok := s.versions[id]; !ok {
+		return store.ErrSecretNotFound
+	}
+	// the package's delete command shadows the builtin
+	versions := map[store.SecretId][]fakeVersion{}
+	for k, v := range s.versions {
+		if k != id {
+			versions[k] = v
+		}
+	}
+	s.versions = versions
+	return nil
+}
+
+// ids returns the ids of the keys of service, sorted by key
+func (s *fakeStore) ids(service string) []store.SecretId {
+	var ids []store.SecretId
+	for id := range s.versions {
+		if id.Service == service {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].Key < ids[j].Key })
+	return ids
+}
+
+func (s *fakeStore) List(service string, includeValues bool) ([]store.Secret, error) {
+	var secrets []store.Secret
+	for _, id := range s.ids(service) {
+		secret := s.secret(id, len(s.versions[id]))
+		if !includeValues {
+			secret.Value = nil
+		}
+		secrets = append(secrets, secret)
+	}
+	return secrets, nil
+}
+
+func (s *fakeStore) ListRaw(service string) ([]store.RawSecret, error) {
+	var rawSecrets []store.RawSecret
+	for _, id := range s.ids(service) {
+		secret := s.secret(id, len(s.versions[id]))
+		rawSecrets = append(rawSecrets, store.RawSecret{Key: secret.Meta.Key, Value: *secret.Value})
+	}
+	return rawSecrets, nil
+}
+
+func (s *fakeStore) History(id store.SecretId) ([]store.ChangeEvent, error) {
+	var events []store.ChangeEvent
+	for i, v := range s.versions[id] {
+		events = append(events, store.ChangeEvent{Type: store.Updated, Version: i + 1, Time: v.created})
+	}
+	return events, nil
+}
+
+func (s *fakeStore) ListNative(service string) (map[string]store.NativeAttributes, error) {
+	attributes := map[string]store.NativeAttributes{}
+	for id, a := range s.native {
+		if id.Service == service {
+			attributes[id.Key] = a
+		}
+	}
+	return attributes, nil
+}
+
+func (s *fakeStore) ReadNativeVersion(id store.SecretId, version int64) (store.Secret, error) {
+	if len(s.versions[id]) == 0 || s.native[id].ParameterVersion != version {
+		return store.Secret{}, store.ErrSecretNotFound
+	}
+	return s.secret(id, len(s.versions[id])), nil
+}
@@ -44,20 +44,25 @@ func find(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return errors.Wrap(err, "Failed to get secret store")
 	}
-	services, err := secretStore.ListServices(blankService, includeSecrets)
-	if err != nil {
-		return errors.Wrap(err, "Failed to list store contents")
+	if err := requireCapabilities(secretStore, store.Capabilities{ListServices: true}); err != nil {
+		return err
 	}
-
-	if byValue {
-		for _, service := range services {
-			allSecrets, err := secretStore.List(service, true)
-			if err == nil {
-				matches = append(matches, findValueMatch(allSecrets, findSecret)...)
+	// Services are searched page by page, so only the matches are kept
+	err = store.StreamServices(secretStore, blankService, includeSecrets, func(services []string) error {
+		if byValue {
+			for _, service := range services {
+				allSecrets, err := secretStore.List(service, true)
+				if err == nil {
+					matches = append(matches, findValueMatch(allSecrets, findSecret)...)
+				}
 			}
+		} else {
+			matches = append(matches, findKeyMatch(services, findSecret)...)
 		}
-	} else {
-		matches = append(matches, findKeyMatch(services, findSecret)...)
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to list store contents")
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, '\t', 0)
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+)
+
+var (
+	fixReencrypt bool
+
+	// fixCmd represents the fix command
+	fixCmd = &cobra.Command{
+		Use:   "fix --reencrypt <service...>",
+		Short: "Fix how the secrets of services are stored, without changing their values",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  fix,
+		Example: `
+	$ chamber fix --reencrypt app/prod
+`,
+	}
+)
+
+func init() {
+	fixCmd.Flags().BoolVarP(&fixReencrypt, "reencrypt", "", false, "Rewrite the String parameters that audit --insecure-params lists as SecureString, with the same value and the configured KMS key")
+	RootCmd.AddCommand(fixCmd)
+}
+
+func fix(cmd *cobra.Command, args []string) error {
+	if !fixReencrypt {
+		return errors.New("Nothing to fix; use --reencrypt")
+	}
+	services, err := expandServices(args)
+	if err != nil {
+		return errors.Wrap(err, "Failed to expand service")
+	}
+	for i, service := range services {
+		services[i] = strings.ToLower(service)
+		if err := validateService(services[i]); err != nil {
+			return errors.Wrap(err, "Failed to validate service")
+		}
+	}
+
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", "fix").
+				Set("chamber-version", chamberVersion).
+				Set("services", services).
+				Set("backend", backend).
+				Set("reencrypt", fixReencrypt),
+		})
+	}
+
+	secretStore, err := getSecretStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get secret store")
+	}
+
+	for _, service := range services {
+		insecure, err := insecureParams(secretStore, service)
+		if err != nil {
+			return errors.Wrap(err, "Failed to describe parameters")
+		}
+		if len(insecure) == 0 {
+			continue
+		}
+		event := HookEvent{Command: "fix", Services: []string{service}, Keys: nativeKeys(insecure)}
+		if err := runHooks(withEvent(event, PreWriteHook)); err != nil {
+			return err
+		}
+		if err := reencrypt(secretStore, service, insecure); err != nil {
+			return err
+		}
+		runPostHooks(withEvent(event, PostWriteHook))
+	}
+	return nil
+}
+
+// reencrypt writes the value of each parameter of service again, which SSM
+// stores as a new SecureString version encrypted with the configured KMS key.
+// Values are read as stored, so references are written back unresolved.
+func reencrypt(secretStore store.Store, service string, params map[string]store.NativeAttributes) error {
+	for _, k := range nativeKeys(params) {
+		id := store.SecretId{Service: service, Key: k}
+		secret, err := store.ReadNativeVersion(secretStore, id, params[k].ParameterVersion)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to read %s/%s", service, k)
+		}
+		if err := secretStore.Write(id, *secret.Value); err != nil {
+			return errors.Wrapf(err, "Failed to reencrypt %s/%s", service, k)
+		}
+		infof("Reencrypted %s/%s as version %d\n", service, k, secret.Meta.Version+1)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTypedStore has parameters of app at version 4, of the given types
+func newTypedStore(types map[string]string) *fakeStore {
+	s := newFakeStore()
+	for key, value := range map[string]string{
+		"db_password": "ref://other/db_password",
+		"api_key":     "abc",
+		"log_level":   "debug",
+	} {
+		s.set(store.SecretId{Service: "app", Key: key}, value)
+	}
+	for key, t := range types {
+		s.native[store.SecretId{Service: "app", Key: key}] = store.NativeAttributes{Type: t, ParameterVersion: 4}
+	}
+	return s
+}
+
+var typedParams = map[string]string{
+	"db_password": store.ParameterTypePlaintext,
+	"api_key":     store.ParameterTypeSecure,
+	"log_level":   store.ParameterTypePlaintext,
+}
+
+func TestInsecureParams(t *testing.T) {
+	t.Run("Should find the String parameters", func(t *testing.T) {
+		insecure, err := insecureParams(newTypedStore(typedParams), "app")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"db_password", "log_level"}, nativeKeys(insecure))
+	})
+
+	t.Run("Should leave out plaintext keys", func(t *testing.T) {
+		plaintextKeys = []string{"log_*"}
+		defer func() { plaintextKeys = nil }()
+		insecure, err := insecureParams(newTypedStore(typedParams), "app")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"db_password"}, nativeKeys(insecure))
+	})
+}
+
+func TestAuditInsecureParams(t *testing.T) {
+	t.Run("Should list String parameters and fail", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := auditInsecureParams(&buf, newTypedStore(typedParams), []string{"app"})
+		assert.Error(t, err)
+		assert.Regexp(t, `app\s+db_password\s+String`, buf.String())
+		assert.Regexp(t, `app\s+log_level\s+String`, buf.String())
+		assert.NotContains(t, buf.String(), "api_key")
+	})
+
+	t.Run("Should pass without String parameters", func(t *testing.T) {
+		s := newTypedStore(map[string]string{"api_key": store.ParameterTypeSecure})
+		assert.Nil(t, auditInsecureParams(&bytes.Buffer{}, s, []string{"app"}))
+	})
+}
+
+func TestReencrypt(t *testing.T) {
+	t.Run("Should write the stored values again", func(t *testing.T) {
+		s := newTypedStore(typedParams)
+		insecure, err := insecureParams(s, "app")
+		assert.Nil(t, err)
+		assert.Nil(t, reencrypt(s, "app", insecure))
+		app := func(key string) store.SecretId { return store.SecretId{Service: "app", Key: key} }
+		assert.Equal(t, []string{"ref://other/db_password", "ref://other/db_password"}, s.values(app("db_password")))
+		assert.Equal(t, []string{"debug", "debug"}, s.values(app("log_level")))
+		assert.Equal(t, []string{"abc"}, s.values(app("api_key")))
+	})
+
+	t.Run("Should fail when a parameter can't be read", func(t *testing.T) {
+		s := newTypedStore(typedParams)
+		assert.Nil(t, s.Delete(store.SecretId{Service: "app", Key: "log_level"}))
+		insecure, err := insecureParams(s, "app")
+		assert.Nil(t, err)
+		assert.Error(t, reencrypt(s, "app", insecure))
+	})
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+)
+
+var (
+	freezeReason string
+	freezeUntil  string
+	freezeAllow  []string
+
+	// freezeCmd represents the freeze command
+	freezeCmd = &cobra.Command{
+		Use:   "freeze <service>",
+		Short: "Block all writes to a service, e.g. during a change freeze",
+		Args:  cobra.ExactArgs(1),
+		RunE:  freeze,
+		Example: `
+	$ chamber freeze app/prod --reason "change freeze" --until 2025-01-02
+`,
+	}
+)
+
+func init() {
+	freezeCmd.Flags().StringVarP(&freezeReason, "reason", "", "", "Why the service is frozen; shown to everyone whose writes fail")
+	freezeCmd.Flags().StringVarP(&freezeUntil, "until", "", "", "When the freeze ends by itself, as YYYY-MM-DD or RFC 3339; default is never")
+	freezeCmd.Flags().StringSliceVarP(&freezeAllow, "allow", "", nil, "Additional AWS identities (ARN patterns) allowed to unfreeze the service")
+	freezeCmd.MarkFlagRequired("reason")
+	RootCmd.AddCommand(freezeCmd)
+}
+
+func freeze(cmd *cobra.Command, args []string) error {
+	service, err := expandService(args[0])
+	if err != nil {
+		return errors.Wrap(err, "Failed to expand service")
+	}
+	service = strings.ToLower(service)
+	if err := validateService(service); err != nil {
+		return errors.Wrap(err, "Failed to validate service")
+	}
+
+	var until time.Time
+	if freezeUntil != "" {
+		if until, err = parseFreezeUntil(freezeUntil); err != nil {
+			return errors.Wrap(err, "Failed to parse --until")
+		}
+	}
+
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", "freeze").
+				Set("chamber-version", chamberVersion).
+				Set("service", service).
+				Set("backend", backend),
+		})
+	}
+
+	secretStore, err := getSecretStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get secret store")
+	}
+	identity, _, err := store.CallerIdentity(numRetries)
+	if err != nil {
+		return errors.Wrap(err, "Failed to determine caller identity")
+	}
+
+	// Re-freezing must not become a way around the allow list
+	current, err := store.ReadFreeze(secretStore, service)
+	if err != nil {
+		return errors.Wrap(err, "Failed to read freeze")
+	}
+	if current != nil && current.Active(time.Now()) && !current.Allows(identity) {
+		return fmt.Errorf("%s is not allowed to change the freeze of %s; ask %s", identity, service, current.By)
+	}
+
+	f := store.Freeze{
+		Reason: freezeReason,
+		By:     identity,
+		Until:  until,
+		Allow:  append([]string{identity}, freezeAllow...),
+	}
+	return store.WriteFreeze(secretStore, service, f)
+}
+
+// parseFreezeUntil accepts a local date or an RFC 3339 timestamp
+func parseFreezeUntil(s string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
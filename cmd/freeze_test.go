@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFreezeUntil(t *testing.T) {
+	t.Run("Should accept local dates", func(t *testing.T) {
+		until, err := parseFreezeUntil("2025-01-02")
+		assert.Nil(t, err)
+		assert.Equal(t, time.Date(2025, 1, 2, 0, 0, 0, 0, time.Local), until)
+	})
+
+	t.Run("Should accept RFC 3339 timestamps", func(t *testing.T) {
+		until, err := parseFreezeUntil("2025-01-02T15:04:05Z")
+		assert.Nil(t, err)
+		assert.Equal(t, time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC), until)
+	})
+
+	t.Run("Should reject anything else", func(t *testing.T) {
+		_, err := parseFreezeUntil("next week")
+		assert.Error(t, err)
+	})
+}
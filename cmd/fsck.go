@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+)
+
+var (
+	fsckFix bool
+
+	// fsckCmd represents the fsck command
+	fsckCmd = &cobra.Command{
+		Use:   "fsck <service...>",
+		Short: "Check the versions and metadata chamber records against the backend",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  fsck,
+	}
+)
+
+func init() {
+	fsckCmd.Flags().BoolVarP(&fsckFix, "fix", "", false, "Repair the inconsistencies that can be repaired")
+	RootCmd.AddCommand(fsckCmd)
+}
+
+func fsck(cmd *cobra.Command, args []string) error {
+	services := make([]string, 0, len(args))
+	for _, arg := range args {
+		service, err := expandService(arg)
+		if err != nil {
+			return errors.Wrap(err, "Failed to expand service")
+		}
+		service = strings.ToLower(service)
+		if err := validateService(service); err != nil {
+			return errors.Wrap(err, "Failed to validate service")
+		}
+		services = append(services, service)
+	}
+
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", "fsck").
+				Set("chamber-version", chamberVersion).
+				Set("services", services).
+				Set("backend", backend).
+				Set("fix", fsckFix),
+		})
+	}
+
+	secretStore, err := getSecretStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get secret store")
+	}
+
+	unfixed := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, '\t', 0)
+	fmt.Fprintln(w, "Service\tProblem\tStatus")
+	for _, service := range services {
+		inconsistencies, err := store.CheckConsistency(secretStore, service)
+		if err != nil {
+			w.Flush()
+			return errors.Wrapf(err, "Failed to check %s", service)
+		}
+		for _, i := range inconsistencies {
+			status := "can't be fixed"
+			switch {
+			case i.Fixable && fsckFix:
+				if err := i.Fix(secretStore); err != nil {
+					w.Flush()
+					return errors.Wrapf(err, "Failed to fix %s", i.Problem)
+				}
+				status = "fixed"
+			case i.Fixable:
+				status = "fixable"
+				unfixed++
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", service, i.Problem, status)
+		}
+	}
+	w.Flush()
+
+	if unfixed > 0 {
+		return fmt.Errorf("%d inconsistencies can be fixed with --fix", unfixed)
+	}
+	return nil
+}
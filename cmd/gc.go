@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+)
+
+var (
+	gcDryRun bool
+
+	// gcCmd represents the gc command
+	gcCmd = &cobra.Command{
+		Use:   "gc",
+		Short: "Delete the metadata of deleted keys and ended freezes",
+		Args:  cobra.NoArgs,
+		RunE:  gc,
+	}
+)
+
+func init() {
+	gcCmd.Flags().BoolVarP(&gcDryRun, "dry-run", "", false, "Only print what would be deleted")
+	RootCmd.AddCommand(gcCmd)
+}
+
+func gc(cmd *cobra.Command, args []string) error {
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", "gc").
+				Set("chamber-version", chamberVersion).
+				Set("backend", backend).
+				Set("dry-run", gcDryRun),
+		})
+	}
+
+	secretStore, err := getSecretStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get secret store")
+	}
+
+	garbage, err := store.FindGarbage(secretStore, time.Now())
+	if err != nil {
+		return errors.Wrap(err, "Failed to find garbage")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, '\t', 0)
+	defer w.Flush()
+	for _, g := range garbage {
+		if !gcDryRun {
+			if err := g.Collect(secretStore); err != nil {
+				return errors.Wrapf(err, "Failed to delete %s/%s", g.Id.Service, g.Id.Key)
+			}
+		}
+		fmt.Fprintf(w, "%s/%s\t%s\n", g.Id.Service, g.Id.Key, g.Reason)
+	}
+	return nil
+}
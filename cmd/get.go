@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+)
+
+var (
+	getService  string
+	getKeysFile string
+	getFormat   string
+	getOutput   string
+
+	// getCmd represents the get command
+	getCmd = &cobra.Command{
+		Use:   "get -s <service> --keys-file <file|->",
+		Short: "Read a list of keys of a service at once, failing if any is missing",
+		Args:  cobra.NoArgs,
+		RunE:  get,
+		Example: `
+Given a keys.txt with one key per line:
+
+	db_url
+	api_token
+
+	$ chamber get -s app/prod --keys-file keys.txt --format json
+	{"api_token":"...","db_url":"..."}
+`,
+	}
+)
+
+func init() {
+	getCmd.Flags().StringVarP(&getService, "service", "s", "", "Service to read the keys of")
+	getCmd.Flags().StringVarP(&getKeysFile, "keys-file", "k", "", "File listing one key per line, or - for stdin")
+	getCmd.Flags().StringVarP(&getFormat, "format", "f", "json", "Output format (json, yaml, java-properties, csv, tsv, dotenv, tfvars)")
+	getCmd.Flags().StringVarP(&getOutput, "output-file", "o", "", "Output file (default is standard output)")
+	getCmd.MarkFlagRequired("service")
+	getCmd.MarkFlagRequired("keys-file")
+	RootCmd.AddCommand(getCmd)
+}
+
+func get(cmd *cobra.Command, args []string) error {
+	service, err := expandService(getService)
+	if err != nil {
+		return errors.Wrap(err, "Failed to expand service")
+	}
+	service = strings.ToLower(service)
+	if err := validateService(service); err != nil {
+		return errors.Wrap(err, "Failed to validate service")
+	}
+
+	var in io.Reader = os.Stdin
+	if getKeysFile != "-" {
+		f, err := os.Open(getKeysFile)
+		if err != nil {
+			return errors.Wrap(err, "Failed to open file")
+		}
+		defer f.Close()
+		in = f
+	}
+	keys, err := parseKeyList(in)
+	if err != nil {
+		return errors.Wrap(err, "Failed to parse keys file")
+	}
+
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", "get").
+				Set("chamber-version", chamberVersion).
+				Set("service", service).
+				Set("backend", backend).
+				Set("keys", len(keys)).
+				Set("format", getFormat),
+		})
+	}
+
+	secretStore, err := getSecretStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get secret store")
+	}
+	secretStore = newExpiryFilter(secretStore, time.Now())
+
+	// a single listing of the service is far cheaper than reading each key
+	rawSecrets, err := secretStore.ListRaw(service)
+	if err != nil {
+		return errors.Wrap(err, "Failed to list store contents")
+	}
+	values := make(map[string]string, len(rawSecrets))
+	for _, rawSecret := range rawSecrets {
+		values[key(rawSecret.Key)] = rawSecret.Value
+	}
+	params, err := selectKeys(values, keys)
+	if err != nil {
+		return err
+	}
+
+	file := os.Stdout
+	if getOutput != "" {
+		if file, err = os.OpenFile(getOutput, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644); err != nil {
+			return errors.Wrap(err, "Failed to open output file for writing")
+		}
+		defer file.Close()
+		defer file.Sync()
+	}
+	w := bufio.NewWriter(file)
+	defer w.Flush()
+
+	if err := exportParams(params, getFormat, w); err != nil {
+		return errors.Wrap(err, "Unable to export parameters")
+	}
+	return nil
+}
+
+// parseKeyList reads one key per line, skipping blank lines and # comments
+func parseKeyList(in io.Reader) ([]string, error) {
+	var keys []string
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		k := strings.ToLower(line)
+		if err := validateKey(k); err != nil {
+			return nil, err
+		}
+		if !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no keys listed")
+	}
+	return keys, nil
+}
+
+// selectKeys picks keys from values, failing with all missing keys at once
+func selectKeys(values map[string]string, keys []string) (map[string]string, error) {
+	params := make(map[string]string, len(keys))
+	var missing []string
+	for _, k := range keys {
+		value, ok := values[k]
+		if !ok {
+			missing = append(missing, k)
+			continue
+		}
+		params[k] = value
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("missing keys: %s", strings.Join(missing, ", "))
+	}
+	return params, nil
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKeyList(t *testing.T) {
+	t.Run("Should skip comments and blank lines", func(t *testing.T) {
+		keys, err := parseKeyList(strings.NewReader("# database\nDB_URL\n\napi_token\ndb_url\n"))
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"db_url", "api_token"}, keys)
+	})
+
+	t.Run("Should reject invalid keys", func(t *testing.T) {
+		_, err := parseKeyList(strings.NewReader("db url\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("Should reject empty lists", func(t *testing.T) {
+		_, err := parseKeyList(strings.NewReader("# nothing\n"))
+		assert.Error(t, err)
+	})
+}
+
+func TestSelectKeys(t *testing.T) {
+	values := map[string]string{"db_url": "postgres://db", "api_token": "token", "other": "value"}
+
+	t.Run("Should select only the listed keys", func(t *testing.T) {
+		params, err := selectKeys(values, []string{"db_url", "api_token"})
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"db_url": "postgres://db", "api_token": "token"}, params)
+	})
+
+	t.Run("Should report every missing key", func(t *testing.T) {
+		_, err := selectKeys(values, []string{"db_url", "smtp_password", "redis_url"})
+		assert.EqualError(t, err, "missing keys: redis_url, smtp_password")
+	})
+}
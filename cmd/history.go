@@ -3,8 +3,8 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
-	"text/tabwriter"
 
 	"github.com/pkg/errors"
 	"github.com/segmentio/chamber/v2/store"
@@ -25,7 +25,11 @@ func init() {
 }
 
 func history(cmd *cobra.Command, args []string) error {
-	service := strings.ToLower(args[0])
+	service, err := expandService(args[0])
+	if err != nil {
+		return errors.Wrap(err, "Failed to expand service")
+	}
+	service = strings.ToLower(service)
 	if err := validateService(service); err != nil {
 		return errors.Wrap(err, "Failed to validate service")
 	}
@@ -52,6 +56,9 @@ func history(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return errors.Wrap(err, "Failed to get secret store")
 	}
+	if err := requireCapabilities(secretStore, store.Capabilities{History: true}); err != nil {
+		return err
+	}
 	secretId := store.SecretId{
 		Service: service,
 		Key:     key,
@@ -62,16 +69,35 @@ func history(cmd *cobra.Command, args []string) error {
 		return errors.Wrap(err, "Failed to get history")
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, '\t', 0)
-	fmt.Fprintln(w, "Event\tVersion\tDate\tUser")
+	w := newTableWriter(os.Stdout)
+	w.Header("Event", "Version", "Date", "User")
 	for _, event := range events {
-		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n",
-			event.Type,
-			event.Version,
-			event.Time.Local().Format(ShortTimeFormat),
-			event.User,
+		eventType := event.Type.String()
+		if event.Type == store.Created {
+			w.Role(addedRole)
+		} else {
+			w.Role(changedRole)
+		}
+		if event.Type == store.Renamed {
+			eventType = fmt.Sprintf("Renamed from %s", field(event.RenamedFrom))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			eventType,
+			historyVersion(event),
+			timestamp(event.Time),
+			field(event.User),
 		)
 	}
 	w.Flush()
 	return nil
 }
+
+// historyVersion shows the version chamber recorded for an event, or the
+// backend's own as pN for secrets written without chamber, which read
+// --parameter-version N reads
+func historyVersion(event store.ChangeEvent) string {
+	if event.Version == 0 && event.NativeVersion != 0 {
+		return fmt.Sprintf("p%d", event.NativeVersion)
+	}
+	return strconv.Itoa(event.Version)
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	osexec "os/exec"
+	"runtime"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The events hooks run on
+const (
+	PreWriteHook  = "pre-write"
+	PostWriteHook = "post-write"
+	PreExecHook   = "pre-exec"
+)
+
+var hookEvents = []string{PreWriteHook, PostWriteHook, PreExecHook}
+
+// HookEvent describes what chamber is about to do, or has done. Hook commands
+// get it as JSON on stdin. It never holds secret values.
+type HookEvent struct {
+	Event   string `json:"event"`
+	Command string `json:"command"`
+	Backend string `json:"backend"`
+
+	// Services are the services written to or injected
+	Services []string `json:"services"`
+
+	// Keys are the keys written or deleted, for write events
+	Keys []string `json:"keys,omitempty"`
+
+	// Args is the command line of the command run, for exec events
+	Args []string `json:"args,omitempty"`
+}
+
+// HookFunc is a hook compiled into chamber. An error returned by a pre- hook
+// aborts the command.
+type HookFunc func(HookEvent) error
+
+var compiledHooks = map[string][]HookFunc{}
+
+// RegisterHook adds fn to the hooks of event, for programs that embed
+// chamber's commands. Compiled hooks run before the hooks of --hooks.
+func RegisterHook(event string, fn HookFunc) {
+	compiledHooks[event] = append(compiledHooks[event], fn)
+}
+
+// hookCommands are the hooks of the --hooks file, as shell commands by event
+var hookCommands map[string][]string
+
+// parseHooks reads a hooks file, listing the commands to run by event
+func parseHooks(data []byte) (map[string][]string, error) {
+	var hooks map[string][]string
+	if err := yaml.Unmarshal(data, &hooks); err != nil {
+		return nil, err
+	}
+	for event := range hooks {
+		if !stringInSlice(event, hookEvents) {
+			return nil, fmt.Errorf("unknown hook event %s", event)
+		}
+	}
+	return hooks, nil
+}
+
+// loadHooks reads the file of --hooks, or $CHAMBER_HOOKS, once
+func loadHooks() (map[string][]string, error) {
+	if hookCommands != nil {
+		return hookCommands, nil
+	}
+	path := hooksFlag
+	if hooksEnvVarValue := os.Getenv(HooksEnvVar); !RootCmd.PersistentFlags().Changed("hooks") && hooksEnvVarValue != "" {
+		path = hooksEnvVarValue
+	}
+	hookCommands = map[string][]string{}
+	if path == "" {
+		return hookCommands, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if hookCommands, err = parseHooks(data); err != nil {
+		return nil, fmt.Errorf("invalid hooks file %s: %s", path, err)
+	}
+	return hookCommands, nil
+}
+
+// runHooks runs the hooks of e.Event, stopping at the first that fails
+func runHooks(e HookEvent) error {
+	e.Backend = backend
+	for _, fn := range compiledHooks[e.Event] {
+		if err := fn(e); err != nil {
+			return fmt.Errorf("%s hook failed: %s", e.Event, err)
+		}
+	}
+
+	hooks, err := loadHooks()
+	if err != nil {
+		return err
+	}
+	if len(hooks[e.Event]) == 0 {
+		return nil
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	for _, command := range hooks[e.Event] {
+		c := shellCommand(command)
+		c.Stdin = bytes.NewReader(payload)
+		c.Stdout = os.Stderr
+		c.Stderr = os.Stderr
+		if err := c.Run(); err != nil {
+			return fmt.Errorf("%s hook %q failed: %s", e.Event, command, err)
+		}
+	}
+	return nil
+}
+
+// shellCommand returns a command running command with the system's shell
+func shellCommand(command string) *osexec.Cmd {
+	if runtime.GOOS == "windows" {
+		return osexec.Command("cmd", "/C", command)
+	}
+	return osexec.Command("sh", "-c", command)
+}
+
+// withEvent returns e for event
+func withEvent(e HookEvent, event string) HookEvent {
+	e.Event = event
+	return e
+}
+
+// runPostHooks runs the hooks of e.Event after the fact, when failing can
+// only be reported
+func runPostHooks(e HookEvent) {
+	if err := runHooks(e); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", err)
+	}
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHooks(t *testing.T) {
+	t.Run("Should read commands by event", func(t *testing.T) {
+		hooks, err := parseHooks([]byte("pre-write:\n  - ./require-ticket\npre-exec: [echo hi]\n"))
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"./require-ticket"}, hooks[PreWriteHook])
+		assert.Equal(t, []string{"echo hi"}, hooks[PreExecHook])
+	})
+
+	t.Run("Should reject unknown events", func(t *testing.T) {
+		_, err := parseHooks([]byte("pre-read: [echo hi]\n"))
+		assert.NotNil(t, err)
+	})
+}
+
+func TestRunHooks(t *testing.T) {
+	defer func() { hookCommands, compiledHooks = nil, map[string][]HookFunc{} }()
+
+	t.Run("Should abort on failing compiled hooks", func(t *testing.T) {
+		hookCommands = map[string][]string{}
+		var got HookEvent
+		RegisterHook(PreWriteHook, func(e HookEvent) error {
+			got = e
+			return errors.New("ticket required")
+		})
+		err := runHooks(HookEvent{Event: PreWriteHook, Command: "write", Services: []string{"app"}, Keys: []string{"key"}})
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "ticket required")
+		assert.Equal(t, []string{"key"}, got.Keys)
+		assert.Nil(t, runHooks(HookEvent{Event: PostWriteHook}))
+	})
+
+	t.Run("Should pass the event as JSON to hook commands", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("hook commands run with sh")
+		}
+		dir, err := ioutil.TempDir("", "chamber-hooks")
+		assert.Nil(t, err)
+		defer os.RemoveAll(dir)
+		out := filepath.Join(dir, "event.json")
+
+		hookCommands = map[string][]string{PreExecHook: {"cat > " + out}}
+		assert.Nil(t, runHooks(HookEvent{Event: PreExecHook, Command: "exec", Services: []string{"app"}, Args: []string{"env"}}))
+		payload, err := ioutil.ReadFile(out)
+		assert.Nil(t, err)
+		assert.Contains(t, string(payload), `"event":"pre-exec"`)
+		assert.Contains(t, string(payload), `"args":["env"]`)
+
+		hookCommands = map[string][]string{PreExecHook: {"exit 1"}}
+		assert.NotNil(t, runHooks(HookEvent{Event: PreExecHook}))
+	})
+}
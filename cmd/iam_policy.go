@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+)
+
+var (
+	policyWrite   bool
+	policyAccount string
+	policyRegion  string
+
+	// iamPolicyCmd represents the iam-policy command
+	iamPolicyCmd = &cobra.Command{
+		Use:   "iam-policy <service>",
+		Short: "Print the IAM policy needed to access a service with the current backend",
+		Args:  cobra.ExactArgs(1),
+		RunE:  iamPolicy,
+	}
+)
+
+func init() {
+	iamPolicyCmd.Flags().BoolVarP(&policyWrite, "write", "w", false, "Also allow writing and deleting secrets")
+	iamPolicyCmd.Flags().BoolP("read-only", "", true, "Only allow reading secrets (the default)")
+	iamPolicyCmd.Flags().StringVarP(&policyAccount, "account", "", "*", "AWS account ID to restrict the policy to")
+	iamPolicyCmd.Flags().StringVarP(&policyRegion, "region", "", "*", "AWS region to restrict the policy to")
+	RootCmd.AddCommand(iamPolicyCmd)
+}
+
+type policyDocument struct {
+	Version   string
+	Statement []policyStatement
+}
+
+type policyStatement struct {
+	Effect    string
+	Action    []string
+	Resource  []string
+	Condition map[string]map[string]interface{} `json:",omitempty"`
+}
+
+// policyOptions describes what an IAM policy grants access to
+type policyOptions struct {
+	backend   string
+	service   string
+	namespace string
+	layout    *store.Layout
+	write     bool
+	account   string
+	region    string
+	noPaths   bool
+	bucket    string
+	kmsAlias  string
+}
+
+func iamPolicy(cmd *cobra.Command, args []string) error {
+	service, err := expandService(args[0])
+	if err != nil {
+		return errors.Wrap(err, "Failed to expand service")
+	}
+	service = strings.ToLower(service)
+	if err := validateService(service); err != nil {
+		return errors.Wrap(err, "Failed to validate service")
+	}
+	if policyWrite && cmd.Flags().Changed("read-only") {
+		return errors.New("--read-only and --write are mutually exclusive")
+	}
+
+	resolveBackend()
+	_, noPaths := os.LookupEnv("CHAMBER_NO_PATHS")
+	opts := policyOptions{
+		backend:  backend,
+		service:  service,
+		write:    policyWrite,
+		account:  policyAccount,
+		region:   policyRegion,
+		noPaths:  noPaths,
+		bucket:   backendS3BucketFlag,
+		kmsAlias: store.DefaultKeyID,
+	}
+	rootPflags := RootCmd.PersistentFlags()
+	if bucketEnvVarValue := os.Getenv(BucketEnvVar); !rootPflags.Changed("backend-s3-bucket") && bucketEnvVarValue != "" {
+		opts.bucket = bucketEnvVarValue
+	}
+	if opts.namespace, err = namespaceFromEnv(); err != nil {
+		return err
+	}
+	if layoutSpec := layoutSpecFromFlags(); layoutSpec != "" {
+		if backend != SSMBackend {
+			return errors.New("Unable to use --layout with this backend")
+		}
+		if noPaths {
+			return errors.New("Layouts require path-based services; unset CHAMBER_NO_PATHS")
+		}
+		layout, err := store.ParseLayout(layoutSpec)
+		if err != nil {
+			return errors.Wrap(err, "Invalid --layout")
+		}
+		opts.layout = &layout
+	}
+	switch backend {
+	case SSMBackend:
+		if kmsKeyAliasValue := os.Getenv(KMSKeyEnvVar); kmsKeyAliasValue != "" {
+			opts.kmsAlias = kmsKeyAliasValue
+		}
+	case S3KMSBackend:
+		opts.kmsAlias = kmsKeyAliasFlag
+		if kmsKeyAliasValue := os.Getenv(KMSKeyEnvVar); !rootPflags.Changed("kms-key-alias") && kmsKeyAliasValue != "" {
+			opts.kmsAlias = kmsKeyAliasValue
+		}
+	}
+	if !strings.HasPrefix(opts.kmsAlias, "alias/") {
+		opts.kmsAlias = fmt.Sprintf("alias/%s", opts.kmsAlias)
+	}
+
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", "iam-policy").
+				Set("chamber-version", chamberVersion).
+				Set("service", service).
+				Set("backend", backend).
+				Set("write", policyWrite),
+		})
+	}
+
+	policy, err := buildPolicy(opts)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(policy)
+}
+
+// buildPolicy returns the least privileged policy for opts, covering the
+// records chamber keeps about the service besides its secrets. Services are
+// named as the backend names them, inside the namespace and, for SSM, after
+// the layout.
+func buildPolicy(opts policyOptions) (policyDocument, error) {
+	kmsCondition := map[string]map[string]interface{}{
+		"ForAnyValue:StringEquals": {"kms:ResourceAliases": opts.kmsAlias},
+	}
+	policy := policyDocument{Version: "2012-10-17"}
+
+	// a service in another region, like us-west-2:app/prod, is only
+	// readable in that region
+	region, service := store.SplitRegion(opts.service)
+	if region != "" {
+		if opts.region != "*" && opts.region != region {
+			return policy, fmt.Errorf("service %s is in region %s, not %s", opts.service, region, opts.region)
+		}
+		opts.region, opts.service = region, service
+	}
+	namespaced := func(service string) string {
+		if opts.namespace == "" {
+			return service
+		}
+		return store.Namespaced(opts.namespace, service)
+	}
+
+	switch opts.backend {
+	case SSMBackend:
+		sep := "/"
+		if opts.noPaths {
+			sep = "."
+		}
+		parameter := func(service string) string {
+			// the parameters of the service all begin with the name of a
+			// parameter with an empty key
+			prefix := store.ParameterName(opts.layout, !opts.noPaths, store.SecretId{Service: namespaced(service)})
+			return fmt.Sprintf("arn:aws:ssm:%s:%s:parameter/%s*", opts.region, opts.account, strings.TrimPrefix(prefix, "/"))
+		}
+		readable := []string{
+			parameter(opts.service),
+			parameter("_chamber-staged" + sep + opts.service),
+			parameter("_chamber-meta" + sep + opts.service),
+			parameter("_chamber-freeze" + sep + opts.service),
+		}
+		// writers may also stage new values of the service's keys, as rotate
+		// does before promoting them
+		writable := readable[:2]
+		policy.Statement = append(policy.Statement,
+			policyStatement{
+				Effect:   "Allow",
+				Action:   []string{"ssm:GetParameters", "ssm:GetParametersByPath", "ssm:GetParameterHistory"},
+				Resource: readable,
+			},
+			policyStatement{
+				// DescribeParameters doesn't support resource-level permissions
+				Effect:   "Allow",
+				Action:   []string{"ssm:DescribeParameters"},
+				Resource: []string{"*"},
+			},
+			policyStatement{
+				Effect:    "Allow",
+				Action:    []string{"kms:Decrypt"},
+				Resource:  []string{"*"},
+				Condition: kmsCondition,
+			},
+		)
+		if opts.write {
+			policy.Statement = append(policy.Statement,
+				policyStatement{
+					Effect:   "Allow",
+					Action:   []string{"ssm:PutParameter", "ssm:DeleteParameter", "ssm:LabelParameterVersion"},
+					Resource: writable,
+				},
+				policyStatement{
+					Effect:    "Allow",
+					Action:    []string{"kms:Encrypt"},
+					Resource:  []string{"*"},
+					Condition: kmsCondition,
+				},
+			)
+		}
+	case S3Backend, S3KMSBackend:
+		if opts.bucket == "" {
+			return policy, errors.New("Must set bucket for s3 backend")
+		}
+		objects := []string{fmt.Sprintf("arn:aws:s3:::%s/%s/*", opts.bucket, namespaced(opts.service))}
+		policy.Statement = append(policy.Statement, policyStatement{
+			Effect:   "Allow",
+			Action:   []string{"s3:GetObject"},
+			Resource: objects,
+		})
+		if opts.write {
+			policy.Statement = append(policy.Statement, policyStatement{
+				Effect:   "Allow",
+				Action:   []string{"s3:PutObject", "s3:DeleteObject"},
+				Resource: objects,
+			})
+		}
+		if opts.backend == S3KMSBackend {
+			policy.Statement = append(policy.Statement, policyStatement{
+				Effect:   "Allow",
+				Action:   []string{"s3:ListBucket"},
+				Resource: []string{fmt.Sprintf("arn:aws:s3:::%s", opts.bucket)},
+				Condition: map[string]map[string]interface{}{
+					"StringLike": {"s3:prefix": namespaced(opts.service) + "/__kms*"},
+				},
+			})
+			kmsActions := []string{"kms:Decrypt"}
+			if opts.write {
+				kmsActions = append(kmsActions, "kms:GenerateDataKey")
+			}
+			policy.Statement = append(policy.Statement, policyStatement{
+				Effect:    "Allow",
+				Action:    kmsActions,
+				Resource:  []string{"*"},
+				Condition: kmsCondition,
+			})
+		}
+	default:
+		return policy, fmt.Errorf("no IAM policy is needed for the %s backend", strings.ToLower(opts.backend))
+	}
+	return policy, nil
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildPolicy(t *testing.T) {
+	t.Run("Should scope SSM read access to the service's parameters", func(t *testing.T) {
+		policy, err := buildPolicy(policyOptions{backend: SSMBackend, service: "app/prod", account: "123456789012", region: "us-east-1", kmsAlias: "alias/parameter_store_key"})
+		assert.Nil(t, err)
+		assert.Len(t, policy.Statement, 3)
+		assert.Contains(t, policy.Statement[0].Resource, "arn:aws:ssm:us-east-1:123456789012:parameter/app/prod/*")
+		assert.Contains(t, policy.Statement[0].Resource, "arn:aws:ssm:us-east-1:123456789012:parameter/_chamber-freeze/app/prod/*")
+		assert.NotContains(t, policy.Statement[0].Action, "ssm:PutParameter")
+		assert.Equal(t, []string{"kms:Decrypt"}, policy.Statement[2].Action)
+	})
+
+	t.Run("Should allow SSM writes only to the service and its staged values", func(t *testing.T) {
+		policy, err := buildPolicy(policyOptions{backend: SSMBackend, service: "app", account: "*", region: "*", write: true, noPaths: true, kmsAlias: "alias/chamber"})
+		assert.Nil(t, err)
+		assert.Len(t, policy.Statement, 5)
+		assert.Equal(t, []string{"arn:aws:ssm:*:*:parameter/app.*", "arn:aws:ssm:*:*:parameter/_chamber-staged.app.*"}, policy.Statement[3].Resource)
+		assert.Equal(t, "alias/chamber", policy.Statement[4].Condition["ForAnyValue:StringEquals"]["kms:ResourceAliases"])
+	})
+
+	t.Run("Should name parameters inside the namespace and after the layout", func(t *testing.T) {
+		policy, err := buildPolicy(policyOptions{backend: SSMBackend, service: "app/prod", namespace: "tenant", account: "*", region: "*", kmsAlias: "alias/chamber"})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{
+			"arn:aws:ssm:*:*:parameter/tenant/app/prod/*",
+			"arn:aws:ssm:*:*:parameter/tenant/_chamber-staged/app/prod/*",
+			"arn:aws:ssm:*:*:parameter/tenant/_chamber-meta/app/prod/*",
+			"arn:aws:ssm:*:*:parameter/tenant/_chamber-freeze/app/prod/*",
+		}, policy.Statement[0].Resource)
+
+		layout, err := store.ParseLayout("prefix=/,separator=.,key-case=upper")
+		assert.Nil(t, err)
+		policy, err = buildPolicy(policyOptions{backend: SSMBackend, service: "app/prod", layout: &layout, account: "*", region: "*", kmsAlias: "alias/chamber"})
+		assert.Nil(t, err)
+		assert.Contains(t, policy.Statement[0].Resource, "arn:aws:ssm:*:*:parameter/app.prod.*")
+	})
+
+	t.Run("Should restrict services in another region to that region", func(t *testing.T) {
+		policy, err := buildPolicy(policyOptions{backend: SSMBackend, service: "us-west-2:app/prod", account: "*", region: "*", kmsAlias: "alias/chamber"})
+		assert.Nil(t, err)
+		assert.Contains(t, policy.Statement[0].Resource, "arn:aws:ssm:us-west-2:*:parameter/app/prod/*")
+		assert.Contains(t, policy.Statement[0].Resource, "arn:aws:ssm:us-west-2:*:parameter/_chamber-meta/app/prod/*")
+
+		_, err = buildPolicy(policyOptions{backend: SSMBackend, service: "us-west-2:app/prod", account: "*", region: "us-east-1", kmsAlias: "alias/chamber"})
+		assert.Error(t, err)
+	})
+
+	t.Run("Should scope S3 access to the service's prefix", func(t *testing.T) {
+		policy, err := buildPolicy(policyOptions{backend: S3KMSBackend, service: "app", bucket: "secrets", write: true, kmsAlias: "alias/chamber"})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"arn:aws:s3:::secrets/app/*"}, policy.Statement[0].Resource)
+		assert.Equal(t, []string{"kms:Decrypt", "kms:GenerateDataKey"}, policy.Statement[3].Action)
+	})
+
+	t.Run("Should require a bucket for S3", func(t *testing.T) {
+		_, err := buildPolicy(policyOptions{backend: S3Backend, service: "app"})
+		assert.Error(t, err)
+	})
+}
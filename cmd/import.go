@@ -1,10 +1,11 @@
 package cmd
 
 import (
-	"fmt"
 	"io"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/segmentio/chamber/v2/store"
@@ -14,6 +15,8 @@ import (
 )
 
 var (
+	importVerify bool
+
 	importCmd = &cobra.Command{
 		Use:   "import <service> <file|->",
 		Short: "import secrets from json or yaml",
@@ -23,17 +26,21 @@ var (
 )
 
 func init() {
+	importCmd.Flags().BoolVarP(&importVerify, "verify", "", false, "Read the secrets back after writing them, and fail unless each holds the value written")
 	RootCmd.AddCommand(importCmd)
 }
 
 func importRun(cmd *cobra.Command, args []string) error {
-	service := strings.ToLower(args[0])
+	service, err := expandService(args[0])
+	if err != nil {
+		return errors.Wrap(err, "Failed to expand service")
+	}
+	service = strings.ToLower(service)
 	if err := validateService(service); err != nil {
 		return errors.Wrap(err, "Failed to validate service")
 	}
 
 	var in io.Reader
-	var err error
 
 	file := args[1]
 	if file == "-" {
@@ -60,7 +67,8 @@ func importRun(cmd *cobra.Command, args []string) error {
 				Set("command", "import").
 				Set("chamber-version", chamberVersion).
 				Set("service", service).
-				Set("backend", backend),
+				Set("backend", backend).
+				Set("verify", importVerify),
 		})
 	}
 
@@ -68,17 +76,46 @@ func importRun(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return errors.Wrap(err, "Failed to get secret store")
 	}
+	if err := requireCapabilities(secretStore, store.Capabilities{Write: true}); err != nil {
+		return err
+	}
 
-	for key, value := range toBeImported {
-		secretId := store.SecretId{
-			Service: service,
-			Key:     key,
-		}
-		if err := secretStore.Write(secretId, value); err != nil {
-			return errors.Wrap(err, "Failed to write secret")
+	if err := checkQuotas(service, toBeImported, os.Stderr); err != nil {
+		return errors.Wrap(err, "Failed to check quotas")
+	}
+
+	event := HookEvent{Command: "import", Services: []string{service}}
+	for key := range toBeImported {
+		event.Keys = append(event.Keys, key)
+	}
+	sort.Strings(event.Keys)
+	if err := runHooks(withEvent(event, PreWriteHook)); err != nil {
+		return err
+	}
+
+	if err := store.WriteMany(secretStore, service, toBeImported); err != nil {
+		return errors.Wrap(err, "Failed to write secrets")
+	}
+	if importVerify {
+		for _, key := range event.Keys {
+			secretId := store.SecretId{
+				Service: service,
+				Key:     key,
+			}
+			if err := store.VerifyWrite(secretStore, secretId, toBeImported[key]); err != nil {
+				return errors.Wrap(err, "Failed to verify write")
+			}
 		}
 	}
 
-	fmt.Fprintf(os.Stdout, "Successfully imported %d secrets\n", len(toBeImported))
+	defer runPostHooks(withEvent(event, PostWriteHook))
+
+	now := time.Now()
+	for _, key := range event.Keys {
+		if err := setExpiry(secretStore, service, key, 0, now); err != nil {
+			return err
+		}
+	}
+	infof("Successfully imported %d secrets\n", len(toBeImported))
 	return nil
 }
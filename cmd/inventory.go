@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	inventoryAccounts string
+	inventoryFormat   string
+
+	// inventoryCmd represents the inventory command
+	inventoryCmd = &cobra.Command{
+		Use:   "inventory --accounts <file>",
+		Short: "Summarize the services of many AWS accounts and regions",
+		Args:  cobra.NoArgs,
+		RunE:  inventory,
+		Example: `
+Given an accounts.yaml like:
+
+	- account: "123456789012"
+	  region: us-east-1
+	  role_arn: arn:aws:iam::123456789012:role/chamber-inventory
+	- account: "210987654321"
+	  region: eu-west-1
+	  role_arn: arn:aws:iam::210987654321:role/chamber-inventory
+
+	$ chamber inventory --accounts accounts.yaml --format csv
+`,
+	}
+)
+
+func init() {
+	inventoryCmd.Flags().StringVarP(&inventoryAccounts, "accounts", "a", "", "YAML file listing the account, region and role_arn of each account to inventory")
+	inventoryCmd.Flags().StringVarP(&inventoryFormat, "format", "f", "json", "Output format (json, csv)")
+	inventoryCmd.MarkFlagRequired("accounts")
+	RootCmd.AddCommand(inventoryCmd)
+}
+
+// inventoryAccount is one entry of the accounts file
+type inventoryAccount struct {
+	Account string `yaml:"account"`
+	Region  string `yaml:"region"`
+	RoleARN string `yaml:"role_arn"`
+}
+
+// inventoryRow is a service of an account in the report
+type inventoryRow struct {
+	Account string `json:"account"`
+	Region  string `json:"region"`
+	store.ServiceSummary
+}
+
+func parseInventoryAccounts(in io.Reader) ([]inventoryAccount, error) {
+	var accounts []inventoryAccount
+	if err := yaml.NewDecoder(in).Decode(&accounts); err != nil {
+		return nil, err
+	}
+	for i, a := range accounts {
+		if a.Account == "" || a.Region == "" {
+			return nil, fmt.Errorf("entry %d: account and region are required", i+1)
+		}
+	}
+	return accounts, nil
+}
+
+func inventory(cmd *cobra.Command, args []string) error {
+	format := strings.ToLower(inventoryFormat)
+	if format != "json" && format != "csv" {
+		return errors.Errorf("Unsupported inventory format: %s", inventoryFormat)
+	}
+
+	f, err := os.Open(inventoryAccounts)
+	if err != nil {
+		return errors.Wrap(err, "Failed to open file")
+	}
+	defer f.Close()
+	accounts, err := parseInventoryAccounts(f)
+	if err != nil {
+		return errors.Wrap(err, "Failed to parse accounts file")
+	}
+
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", "inventory").
+				Set("chamber-version", chamberVersion).
+				Set("accounts", len(accounts)).
+				Set("format", format),
+		})
+	}
+
+	if err := configureSessions(); err != nil {
+		return err
+	}
+
+	rows := []inventoryRow{}
+	for _, a := range accounts {
+		s, err := store.NewSSMStoreForAccount(numRetries, minThrottleDelay, a.Region, a.RoleARN)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to get secret store for %s in %s", a.Account, a.Region)
+		}
+		summaries, err := s.Inventory()
+		if err != nil {
+			return errors.Wrapf(err, "Failed to inventory %s in %s", a.Account, a.Region)
+		}
+		for _, summary := range summaries {
+			rows = append(rows, inventoryRow{Account: a.Account, Region: a.Region, ServiceSummary: summary})
+		}
+	}
+
+	if format == "csv" {
+		return writeInventoryCsv(os.Stdout, rows)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rows)
+}
+
+func writeInventoryCsv(w io.Writer, rows []inventoryRow) error {
+	csvWriter := csv.NewWriter(w)
+	csvWriter.Write([]string{"account", "region", "service", "keys", "oldest", "newest", "owner"})
+	for _, r := range rows {
+		csvWriter.Write([]string{
+			r.Account,
+			r.Region,
+			r.Service,
+			strconv.Itoa(r.Keys),
+			r.Oldest.UTC().Format(time.RFC3339),
+			r.Newest.UTC().Format(time.RFC3339),
+			r.Owner,
+		})
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
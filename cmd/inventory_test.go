@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInventoryAccounts(t *testing.T) {
+	t.Run("Should read each account", func(t *testing.T) {
+		accounts, err := parseInventoryAccounts(strings.NewReader(`
+- account: "123456789012"
+  region: us-east-1
+  role_arn: arn:aws:iam::123456789012:role/chamber-inventory
+- account: "210987654321"
+  region: eu-west-1
+`))
+		assert.Nil(t, err)
+		assert.Equal(t, []inventoryAccount{
+			{Account: "123456789012", Region: "us-east-1", RoleARN: "arn:aws:iam::123456789012:role/chamber-inventory"},
+			{Account: "210987654321", Region: "eu-west-1"},
+		}, accounts)
+	})
+
+	t.Run("Should require a region", func(t *testing.T) {
+		_, err := parseInventoryAccounts(strings.NewReader(`- account: "123456789012"`))
+		assert.Error(t, err)
+	})
+}
+
+func TestWriteInventoryCsv(t *testing.T) {
+	day := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	err := writeInventoryCsv(&buf, []inventoryRow{{
+		Account:        "123456789012",
+		Region:         "us-east-1",
+		ServiceSummary: store.ServiceSummary{Service: "app", Keys: 2, Oldest: day, Newest: day, Owner: "apps (#apps)"},
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, "account,region,service,keys,oldest,newest,owner\n"+
+		"123456789012,us-east-1,app,2,2024-06-01T00:00:00Z,2024-06-01T00:00:00Z,apps (#apps)\n", buf.String())
+}
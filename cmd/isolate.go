@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SecretsDirEnvVar names the directory holding the secrets of a command run
+// with exec --isolate, one file per variable
+const SecretsDirEnvVar = "CHAMBER_SECRETS_DIR"
+
+// credentialEnvVars are the variables that would give an isolated command
+// chamber's own AWS credentials
+var credentialEnvVars = []string{
+	"AWS_ACCESS_KEY_ID",
+	"AWS_SECRET_ACCESS_KEY",
+	"AWS_SESSION_TOKEN",
+	"AWS_SECURITY_TOKEN",
+	"AWS_PROFILE",
+	"AWS_DEFAULT_PROFILE",
+	"AWS_ROLE_ARN",
+	"AWS_WEB_IDENTITY_TOKEN_FILE",
+	"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI",
+	"AWS_CONTAINER_CREDENTIALS_FULL_URI",
+	"AWS_CONTAINER_AUTHORIZATION_TOKEN",
+	"AWS_SHARED_CREDENTIALS_FILE",
+	"AWS_CONFIG_FILE",
+	"ACTIONS_ID_TOKEN_REQUEST_TOKEN",
+	CredentialProcessEnvVar,
+}
+
+// withoutCredentials returns env without the variables holding or pointing
+// to AWS credentials
+func withoutCredentials(env []string) []string {
+	result := make([]string, 0, len(env))
+	for _, v := range env {
+		name := strings.SplitN(v, "=", 2)[0]
+		if !stringInSlice(name, credentialEnvVars) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// credentialDirs returns the existing directories holding the AWS credentials
+// and configuration of env, to hide from an isolated command
+func credentialDirs(env []string, home string) []string {
+	candidates := []string{}
+	if home != "" {
+		candidates = append(candidates, filepath.Join(home, ".aws"))
+	}
+	for _, v := range env {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) == 2 && parts[1] != "" && (parts[0] == "AWS_SHARED_CREDENTIALS_FILE" || parts[0] == "AWS_CONFIG_FILE" || parts[0] == "AWS_WEB_IDENTITY_TOKEN_FILE") {
+			candidates = append(candidates, filepath.Dir(parts[1]))
+		}
+	}
+
+	dirs := []string{}
+	for _, dir := range candidates {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() && !stringInSlice(dir, dirs) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// writeSecretFiles writes each secret to a file of dir named after its
+// variable, readable only by the current user
+func writeSecretFiles(dir string, secrets map[string]string) error {
+	for name, value := range secrets {
+		if strings.ContainsAny(name, "/\x00") || name == "." || name == ".." {
+			continue
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(value), 0400); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func stringInSlice(val string, sl []string) bool {
+	for _, v := range sl {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
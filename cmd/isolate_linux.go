@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	osexec "os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// isolateHelperCmd runs inside the namespaces of exec --isolate to set up the
+// mounts of the command before becoming it. It is internal to chamber.
+var isolateHelperCmd = &cobra.Command{
+	Use:                "__isolate <dir> <command> [<arg...>]",
+	Hidden:             true,
+	DisableFlagParsing: true,
+	Args:               cobra.MinimumNArgs(2),
+	RunE:               isolateHelper,
+}
+
+func init() {
+	RootCmd.AddCommand(isolateHelperCmd)
+}
+
+// execIsolated runs the command in private user and mount namespaces, where
+// secrets are files on a tmpfs only it can see, and chamber's AWS
+// credentials are hidden, and exits with its exit code.
+func execIsolated(command string, args []string, env []string, secrets map[string]string) error {
+	self, err := os.Executable()
+	if err != nil {
+		return errors.Wrap(err, "Failed to find chamber executable")
+	}
+	// The mount point of the tmpfs; it stays empty outside the namespace
+	dir, err := ioutil.TempDir("", "chamber-secrets")
+	if err != nil {
+		return errors.Wrap(err, "Failed to create secrets directory")
+	}
+	defer os.RemoveAll(dir)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+
+	ecmd := osexec.Command(self, append([]string{isolateHelperCmd.Name(), dir, command}, args...)...)
+	ecmd.Stdin = os.Stdin
+	ecmd.Stdout = os.Stdout
+	ecmd.Stderr = os.Stderr
+	ecmd.Env = append(withoutCredentials(env), SecretsDirEnvVar+"="+dir)
+	ecmd.ExtraFiles = []*os.File{r}
+	ecmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags:                 syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS,
+		UidMappings:                []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}},
+		GidMappings:                []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}},
+		GidMappingsEnableSetgroups: false,
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan)
+	if err := ecmd.Start(); err != nil {
+		w.Close()
+		r.Close()
+		return errors.Wrap(err, "Failed to start isolated command; unprivileged user namespaces may be disabled")
+	}
+	r.Close()
+	go func() {
+		for sig := range sigChan {
+			ecmd.Process.Signal(sig)
+		}
+	}()
+
+	// The helper reads the secrets while chamber waits for the command
+	go func() {
+		json.NewEncoder(w).Encode(secrets)
+		w.Close()
+	}()
+
+	err = ecmd.Wait()
+	os.RemoveAll(dir)
+	if exitErr, ok := err.(*osexec.ExitError); ok {
+		os.Exit(exitErr.Sys().(syscall.WaitStatus).ExitStatus())
+	}
+	if err != nil {
+		return errors.Wrap(err, "Failed to wait for command termination")
+	}
+	os.Exit(0)
+	return nil // unreachable but Go doesn't know about it
+}
+
+func isolateHelper(cmd *cobra.Command, args []string) error {
+	dir, command, commandArgs := args[0], args[1], args[2:]
+
+	var secrets map[string]string
+	in := os.NewFile(3, "secrets")
+	if err := json.NewDecoder(in).Decode(&secrets); err != nil {
+		return errors.Wrap(err, "Failed to read secrets")
+	}
+	in.Close()
+
+	// Keep the mounts below from propagating back to the host
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return errors.Wrap(err, "Failed to make mounts private")
+	}
+	home, _ := os.UserHomeDir()
+	for _, credentials := range credentialDirs(os.Environ(), home) {
+		if err := syscall.Mount("tmpfs", credentials, "tmpfs", syscall.MS_RDONLY|syscall.MS_NOSUID|syscall.MS_NODEV, "size=4k,mode=0500"); err != nil {
+			return errors.Wrapf(err, "Failed to hide %s", credentials)
+		}
+	}
+	if err := syscall.Mount("tmpfs", dir, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV|syscall.MS_NOEXEC, "mode=0700"); err != nil {
+		return errors.Wrap(err, "Failed to mount secrets directory")
+	}
+	if err := writeSecretFiles(dir, secrets); err != nil {
+		return errors.Wrap(err, "Failed to write secrets")
+	}
+	if err := syscall.Mount("", dir, "", syscall.MS_REMOUNT|syscall.MS_RDONLY|syscall.MS_NOSUID|syscall.MS_NODEV|syscall.MS_NOEXEC, ""); err != nil {
+		return errors.Wrap(err, "Failed to make secrets directory read-only")
+	}
+
+	if err := exec(command, commandArgs, os.Environ()); err != nil {
+		return fmt.Errorf("Failed to run %s: %s", command, err)
+	}
+	return nil
+}
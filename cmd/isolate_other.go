@@ -0,0 +1,10 @@
+// +build !linux
+
+package cmd
+
+import "errors"
+
+// execIsolated needs Linux namespaces
+func execIsolated(command string, args []string, env []string, secrets map[string]string) error {
+	return errors.New("--isolate is only supported on Linux")
+}
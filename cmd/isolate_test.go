@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithoutCredentials(t *testing.T) {
+	t.Run("Should drop variables holding or pointing to credentials", func(t *testing.T) {
+		env := []string{"PATH=/bin", "AWS_ACCESS_KEY_ID=id", "AWS_SECRET_ACCESS_KEY=secret", "AWS_REGION=us-east-1", "AWS_PROFILE=prod"}
+		assert.Equal(t, []string{"PATH=/bin", "AWS_REGION=us-east-1"}, withoutCredentials(env))
+	})
+}
+
+func TestCredentialDirs(t *testing.T) {
+	home, err := ioutil.TempDir("", "chamber-isolate")
+	assert.Nil(t, err)
+	defer os.RemoveAll(home)
+	assert.Nil(t, os.Mkdir(filepath.Join(home, ".aws"), 0700))
+	assert.Nil(t, os.Mkdir(filepath.Join(home, "creds"), 0700))
+
+	t.Run("Should include ~/.aws and the directories of credential files", func(t *testing.T) {
+		env := []string{"AWS_SHARED_CREDENTIALS_FILE=" + filepath.Join(home, "creds", "credentials"), "AWS_CONFIG_FILE=" + filepath.Join(home, ".aws", "config")}
+		assert.Equal(t, []string{filepath.Join(home, ".aws"), filepath.Join(home, "creds")}, credentialDirs(env, home))
+	})
+
+	t.Run("Should skip directories that don't exist", func(t *testing.T) {
+		env := []string{"AWS_CONFIG_FILE=" + filepath.Join(home, "missing", "config")}
+		assert.Equal(t, []string{filepath.Join(home, ".aws")}, credentialDirs(env, home))
+	})
+}
+
+func TestWriteSecretFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chamber-isolate")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	t.Run("Should write one read-only file per secret", func(t *testing.T) {
+		assert.Nil(t, writeSecretFiles(dir, map[string]string{"DB_PASSWORD": "hunter2", "../escape": "nope"}))
+		value, err := ioutil.ReadFile(filepath.Join(dir, "DB_PASSWORD"))
+		assert.Nil(t, err)
+		assert.Equal(t, "hunter2", string(value))
+
+		info, err := os.Stat(filepath.Join(dir, "DB_PASSWORD"))
+		assert.Nil(t, err)
+		assert.Equal(t, os.FileMode(0400), info.Mode().Perm())
+
+		files, err := ioutil.ReadDir(dir)
+		assert.Nil(t, err)
+		assert.Len(t, files, 1)
+	})
+}
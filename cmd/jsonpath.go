@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonPathStep is one field name or array index of a JSONPath
+type jsonPathStep struct {
+	field   string
+	index   int
+	isIndex bool
+}
+
+// parseJSONPath parses the subset of JSONPath needed to pick a field out of
+// a JSON-valued secret: $, .field, ['field'] and [index].
+func parseJSONPath(expr string) ([]jsonPathStep, error) {
+	if !strings.HasPrefix(expr, "$") {
+		return nil, fmt.Errorf("invalid JSONPath %q: must start with $", expr)
+	}
+	rest := expr[1:]
+	var steps []jsonPathStep
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end == -1 {
+				end = len(rest) - 1
+			}
+			field := rest[1 : end+1]
+			if field == "" {
+				return nil, fmt.Errorf("invalid JSONPath %q: empty field name", expr)
+			}
+			steps = append(steps, jsonPathStep{field: field})
+			rest = rest[end+1:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("invalid JSONPath %q: unterminated [", expr)
+			}
+			inner := rest[1:end]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				steps = append(steps, jsonPathStep{field: inner[1 : len(inner)-1]})
+			} else {
+				index, err := strconv.Atoi(inner)
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf("invalid JSONPath %q: %q is neither a quoted field nor an index", expr, inner)
+				}
+				steps = append(steps, jsonPathStep{index: index, isIndex: true})
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid JSONPath %q: unexpected %q", expr, rest[0])
+		}
+	}
+	return steps, nil
+}
+
+// extractJSONPath returns the field of the JSON document value at expr.
+// Strings are returned as they are; other values as JSON.
+func extractJSONPath(value, expr string) (string, error) {
+	steps, err := parseJSONPath(expr)
+	if err != nil {
+		return "", err
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return "", fmt.Errorf("value is not JSON: %s", err)
+	}
+
+	for _, step := range steps {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			if step.isIndex {
+				return "", fmt.Errorf("%s: [%d] indexes an object", expr, step.index)
+			}
+			field, ok := node[step.field]
+			if !ok {
+				return "", fmt.Errorf("%s: no field %s", expr, step.field)
+			}
+			doc = field
+		case []interface{}:
+			if !step.isIndex {
+				return "", fmt.Errorf("%s: field %s of an array", expr, step.field)
+			}
+			if step.index >= len(node) {
+				return "", fmt.Errorf("%s: index %d out of range", expr, step.index)
+			}
+			doc = node[step.index]
+		default:
+			return "", fmt.Errorf("%s: cannot descend into a %T", expr, doc)
+		}
+	}
+
+	if s, ok := doc.(string); ok {
+		return s, nil
+	}
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(doc); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(out.String(), "\n"), nil
+}
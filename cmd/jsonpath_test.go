@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractJSONPath(t *testing.T) {
+	value := `{"username":"root","password":"hunter2","port":5432,"hosts":["a","b"],"db.name":{"x":1}}`
+
+	t.Run("Should extract fields", func(t *testing.T) {
+		for expr, expected := range map[string]string{
+			"$.password":     "hunter2",
+			"$['username']":  "root",
+			"$.port":         "5432",
+			"$.hosts[1]":     "b",
+			"$['db.name']":   `{"x":1}`,
+			`$["db.name"].x`: "1",
+			"$.hosts":        `["a","b"]`,
+		} {
+			got, err := extractJSONPath(value, expr)
+			assert.Nil(t, err, expr)
+			assert.Equal(t, expected, got, expr)
+		}
+	})
+
+	t.Run("Should fail on missing fields", func(t *testing.T) {
+		for _, expr := range []string{"$.missing", "$.hosts[2]", "$.hosts.x", "$.port.x", "$[0]"} {
+			_, err := extractJSONPath(value, expr)
+			assert.Error(t, err, expr)
+		}
+	})
+
+	t.Run("Should reject invalid paths", func(t *testing.T) {
+		for _, expr := range []string{"password", "$..x", "$[", "$[x]", "$x"} {
+			_, err := extractJSONPath(value, expr)
+			assert.Error(t, err, expr)
+		}
+	})
+
+	t.Run("Should reject values that are not JSON", func(t *testing.T) {
+		_, err := extractJSONPath("hunter2", "$.password")
+		assert.Error(t, err)
+	})
+}
+
+func TestParseExtractions(t *testing.T) {
+	t.Run("Should parse VAR=KEY#path", func(t *testing.T) {
+		extractions, err := parseExtractions([]string{"DB_PASSWORD=DB_CREDENTIALS#$.password"})
+		assert.Nil(t, err)
+		assert.Equal(t, []extraction{{variable: "DB_PASSWORD", source: "DB_CREDENTIALS", path: "$.password"}}, extractions)
+	})
+
+	t.Run("Should reject malformed mappings", func(t *testing.T) {
+		for _, mapping := range []string{"DB_PASSWORD", "DB_PASSWORD=DB_CREDENTIALS", "=DB#$.x", "X=#$.x", "X=DB#x"} {
+			_, err := parseExtractions([]string{mapping})
+			assert.Error(t, err, mapping)
+		}
+	})
+}
@@ -5,9 +5,9 @@ import (
 	"os"
 	"sort"
 	"strings"
-	"text/tabwriter"
 
 	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/store"
 	"github.com/spf13/cobra"
 )
 
@@ -32,21 +32,26 @@ func listServices(cmd *cobra.Command, args []string) error {
 	if len(args) == 0 {
 		service = ""
 	} else {
-		service = strings.ToLower(args[0])
-
+		expanded, err := expandService(args[0])
+		if err != nil {
+			return errors.Wrap(err, "Failed to expand service")
+		}
+		service = strings.ToLower(expanded)
 	}
 	secretStore, err := getSecretStore()
 	if err != nil {
 		return errors.Wrap(err, "Failed to get secret store")
 	}
+	if err := requireCapabilities(secretStore, store.Capabilities{ListServices: true}); err != nil {
+		return err
+	}
 	secrets, err := secretStore.ListServices(service, includeSecretName)
 	if err != nil {
 		return errors.Wrap(err, "Failed to list store contents")
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, '\t', 0)
-	fmt.Fprint(w, "Service")
-	fmt.Fprintln(w, "")
+	w := newTableWriter(os.Stdout)
+	w.Header("Service")
 
 	sort.Strings(secrets)
 
@@ -1,11 +1,13 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"sort"
 	"strings"
-	"text/tabwriter"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/segmentio/chamber/v2/store"
@@ -26,21 +28,46 @@ var (
 	sortByTime    bool
 	sortByUser    bool
 	sortByVersion bool
+
+	// listJSON prints a JSON document per key instead of a table, with the
+	// backend's own attributes of each key if listNative is set
+	listJSON   bool
+	listNative bool
 )
 
+// listEntry is the JSON document list --json prints for each key
+type listEntry struct {
+	Key          string                  `json:"key"`
+	Version      int                     `json:"version"`
+	LastModified time.Time               `json:"last_modified"`
+	User         string                  `json:"user"`
+	Value        *string                 `json:"value,omitempty"`
+	Metadata     *store.KeyMetadata      `json:"metadata,omitempty"`
+	Native       *store.NativeAttributes `json:"native,omitempty"`
+}
+
 func init() {
 	listCmd.Flags().BoolVarP(&withValues, "expand", "e", false, "Expand parameter list with values")
 	listCmd.Flags().BoolVarP(&sortByTime, "time", "t", false, "Sort by modified time")
 	listCmd.Flags().BoolVarP(&sortByUser, "user", "u", false, "Sort by user")
 	listCmd.Flags().BoolVarP(&sortByVersion, "version", "v", false, "Sort by version")
+	listCmd.Flags().BoolVarP(&listJSON, "json", "", false, "Print a JSON document per key, with its metadata, instead of a table")
+	listCmd.Flags().BoolVarP(&listNative, "include-native", "", false, "Include the backend's own attributes of each key, like the SSM type, tier and KMS key; requires --json")
 	RootCmd.AddCommand(listCmd)
 }
 
 func list(cmd *cobra.Command, args []string) error {
-	service := strings.ToLower(args[0])
+	service, err := expandService(args[0])
+	if err != nil {
+		return errors.Wrap(err, "Failed to expand service")
+	}
+	service = strings.ToLower(service)
 	if err := validateServiceWithLabel(service); err != nil {
 		return errors.Wrap(err, "Failed to validate service")
 	}
+	if listNative && !listJSON {
+		return errors.New("--include-native requires --json")
+	}
 
 	if analyticsEnabled && analyticsClient != nil {
 		analyticsClient.Enqueue(analytics.Track{
@@ -50,7 +77,9 @@ func list(cmd *cobra.Command, args []string) error {
 				Set("command", "list").
 				Set("chamber-version", chamberVersion).
 				Set("service", service).
-				Set("backend", backend),
+				Set("backend", backend).
+				Set("json", listJSON).
+				Set("include-native", listNative),
 		})
 	}
 
@@ -62,14 +91,22 @@ func list(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return errors.Wrap(err, "Failed to list store contents")
 	}
+	if listJSON {
+		return listAsJSON(os.Stdout, secretStore, service, secrets)
+	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, '\t', 0)
+	// Owners go to stderr so the table stays the same for scripts
+	if o := serviceOwners(secretStore).Owner(service); o != nil {
+		infof("Owner: %s\n", o)
+	}
+
+	w := newTableWriter(os.Stdout)
 
-	fmt.Fprint(w, "Key\tVersion\tLastModified\tUser")
+	columns := []string{"Key", "Version", "LastModified", "User"}
 	if withValues {
-		fmt.Fprint(w, "\tValue")
+		columns = append(columns, "Value")
 	}
-	fmt.Fprintln(w, "")
+	w.Header(columns...)
 
 	sort.Sort(ByName(secrets))
 	if sortByTime {
@@ -82,14 +119,32 @@ func list(cmd *cobra.Command, args []string) error {
 		sort.Sort(ByVersion(secrets))
 	}
 
+	// Only colored tables show which keys have expired or are deprecated,
+	// sparing scripts the extra request
+	var metadata map[string]store.KeyMetadata
+	if w.theme != nil {
+		if metadata, err = store.ListKeyMetadata(secretStore, service); err != nil {
+			return errors.Wrap(err, "Failed to list key metadata")
+		}
+	}
+	now := time.Now()
+
 	for _, secret := range secrets {
+		if m, ok := metadata[key(secret.Meta.Key)]; ok {
+			switch {
+			case m.Expired(now):
+				w.Role(expiredRole)
+			case m.Deprecation != nil:
+				w.Role(deprecatedRole)
+			}
+		}
 		fmt.Fprintf(w, "%s\t%d\t%s\t%s",
 			key(secret.Meta.Key),
 			secret.Meta.Version,
-			secret.Meta.Created.Local().Format(ShortTimeFormat),
-			secret.Meta.CreatedBy)
+			timestamp(secret.Meta.Created),
+			field(secret.Meta.CreatedBy))
 		if withValues {
-			fmt.Fprintf(w, "\t%s", *secret.Value)
+			fmt.Fprintf(w, "\t%s", field(*secret.Value))
 		}
 		fmt.Fprintln(w, "")
 	}
@@ -98,6 +153,45 @@ func list(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// listAsJSON writes the secrets of service as JSON documents, merging in
+// their key metadata and, with --include-native, the backend's attributes
+func listAsJSON(w io.Writer, secretStore store.Store, service string, secrets []store.Secret) error {
+	metadata, err := store.ListKeyMetadata(secretStore, service)
+	if err != nil {
+		return errors.Wrap(err, "Failed to list key metadata")
+	}
+	var native map[string]store.NativeAttributes
+	if listNative {
+		if native, err = store.ListNative(secretStore, service); err != nil {
+			return errors.Wrap(err, "Failed to list native attributes")
+		}
+	}
+
+	sort.Sort(ByName(secrets))
+	entries := make([]listEntry, 0, len(secrets))
+	for _, secret := range secrets {
+		k := key(secret.Meta.Key)
+		entry := listEntry{
+			Key:          k,
+			Version:      secret.Meta.Version,
+			LastModified: secret.Meta.Created,
+			User:         secret.Meta.CreatedBy,
+			Value:        secret.Value,
+		}
+		if m, ok := metadata[k]; ok {
+			entry.Metadata = &m
+		}
+		if a, ok := native[k]; ok {
+			entry.Native = &a
+		}
+		entries = append(entries, entry)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
 func key(s string) string {
 	_, noPaths := os.LookupEnv("CHAMBER_NO_PATHS")
 	sep := "/"
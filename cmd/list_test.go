@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListAsJSON(t *testing.T) {
+	created := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	secrets := []store.Secret{
+		{Meta: store.SecretMetadata{Key: "/app/db_password", Version: 3, Created: created, CreatedBy: "alice"}},
+		{Meta: store.SecretMetadata{Key: "/app/api_key", Version: 1, Created: created, CreatedBy: "bob"}},
+	}
+	s := newFakeStore()
+	s.native[store.SecretId{Service: "app", Key: "db_password"}] = store.NativeAttributes{Type: "SecureString", Tier: "Standard", KMSKeyId: "alias/parameter_store_key"}
+
+	t.Run("Should print a document per key, sorted by key", func(t *testing.T) {
+		listNative = false
+		var buf bytes.Buffer
+		assert.Nil(t, listAsJSON(&buf, s, "app", secrets))
+
+		var entries []listEntry
+		assert.Nil(t, json.Unmarshal(buf.Bytes(), &entries))
+		assert.Equal(t, []listEntry{
+			{Key: "api_key", Version: 1, LastModified: created, User: "bob"},
+			{Key: "db_password", Version: 3, LastModified: created, User: "alice"},
+		}, entries)
+	})
+
+	t.Run("Should merge in native attributes with --include-native", func(t *testing.T) {
+		listNative = true
+		defer func() { listNative = false }()
+		var buf bytes.Buffer
+		assert.Nil(t, listAsJSON(&buf, s, "app", secrets))
+
+		var entries []listEntry
+		assert.Nil(t, json.Unmarshal(buf.Bytes(), &entries))
+		assert.Nil(t, entries[0].Native)
+		assert.Equal(t, &store.NativeAttributes{Type: "SecureString", Tier: "Standard", KMSKeyId: "alias/parameter_store_key"}, entries[1].Native)
+	})
+
+	t.Run("Should fail with --include-native for backends without native attributes", func(t *testing.T) {
+		listNative = true
+		defer func() { listNative = false }()
+		assert.Error(t, listAsJSON(&bytes.Buffer{}, &store.NullStore{}, "app", secrets))
+	})
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+)
+
+var (
+	metadataOutput string
+
+	// metadataCmd groups the commands handling key metadata
+	metadataCmd = &cobra.Command{
+		Use:   "metadata",
+		Short: "Export or import the metadata of the keys of a service, without their values",
+	}
+
+	metadataExportCmd = &cobra.Command{
+		Use:   "export <service>",
+		Short: "Print the metadata of the keys of a service as JSON",
+		Args:  cobra.ExactArgs(1),
+		RunE:  metadataExport,
+	}
+
+	metadataImportCmd = &cobra.Command{
+		Use:   "import <service> <file|->",
+		Short: "Record the metadata of keys of a service from JSON",
+		Args:  cobra.ExactArgs(2),
+		RunE:  metadataImport,
+		Example: `
+Apply the allowed values and expiry of staging's keys to production:
+
+	$ chamber metadata export app/staging | chamber metadata import app/prod -
+`,
+	}
+)
+
+func init() {
+	metadataExportCmd.Flags().StringVarP(&metadataOutput, "output-file", "o", "", "Write to file instead of stdout")
+	metadataCmd.AddCommand(metadataExportCmd)
+	metadataCmd.AddCommand(metadataImportCmd)
+	RootCmd.AddCommand(metadataCmd)
+}
+
+func metadataServiceArg(arg string) (string, error) {
+	service, err := expandService(arg)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to expand service")
+	}
+	service = strings.ToLower(service)
+	if err := validateService(service); err != nil {
+		return "", errors.Wrap(err, "Failed to validate service")
+	}
+	return service, nil
+}
+
+func trackMetadata(command, service string) {
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", command).
+				Set("chamber-version", chamberVersion).
+				Set("service", service).
+				Set("backend", backend),
+		})
+	}
+}
+
+func metadataExport(cmd *cobra.Command, args []string) error {
+	service, err := metadataServiceArg(args[0])
+	if err != nil {
+		return err
+	}
+	trackMetadata("metadata export", service)
+
+	secretStore, err := getSecretStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get secret store")
+	}
+	metadata, err := store.ListKeyMetadata(secretStore, service)
+	if err != nil {
+		return errors.Wrap(err, "Failed to list metadata")
+	}
+
+	out := os.Stdout
+	if metadataOutput != "" {
+		if out, err = os.OpenFile(metadataOutput, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644); err != nil {
+			return errors.Wrap(err, "Failed to open output file for writing")
+		}
+		defer out.Close()
+	}
+	return writeMetadata(out, metadata)
+}
+
+// writeMetadata prints the metadata of keys as JSON, by key
+func writeMetadata(w io.Writer, metadata map[string]store.KeyMetadata) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(metadata)
+}
+
+func metadataImport(cmd *cobra.Command, args []string) error {
+	service, err := metadataServiceArg(args[0])
+	if err != nil {
+		return err
+	}
+	trackMetadata("metadata import", service)
+
+	var in io.Reader = os.Stdin
+	if args[1] != "-" {
+		f, err := os.Open(args[1])
+		if err != nil {
+			return errors.Wrap(err, "Failed to open file")
+		}
+		defer f.Close()
+		in = f
+	}
+	metadata, err := readMetadata(in)
+	if err != nil {
+		return errors.Wrap(err, "Failed to decode input as json")
+	}
+
+	secretStore, err := getSecretStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get secret store")
+	}
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := store.WriteKeyMetadata(secretStore, service, key, metadata[key]); err != nil {
+			return errors.Wrapf(err, "Failed to write metadata of %s", key)
+		}
+	}
+
+	infof("Successfully imported the metadata of %d keys\n", len(keys))
+	return nil
+}
+
+// readMetadata reads the metadata of keys as printed by writeMetadata
+func readMetadata(r io.Reader) (map[string]store.KeyMetadata, error) {
+	var raw map[string]store.KeyMetadata
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+	metadata := make(map[string]store.KeyMetadata, len(raw))
+	for key, m := range raw {
+		key = strings.ToLower(key)
+		if err := validateKey(key); err != nil {
+			return nil, err
+		}
+		metadata[key] = m
+	}
+	return metadata, nil
+}
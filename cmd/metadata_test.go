@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadataExportImport(t *testing.T) {
+	expires := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	metadata := map[string]store.KeyMetadata{
+		"log_level": {Enum: []string{"debug", "info"}},
+		"token":     {Expires: expires},
+	}
+
+	t.Run("Should read back what it writes", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.Nil(t, writeMetadata(&buf, metadata))
+		assert.Contains(t, buf.String(), `"log_level"`)
+
+		read, err := readMetadata(&buf)
+		assert.Nil(t, err)
+		assert.Equal(t, metadata, read)
+	})
+
+	t.Run("Should reject invalid keys and unknown fields", func(t *testing.T) {
+		_, err := readMetadata(strings.NewReader(`{"bad key!": {}}`))
+		assert.NotNil(t, err)
+		_, err = readMetadata(strings.NewReader(`{"key": {"owner": "team"}}`))
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+)
+
+var (
+	migrateFrom   string
+	migratePath   string
+	migrateTo     string
+	migrateDryRun bool
+
+	// migrateCmd represents the migrate command
+	migrateCmd = &cobra.Command{
+		Use:   "migrate --from vault --path <mount/path> --to [backend:]<service>",
+		Short: "Migrate a secret and its history from another secret manager",
+		Args:  cobra.NoArgs,
+		RunE:  migrate,
+		Example: `
+	$ chamber migrate --from vault --path secret/app --to ssm:app/prod --dry-run
+	db_password  v1  2023-01-02 15:04:05
+	db_password  v3  2023-06-01 09:00:00
+	db_username  v1  2023-01-02 15:04:05
+`,
+	}
+)
+
+func init() {
+	migrateCmd.Flags().StringVarP(&migrateFrom, "from", "", "vault", "Secret manager to migrate from; only vault (KV v2) is supported")
+	migrateCmd.Flags().StringVarP(&migratePath, "path", "", "", "Path of the secret to migrate, including its mount, e.g. secret/app")
+	migrateCmd.Flags().StringVarP(&migrateTo, "to", "", "", "Service to migrate to, optionally prefixed by its backend, e.g. ssm:app/prod")
+	migrateCmd.Flags().BoolVarP(&migrateDryRun, "dry-run", "", false, "Only print the versions that would be written")
+	migrateCmd.MarkFlagRequired("path")
+	migrateCmd.MarkFlagRequired("to")
+	RootCmd.AddCommand(migrateCmd)
+}
+
+// migrationStep is one version of a key replayed into chamber
+type migrationStep struct {
+	key     string
+	value   string
+	version int
+	created time.Time
+}
+
+func migrate(cmd *cobra.Command, args []string) error {
+	if strings.ToLower(migrateFrom) != "vault" {
+		return fmt.Errorf("Unsupported source %s; only vault is supported", migrateFrom)
+	}
+
+	service := migrateTo
+	if parts := strings.SplitN(migrateTo, ":", 2); len(parts) == 2 {
+		switch strings.ToUpper(parts[0]) {
+		case SSMBackend, S3Backend, S3KMSBackend, NullBackend:
+			if err := RootCmd.PersistentFlags().Set("backend", parts[0]); err != nil {
+				return errors.Wrap(err, "Failed to set backend")
+			}
+			service = parts[1]
+		default:
+			return fmt.Errorf("Unsupported backend %s", parts[0])
+		}
+	}
+	service, err := expandService(service)
+	if err != nil {
+		return errors.Wrap(err, "Failed to expand service")
+	}
+	service = strings.ToLower(service)
+	if err := validateService(service); err != nil {
+		return errors.Wrap(err, "Failed to validate service")
+	}
+
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", "migrate").
+				Set("chamber-version", chamberVersion).
+				Set("service", service).
+				Set("backend", backend).
+				Set("from", "vault").
+				Set("dry-run", migrateDryRun),
+		})
+	}
+
+	secretStore, err := getSecretStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get secret store")
+	}
+	vault, err := store.NewVaultKVClient()
+	if err != nil {
+		return errors.Wrap(err, "Failed to configure Vault")
+	}
+	versions, err := vault.History(migratePath)
+	if err != nil {
+		return errors.Wrap(err, "Failed to read Vault history")
+	}
+	steps, err := planMigration(versions)
+	if err != nil {
+		return errors.Wrap(err, "Failed to plan migration")
+	}
+
+	if migrateDryRun {
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, '\t', 0)
+		defer w.Flush()
+		for _, step := range steps {
+			fmt.Fprintf(w, "%s\tv%d\t%s\n", step.key, step.version, step.created.Local().Format(ShortTimeFormat))
+		}
+		return nil
+	}
+
+	imported := map[string][]store.ImportedVersion{}
+	for _, step := range steps {
+		secretId := store.SecretId{Service: service, Key: step.key}
+		if err := secretStore.Write(secretId, step.value); err != nil {
+			return errors.Wrapf(err, "Failed to write version %d of %s", step.version, step.key)
+		}
+		imported[step.key] = append(imported[step.key], store.ImportedVersion{Version: step.version, Created: step.created})
+	}
+
+	// Backends record when the versions were written, not when they were
+	// created in Vault, so the original times are kept as metadata
+	for key, history := range imported {
+		m, err := store.ReadKeyMetadata(secretStore, service, key)
+		if err != nil {
+			return errors.Wrap(err, "Failed to read metadata")
+		}
+		if m == nil {
+			m = &store.KeyMetadata{}
+		}
+		m.Imported = &store.ImportedHistory{Source: "vault:" + migratePath, Versions: history}
+		if err := store.WriteKeyMetadata(secretStore, service, key, *m); err != nil {
+			return errors.Wrap(err, "Failed to write metadata")
+		}
+	}
+	return nil
+}
+
+// planMigration returns the writes that replay the history of the keys of
+// the latest version, oldest first. Versions that leave a key unchanged
+// aren't replayed for it, and keys since removed aren't migrated.
+func planMigration(versions []store.VaultVersion) ([]migrationStep, error) {
+	if len(versions) == 0 {
+		return nil, nil
+	}
+	current := map[string]bool{}
+	for key := range versions[len(versions)-1].Data {
+		current[strings.ToLower(key)] = true
+	}
+
+	var steps []migrationStep
+	last := map[string]string{}
+	for _, version := range versions {
+		values := map[string]string{}
+		for key, value := range version.Data {
+			lower := strings.ToLower(key)
+			if err := validateKey(lower); err != nil {
+				return nil, err
+			}
+			if _, ok := values[lower]; ok {
+				return nil, fmt.Errorf("version %d has keys differing only in case: %s", version.Version, lower)
+			}
+			values[lower] = value
+		}
+
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			previous, ok := last[key]
+			if !current[key] || (ok && previous == values[key]) {
+				continue
+			}
+			last[key] = values[key]
+			steps = append(steps, migrationStep{key: key, value: values[key], version: version.Version, created: version.Created})
+		}
+	}
+
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].key < steps[j].key })
+	return steps, nil
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanMigration(t *testing.T) {
+	created := time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC)
+	versions := []store.VaultVersion{
+		{Version: 1, Created: created, Data: map[string]string{"DB_PASSWORD": "one", "db_user": "app", "legacy": "x"}},
+		{Version: 2, Created: created.Add(time.Hour), Data: map[string]string{"DB_PASSWORD": "two", "db_user": "app"}},
+	}
+
+	t.Run("Should replay the changes of current keys in order", func(t *testing.T) {
+		steps, err := planMigration(versions)
+		assert.Nil(t, err)
+		assert.Equal(t, []migrationStep{
+			{key: "db_password", value: "one", version: 1, created: created},
+			{key: "db_password", value: "two", version: 2, created: created.Add(time.Hour)},
+			{key: "db_user", value: "app", version: 1, created: created},
+		}, steps)
+	})
+
+	t.Run("Should reject keys differing only in case", func(t *testing.T) {
+		_, err := planMigration([]store.VaultVersion{{Version: 1, Data: map[string]string{"a": "1", "A": "2"}}})
+		assert.Error(t, err)
+	})
+}
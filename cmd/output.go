@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// PorcelainV1 is the first, and so far only, version of the output format of
+// --porcelain. Its output only ever changes in a new version.
+const PorcelainV1 = "v1"
+
+var (
+	// quiet drops informational messages, leaving only data on stdout and
+	// warnings and errors on stderr
+	quiet bool
+
+	// porcelain is the version of the stable output format scripts asked for
+	// with --porcelain, or empty for output meant for humans
+	porcelain porcelainValue
+)
+
+// porcelainValue is the pflag.Value of --porcelain, which rejects versions
+// this chamber doesn't know instead of guessing at their format.
+type porcelainValue string
+
+func (p *porcelainValue) String() string { return string(*p) }
+
+func (p *porcelainValue) Set(v string) error {
+	if v != PorcelainV1 {
+		return fmt.Errorf("unknown porcelain version %q, only %s is supported", v, PorcelainV1)
+	}
+	*p = porcelainValue(v)
+	return nil
+}
+
+func (p *porcelainValue) Type() string { return "version" }
+
+func init() {
+	RootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Only print data, like the value read by read, on STDOUT and no informational messages on STDERR")
+	RootCmd.PersistentFlags().VarP(&porcelain, "porcelain", "", "Print tables in a stable format for scripts, without headers, one record per line and fields separated by a tab; --porcelain is --porcelain=v1")
+	RootCmd.PersistentFlags().Lookup("porcelain").NoOptDefVal = PorcelainV1
+}
+
+// infof prints a message for humans, like the outcome of a write, to stderr
+// so it never mixes with the data scripts read from stdout
+func infof(format string, args ...interface{}) {
+	if quiet || porcelain != "" {
+		return
+	}
+	fmt.Fprintf(os.Stderr, format, args...)
+}
+
+// table writes the rows of a table, each field followed by a tab. For humans
+// it aligns the columns and colors rows by their role; with --porcelain it
+// keeps fields separated by exactly one tab.
+type table struct {
+	out   io.Writer
+	tw    *tabwriter.Writer
+	buf   bytes.Buffer
+	theme theme
+
+	// lines is the number of lines written so far, and roles the role of the
+	// lines starting a row with one
+	lines int
+	roles map[int]string
+}
+
+func newTableWriter(out io.Writer) *table {
+	t := &table{out: out, roles: map[int]string{}}
+	if porcelain != "" {
+		return t
+	}
+	if f, ok := out.(*os.File); ok {
+		t.theme = colorTheme(f)
+	}
+	t.tw = tabwriter.NewWriter(&t.buf, 0, 8, 2, '\t', 0)
+	return t
+}
+
+func (t *table) Write(p []byte) (int, error) {
+	if t.tw == nil {
+		return t.out.Write(p)
+	}
+	t.lines += bytes.Count(p, []byte("\n"))
+	return t.tw.Write(p)
+}
+
+// Header prints the header row of the table, which --porcelain omits
+func (t *table) Header(columns ...string) {
+	if porcelain != "" {
+		return
+	}
+	t.Role(headerRole)
+	fmt.Fprintln(t, strings.Join(columns, "\t"))
+}
+
+// Role colors the next row as role
+func (t *table) Role(role string) {
+	t.roles[t.lines] = role
+}
+
+// Flush writes the aligned table. Rows are colored only once aligned, as
+// tabwriter would count the escape sequences as part of the cells.
+func (t *table) Flush() error {
+	if t.tw == nil {
+		return nil
+	}
+	if err := t.tw.Flush(); err != nil {
+		return err
+	}
+	lines := strings.SplitAfter(t.buf.String(), "\n")
+	for i, line := range lines {
+		if role, ok := t.roles[i]; ok && t.theme != nil {
+			text := strings.TrimSuffix(line, "\n")
+			line = t.theme.colorize(role, text) + line[len(text):]
+		}
+		if _, err := io.WriteString(t.out, line); err != nil {
+			return err
+		}
+	}
+	t.buf.Reset()
+	return nil
+}
+
+// field formats a value for a table cell. With --porcelain, backslashes, tabs
+// and line breaks are escaped so every record stays on one line with the same
+// number of fields.
+func field(s string) string {
+	if porcelain == "" {
+		return s
+	}
+	return porcelainEscaper.Replace(s)
+}
+
+var porcelainEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// timestamp formats t for humans in the local time zone, or as RFC 3339 in
+// UTC with --porcelain
+func timestamp(t time.Time) string {
+	if porcelain != "" {
+		return t.UTC().Format(time.RFC3339)
+	}
+	return t.Local().Format(ShortTimeFormat)
+}
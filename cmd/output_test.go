@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPorcelain(t *testing.T) {
+	defer func() { porcelain = "" }()
+
+	t.Run("Should only accept known versions", func(t *testing.T) {
+		var p porcelainValue
+		assert.Nil(t, p.Set("v1"))
+		assert.NotNil(t, p.Set("v2"))
+		assert.Equal(t, PorcelainV1, p.String())
+	})
+
+	t.Run("Should align tables for humans", func(t *testing.T) {
+		porcelain = ""
+		var buf bytes.Buffer
+		w := newTableWriter(&buf)
+		w.Header("Key", "Value")
+		fmt.Fprintf(w, "%s\t%s\n", "a_long_key", field("a\\b"))
+		w.Flush()
+		assert.Equal(t, "Key\t\tValue\na_long_key\ta\\b\n", buf.String())
+	})
+
+	t.Run("Should print one escaped record per line", func(t *testing.T) {
+		porcelain = PorcelainV1
+		var buf bytes.Buffer
+		w := newTableWriter(&buf)
+		w.Header("Key", "Value")
+		fmt.Fprintf(w, "%s\t%s\n", "k", field("a\tb\nc\\d"))
+		w.Flush()
+		assert.Equal(t, "k\ta\\tb\\nc\\\\d\n", buf.String())
+	})
+
+	t.Run("Should print times in UTC", func(t *testing.T) {
+		porcelain = PorcelainV1
+		at := time.Date(2020, 1, 2, 3, 4, 5, 0, time.FixedZone("EST", -5*3600))
+		assert.Equal(t, "2020-01-02T08:04:05Z", timestamp(at))
+	})
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+)
+
+var (
+	ownerSet string
+
+	// ownerCmd represents the owner command
+	ownerCmd = &cobra.Command{
+		Use:   "owner [service...]",
+		Short: "Show which team owns a service, or set the owner registry",
+		RunE:  owner,
+		Example: `
+Given an owners.yaml mapping service prefixes to their owners:
+
+	owners:
+	  - prefix: payments
+	    team: payments
+	    slack: "#payments-oncall"
+	    escalation: https://example.com/payments-escalation
+
+	$ chamber owner --set owners.yaml
+	$ chamber owner payments/prod
+	Service        Team      Slack             Escalation
+	payments/prod  payments  #payments-oncall  https://example.com/payments-escalation
+`,
+	}
+)
+
+func init() {
+	ownerCmd.Flags().StringVarP(&ownerSet, "set", "", "", "Replace the owner registry with a YAML file, or - for stdin")
+	RootCmd.AddCommand(ownerCmd)
+}
+
+func owner(cmd *cobra.Command, args []string) error {
+	if ownerSet == "" && len(args) == 0 {
+		return errors.New("Must specify a service or --set")
+	}
+	services, err := expandServices(args)
+	if err != nil {
+		return errors.Wrap(err, "Failed to expand service")
+	}
+	for i, service := range services {
+		services[i] = strings.ToLower(service)
+		if err := validateServiceWithLabel(services[i]); err != nil {
+			return errors.Wrap(err, "Failed to validate service")
+		}
+	}
+
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", "owner").
+				Set("chamber-version", chamberVersion).
+				Set("services", services).
+				Set("backend", backend).
+				Set("set", ownerSet != ""),
+		})
+	}
+
+	secretStore, err := getSecretStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get secret store")
+	}
+
+	if ownerSet != "" {
+		var data []byte
+		if ownerSet == "-" {
+			data, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			data, err = ioutil.ReadFile(ownerSet)
+		}
+		if err != nil {
+			return errors.Wrap(err, "Failed to read owner registry")
+		}
+		if err := store.WriteOwnerRegistry(secretStore, data); err != nil {
+			return errors.Wrap(err, "Failed to write owner registry")
+		}
+		if len(services) == 0 {
+			return nil
+		}
+	}
+
+	registry, err := store.ReadOwnerRegistry(secretStore)
+	if err != nil {
+		return errors.Wrap(err, "Failed to read owner registry")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, '\t', 0)
+	defer w.Flush()
+	fmt.Fprintln(w, "Service\tTeam\tSlack\tEscalation")
+	for _, service := range services {
+		o := registry.Owner(service)
+		if o == nil {
+			fmt.Fprintf(w, "%s\t%s\t\t\n", service, "unowned")
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", service, o.Team, o.Slack, o.Escalation)
+	}
+	return nil
+}
+
+// serviceOwners returns the owner registry for annotating other output. It
+// never fails: without a readable registry no owners are shown.
+func serviceOwners(s store.Store) *store.OwnerRegistry {
+	registry, err := store.ReadOwnerRegistry(s)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: unable to read owner registry: %s\n", err)
+		return &store.OwnerRegistry{}
+	}
+	return registry
+}
+
+// ownerName is the owner of service as shown in tables
+func ownerName(registry *store.OwnerRegistry, service string) string {
+	if o := registry.Owner(service); o != nil {
+		return o.String()
+	}
+	return "unowned"
+}
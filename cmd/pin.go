@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/segmentio/chamber/v2/store"
+)
+
+// pinFilter lists a service named with a version pin, like
+// app/prod/db_password@3, as that one version of that one key, so exec and
+// export take pinned keys alongside whole services
+type pinFilter struct {
+	store.Store
+	now time.Time
+}
+
+func newPinFilter(s store.Store, now time.Time) *pinFilter {
+	return &pinFilter{Store: s, now: now}
+}
+
+func (f *pinFilter) ListRaw(service string) ([]store.RawSecret, error) {
+	name, pin, err := store.SplitVersionPin(service)
+	if err != nil {
+		return nil, err
+	}
+	if pin == nil {
+		return f.Store.ListRaw(service)
+	}
+	id, err := pinnedId(name)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := store.ReadPinned(f.Store, id, pin)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.CheckExpiry(f.Store, id.Service, id.Key, f.now); err != nil {
+		return nil, err
+	}
+	return []store.RawSecret{{Key: name, Value: *secret.Value}}, nil
+}
+
+// pinnedId splits the name of a pinned key, like app/prod/db_password, into
+// its service and key
+func pinnedId(name string) (store.SecretId, error) {
+	_, noPaths := os.LookupEnv("CHAMBER_NO_PATHS")
+	sep := "/"
+	if noPaths {
+		sep = "."
+	}
+
+	i := strings.LastIndex(name, sep)
+	if i <= 0 || i == len(name)-1 {
+		return store.SecretId{}, fmt.Errorf("a version pin needs a key, as in <service>%s<key>@<version pin>; got %s", sep, name)
+	}
+	return store.SecretId{Service: name[:i], Key: name[i+1:]}, nil
+}
+
+// validateServiceOrPin validates service with validate, or if it names a key
+// with a version pin, the service and key it names
+func validateServiceOrPin(service string, validate func(string) error) error {
+	name, pin, err := store.SplitVersionPin(service)
+	if err != nil {
+		return err
+	}
+	if pin == nil {
+		return validate(service)
+	}
+	id, err := pinnedId(name)
+	if err != nil {
+		return err
+	}
+	if err := validateService(id.Service); err != nil {
+		return err
+	}
+	return validateKey(id.Key)
+}
+
+// hasPin reports whether any of services names a key with a version pin
+func hasPin(services []string) bool {
+	for _, service := range services {
+		if strings.Contains(service, store.VersionPinSeparator) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPinFilter(t *testing.T) {
+	versions := newFakeStore()
+	versions.set(store.SecretId{Service: "app/prod", Key: "db_password"}, "one", "two", "three")
+	versions.set(store.SecretId{Service: "app/prod", Key: "other"}, "unpinned")
+	s := newPinFilter(versions, time.Now())
+
+	t.Run("Should list a pinned key as its pinned version", func(t *testing.T) {
+		rawSecrets, err := s.ListRaw("app/prod/db_password@latest-1")
+		assert.Nil(t, err)
+		assert.Equal(t, []store.RawSecret{{Key: "app/prod/db_password", Value: "two"}}, rawSecrets)
+		assert.Equal(t, "db_password", key(rawSecrets[0].Key))
+	})
+
+	t.Run("Should list a key pinned to a version number", func(t *testing.T) {
+		rawSecrets, err := s.ListRaw("app/prod/db_password@1")
+		assert.Nil(t, err)
+		assert.Equal(t, "one", rawSecrets[0].Value)
+	})
+
+	t.Run("Should list services without a pin as usual", func(t *testing.T) {
+		rawSecrets, err := s.ListRaw("app/prod")
+		assert.Nil(t, err)
+		assert.Equal(t, []store.RawSecret{
+			{Key: "/app/prod/db_password", Value: "three"},
+			{Key: "/app/prod/other", Value: "unpinned"},
+		}, rawSecrets)
+	})
+
+	t.Run("Should fail on versions that don't exist", func(t *testing.T) {
+		_, err := s.ListRaw("app/prod/db_password@7")
+		assert.Error(t, err)
+		_, err = s.ListRaw("app/prod/db_password@latest-3")
+		assert.Error(t, err)
+	})
+
+	t.Run("Should fail on a pin without a key", func(t *testing.T) {
+		_, err := s.ListRaw("app@3")
+		assert.Error(t, err)
+	})
+}
+
+func TestValidateServiceOrPin(t *testing.T) {
+	t.Run("Should validate a pinned key by its service and key", func(t *testing.T) {
+		assert.Nil(t, validateServiceOrPin("app/prod/db_password@label:blessed", validateService))
+		assert.Error(t, validateServiceOrPin("app/prod/db password@3", validateService))
+		assert.Error(t, validateServiceOrPin("app/prod/db_password@soon", validateService))
+	})
+
+	t.Run("Should validate services without a pin as usual", func(t *testing.T) {
+		assert.Nil(t, validateServiceOrPin("app/prod:current", validateServiceWithLabel))
+		assert.Error(t, validateServiceOrPin("app/prod:current", validateService))
+	})
+}
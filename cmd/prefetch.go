@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+)
+
+var (
+	prefetchServices []string
+	prefetchCache    bool
+	prefetchTTL      time.Duration
+
+	// prefetchCmd represents the prefetch command
+	prefetchCmd = &cobra.Command{
+		Use:   "prefetch -s <service> [-s <service>...]",
+		Short: "Fetch and decrypt the secrets of services ahead of a deployment, optionally caching them",
+		Args:  cobra.NoArgs,
+		RunE:  prefetch,
+		Example: `
+Before a deployment window, check that every secret can be decrypted and cache
+them, so the rollout's exec calls don't depend on AWS:
+
+	$ chamber prefetch -s app/prod -s shared/prod --cache --ttl 2h
+	$ CHAMBER_USE_CACHE=true chamber exec app/prod shared/prod -- ./server
+`,
+	}
+)
+
+func init() {
+	prefetchCmd.Flags().StringSliceVarP(&prefetchServices, "service", "s", nil, "Service to prefetch; may be repeated")
+	prefetchCmd.Flags().BoolVarP(&prefetchCache, "cache", "", false, "Cache the secrets in the OS keyring, for exec --use-cache")
+	prefetchCmd.Flags().DurationVarP(&prefetchTTL, "ttl", "", time.Hour, "How long exec --use-cache serves the cached secrets")
+	prefetchCmd.MarkFlagRequired("service")
+	RootCmd.AddCommand(prefetchCmd)
+}
+
+func prefetch(cmd *cobra.Command, args []string) error {
+	services, err := expandServices(prefetchServices)
+	if err != nil {
+		return errors.Wrap(err, "Failed to expand service")
+	}
+	for i, service := range services {
+		services[i] = strings.ToLower(service)
+		if err := validateService(services[i]); err != nil {
+			return errors.Wrap(err, "Failed to validate service")
+		}
+	}
+	if prefetchTTL <= 0 {
+		return errors.New("--ttl must be positive")
+	}
+
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", "prefetch").
+				Set("chamber-version", chamberVersion).
+				Set("services", services).
+				Set("backend", backend).
+				Set("cache", prefetchCache),
+		})
+	}
+
+	if prefetchCache {
+		cacheMode = store.CacheRefresh
+	}
+	secretStore, err := getSecretStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get secret store")
+	}
+	if prefetchCache && (backend == NullBackend || backend == StaticBackend) {
+		return fmt.Errorf("the %s backend is local, so there is nothing to cache", strings.ToLower(backend))
+	}
+
+	// listing decrypts every secret, so it fails on those that can't be
+	failed := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, '\t', 0)
+	fmt.Fprintln(w, "Service\tSecrets\tStatus")
+	for _, service := range services {
+		rawSecrets, err := secretStore.ListRaw(service)
+		if err != nil {
+			failed++
+			fmt.Fprintf(w, "%s\t-\t%s\n", service, err)
+			continue
+		}
+		status := "ok"
+		if prefetchCache {
+			status = fmt.Sprintf("cached until %s", time.Now().Add(prefetchTTL).Local().Format(ShortTimeFormat))
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\n", service, len(rawSecrets), status)
+	}
+	w.Flush()
+
+	if failed > 0 {
+		return fmt.Errorf("failed to prefetch %d of %d services", failed, len(services))
+	}
+	return nil
+}
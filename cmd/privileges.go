@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"os/user"
+	"strconv"
+)
+
+// execIdentity is the user and groups exec --user and --group run the command
+// as, after chamber read its secrets with its own credentials
+type execIdentity struct {
+	uid    int
+	gid    int
+	groups []int
+}
+
+// resolveExecIdentity looks up the user and group to run the command as, by
+// name or numeric id. The group defaults to the primary group of the user,
+// and the supplementary groups are those of the user, as with login. Without
+// a user, only the group changes.
+func resolveExecIdentity(userSpec, groupSpec string) (*execIdentity, error) {
+	id := &execIdentity{uid: -1, gid: -1}
+	if userSpec != "" {
+		u, err := lookupUser(userSpec)
+		if err != nil {
+			return nil, err
+		}
+		if u == nil {
+			// a numeric id without an entry in the user database
+			id.uid, _ = strconv.Atoi(userSpec)
+		} else {
+			id.uid, _ = strconv.Atoi(u.Uid)
+			id.gid, _ = strconv.Atoi(u.Gid)
+			groupIds, err := u.GroupIds()
+			if err != nil {
+				return nil, fmt.Errorf("unable to list the groups of user %s: %s", userSpec, err)
+			}
+			for _, g := range groupIds {
+				if gid, err := strconv.Atoi(g); err == nil {
+					id.groups = append(id.groups, gid)
+				}
+			}
+		}
+	}
+
+	if groupSpec != "" {
+		gid, err := lookupGroup(groupSpec)
+		if err != nil {
+			return nil, err
+		}
+		id.gid = gid
+	}
+	if id.gid == -1 {
+		return nil, fmt.Errorf("user %s has no entry in the user database; set --group", userSpec)
+	}
+	if !intInSlice(id.gid, id.groups) {
+		id.groups = append([]int{id.gid}, id.groups...)
+	}
+	return id, nil
+}
+
+// lookupUser returns the user named or numbered spec, or nil for a numeric
+// id the user database doesn't know
+func lookupUser(spec string) (*user.User, error) {
+	if _, err := strconv.Atoi(spec); err == nil {
+		u, err := user.LookupId(spec)
+		if _, ok := err.(user.UnknownUserIdError); ok {
+			return nil, nil
+		}
+		return u, err
+	}
+	u, err := user.Lookup(spec)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find user %s: %s", spec, err)
+	}
+	return u, nil
+}
+
+func lookupGroup(spec string) (int, error) {
+	if gid, err := strconv.Atoi(spec); err == nil {
+		return gid, nil
+	}
+	g, err := user.LookupGroup(spec)
+	if err != nil {
+		return 0, fmt.Errorf("unable to find group %s: %s", spec, err)
+	}
+	return strconv.Atoi(g.Gid)
+}
+
+func intInSlice(val int, sl []int) bool {
+	for _, v := range sl {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,10 @@
+// +build !linux,!darwin
+
+package cmd
+
+import "errors"
+
+// dropPrivileges needs setuid and setgid
+func dropPrivileges(id *execIdentity) error {
+	return errors.New("--user and --group are not supported on this platform")
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"os/user"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveExecIdentity(t *testing.T) {
+	t.Run("Should default to the primary group of the user", func(t *testing.T) {
+		current, err := user.Current()
+		if err != nil {
+			t.Skip("no current user:", err)
+		}
+		id, err := resolveExecIdentity(current.Username, "")
+		assert.Nil(t, err)
+		assert.Equal(t, current.Uid, strconv.Itoa(id.uid))
+		assert.Equal(t, current.Gid, strconv.Itoa(id.gid))
+		assert.Contains(t, id.groups, id.gid)
+	})
+
+	t.Run("Should accept ids the user database doesn't know with a group", func(t *testing.T) {
+		id, err := resolveExecIdentity("54321", "54322")
+		assert.Nil(t, err)
+		assert.Equal(t, &execIdentity{uid: 54321, gid: 54322, groups: []int{54322}}, id)
+	})
+
+	t.Run("Should require a group for unknown ids", func(t *testing.T) {
+		_, err := resolveExecIdentity("54321", "")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Should only change the group without a user", func(t *testing.T) {
+		id, err := resolveExecIdentity("", "54322")
+		assert.Nil(t, err)
+		assert.Equal(t, -1, id.uid)
+		assert.Equal(t, 54322, id.gid)
+	})
+
+	t.Run("Should fail for unknown names", func(t *testing.T) {
+		_, err := resolveExecIdentity("no-such-user-chamber", "")
+		assert.NotNil(t, err)
+		_, err = resolveExecIdentity("", "no-such-group-chamber")
+		assert.NotNil(t, err)
+	})
+}
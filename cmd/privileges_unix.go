@@ -0,0 +1,28 @@
+// +build linux darwin
+
+package cmd
+
+import (
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// dropPrivileges switches chamber, and so the command it becomes or starts,
+// to id. The groups change first, while chamber may still change them.
+func dropPrivileges(id *execIdentity) error {
+	if id.uid != -1 {
+		if err := syscall.Setgroups(id.groups); err != nil {
+			return errors.Wrap(err, "Failed to set supplementary groups")
+		}
+	}
+	if err := syscall.Setgid(id.gid); err != nil {
+		return errors.Wrapf(err, "Failed to set group %d", id.gid)
+	}
+	if id.uid != -1 {
+		if err := syscall.Setuid(id.uid); err != nil {
+			return errors.Wrapf(err, "Failed to set user %d", id.uid)
+		}
+	}
+	return nil
+}
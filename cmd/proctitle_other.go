@@ -0,0 +1,6 @@
+// +build !linux,!darwin
+
+package cmd
+
+// maskArgs does nothing where the command line can't be rewritten in place
+func maskArgs(argv []string, arg string) {}
@@ -0,0 +1,35 @@
+// +build linux darwin
+
+package cmd
+
+import "unsafe"
+
+// maskArgs overwrites arg in place, in the memory the OS reads the process's
+// command line from, so that ps and /proc/<pid>/cmdline stop showing it. arg
+// must be one of argv, or part of one, as cobra and pflag pass arguments and
+// flag values; only its own bytes are masked, never other arguments that
+// happen to be equal to it. Go's os.Args share that memory, so callers must
+// copy arg before masking it.
+func maskArgs(argv []string, arg string) {
+	if arg == "" {
+		return
+	}
+	start := uintptr(stringData(arg))
+	for i := 1; i < len(argv); i++ {
+		data := stringData(argv[i])
+		offset := start - uintptr(data)
+		if start < uintptr(data) || offset+uintptr(len(arg)) > uintptr(len(argv[i])) {
+			continue
+		}
+		b := (*[1 << 30]byte)(data)[offset : offset+uintptr(len(arg)) : offset+uintptr(len(arg))]
+		for j := range b {
+			b[j] = '*'
+		}
+		return
+	}
+}
+
+// stringData returns a pointer to the bytes of s
+func stringData(s string) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(&s))
+}
@@ -0,0 +1,41 @@
+// +build linux darwin
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaskArgs(t *testing.T) {
+	t.Run("Should overwrite the argument", func(t *testing.T) {
+		// The arguments must not be constants, which live in read-only memory
+		argv := []string{"chamber", string([]byte("write")), string([]byte("hunter2"))}
+		secret := string([]byte(argv[2]))
+		maskArgs(argv, argv[2])
+		assert.Equal(t, []string{"chamber", "write", "*******"}, argv)
+		assert.Equal(t, "hunter2", secret)
+	})
+
+	t.Run("Should leave other arguments equal to it alone", func(t *testing.T) {
+		argv := []string{"chamber", string([]byte("write")), string([]byte("myapp")), string([]byte("token")), string([]byte("token"))}
+		key := argv[3]
+		maskArgs(argv, argv[4])
+		assert.Equal(t, []string{"chamber", "write", "myapp", "token", "*****"}, argv)
+		assert.Equal(t, "token", key)
+	})
+
+	t.Run("Should overwrite a flag value given with =", func(t *testing.T) {
+		argv := []string{"chamber", string([]byte("replace")), string([]byte("--match-value=hunter2"))}
+		maskArgs(argv, argv[2][len("--match-value="):])
+		assert.Equal(t, []string{"chamber", "replace", "--match-value=*******"}, argv)
+	})
+
+	t.Run("Should leave values not on the command line alone", func(t *testing.T) {
+		argv := []string{"chamber", string([]byte("write")), string([]byte("hunter2"))}
+		maskArgs(argv, string([]byte("hunter2")))
+		maskArgs(argv, "")
+		assert.Equal(t, []string{"chamber", "write", "hunter2"}, argv)
+	})
+}
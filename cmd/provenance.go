@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+const (
+	provenancePrefix = "# chamber-provenance: "
+	signaturePrefix  = "# chamber-signature: ed25519:"
+)
+
+// exportProvenance describes where an exported file came from. It is written
+// as a comment heading the file, so only formats with # comments carry it.
+type exportProvenance struct {
+	Services       []provenanceService `json:"services"`
+	AsOf           *time.Time          `json:"as_of,omitempty"`
+	Exported       time.Time           `json:"exported"`
+	ChamberVersion string              `json:"chamber_version"`
+	Format         string              `json:"format"`
+
+	// SHA256 is the digest of the file below the header
+	SHA256 string `json:"sha256"`
+}
+
+// provenanceService lists the exported version of each key of a service
+type provenanceService struct {
+	Service  string         `json:"service"`
+	Versions map[string]int `json:"versions"`
+}
+
+// provenanceFormats are the export formats whose comments start with #
+var provenanceFormats = map[string]bool{
+	"dotenv":          true,
+	"tfvars":          true,
+	"yaml":            true,
+	"java-properties": true,
+	"properties":      true,
+}
+
+// withProvenance heads body with p, and with the signature of p by key if
+// key isn't nil
+func withProvenance(p exportProvenance, body []byte, key ed25519.PrivateKey) ([]byte, error) {
+	digest := sha256.Sum256(body)
+	p.SHA256 = hex.EncodeToString(digest[:])
+	header, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(provenancePrefix)
+	buf.Write(header)
+	buf.WriteString("\n")
+	if key != nil {
+		buf.WriteString(signaturePrefix)
+		buf.WriteString(base64.StdEncoding.EncodeToString(ed25519.Sign(key, header)))
+		buf.WriteString("\n")
+	}
+	buf.Write(body)
+	return buf.Bytes(), nil
+}
+
+// verifyProvenance checks that data is unchanged since it was exported, and
+// that it was signed by key if key isn't nil. It reports whether data was
+// signed at all.
+func verifyProvenance(data []byte, key ed25519.PublicKey) (*exportProvenance, bool, error) {
+	line, rest := splitLine(data)
+	if !strings.HasPrefix(line, provenancePrefix) {
+		return nil, false, fmt.Errorf("file has no provenance header")
+	}
+	header := []byte(strings.TrimPrefix(line, provenancePrefix))
+	var p exportProvenance
+	if err := json.Unmarshal(header, &p); err != nil {
+		return nil, false, fmt.Errorf("invalid provenance header: %s", err)
+	}
+
+	var signature []byte
+	if line, body := splitLine(rest); strings.HasPrefix(line, signaturePrefix) {
+		var err error
+		if signature, err = base64.StdEncoding.DecodeString(strings.TrimPrefix(line, signaturePrefix)); err != nil {
+			return nil, false, fmt.Errorf("invalid signature: %s", err)
+		}
+		rest = body
+	}
+
+	digest := sha256.Sum256(rest)
+	if hex.EncodeToString(digest[:]) != p.SHA256 {
+		return nil, signature != nil, fmt.Errorf("file was modified after it was exported")
+	}
+	if key != nil {
+		if signature == nil {
+			return nil, false, fmt.Errorf("file is not signed")
+		}
+		if !ed25519.Verify(key, header, signature) {
+			return nil, true, fmt.Errorf("signature does not match the public key")
+		}
+	}
+	return &p, signature != nil, nil
+}
+
+func splitLine(data []byte) (string, []byte) {
+	i := bytes.IndexByte(data, '\n')
+	if i < 0 {
+		return string(data), nil
+	}
+	return string(data[:i]), data[i+1:]
+}
+
+// readSigningKey reads an Ed25519 private key in PKCS #8 PEM, as written by
+// openssl genpkey -algorithm ed25519
+func readSigningKey(file string) (ed25519.PrivateKey, error) {
+	block, err := readPEM(file)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	private, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an Ed25519 private key", file)
+	}
+	return private, nil
+}
+
+// readVerifyingKey reads an Ed25519 public key in PKIX PEM, as written by
+// openssl pkey -pubout
+func readVerifyingKey(file string) (ed25519.PublicKey, error) {
+	block, err := readPEM(file)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	public, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an Ed25519 public key", file)
+	}
+	return public, nil
+}
+
+func readPEM(file string) (*pem.Block, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not PEM encoded", file)
+	}
+	return block, nil
+}
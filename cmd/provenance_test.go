@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvenance(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	otherPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+
+	p := exportProvenance{
+		Services:       []provenanceService{{Service: "app/prod", Versions: map[string]int{"db_password": 3}}},
+		Exported:       time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		ChamberVersion: "v2.0.0",
+		Format:         "dotenv",
+	}
+	body := []byte("DB_PASSWORD=\"hunter2\"\n")
+
+	t.Run("Should verify unchanged files", func(t *testing.T) {
+		data, err := withProvenance(p, body, nil)
+		assert.Nil(t, err)
+		verified, signed, err := verifyProvenance(data, nil)
+		assert.Nil(t, err)
+		assert.False(t, signed)
+		assert.Equal(t, 3, verified.Services[0].Versions["db_password"])
+	})
+
+	t.Run("Should detect modified files", func(t *testing.T) {
+		data, err := withProvenance(p, body, private)
+		assert.Nil(t, err)
+		data[len(data)-3] = 'X'
+		_, _, err = verifyProvenance(data, public)
+		assert.Error(t, err)
+	})
+
+	t.Run("Should check signatures against the public key", func(t *testing.T) {
+		data, err := withProvenance(p, body, private)
+		assert.Nil(t, err)
+		_, signed, err := verifyProvenance(data, public)
+		assert.Nil(t, err)
+		assert.True(t, signed)
+
+		_, _, err = verifyProvenance(data, otherPublic)
+		assert.Error(t, err)
+	})
+
+	t.Run("Should require a signature when given a public key", func(t *testing.T) {
+		data, err := withProvenance(p, body, nil)
+		assert.Nil(t, err)
+		_, _, err = verifyProvenance(data, public)
+		assert.Error(t, err)
+	})
+
+	t.Run("Should reject files without provenance", func(t *testing.T) {
+		_, _, err := verifyProvenance(body, nil)
+		assert.Error(t, err)
+	})
+}
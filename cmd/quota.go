@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// quotaWarnRatio is the share of a limit at which writes start warning
+const quotaWarnRatio = 0.8
+
+// backendQuota holds the limits a backend puts on each value
+type backendQuota struct {
+	// valueSize is the size of a value in bytes
+	valueSize int
+
+	// guidance tells what to do about values over the limits
+	guidance string
+}
+
+// backendQuotas are the limits of the backends that have any. SSM standard
+// parameters hold up to 4 KB. The 10,000 parameters a region holds are shared
+// by every service of the account, so they aren't checked on writes.
+var backendQuotas = map[string]backendQuota{
+	SSMBackend: {
+		valueSize: 4096,
+		guidance:  "move large values to the S3 backend",
+	},
+}
+
+// checkQuotas warns on w about writing values to service that take more than
+// quotaWarnRatio of the limits of the backend, and fails for values that
+// exceed them, so that writes fail early with guidance rather than when the
+// backend rejects them.
+func checkQuotas(service string, values map[string]string, w io.Writer) error {
+	quota, ok := backendQuotas[backend]
+	if !ok {
+		return nil
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		size := len(values[key])
+		switch {
+		case size > quota.valueSize:
+			return fmt.Errorf("%s/%s is %d bytes, over the limit of %d bytes of the %s backend; %s", service, key, size, quota.valueSize, backend, quota.guidance)
+		case float64(size) >= quotaWarnRatio*float64(quota.valueSize):
+			fmt.Fprintf(w, "warning: %s/%s is %d bytes, %d%% of the limit of %d bytes of the %s backend\n", service, key, size, 100*size/quota.valueSize, quota.valueSize, backend)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckQuotas(t *testing.T) {
+	defer func(b string) { backend = b }(backend)
+	backend = SSMBackend
+
+	t.Run("Should warn about values close to the limit and reject values over it", func(t *testing.T) {
+		var w bytes.Buffer
+		assert.Nil(t, checkQuotas("app", map[string]string{"small": "x"}, &w))
+		assert.Empty(t, w.String())
+
+		assert.Nil(t, checkQuotas("app", map[string]string{"cert": strings.Repeat("x", 3500)}, &w))
+		assert.Contains(t, w.String(), "app/cert is 3500 bytes, 85%")
+
+		err := checkQuotas("app", map[string]string{"cert": strings.Repeat("x", 5000)}, &w)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "S3 backend")
+	})
+
+	t.Run("Should not check backends without limits", func(t *testing.T) {
+		backend = S3Backend
+		defer func() { backend = SSMBackend }()
+		assert.Nil(t, checkQuotas("app", map[string]string{"cert": strings.Repeat("x", 5000)}, &bytes.Buffer{}))
+	})
+}
@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
-	"text/tabwriter"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/segmentio/chamber/v2/store"
@@ -14,11 +14,18 @@ import (
 
 var (
 	version int
-	quiet   bool
+	raw     bool
+
+	// parameterVersion is the backend's own version to read, bypassing the
+	// versions chamber records
+	parameterVersion int64
+
+	// jsonPath picks a field out of a JSON-valued secret
+	jsonPath string
 
 	// readCmd represents the read command
 	readCmd = &cobra.Command{
-		Use:   "read <service> <key>",
+		Use:   "read <service> <key>[@<version pin>]",
 		Short: "Read a specific secret from the parameter store",
 		Args:  cobra.ExactArgs(2),
 		RunE:  read,
@@ -27,21 +34,44 @@ var (
 
 func init() {
 	readCmd.Flags().IntVarP(&version, "version", "v", -1, "The version number of the secret. Defaults to latest.")
-	readCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Only print the secret")
+	readCmd.Flags().BoolVarP(&raw, "raw", "", false, "Only print the secret, exactly as stored, without adding a newline")
+	readCmd.Flags().Int64VarP(&parameterVersion, "parameter-version", "", 0, "The SSM parameter version to read, instead of chamber's version number, to debug versions that have drifted")
+	readCmd.Flags().StringVarP(&jsonPath, "jsonpath", "", "", "Only print the field of a JSON-valued secret at this JSONPath, e.g. $.password")
 	RootCmd.AddCommand(readCmd)
 }
 
 func read(cmd *cobra.Command, args []string) error {
-	service := strings.ToLower(args[0])
+	service, err := expandService(args[0])
+	if err != nil {
+		return errors.Wrap(err, "Failed to expand service")
+	}
+	service = strings.ToLower(service)
 	if err := validateService(service); err != nil {
 		return errors.Wrap(err, "Failed to validate service")
 	}
 
-	key := strings.ToLower(args[1])
+	key, pin, err := store.SplitVersionPin(args[1])
+	if err != nil {
+		return errors.Wrap(err, "Failed to parse version pin")
+	}
+	key = strings.ToLower(key)
 	if err := validateKey(key); err != nil {
 		return errors.Wrap(err, "Failed to validate key")
 	}
 
+	if parameterVersion != 0 && cmd.Flags().Changed("version") {
+		return errors.New("--version and --parameter-version can't be combined")
+	}
+	if pin != nil && (parameterVersion != 0 || cmd.Flags().Changed("version")) {
+		return errors.New("a version pin can't be combined with --version or --parameter-version")
+	}
+
+	if jsonPath != "" {
+		if _, err := parseJSONPath(jsonPath); err != nil {
+			return errors.Wrap(err, "Failed to parse --jsonpath")
+		}
+	}
+
 	if analyticsEnabled && analyticsClient != nil {
 		analyticsClient.Enqueue(analytics.Track{
 			UserId: username,
@@ -51,7 +81,10 @@ func read(cmd *cobra.Command, args []string) error {
 				Set("chamber-version", chamberVersion).
 				Set("service", service).
 				Set("key", key).
-				Set("backend", backend),
+				Set("backend", backend).
+				Set("parameter-version", parameterVersion != 0).
+				Set("version-pin", pin != nil).
+				Set("jsonpath", jsonPath != ""),
 		})
 	}
 
@@ -59,30 +92,56 @@ func read(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return errors.Wrap(err, "Failed to get secret store")
 	}
+	if err := requireCapabilities(secretStore, store.Capabilities{Versions: version != -1 || pin != nil}); err != nil {
+		return err
+	}
 
 	secretId := store.SecretId{
 		Service: service,
 		Key:     key,
 	}
 
-	secret, err := secretStore.Read(secretId, version)
+	var secret store.Secret
+	if parameterVersion != 0 {
+		secret, err = store.ReadNativeVersion(secretStore, secretId, parameterVersion)
+	} else if pin != nil {
+		secret, err = store.ReadPinned(secretStore, secretId, pin)
+	} else {
+		secret, err = secretStore.Read(secretId, version)
+	}
 	if err != nil {
 		return errors.Wrap(err, "Failed to read")
 	}
+	if err := store.CheckExpiry(secretStore, service, key, time.Now()); err != nil {
+		return errors.Wrap(err, "Failed to read")
+	}
+
+	if jsonPath != "" {
+		field, err := extractJSONPath(*secret.Value, jsonPath)
+		if err != nil {
+			return errors.Wrap(err, "Failed to extract field")
+		}
+		secret.Value = &field
+	}
+
+	if raw {
+		fmt.Fprint(os.Stdout, *secret.Value)
+		return nil
+	}
 
 	if quiet {
 		fmt.Fprintf(os.Stdout, "%s\n", *secret.Value)
 		return nil
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, '\t', 0)
-	fmt.Fprintln(w, "Key\tValue\tVersion\tLastModified\tUser")
+	w := newTableWriter(os.Stdout)
+	w.Header("Key", "Value", "Version", "LastModified", "User")
 	fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n",
 		key,
-		*secret.Value,
+		field(*secret.Value),
 		secret.Meta.Version,
-		secret.Meta.Created.Local().Format(ShortTimeFormat),
-		secret.Meta.CreatedBy)
+		timestamp(secret.Meta.Created),
+		field(secret.Meta.CreatedBy))
 	w.Flush()
 	return nil
 }
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+)
+
+var (
+	receiveIdentity string
+	receiveService  string
+
+	// receiveCmd represents the receive command
+	receiveCmd = &cobra.Command{
+		Use:   "receive <file|-> --identity <file>",
+		Short: "Import a secret shared with the share command",
+		Args:  cobra.ExactArgs(1),
+		RunE:  receive,
+	}
+)
+
+func init() {
+	receiveCmd.Flags().StringVarP(&receiveIdentity, "identity", "i", "", "age identity file (or SSH private key) to decrypt the bundle with")
+	receiveCmd.Flags().StringVarP(&receiveService, "service", "s", "", "Service to import the secret to (default is the service it was shared from)")
+	receiveCmd.MarkFlagRequired("identity")
+	RootCmd.AddCommand(receiveCmd)
+}
+
+func receive(cmd *cobra.Command, args []string) error {
+	var encrypted []byte
+	var err error
+	if args[0] == "-" {
+		encrypted, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		encrypted, err = ioutil.ReadFile(args[0])
+	}
+	if err != nil {
+		return errors.Wrap(err, "Failed to read bundle")
+	}
+
+	decrypted, err := runAge(encrypted, "--decrypt", "--identity", receiveIdentity)
+	if err != nil {
+		return errors.Wrap(err, "Failed to decrypt bundle")
+	}
+	bundle, err := openBundle(decrypted, time.Now())
+	if err != nil {
+		return errors.Wrap(err, "Failed to open bundle")
+	}
+
+	service := bundle.Service
+	if receiveService != "" {
+		if service, err = expandService(receiveService); err != nil {
+			return errors.Wrap(err, "Failed to expand service")
+		}
+		service = strings.ToLower(service)
+	}
+	if err := validateService(service); err != nil {
+		return errors.Wrap(err, "Failed to validate service")
+	}
+	if err := validateKey(bundle.Key); err != nil {
+		return errors.Wrap(err, "Failed to validate key")
+	}
+
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", "receive").
+				Set("chamber-version", chamberVersion).
+				Set("service", service).
+				Set("key", bundle.Key).
+				Set("backend", backend),
+		})
+	}
+
+	secretStore, err := getSecretStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get secret store")
+	}
+	store.RegisterSecretValue(bundle.Value)
+	if err := validateEnum(secretStore, service, bundle.Key, bundle.Value); err != nil {
+		return errors.Wrap(err, "Failed to validate value")
+	}
+	if err := secretStore.Write(store.SecretId{Service: service, Key: bundle.Key}, bundle.Value); err != nil {
+		return errors.Wrap(err, "Failed to write secret")
+	}
+	sharedBy := bundle.SharedBy
+	if sharedBy == "" {
+		sharedBy = "unknown"
+	}
+	infof("Received %s/%s (version %d) shared by %s\n", service, bundle.Key, bundle.Version, sharedBy)
+	return nil
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+)
+
+var (
+	rekeyKMSKey string
+
+	// rekeyCmd represents the rekey command
+	rekeyCmd = &cobra.Command{
+		Use:   "rekey <service> --kms-key <key>",
+		Short: "Re-encrypt the secrets of a service under another KMS key",
+		Args:  cobra.ExactArgs(1),
+		RunE:  rekey,
+		Example: `
+	$ chamber rekey app/prod --kms-key alias/app-prod-2024
+	[1/2] db_password: re-encrypted
+	[2/2] log_level: skipped
+	Re-encrypted 1 of 2 secrets of app/prod under alias/app-prod-2024
+`,
+	}
+)
+
+func init() {
+	rekeyCmd.Flags().StringVarP(&rekeyKMSKey, "kms-key", "", "", "KMS key to re-encrypt under, as an alias/<name>, key ID or ARN")
+	rekeyCmd.MarkFlagRequired("kms-key")
+	RootCmd.AddCommand(rekeyCmd)
+}
+
+func rekey(cmd *cobra.Command, args []string) error {
+	service, err := expandService(args[0])
+	if err != nil {
+		return errors.Wrap(err, "Failed to expand service")
+	}
+	service = strings.ToLower(service)
+	if err := validateService(service); err != nil {
+		return errors.Wrap(err, "Failed to validate service")
+	}
+	if rekeyKMSKey == "" {
+		return errors.New("Must set --kms-key")
+	}
+
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", "rekey").
+				Set("chamber-version", chamberVersion).
+				Set("service", service).
+				Set("backend", backend),
+		})
+	}
+
+	secretStore, err := getSecretStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get secret store")
+	}
+	if err := requireCapabilities(secretStore, store.Capabilities{Write: true, Rekey: true}); err != nil {
+		return err
+	}
+
+	secrets, err := secretStore.List(service, false)
+	if err != nil {
+		return errors.Wrap(err, "Failed to list store contents")
+	}
+	keys := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		keys = append(keys, key(secret.Meta.Key))
+	}
+	sort.Strings(keys)
+
+	event := HookEvent{Command: "rekey", Services: []string{service}, Keys: keys}
+	if err := runHooks(withEvent(event, PreWriteHook)); err != nil {
+		return err
+	}
+
+	rekeyed, err := rekeyKeys(secretStore, service, keys, rekeyKMSKey)
+	if err != nil {
+		return err
+	}
+
+	runPostHooks(withEvent(event, PostWriteHook))
+
+	infof("Re-encrypted %d of %d secrets of %s under %s\n", rekeyed, len(keys), service, rekeyKMSKey)
+	return nil
+}
+
+// rekeyKeys re-encrypts keys of service, and their metadata, under kmsKey,
+// reporting progress as it goes, and returns how many secrets it rewrote.
+// Secrets already under kmsKey, or not encrypted with KMS, are skipped.
+func rekeyKeys(secretStore store.Store, service string, keys []string, kmsKey string) (int, error) {
+	rekeyed := 0
+	for i, k := range keys {
+		done, err := store.Rekey(secretStore, store.SecretId{Service: service, Key: k}, kmsKey)
+		if err != nil {
+			return rekeyed, errors.Wrapf(err, "Failed to re-encrypt %s after re-encrypting %d secrets", k, rekeyed)
+		}
+		if _, err := store.RekeyKeyMetadata(secretStore, service, k, kmsKey); err != nil && err != store.ErrSecretNotFound {
+			return rekeyed, errors.Wrapf(err, "Failed to re-encrypt the metadata of %s", k)
+		}
+
+		status := "skipped"
+		if done {
+			rekeyed++
+			status = "re-encrypted"
+		}
+		infof("[%d/%d] %s: %s\n", i+1, len(keys), k, status)
+	}
+	return rekeyed, nil
+}
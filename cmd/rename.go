@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+)
+
+var (
+	renameMapFile string
+	renameDryRun  bool
+
+	// renameCmd represents the rename command
+	renameCmd = &cobra.Command{
+		Use:   "rename <service> --map <file|->",
+		Short: "Rename many keys of a service at once",
+		Args:  cobra.ExactArgs(1),
+		RunE:  rename,
+		Example: `
+Given a renames.csv with one old,new pair per line:
+
+	db_pass,db_password
+	db_user,db_username
+
+	$ chamber rename app --map renames.csv --dry-run
+	db_pass -> db_password
+	db_user -> db_username
+`,
+	}
+)
+
+func init() {
+	renameCmd.Flags().StringVarP(&renameMapFile, "map", "m", "", "CSV file of old,new key pairs, or - for stdin")
+	renameCmd.Flags().BoolVarP(&renameDryRun, "dry-run", "", false, "Only print the renames that would be made")
+	renameCmd.MarkFlagRequired("map")
+	RootCmd.AddCommand(renameCmd)
+}
+
+// keyRename is one line of a rename mapping file
+type keyRename struct {
+	from string
+	to   string
+}
+
+func rename(cmd *cobra.Command, args []string) error {
+	service, err := expandService(args[0])
+	if err != nil {
+		return errors.Wrap(err, "Failed to expand service")
+	}
+	service = strings.ToLower(service)
+	if err := validateService(service); err != nil {
+		return errors.Wrap(err, "Failed to validate service")
+	}
+
+	var in io.Reader = os.Stdin
+	if renameMapFile != "-" {
+		f, err := os.Open(renameMapFile)
+		if err != nil {
+			return errors.Wrap(err, "Failed to open file")
+		}
+		defer f.Close()
+		in = f
+	}
+	renames, err := parseRenames(in)
+	if err != nil {
+		return errors.Wrap(err, "Failed to parse rename map")
+	}
+
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", "rename").
+				Set("chamber-version", chamberVersion).
+				Set("service", service).
+				Set("backend", backend).
+				Set("renames", len(renames)).
+				Set("dry-run", renameDryRun),
+		})
+	}
+
+	if renameDryRun {
+		for _, r := range renames {
+			fmt.Fprintf(os.Stdout, "%s -> %s\n", r.from, r.to)
+		}
+		return nil
+	}
+
+	secretStore, err := getSecretStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get secret store")
+	}
+	if err := requireCapabilities(secretStore, store.Capabilities{Write: true}); err != nil {
+		return err
+	}
+	event := HookEvent{Command: "rename", Services: []string{service}}
+	for _, r := range renames {
+		event.Keys = append(event.Keys, r.from, r.to)
+	}
+	if err := runHooks(withEvent(event, PreWriteHook)); err != nil {
+		return err
+	}
+
+	for _, r := range renames {
+		secretId := store.SecretId{Service: service, Key: r.from}
+		if err := store.Rename(secretStore, secretId, r.to); err != nil {
+			return errors.Wrapf(err, "Failed to rename %s to %s", r.from, r.to)
+		}
+		if err := store.RenameKeyMetadata(secretStore, service, r.from, r.to); err != nil {
+			return errors.Wrapf(err, "Failed to move the metadata of %s to %s", r.from, r.to)
+		}
+	}
+	runPostHooks(withEvent(event, PostWriteHook))
+	return nil
+}
+
+// parseRenames reads old,new key pairs, rejecting mappings that are invalid
+// as a whole so nothing is renamed when any line is wrong.
+func parseRenames(in io.Reader) ([]keyRename, error) {
+	reader := csv.NewReader(in)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	renames := make([]keyRename, 0, len(records))
+	sources := map[string]bool{}
+	targets := map[string]bool{}
+	for _, record := range records {
+		r := keyRename{
+			from: strings.ToLower(strings.TrimSpace(record[0])),
+			to:   strings.ToLower(strings.TrimSpace(record[1])),
+		}
+		for _, key := range []string{r.from, r.to} {
+			if err := validateKey(key); err != nil {
+				return nil, err
+			}
+		}
+		if r.from == r.to {
+			return nil, fmt.Errorf("cannot rename %s to itself", r.from)
+		}
+		if sources[r.from] {
+			return nil, fmt.Errorf("%s is renamed more than once", r.from)
+		}
+		if targets[r.to] {
+			return nil, fmt.Errorf("more than one key is renamed to %s", r.to)
+		}
+		if sources[r.to] || targets[r.from] {
+			return nil, fmt.Errorf("%s is both renamed and a rename target", r.from)
+		}
+		sources[r.from], targets[r.to] = true, true
+		renames = append(renames, r)
+	}
+	return renames, nil
+}
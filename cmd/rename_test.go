@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRenames(t *testing.T) {
+	t.Run("Should read old,new pairs", func(t *testing.T) {
+		renames, err := parseRenames(strings.NewReader("# old,new\nDB_PASS, db_password\ndb_user,db_username\n"))
+		assert.Nil(t, err)
+		assert.Equal(t, []keyRename{{"db_pass", "db_password"}, {"db_user", "db_username"}}, renames)
+	})
+
+	invalid := map[string]string{
+		"wrong number of fields": "a,b,c\n",
+		"invalid key":            "a,b/c\n",
+		"rename to itself":       "a,a\n",
+		"same source twice":      "a,b\na,c\n",
+		"same target twice":      "a,c\nb,c\n",
+		"chained renames":        "a,b\nb,c\n",
+	}
+	for name, mapping := range invalid {
+		t.Run("Should reject "+name, func(t *testing.T) {
+			_, err := parseRenames(strings.NewReader(mapping))
+			assert.Error(t, err)
+		})
+	}
+}
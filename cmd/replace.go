@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	gopath "path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+)
+
+var (
+	replaceMatchValue string
+	replaceWith       string
+	replaceServices   string
+	replaceDryRun     bool
+
+	// replaceCmd represents the replace command
+	replaceCmd = &cobra.Command{
+		Use:   "replace --match-value <old> --with <new>",
+		Short: "Replace a value everywhere it was written, across keys and services",
+		Args:  cobra.NoArgs,
+		RunE:  replace,
+		Example: `
+Review, then rotate a credential that was copied into several services:
+
+	$ chamber replace --match-value hunter22 --with correcthorse --services 'app/*' --dry-run
+	Service     Key
+	app/api     db_password
+	app/worker  database_password
+	$ chamber replace --match-value hunter22 --with correcthorse --services 'app/*'
+`,
+	}
+)
+
+func init() {
+	replaceCmd.Flags().StringVarP(&replaceMatchValue, "match-value", "", "", "The value to replace")
+	replaceCmd.Flags().StringVarP(&replaceWith, "with", "", "", "The value to write instead")
+	replaceCmd.Flags().StringVarP(&replaceServices, "services", "", "", "Only replace values in services matching this pattern, e.g. 'app/*'; default is every service")
+	replaceCmd.Flags().BoolVarP(&replaceDryRun, "dry-run", "", false, "Only print the keys whose values would be replaced")
+	replaceCmd.MarkFlagRequired("match-value")
+	replaceCmd.MarkFlagRequired("with")
+	RootCmd.AddCommand(replaceCmd)
+}
+
+func replace(cmd *cobra.Command, args []string) error {
+	// Keep copies, since masking the values on the command line overwrites
+	// the arguments' memory
+	oldValue, newValue := string([]byte(replaceMatchValue)), string([]byte(replaceWith))
+	maskArgs(os.Args, replaceMatchValue)
+	maskArgs(os.Args, replaceWith)
+
+	if oldValue == "" {
+		return errors.New("--match-value must not be empty")
+	}
+	if oldValue == newValue {
+		return errors.New("--match-value and --with are the same")
+	}
+	if err := store.ValidateValue(newValue); err != nil {
+		return errors.Wrap(err, "Failed to validate value")
+	}
+	// Copy the pattern too, as it shares memory with the command line
+	pattern := strings.ToLower(string([]byte(replaceServices)))
+	if _, err := gopath.Match(pattern, ""); err != nil {
+		return errors.Wrap(err, "Failed to parse --services")
+	}
+
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", "replace").
+				Set("chamber-version", chamberVersion).
+				Set("backend", backend).
+				Set("services", pattern != "").
+				Set("dry-run", replaceDryRun),
+		})
+	}
+
+	secretStore, err := getSecretStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get secret store")
+	}
+	if err := requireCapabilities(secretStore, store.Capabilities{Write: !replaceDryRun, ListServices: true}); err != nil {
+		return err
+	}
+	services, err := secretStore.ListServices(servicePatternPrefix(pattern), false)
+	if err != nil {
+		return errors.Wrap(err, "Failed to list store contents")
+	}
+	matches, err := findReplacements(secretStore, matchServices(services, pattern), oldValue)
+	if err != nil {
+		return errors.Wrap(err, "Failed to list store contents")
+	}
+	if len(matches) == 0 {
+		return errors.New("no key holds the value of --match-value")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, '\t', 0)
+	fmt.Fprintln(w, "Service\tKey")
+	for _, match := range matches {
+		fmt.Fprintf(w, "%s\t%s\n", match.Service, match.Key)
+	}
+	w.Flush()
+	if replaceDryRun {
+		return nil
+	}
+
+	event := HookEvent{Command: "replace"}
+	seen := map[string]bool{}
+	for _, match := range matches {
+		if err := validateEnum(secretStore, match.Service, match.Key, newValue); err != nil {
+			return errors.Wrap(err, "Failed to validate value")
+		}
+		if !seen[match.Service] {
+			event.Services = append(event.Services, match.Service)
+			seen[match.Service] = true
+		}
+		event.Keys = append(event.Keys, match.Key)
+	}
+	if err := runHooks(withEvent(event, PreWriteHook)); err != nil {
+		return err
+	}
+	defer runPostHooks(withEvent(event, PostWriteHook))
+
+	now := time.Now()
+	for i, match := range matches {
+		if err := secretStore.Write(match, newValue); err != nil {
+			return errors.Wrapf(err, "Failed to write %s/%s after replacing %d of %d values", match.Service, match.Key, i, len(matches))
+		}
+		if err := setExpiry(secretStore, match.Service, match.Key, 0, now); err != nil {
+			return err
+		}
+	}
+	infof("Replaced %d values\n", len(matches))
+	return nil
+}
+
+// servicePatternPrefix returns the literal beginning of a service pattern, to
+// list fewer services before matching them
+func servicePatternPrefix(pattern string) string {
+	if _, noPaths := os.LookupEnv("CHAMBER_NO_PATHS"); noPaths {
+		// services are listed by their whole name without paths
+		return ""
+	}
+	if i := strings.IndexAny(pattern, `*?[\`); i != -1 {
+		return pattern[:i]
+	}
+	return pattern
+}
+
+// matchServices returns the services matching pattern in order, or all if
+// it's empty, leaving out those chamber keeps its own records in
+func matchServices(services []string, pattern string) []string {
+	var matched []string
+	for _, service := range services {
+		if strings.HasPrefix(service, "_chamber-") {
+			continue
+		}
+		if pattern != "" {
+			if ok, _ := gopath.Match(pattern, service); !ok {
+				continue
+			}
+		}
+		matched = append(matched, service)
+	}
+	sort.Strings(matched)
+	return matched
+}
+
+// findReplacements returns the keys of services whose latest value, as
+// stored, is value. Keys referencing another key's value are left out, as
+// replacing them would overwrite the reference; the key they reference is
+// replaced instead.
+func findReplacements(s store.Store, services []string, value string) ([]store.SecretId, error) {
+	var matches []store.SecretId
+	for _, service := range services {
+		rawSecrets, err := store.ListUnresolved(s, service)
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(rawSecrets, func(i, j int) bool { return rawSecrets[i].Key < rawSecrets[j].Key })
+		for _, rawSecret := range rawSecrets {
+			if strings.HasPrefix(rawSecret.Value, store.ReferencePrefix) {
+				continue
+			}
+			if rawSecret.Value == value {
+				matches = append(matches, store.SecretId{Service: service, Key: key(rawSecret.Key)})
+			}
+		}
+	}
+	return matches, nil
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchServices(t *testing.T) {
+	services := []string{"app/worker", "app/api", "app", "other", "_chamber-meta/app"}
+
+	t.Run("Should match services by pattern", func(t *testing.T) {
+		assert.Equal(t, []string{"app/api", "app/worker"}, matchServices(services, "app/*"))
+	})
+
+	t.Run("Should match every service without a pattern", func(t *testing.T) {
+		assert.Equal(t, []string{"app", "app/api", "app/worker", "other"}, matchServices(services, ""))
+	})
+}
+
+func TestServicePatternPrefix(t *testing.T) {
+	assert.Equal(t, "app/", servicePatternPrefix("app/*"))
+	assert.Equal(t, "app", servicePatternPrefix("app"))
+	assert.Equal(t, "", servicePatternPrefix("*"))
+}
+
+func TestFindReplacements(t *testing.T) {
+	s := store.NewStaticStore(map[string]map[string]string{
+		"app/api":    {"db_password": "hunter22", "db_user": "root"},
+		"app/worker": {"database_password": "hunter22"},
+		"other":      {"password": "hunter2"},
+	})
+
+	t.Run("Should find every key holding the value", func(t *testing.T) {
+		matches, err := findReplacements(s, []string{"app/api", "app/worker", "other"}, "hunter22")
+		assert.Nil(t, err)
+		assert.Equal(t, []store.SecretId{
+			{Service: "app/api", Key: "db_password"},
+			{Service: "app/worker", Key: "database_password"},
+		}, matches)
+	})
+
+	t.Run("Should leave out keys referencing the value", func(t *testing.T) {
+		referenced := store.NewReferenceStore(store.NewStaticStore(map[string]map[string]string{
+			"app/api":    {"db_password": "hunter22"},
+			"app/worker": {"database_password": "chamber-ref://app/api/db_password"},
+		}))
+		matches, err := findReplacements(referenced, []string{"app/api", "app/worker"}, "hunter22")
+		assert.Nil(t, err)
+		assert.Equal(t, []store.SecretId{{Service: "app/api", Key: "db_password"}}, matches)
+	})
+
+	t.Run("Should not match parts of values", func(t *testing.T) {
+		matches, err := findReplacements(s, []string{"other"}, "hunter")
+		assert.Nil(t, err)
+		assert.Empty(t, matches)
+	})
+}
@@ -2,8 +2,10 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"regexp"
+	"runtime/debug"
 	"strings"
 	"time"
 
@@ -21,16 +23,50 @@ var (
 	validServiceFormatWithLabel     = regexp.MustCompile(`^[\w\-\.\:]+$`)
 	validServicePathFormatWithLabel = regexp.MustCompile(`^[\w\-\.]+((\/[\w\-\.]+)+(\:[\w\-\.]+)*)?$`)
 
-	verbose          bool
-	numRetries       int
-	minThrottleDelay time.Duration
-	chamberVersion   string
+	verbose           bool
+	numRetries        int
+	minThrottleDelay  time.Duration
+	requestTimeout    time.Duration
+	maxElapsed        time.Duration
+	maxAPICalls       int
+	explain           bool
+	retryModeFlag     string
+	retryBaseDelay    time.Duration
+	retryMaxDelay     time.Duration
+	retryJitter       float64
+	profileFlag       string
+	roleARN           string
+	roleSessionName   string
+	webIdentityToken  string
+	githubOIDC        bool
+	credentialProc    string
+	cacheCredentials  bool
+	disableHTTP2      bool
+	usageSinkFlag     string
+	hooksFlag         string
+	statsFlag         bool
+	layoutFlag        string
+	useCache          bool
+	plaintextKeysFlag []string
+	// plaintextKeys are the patterns of keys stored unencrypted, from
+	// --plaintext-keys or $CHAMBER_PLAINTEXT_KEYS
+	plaintextKeys  []string
+	proxyFlag      string
+	caBundleFlag   string
+	tlsMinVersion  string
+	chamberVersion string
 	// one of *Backend consts
 	backend             string
 	backendFlag         string
 	backendS3BucketFlag string
+	vaultMountFlag      string
 	kmsKeyAliasFlag     string
 
+	// requireSecureString refuses --plaintext-keys, so every parameter chamber
+	// writes is a SecureString; from --require-securestring or
+	// $CHAMBER_REQUIRE_SECURESTRING
+	requireSecureString bool
+
 	analyticsEnabled  bool
 	analyticsWriteKey string
 	analyticsClient   analytics.Client
@@ -46,41 +82,92 @@ const (
 )
 
 const (
-	NullBackend  = "NULL"
-	SSMBackend   = "SSM"
-	S3Backend    = "S3"
-	S3KMSBackend = "S3-KMS"
-
-	BackendEnvVar = "CHAMBER_SECRET_BACKEND"
-	BucketEnvVar  = "CHAMBER_S3_BUCKET"
-	KMSKeyEnvVar  = "CHAMBER_KMS_KEY_ALIAS"
+	NullBackend   = "NULL"
+	SSMBackend    = "SSM"
+	S3Backend     = "S3"
+	S3KMSBackend  = "S3-KMS"
+	StaticBackend = "STATIC"
+	VaultBackend  = "VAULT"
+
+	BackendEnvVar    = "CHAMBER_SECRET_BACKEND"
+	BucketEnvVar     = "CHAMBER_S3_BUCKET"
+	VaultMountEnvVar = "CHAMBER_VAULT_MOUNT"
+	KMSKeyEnvVar     = "CHAMBER_KMS_KEY_ALIAS"
+	RetryModeEnvVar  = "CHAMBER_RETRY_MODE"
+	NamespaceEnvVar  = "CHAMBER_NAMESPACE"
+	ProfileEnvVar    = "CHAMBER_PROFILE"
+
+	CredentialProcessEnvVar = "CHAMBER_CREDENTIAL_PROCESS"
+	CacheCredentialsEnvVar  = "CHAMBER_CACHE_CREDENTIALS"
+	UsageSinkEnvVar         = "CHAMBER_USAGE_SINK"
+	PlaintextKeysEnvVar     = "CHAMBER_PLAINTEXT_KEYS"
+	RequireSecureEnvVar     = "CHAMBER_REQUIRE_SECURESTRING"
+	HooksEnvVar             = "CHAMBER_HOOKS"
+	StatsEnvVar             = "CHAMBER_STATS"
+	LayoutEnvVar            = "CHAMBER_LAYOUT"
+	UseCacheEnvVar          = "CHAMBER_USE_CACHE"
+	SnapshotLocationEnvVar  = "CHAMBER_SNAPSHOT_LOCATION"
 
 	DefaultKMSKey = "alias/parameter_store_key"
 )
 
-var Backends = []string{SSMBackend, S3Backend, NullBackend, S3KMSBackend}
+var Backends = []string{SSMBackend, S3Backend, NullBackend, S3KMSBackend, StaticBackend, VaultBackend}
 
 // RootCmd represents the base command when called without any subcommands
 var RootCmd = &cobra.Command{
 	Use:               "chamber",
 	Short:             "CLI for storing secrets",
 	SilenceUsage:      true,
+	SilenceErrors:     true,
 	PersistentPreRun:  prerun,
 	PersistentPostRun: postrun,
 }
 
 func init() {
 	RootCmd.PersistentFlags().IntVarP(&numRetries, "retries", "r", DefaultNumRetries, "For SSM, the number of retries we'll make before giving up")
-	RootCmd.PersistentFlags().DurationVarP(&minThrottleDelay, "min-throttle-delay", "", store.DefaultMinThrottleDelay, "For SSM, minimal delay before retrying throttled requests. Default 500ms.")
-	RootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "", false, "Print more information to STDOUT")
+	RootCmd.PersistentFlags().DurationVarP(&minThrottleDelay, "min-throttle-delay", "", store.DefaultMinThrottleDelay, "Minimal delay before retrying throttled requests. Default 500ms.")
+	RootCmd.PersistentFlags().StringVarP(&retryModeFlag, "retry-mode", "", "",
+		`How to retry failed AWS requests; AKA $CHAMBER_RETRY_MODE. Uses the AWS SDK's retryer when unset
+	standard: exponential backoff with jitter
+	adaptive: like standard, but also slows down all requests while AWS is throttling`,
+	)
+	RootCmd.PersistentFlags().DurationVarP(&retryBaseDelay, "retry-base-delay", "", store.DefaultRetryBaseDelay, "Delay before the first retry; doubles on every further attempt")
+	RootCmd.PersistentFlags().DurationVarP(&retryMaxDelay, "retry-max-delay", "", store.DefaultRetryMaxDelay, "Maximum delay between two attempts")
+	RootCmd.PersistentFlags().Float64VarP(&retryJitter, "retry-jitter", "", 1, "Fraction (0 to 1) of each retry delay that is randomized")
+	RootCmd.PersistentFlags().StringVarP(&proxyFlag, "proxy", "", "", "Proxy URL for all AWS requests (default is to honor $HTTPS_PROXY and $NO_PROXY)")
+	RootCmd.PersistentFlags().StringVarP(&caBundleFlag, "ca-bundle", "", "", "PEM file with the certificate authorities to trust for AWS endpoints")
+	RootCmd.PersistentFlags().StringVarP(&tlsMinVersion, "tls-min-version", "", "", "Minimum TLS version for AWS requests (1.0, 1.1, 1.2 or 1.3)")
+	RootCmd.PersistentFlags().StringVarP(&credentialProc, "credential-process", "", "", "Command printing AWS credentials in the credential_process format, used instead of the default credentials")
+	RootCmd.PersistentFlags().BoolVarP(&disableHTTP2, "disable-http2", "", false, "Only use HTTP/1.1 for AWS requests")
+	RootCmd.PersistentFlags().BoolVarP(&cacheCredentials, "cache-credentials", "", false, "Cache assumed role credentials in the OS keyring until they expire")
+	RootCmd.PersistentFlags().StringVarP(&roleARN, "role-arn", "", "", "IAM role to assume for all AWS requests (default is $AWS_ROLE_ARN with --web-identity-token or --github-oidc)")
+	RootCmd.PersistentFlags().StringVarP(&roleSessionName, "role-session-name", "", store.DefaultRoleSessionName, "Session name used when assuming --role-arn")
+	RootCmd.PersistentFlags().StringVarP(&webIdentityToken, "web-identity-token", "", "", "File with an OIDC token to assume --role-arn with")
+	RootCmd.PersistentFlags().BoolVarP(&githubOIDC, "github-oidc", "", false, "Assume --role-arn with the OIDC token of the current GitHub Actions job")
+	RootCmd.PersistentFlags().DurationVarP(&requestTimeout, "timeout", "", 0, "Maximum time to wait for a single AWS API request; 0 means no limit")
+	RootCmd.PersistentFlags().DurationVarP(&maxElapsed, "max-elapsed", "", 0, "Maximum total time to spend waiting on AWS API requests, including retries; 0 means no limit")
+	RootCmd.PersistentFlags().IntVarP(&maxAPICalls, "max-api-calls", "", 0, "Maximum number of AWS API calls to make, not counting retries; 0 means no limit")
+	RootCmd.PersistentFlags().BoolVarP(&explain, "explain", "", false, "Print the AWS API calls made, by operation, and the slowest ones to STDERR")
+	RootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "", false, "Print more information to STDERR")
 	RootCmd.PersistentFlags().StringVarP(&backendFlag, "backend", "b", "ssm",
 		`Backend to use; AKA $CHAMBER_SECRET_BACKEND
 	null: no-op
 	ssm: SSM Parameter Store
 	s3: S3; requires --backend-s3-bucket
-	s3-kms: S3 using AWS-KMS encryption; requires --backend-s3-bucket and --kms-key-alias set (if you want to write or delete keys).`,
+	s3-kms: S3 using AWS-KMS encryption; requires --backend-s3-bucket and --kms-key-alias set (if you want to write or delete keys).
+	static:<file>: read-only secrets from a JSON, YAML or .env file, or "-" for stdin
+	vault: HashiCorp Vault KV v2; configured like the vault CLI, with $VAULT_ADDR and $VAULT_TOKEN`,
 	)
+	RootCmd.PersistentFlags().StringSliceVarP(&plaintextKeysFlag, "plaintext-keys", "", nil, "For SSM, patterns of non-sensitive keys, like log_* or app/*/port, to write as String instead of SecureString parameters; AKA $CHAMBER_PLAINTEXT_KEYS")
+	RootCmd.PersistentFlags().BoolVarP(&requireSecureString, "require-securestring", "", false, "For SSM, require every parameter chamber writes to be a SecureString, refusing --plaintext-keys; AKA $CHAMBER_REQUIRE_SECURESTRING")
+	RootCmd.PersistentFlags().StringVarP(&layoutFlag, "layout", "", "", "For SSM, how services and keys map onto parameter names, like prefix=/,separator=.,key-case=upper for /app.prod.KEY; AKA $CHAMBER_LAYOUT")
+	RootCmd.PersistentFlags().BoolVarP(&useCache, "use-cache", "", false, "Serve the secrets exec injects from the OS keyring while the listings cached by chamber prefetch --cache are fresh; AKA $CHAMBER_USE_CACHE")
+	RootCmd.PersistentFlags().StringVarP(&usageSinkFlag, "usage-sink", "", "", "S3 location, like s3://bucket/prefix, where exec records the keys it injects; AKA $CHAMBER_USAGE_SINK")
+	RootCmd.PersistentFlags().StringVarP(&hooksFlag, "hooks", "", "", "YAML file listing commands to run, by event, before writes, after writes and before exec; AKA $CHAMBER_HOOKS")
+	RootCmd.PersistentFlags().BoolVarP(&statsFlag, "stats", "", false, "Record how often and how long commands run, without their arguments, in a local summary for chamber stats; AKA $CHAMBER_STATS")
+	RootCmd.PersistentFlags().StringVarP(&profileFlag, "profile", "", "", "Profile whose values are layered over each service's defaults, e.g. canary; AKA $CHAMBER_PROFILE")
 	RootCmd.PersistentFlags().StringVarP(&backendS3BucketFlag, "backend-s3-bucket", "", "", "bucket for S3 backend; AKA $CHAMBER_S3_BUCKET")
+	RootCmd.PersistentFlags().StringVarP(&vaultMountFlag, "backend-vault-mount", "", store.DefaultVaultMount, "mount of the KV v2 engine for the Vault backend; AKA $CHAMBER_VAULT_MOUNT")
 	RootCmd.PersistentFlags().StringVarP(&kmsKeyAliasFlag, "kms-key-alias", "", DefaultKMSKey, "KMS Key Alias for writing and deleting secrets; AKA $CHAMBER_KMS_KEY_ALIAS. This option is currently only supported for the S3-KMS backend.")
 }
 
@@ -92,15 +179,73 @@ func Execute(vers string, writeKey string) {
 	analyticsWriteKey = writeKey
 	analyticsEnabled = analyticsWriteKey != ""
 
-	if cmd, err := RootCmd.ExecuteC(); err != nil {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(os.Stderr, "panic: %s\n\n%s", store.Scrub(fmt.Sprint(r)), store.Scrub(string(debug.Stack())))
+			os.Exit(2)
+		}
+	}()
+
+	commandStart = time.Now()
+	cmd, err := RootCmd.ExecuteC()
+	recordStats(cmd, err)
+	if err != nil {
+		// errors may quote AWS responses or values that failed to parse
+		fmt.Fprintln(os.Stderr, "Error:", store.Scrub(err.Error()))
 		if strings.Contains(err.Error(), "arg(s)") || strings.Contains(err.Error(), "usage") {
 			cmd.Usage()
 		}
+		reportMaxElapsed(os.Stderr)
+		reportMaxAPICalls(os.Stderr)
+		reportAPICalls(os.Stderr)
 		os.Exit(1)
 	}
+	reportAPICalls(os.Stderr)
+}
+
+// commandStart is when chamber started running the command, for --stats
+var commandStart time.Time
+
+// reportMaxElapsed explains a failure caused by running out of --max-elapsed,
+// which would otherwise only surface as a cancelled AWS request.
+func reportMaxElapsed(w io.Writer) {
+	if store.MaxElapsedExceeded() {
+		fmt.Fprintf(w, "chamber: gave up after spending --max-elapsed %s on AWS requests\n", maxElapsed)
+	}
+}
+
+// expandService resolves $VAR and ${VAR} placeholders in a service argument
+// from the environment. Unlike the shell, it fails on unset variables instead
+// of silently addressing a different service.
+func expandService(service string) (string, error) {
+	var missing []string
+	expanded := os.Expand(service, func(name string) string {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("undefined environment variable %s in service '%s'", strings.Join(missing, ", "), service)
+	}
+	return expanded, nil
+}
+
+// expandServices applies expandService to every service argument
+func expandServices(services []string) ([]string, error) {
+	expanded := make([]string, len(services))
+	for i, service := range services {
+		var err error
+		if expanded[i], err = expandService(service); err != nil {
+			return nil, err
+		}
+	}
+	return expanded, nil
 }
 
 func validateService(service string) error {
+	_, service = store.SplitRegion(service)
 	_, noPaths := os.LookupEnv("CHAMBER_NO_PATHS")
 	if noPaths {
 		if !validServiceFormat.MatchString(service) {
@@ -116,6 +261,7 @@ func validateService(service string) error {
 }
 
 func validateServiceWithLabel(service string) error {
+	_, service = store.SplitRegion(service)
 	_, noPaths := os.LookupEnv("CHAMBER_NO_PATHS")
 	if noPaths {
 		if !validServiceFormatWithLabel.MatchString(service) {
@@ -137,21 +283,72 @@ func validateKey(key string) error {
 	return nil
 }
 
-func getSecretStore() (store.Store, error) {
+// resolveBackend sets backend from --backend or $CHAMBER_SECRET_BACKEND, and
+// returns the file of the static backend.
+func resolveBackend() string {
 	rootPflags := RootCmd.PersistentFlags()
 	if backendEnvVarValue := os.Getenv(BackendEnvVar); !rootPflags.Changed("backend") && backendEnvVarValue != "" {
 		backend = backendEnvVarValue
 	} else {
 		backend = backendFlag
 	}
+	// The static backend carries its file, whose case must be preserved
+	var staticFile string
+	if parts := strings.SplitN(backend, ":", 2); len(parts) == 2 && strings.ToUpper(parts[0]) == StaticBackend {
+		backend, staticFile = parts[0], parts[1]
+	}
 	backend = strings.ToUpper(backend)
+	return staticFile
+}
+
+// requireCapabilities fails early when the backend lacks what a command
+// needs, rather than partway through it
+func requireCapabilities(s store.Store, required store.Capabilities) error {
+	if lacking := store.CapabilitiesOf(s).Lacks(required); len(lacking) > 0 {
+		return fmt.Errorf("the %s backend doesn't support %s", backend, strings.Join(lacking, " or "))
+	}
+	return nil
+}
+
+func getSecretStore() (store.Store, error) {
+	rootPflags := RootCmd.PersistentFlags()
+	staticFile := resolveBackend()
+
+	if err := configureSessions(); err != nil {
+		return nil, err
+	}
+
+	plaintextKeys = plaintextKeysFlag
+	if v := os.Getenv(PlaintextKeysEnvVar); !rootPflags.Changed("plaintext-keys") && v != "" {
+		plaintextKeys = strings.Split(v, ",")
+	}
+	if len(plaintextKeys) > 0 && backend != SSMBackend {
+		return nil, errors.New("Unable to use --plaintext-keys with this backend")
+	}
+	if v := os.Getenv(RequireSecureEnvVar); !rootPflags.Changed("require-securestring") && v != "" {
+		requireSecureString = v == "true" || v == "1"
+	}
+	if len(plaintextKeys) > 0 && requireSecureString {
+		return nil, errors.New("Unable to use --plaintext-keys with --require-securestring")
+	}
+
+	layoutSpec := layoutSpecFromFlags()
+	if layoutSpec != "" && backend != SSMBackend {
+		return nil, errors.New("Unable to use --layout with this backend")
+	}
 
 	var s store.Store
 	var err error
+	var newRegionStore func(region string) (store.Store, error)
 
 	switch backend {
 	case NullBackend:
 		s = store.NewNullStore()
+	case StaticBackend:
+		if staticFile == "" {
+			return nil, errors.New("Must set file for static backend, e.g. static:./secrets.json")
+		}
+		s, err = store.NewStaticStoreFromFile(staticFile)
 	case S3Backend:
 		if kmsKeyAliasFlag != DefaultKMSKey {
 			return nil, errors.New("Unable to use --kms-key-alias with this backend.")
@@ -194,16 +391,230 @@ func getSecretStore() (store.Store, error) {
 		}
 
 		s, err = store.NewS3KMSStore(numRetries, bucket, kmsKeyAlias)
+	case VaultBackend:
+		if kmsKeyAliasFlag != DefaultKMSKey {
+			return nil, errors.New("Unable to use --kms-key-alias with this backend.")
+		}
+
+		mount := vaultMountFlag
+		if mountEnvVarValue := os.Getenv(VaultMountEnvVar); !rootPflags.Changed("backend-vault-mount") && mountEnvVarValue != "" {
+			mount = mountEnvVarValue
+		}
+		s, err = store.NewVaultStore(mount)
 	case SSMBackend:
 		if kmsKeyAliasFlag != DefaultKMSKey {
 			return nil, errors.New("Unable to use --kms-key-alias with this backend. Use CHAMBER_KMS_KEY_ALIAS instead.")
 		}
 
-		s, err = store.NewSSMStoreWithMinThrottleDelay(numRetries, minThrottleDelay)
+		var ssmStore *store.SSMStore
+		ssmStore, err = store.NewSSMStoreWithMinThrottleDelay(numRetries, minThrottleDelay)
+		if err != nil {
+			return nil, err
+		}
+		if err := configureSSMStore(ssmStore, layoutSpec); err != nil {
+			return nil, err
+		}
+		s = ssmStore
+
+		// services like us-west-2:app/prod are served from a store of their
+		// own region, created when first used
+		newRegionStore = func(region string) (store.Store, error) {
+			ssmStore, err := store.NewSSMStoreForAccount(numRetries, minThrottleDelay, region, "")
+			if err != nil {
+				return nil, err
+			}
+			if err := configureSSMStore(ssmStore, layoutSpec); err != nil {
+				return nil, err
+			}
+			s, err := cacheStore(ssmStore, region)
+			if err != nil {
+				return nil, err
+			}
+			return wrapStore(s)
+		}
 	default:
 		return nil, fmt.Errorf("invalid backend `%s`", backend)
 	}
-	return s, err
+	if err != nil {
+		return nil, err
+	}
+
+	region := os.Getenv(store.RegionEnvVar)
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	if s, err = cacheStore(s, region); err != nil {
+		return nil, err
+	}
+	s, err = wrapStore(s)
+	if err != nil {
+		return nil, err
+	}
+	return store.NewScrubbingStore(store.NewRegionalStore(s, newRegionStore)), nil
+}
+
+// cacheMode is set by prefetch --cache to refresh the cache
+var cacheMode store.CacheMode
+
+// cacheStore caches the listings of the store of a backend in region when
+// refreshing them or with --use-cache. Listings are cached by backend, region
+// and identity, so they are never served to another account.
+func cacheStore(s store.Store, region string) (store.Store, error) {
+	mode := cacheMode
+	enabled := useCache
+	if v := os.Getenv(UseCacheEnvVar); !RootCmd.PersistentFlags().Changed("use-cache") && v != "" {
+		enabled = v == "true" || v == "1"
+	}
+	if mode == 0 && enabled {
+		mode = store.CacheRead
+	}
+	if mode == 0 || backend == NullBackend || backend == StaticBackend {
+		return s, nil
+	}
+	profile := os.Getenv("AWS_PROFILE")
+	if profile == "" {
+		profile = os.Getenv("AWS_DEFAULT_PROFILE")
+	}
+	scope := strings.Join([]string{backend, region, profile, roleARN}, ":")
+	cached, err := store.NewCachingStore(s, scope, mode, prefetchTTL)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to open cache")
+	}
+	return cached, nil
+}
+
+// configureSSMStore applies --plaintext-keys and --layout to an SSM store
+func configureSSMStore(ssmStore *store.SSMStore, layoutSpec string) error {
+	if err := ssmStore.SetPlaintextKeys(plaintextKeys); err != nil {
+		return errors.Wrap(err, "Invalid --plaintext-keys pattern")
+	}
+	if layoutSpec != "" {
+		layout, err := store.ParseLayout(layoutSpec)
+		if err != nil {
+			return errors.Wrap(err, "Invalid --layout")
+		}
+		if err := ssmStore.SetLayout(layout); err != nil {
+			return errors.Wrap(err, "Invalid --layout")
+		}
+	}
+	return nil
+}
+
+// namespaceFromEnv returns the namespace set with CHAMBER_NAMESPACE, if any
+func namespaceFromEnv() (string, error) {
+	namespace := os.Getenv(NamespaceEnvVar)
+	if namespace == "" {
+		return "", nil
+	}
+	if err := validateService(strings.Trim(namespace, "/")); err != nil {
+		return "", errors.Wrap(err, "Failed to validate namespace")
+	}
+	return strings.ToLower(namespace), nil
+}
+
+// layoutSpecFromFlags returns the --layout flag, or CHAMBER_LAYOUT unless
+// the flag is set
+func layoutSpecFromFlags() string {
+	if v := os.Getenv(LayoutEnvVar); !RootCmd.PersistentFlags().Changed("layout") && v != "" {
+		return v
+	}
+	return layoutFlag
+}
+
+// wrapStore layers the namespace, profile, freezes and references over the
+// store of a backend
+func wrapStore(s store.Store) (store.Store, error) {
+	rootPflags := RootCmd.PersistentFlags()
+	namespace, err := namespaceFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if namespace != "" {
+		s = store.NewNamespacedStore(s, namespace)
+	}
+
+	profile := profileFlag
+	if profileEnvVarValue := os.Getenv(ProfileEnvVar); !rootPflags.Changed("profile") && profileEnvVarValue != "" {
+		profile = profileEnvVarValue
+	}
+	if profile != "" {
+		if _, noPaths := os.LookupEnv("CHAMBER_NO_PATHS"); noPaths {
+			return nil, errors.New("Profiles require path-based services; unset CHAMBER_NO_PATHS")
+		}
+		if err := validateKey(profile); err != nil {
+			return nil, errors.Wrap(err, "Failed to validate profile")
+		}
+		s = store.NewProfileStore(s, strings.ToLower(profile))
+	}
+	return store.NewReferenceStore(store.NewFreezeGuardStore(s)), nil
+}
+
+// configureSessions applies the global AWS flags to the sessions created by
+// the store package.
+func configureSessions() error {
+	rootPflags := RootCmd.PersistentFlags()
+	retryMode := retryModeFlag
+	if retryModeEnvVarValue := os.Getenv(RetryModeEnvVar); !rootPflags.Changed("retry-mode") && retryModeEnvVarValue != "" {
+		retryMode = retryModeEnvVarValue
+	}
+	retry := store.RetryOptions{
+		Mode:      strings.ToLower(retryMode),
+		BaseDelay: retryBaseDelay,
+		MaxDelay:  retryMaxDelay,
+		Jitter:    retryJitter,
+	}
+	if err := retry.Validate(); err != nil {
+		return err
+	}
+
+	var tlsVersion uint16
+	if tlsMinVersion != "" {
+		v, ok := store.TLSVersions[tlsMinVersion]
+		if !ok {
+			return fmt.Errorf("invalid TLS version `%s`", tlsMinVersion)
+		}
+		tlsVersion = v
+	}
+
+	role := store.RoleOptions{
+		RoleARN:              roleARN,
+		SessionName:          roleSessionName,
+		WebIdentityTokenFile: webIdentityToken,
+		GitHubOIDC:           githubOIDC,
+	}
+	if role.RoleARN == "" && (role.WebIdentityTokenFile != "" || role.GitHubOIDC) {
+		role.RoleARN = os.Getenv("AWS_ROLE_ARN")
+	}
+	if role.WebIdentityTokenFile != "" && role.GitHubOIDC {
+		return errors.New("--web-identity-token and --github-oidc are mutually exclusive")
+	}
+
+	credentialProcess := credentialProc
+	if credentialProcessEnvVarValue := os.Getenv(CredentialProcessEnvVar); !rootPflags.Changed("credential-process") && credentialProcessEnvVarValue != "" {
+		credentialProcess = credentialProcessEnvVarValue
+	}
+
+	cache := cacheCredentials
+	if cacheEnvVarValue := os.Getenv(CacheCredentialsEnvVar); !rootPflags.Changed("cache-credentials") && cacheEnvVarValue != "" {
+		cache = cacheEnvVarValue == "true" || cacheEnvVarValue == "1"
+	}
+
+	store.ConfigureSessions(store.SessionOptions{
+		RequestTimeout:    requestTimeout,
+		MaxElapsed:        maxElapsed,
+		RecordAPICalls:    explain,
+		MaxAPICalls:       maxAPICalls,
+		MinThrottleDelay:  minThrottleDelay,
+		Retry:             retry,
+		Proxy:             proxyFlag,
+		CABundle:          caBundleFlag,
+		TLSMinVersion:     tlsVersion,
+		DisableHTTP2:      disableHTTP2,
+		CredentialProcess: credentialProcess,
+		Role:              role,
+		CacheCredentials:  cache,
+	})
+	return nil
 }
 
 func prerun(cmd *cobra.Command, args []string) {
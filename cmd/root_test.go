@@ -1,9 +1,14 @@
 package cmd
 
 import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/segmentio/chamber/v2/store"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -51,6 +56,7 @@ func TestValidations(t *testing.T) {
 		"foo-bar/foo-bar",
 		"foo/bar/foo",
 		"foo/bar/foo-bar",
+		"us-west-2:foo/bar",
 	}
 
 	for _, k := range validServicePathFormat {
@@ -140,3 +146,64 @@ func TestValidations(t *testing.T) {
 		})
 	}
 }
+
+func TestReportMaxElapsed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+	}))
+	defer server.Close()
+	os.Setenv("CHAMBER_AWS_SSM_ENDPOINT", server.URL)
+	os.Setenv("AWS_REGION", "us-east-1")
+	os.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	defer func() {
+		os.Unsetenv("CHAMBER_AWS_SSM_ENDPOINT")
+		os.Unsetenv("AWS_REGION")
+		os.Unsetenv("AWS_ACCESS_KEY_ID")
+		os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+	}()
+
+	maxElapsed = 50 * time.Millisecond
+	store.ConfigureSessions(store.SessionOptions{MaxElapsed: maxElapsed})
+	defer store.ConfigureSessions(store.SessionOptions{})
+
+	t.Run("Nothing should be reported while the budget lasts", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		reportMaxElapsed(buf)
+		assert.Equal(t, "", buf.String())
+	})
+
+	t.Run("Running out of the budget should be explained", func(t *testing.T) {
+		s, err := store.NewSSMStore(0)
+		assert.Nil(t, err)
+		_, err = s.Read(store.SecretId{Service: "service", Key: "key"}, -1)
+		assert.Error(t, err)
+
+		buf := &bytes.Buffer{}
+		reportMaxElapsed(buf)
+		assert.Equal(t, "chamber: gave up after spending --max-elapsed 50ms on AWS requests\n", buf.String())
+	})
+}
+
+func TestExpandService(t *testing.T) {
+	os.Setenv("CHAMBER_TEST_ENV", "prod")
+	defer os.Unsetenv("CHAMBER_TEST_ENV")
+	os.Unsetenv("CHAMBER_TEST_UNSET")
+
+	t.Run("Should resolve placeholders from the environment", func(t *testing.T) {
+		service, err := expandService("app/${CHAMBER_TEST_ENV}/$CHAMBER_TEST_ENV")
+		assert.Nil(t, err)
+		assert.Equal(t, "app/prod/prod", service)
+	})
+
+	t.Run("Should leave services without placeholders alone", func(t *testing.T) {
+		service, err := expandService("app/prod")
+		assert.Nil(t, err)
+		assert.Equal(t, "app/prod", service)
+	})
+
+	t.Run("Should fail on unset variables", func(t *testing.T) {
+		_, err := expandServices([]string{"app", "app/${CHAMBER_TEST_UNSET}"})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/environ"
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+)
+
+// stagedServiceEnvVar names the service the new value is staged in, for
+// canaries that read it from the store themselves
+const stagedServiceEnvVar = "CHAMBER_STAGED_SERVICE"
+
+var (
+	rotateCanary string
+
+	// rotateCmd represents the rotate command
+	rotateCmd = &cobra.Command{
+		Use:   "rotate <service> <key> [--] <value|-> --canary <command>",
+		Short: "Stage a new value of a secret and promote it once a canary command succeeds with it",
+		Args:  cobra.ExactArgs(3),
+		RunE:  rotate,
+		Example: `
+	$ chamber rotate app database_url - --canary 'psql "$DATABASE_URL" -c "select 1"' < new-url
+`,
+	}
+)
+
+func init() {
+	rotateCmd.Flags().StringVarP(&rotateCanary, "canary", "", "", "Shell command that must succeed with the staged value in its environment before it's promoted")
+	rotateCmd.MarkFlagRequired("canary")
+	RootCmd.AddCommand(rotateCmd)
+}
+
+func rotate(cmd *cobra.Command, args []string) error {
+	service, err := expandService(args[0])
+	if err != nil {
+		return errors.Wrap(err, "Failed to expand service")
+	}
+	service = strings.ToLower(service)
+	if err := validateService(service); err != nil {
+		return errors.Wrap(err, "Failed to validate service")
+	}
+
+	key := strings.ToLower(args[1])
+	if err := validateKey(key); err != nil {
+		return errors.Wrap(err, "Failed to validate key")
+	}
+
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", "rotate").
+				Set("chamber-version", chamberVersion).
+				Set("service", service).
+				Set("backend", backend).
+				Set("key", key),
+		})
+	}
+
+	secretStore, err := getSecretStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get secret store")
+	}
+
+	var value string
+	if args[2] == "-" {
+		v, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		value = string(v)
+	} else {
+		value = string([]byte(args[2]))
+		maskArgs(os.Args, args[2])
+	}
+	if err := store.ValidateValue(value); err != nil {
+		return errors.Wrap(err, "Failed to validate value")
+	}
+	if err := validateEnum(secretStore, service, key, value); err != nil {
+		return errors.Wrap(err, "Failed to validate value")
+	}
+	if err := checkQuotas(service, map[string]string{key: value}, os.Stderr); err != nil {
+		return errors.Wrap(err, "Failed to check quotas")
+	}
+
+	return rotateSecret(secretStore, service, key, value, rotateCanary)
+}
+
+// rotateSecret stages value for key of service and promotes it to the key
+// once canary succeeds with it. The staged value is dropped either way.
+func rotateSecret(secretStore store.Store, service, key, value, canary string) error {
+	stagedId := store.StagedId(service, key)
+	if err := store.WriteReserved(secretStore, stagedId, value); err != nil {
+		return errors.Wrap(err, "Failed to stage secret")
+	}
+	defer func() {
+		if err := store.DeleteReserved(secretStore, stagedId); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: unable to drop the staged value of %s/%s: %s\n", service, key, err)
+		}
+	}()
+	staged, err := secretStore.Read(stagedId, -1)
+	if err != nil {
+		return errors.Wrap(err, "Failed to read staged secret")
+	}
+
+	vars, err := envVars(secretStore, service)
+	if err != nil {
+		return errors.Wrap(err, "Failed to list store contents")
+	}
+	vars[strings.ToUpper(key)] = *staged.Value
+	vars[stagedServiceEnvVar] = stagedId.Service
+	if err := runCanary(canary, vars); err != nil {
+		return errors.Wrap(err, "Canary failed, so the staged value was not promoted")
+	}
+
+	event := HookEvent{Command: "rotate", Services: []string{service}, Keys: []string{key}}
+	if err := runHooks(withEvent(event, PreWriteHook)); err != nil {
+		return err
+	}
+	if err := secretStore.Write(store.SecretId{Service: service, Key: key}, *staged.Value); err != nil {
+		return errors.Wrap(err, "Failed to promote staged secret")
+	}
+	defer runPostHooks(withEvent(event, PostWriteHook))
+	return setExpiry(secretStore, service, key, 0, time.Now())
+}
+
+// runCanary runs command with vars, the secrets of the service being rotated
+// including the staged value, added to the environment
+func runCanary(command string, vars map[string]string) error {
+	env := environ.Environ(os.Environ())
+	for k, v := range vars {
+		env.Set(k, v)
+	}
+	c := shellCommand(command)
+	c.Env = env
+	c.Stdout = os.Stderr
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("%q: %s", command, err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunCanary(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("canaries run with sh")
+	}
+
+	t.Run("Should see the new value in its environment", func(t *testing.T) {
+		assert.Nil(t, runCanary(`test "$DB_PASSWORD" = new`, map[string]string{"DB_PASSWORD": "new"}))
+	})
+
+	t.Run("Should fail when the command fails", func(t *testing.T) {
+		err := runCanary(`test "$DB_PASSWORD" = new`, map[string]string{"DB_PASSWORD": "old"})
+		assert.NotNil(t, err)
+	})
+}
+
+func TestRotateSecret(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("canaries run with sh")
+	}
+	id := store.SecretId{Service: "app", Key: "db_password"}
+	staged := store.StagedId("app", "db_password")
+
+	t.Run("Should promote the staged value once the canary succeeds", func(t *testing.T) {
+		s := newFakeStore()
+		s.set(id, "old")
+		canary := `test "$DB_PASSWORD" = new && test "$CHAMBER_STAGED_SERVICE" = _chamber-staged/app`
+		assert.Nil(t, rotateSecret(s, "app", "db_password", "new", canary))
+		assert.Equal(t, []string{"old", "new"}, s.values(id))
+		assert.NotContains(t, s.versions, staged)
+	})
+
+	t.Run("Should drop the staged value when the canary fails", func(t *testing.T) {
+		s := newFakeStore()
+		s.set(id, "old")
+		assert.Error(t, rotateSecret(s, "app", "db_password", "new", "false"))
+		assert.Equal(t, []string{"old"}, s.values(id))
+		assert.NotContains(t, s.versions, staged)
+	})
+}
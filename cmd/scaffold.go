@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+)
+
+var (
+	scaffoldFormat string
+
+	// scaffoldCmd represents the scaffold command
+	scaffoldCmd = &cobra.Command{
+		Use:   "scaffold <service>",
+		Short: "Print infrastructure code declaring the parameters of a service",
+		Args:  cobra.ExactArgs(1),
+		RunE:  scaffold,
+	}
+
+	invalidResourceNameChars = regexp.MustCompile(`[^a-z0-9_]`)
+)
+
+func init() {
+	scaffoldCmd.Flags().StringVarP(&scaffoldFormat, "format", "f", "terraform", "Output format (terraform)")
+	RootCmd.AddCommand(scaffoldCmd)
+}
+
+// scaffoldParameter is an SSM parameter declared by scaffold
+type scaffoldParameter struct {
+	name      string
+	key       string
+	plaintext bool
+}
+
+func scaffold(cmd *cobra.Command, args []string) error {
+	service, err := expandService(args[0])
+	if err != nil {
+		return errors.Wrap(err, "Failed to expand service")
+	}
+	service = strings.ToLower(service)
+	if err := validateService(service); err != nil {
+		return errors.Wrap(err, "Failed to validate service")
+	}
+	if strings.ToLower(scaffoldFormat) != "terraform" {
+		return errors.Errorf("Unsupported scaffold format: %s", scaffoldFormat)
+	}
+
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", "scaffold").
+				Set("chamber-version", chamberVersion).
+				Set("service", service).
+				Set("backend", backend).
+				Set("format", scaffoldFormat),
+		})
+	}
+
+	secretStore, err := getSecretStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get secret store")
+	}
+	if backend != SSMBackend {
+		return errors.New("Scaffolding is only supported for the SSM backend")
+	}
+
+	secrets, err := secretStore.List(service, false)
+	if err != nil {
+		return errors.Wrap(err, "Failed to list store contents")
+	}
+
+	_, noPaths := os.LookupEnv("CHAMBER_NO_PATHS")
+	sep := "/"
+	if noPaths {
+		sep = "."
+	}
+	prefix := service
+	if namespace := strings.Trim(os.Getenv(NamespaceEnvVar), sep); namespace != "" {
+		prefix = strings.ToLower(namespace) + sep + service
+	}
+	if !noPaths {
+		prefix = "/" + prefix
+	}
+
+	params := make([]scaffoldParameter, 0, len(secrets))
+	for _, secret := range secrets {
+		k := key(secret.Meta.Key)
+		params = append(params, scaffoldParameter{
+			name:      prefix + sep + k,
+			key:       k,
+			plaintext: store.IsPlaintextKey(plaintextKeys, store.SecretId{Service: strings.TrimPrefix(prefix, "/"), Key: k}),
+		})
+	}
+	sort.Slice(params, func(i, j int) bool { return params[i].name < params[j].name })
+
+	kmsKey := store.DefaultKeyID
+	if kmsKeyAliasValue := os.Getenv(KMSKeyEnvVar); kmsKeyAliasValue != "" {
+		kmsKey = kmsKeyAliasValue
+		if !strings.HasPrefix(kmsKey, "alias/") {
+			kmsKey = "alias/" + kmsKey
+		}
+	}
+	return scaffoldTerraform(os.Stdout, service, kmsKey, params)
+}
+
+// scaffoldTerraform declares params as aws_ssm_parameter resources. Values
+// and descriptions, where chamber keeps version numbers, are left to chamber.
+func scaffoldTerraform(w io.Writer, service, kmsKey string, params []scaffoldParameter) error {
+	for i, p := range params {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		name := invalidResourceNameChars.ReplaceAllString(strings.ToLower(service+"_"+p.key), "_")
+		typ := fmt.Sprintf(`type   = "SecureString"
+  key_id = %q`, kmsKey)
+		if p.plaintext {
+			typ = `type   = "String"`
+		}
+		_, err := fmt.Fprintf(w, `resource "aws_ssm_parameter" %q {
+  name   = %q
+  %s
+  value  = "managed-by-chamber"
+
+  lifecycle {
+    ignore_changes = [value, description]
+  }
+}
+`, name, p.name, typ)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScaffoldTerraform(t *testing.T) {
+	t.Run("Should declare each parameter with chamber-managed values", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := scaffoldTerraform(&buf, "app/prod", "alias/parameter_store_key", []scaffoldParameter{
+			{name: "/app/prod/db.password", key: "db.password"},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, `resource "aws_ssm_parameter" "app_prod_db_password" {
+  name   = "/app/prod/db.password"
+  type   = "SecureString"
+  key_id = "alias/parameter_store_key"
+  value  = "managed-by-chamber"
+
+  lifecycle {
+    ignore_changes = [value, description]
+  }
+}
+`, buf.String())
+	})
+
+	t.Run("Should declare plaintext keys as String parameters", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := scaffoldTerraform(&buf, "app/prod", "alias/parameter_store_key", []scaffoldParameter{
+			{name: "/app/prod/log_level", key: "log_level", plaintext: true},
+		})
+		assert.Nil(t, err)
+		assert.Contains(t, buf.String(), `type   = "String"`)
+		assert.NotContains(t, buf.String(), "key_id")
+	})
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	osexec "os/exec"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+)
+
+// ageCommand is the age CLI, https://age-encryption.org, that bundles are
+// encrypted with
+var ageCommand = "age"
+
+var (
+	shareTo      []string
+	shareExpires time.Duration
+	shareOutput  string
+
+	// shareCmd represents the share command
+	shareCmd = &cobra.Command{
+		Use:   "share <service> <key> --to <recipient...>",
+		Short: "Encrypt a secret for other operators with age, to import with receive",
+		Args:  cobra.ExactArgs(2),
+		RunE:  share,
+		Example: `
+	$ chamber share app/prod db_password --to age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p --expires 24h -o db_password.age
+	$ chamber receive db_password.age --identity ~/.age/key.txt
+`,
+	}
+)
+
+func init() {
+	shareCmd.Flags().StringSliceVarP(&shareTo, "to", "", nil, "age recipients (age1... or SSH public keys) who may decrypt the bundle")
+	shareCmd.Flags().DurationVarP(&shareExpires, "expires", "", 24*time.Hour, "How long the bundle may be received for")
+	shareCmd.Flags().StringVarP(&shareOutput, "output-file", "o", "", "Output file (default is standard output)")
+	shareCmd.MarkFlagRequired("to")
+	RootCmd.AddCommand(shareCmd)
+}
+
+// shareBundle is the plaintext of an encrypted bundle
+type shareBundle struct {
+	Service  string    `json:"service"`
+	Key      string    `json:"key"`
+	Value    string    `json:"value"`
+	Version  int       `json:"version"`
+	SharedBy string    `json:"shared_by,omitempty"`
+	Shared   time.Time `json:"shared"`
+	Expires  time.Time `json:"expires"`
+}
+
+// openBundle decodes a decrypted bundle, refusing expired ones
+func openBundle(data []byte, now time.Time) (*shareBundle, error) {
+	var b shareBundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("invalid bundle: %s", err)
+	}
+	if b.Service == "" || b.Key == "" {
+		return nil, fmt.Errorf("invalid bundle: missing service or key")
+	}
+	if !now.Before(b.Expires) {
+		return nil, fmt.Errorf("bundle of %s/%s expired at %s", b.Service, b.Key, b.Expires.Local().Format(ShortTimeFormat))
+	}
+	return &b, nil
+}
+
+// runAge runs the age CLI with args on input, keeping the plaintext out of
+// the process list and of temporary files
+func runAge(input []byte, args ...string) ([]byte, error) {
+	if _, err := osexec.LookPath(ageCommand); err != nil {
+		return nil, errors.New("sharing secrets requires the age CLI, see https://age-encryption.org")
+	}
+	var stderr bytes.Buffer
+	cmd := osexec.Command(ageCommand, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("age failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+func share(cmd *cobra.Command, args []string) error {
+	service, err := expandService(args[0])
+	if err != nil {
+		return errors.Wrap(err, "Failed to expand service")
+	}
+	service = strings.ToLower(service)
+	if err := validateService(service); err != nil {
+		return errors.Wrap(err, "Failed to validate service")
+	}
+	key := strings.ToLower(args[1])
+	if err := validateKey(key); err != nil {
+		return errors.Wrap(err, "Failed to validate key")
+	}
+	if shareExpires <= 0 {
+		return errors.New("--expires must be positive")
+	}
+
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", "share").
+				Set("chamber-version", chamberVersion).
+				Set("service", service).
+				Set("key", key).
+				Set("backend", backend).
+				Set("recipients", len(shareTo)),
+		})
+	}
+
+	secretStore, err := getSecretStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get secret store")
+	}
+	secretStore = newExpiryFilter(secretStore, time.Now())
+	secret, err := secretStore.Read(store.SecretId{Service: service, Key: key}, -1)
+	if err != nil {
+		return errors.Wrap(err, "Failed to read")
+	}
+
+	// Receivers are told who shared the secret; the local user will do when
+	// the AWS identity is unavailable
+	sharedBy, _, err := store.CallerIdentity(numRetries)
+	if err != nil {
+		sharedBy = os.Getenv("USER")
+	}
+
+	now := time.Now().UTC()
+	bundle, err := json.Marshal(shareBundle{
+		Service:  service,
+		Key:      key,
+		Value:    *secret.Value,
+		Version:  secret.Meta.Version,
+		SharedBy: sharedBy,
+		Shared:   now,
+		Expires:  now.Add(shareExpires),
+	})
+	if err != nil {
+		return err
+	}
+
+	ageArgs := []string{"--encrypt", "--armor"}
+	for _, recipient := range shareTo {
+		ageArgs = append(ageArgs, "--recipient", recipient)
+	}
+	encrypted, err := runAge(bundle, ageArgs...)
+	if err != nil {
+		return errors.Wrap(err, "Failed to encrypt bundle")
+	}
+
+	if shareOutput == "" {
+		_, err = os.Stdout.Write(encrypted)
+		return err
+	}
+	return ioutil.WriteFile(shareOutput, encrypted, 0600)
+}
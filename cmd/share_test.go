@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenBundle(t *testing.T) {
+	now := time.Now()
+	bundle := func(b shareBundle) []byte {
+		data, err := json.Marshal(b)
+		assert.Nil(t, err)
+		return data
+	}
+
+	t.Run("Should open bundles until they expire", func(t *testing.T) {
+		b, err := openBundle(bundle(shareBundle{Service: "app/prod", Key: "db_password", Value: "hunter2", Expires: now.Add(time.Hour)}), now)
+		assert.Nil(t, err)
+		assert.Equal(t, "hunter2", b.Value)
+	})
+
+	t.Run("Should refuse expired bundles", func(t *testing.T) {
+		_, err := openBundle(bundle(shareBundle{Service: "app/prod", Key: "db_password", Value: "hunter2", Expires: now}), now)
+		assert.Error(t, err)
+	})
+
+	t.Run("Should refuse bundles without a key", func(t *testing.T) {
+		_, err := openBundle(bundle(shareBundle{Service: "app/prod", Expires: now.Add(time.Hour)}), now)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,308 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+)
+
+var (
+	snapshotLocation string
+	snapshotKMSKey   string
+	snapshotDryRun   bool
+
+	// snapshotCmd groups the commands handling snapshots
+	snapshotCmd = &cobra.Command{
+		Use:   "snapshot",
+		Short: "Take, list and restore encrypted snapshots of services in S3",
+	}
+
+	snapshotCreateCmd = &cobra.Command{
+		Use:   "create <service...>",
+		Short: "Store the latest values of the keys of services as a snapshot",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  snapshotCreate,
+		Example: `
+	$ chamber snapshot create --location s3://backups/chamber app/prod app/staging
+	Created snapshot 20240610T120000Z of 12 secrets of 2 services
+`,
+	}
+
+	snapshotListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the stored snapshots, oldest first",
+		Args:  cobra.NoArgs,
+		RunE:  snapshotList,
+	}
+
+	snapshotRestoreCmd = &cobra.Command{
+		Use:   "restore <snapshot> [service...]",
+		Short: "Write back the values of a snapshot that differ from the current ones",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  snapshotRestore,
+		Example: `
+	$ chamber snapshot restore --location s3://backups/chamber 20240610T120000Z app/prod
+	Key          Status
+	db_password  updated
+	db_username  unchanged
+`,
+	}
+)
+
+func init() {
+	snapshotCmd.PersistentFlags().StringVarP(&snapshotLocation, "location", "", "", "S3 location, like s3://bucket/prefix, of the snapshots; AKA $"+SnapshotLocationEnvVar)
+	snapshotCreateCmd.Flags().StringVarP(&snapshotKMSKey, "kms-key", "", DefaultKMSKey, "KMS key to encrypt the snapshot with, as an alias/<name>, key ID or ARN")
+	snapshotRestoreCmd.Flags().BoolVarP(&snapshotDryRun, "dry-run", "", false, "Only print what would be restored")
+	snapshotCmd.AddCommand(snapshotCreateCmd)
+	snapshotCmd.AddCommand(snapshotListCmd)
+	snapshotCmd.AddCommand(snapshotRestoreCmd)
+	RootCmd.AddCommand(snapshotCmd)
+}
+
+func trackSnapshot(command string, services []string) {
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", command).
+				Set("chamber-version", chamberVersion).
+				Set("services", services).
+				Set("backend", backend),
+		})
+	}
+}
+
+func getSnapshotStore() (*store.S3SnapshotStore, error) {
+	location := snapshotLocation
+	if locationEnvVarValue := os.Getenv(SnapshotLocationEnvVar); !snapshotCmd.PersistentFlags().Changed("location") && locationEnvVarValue != "" {
+		location = locationEnvVarValue
+	}
+	if location == "" {
+		return nil, fmt.Errorf("Must set --location or %s", SnapshotLocationEnvVar)
+	}
+	if err := configureSessions(); err != nil {
+		return nil, err
+	}
+	return store.NewS3SnapshotStore(numRetries, location, snapshotKMSKey)
+}
+
+func snapshotServiceArgs(args []string) ([]string, error) {
+	services := make([]string, 0, len(args))
+	for _, arg := range args {
+		service, err := expandService(arg)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to expand service")
+		}
+		service = strings.ToLower(service)
+		if err := validateService(service); err != nil {
+			return nil, errors.Wrap(err, "Failed to validate service")
+		}
+		services = append(services, service)
+	}
+	return services, nil
+}
+
+func snapshotCreate(cmd *cobra.Command, args []string) error {
+	services, err := snapshotServiceArgs(args)
+	if err != nil {
+		return err
+	}
+	trackSnapshot("snapshot create", services)
+
+	snapshots, err := getSnapshotStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get snapshot store")
+	}
+	secretStore, err := getSecretStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get secret store")
+	}
+
+	snapshot, err := takeSnapshot(secretStore, services, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := snapshots.Put(snapshot); err != nil {
+		return errors.Wrap(err, "Failed to store snapshot")
+	}
+
+	secrets := 0
+	for _, values := range snapshot.Services {
+		secrets += len(values)
+	}
+	infof("Created snapshot %s of %d secrets of %d services\n", snapshot.Id, secrets, len(services))
+	return nil
+}
+
+// takeSnapshot copies the latest values of the keys of services
+func takeSnapshot(secretStore store.Store, services []string, now time.Time) (*store.Snapshot, error) {
+	snapshot := store.NewSnapshot(now)
+	for _, service := range services {
+		rawSecrets, err := secretStore.ListRaw(service)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to list %s", service)
+		}
+		values := make(map[string]string, len(rawSecrets))
+		for _, rawSecret := range rawSecrets {
+			values[key(rawSecret.Key)] = rawSecret.Value
+		}
+		snapshot.Services[service] = values
+	}
+	return snapshot, nil
+}
+
+func snapshotList(cmd *cobra.Command, args []string) error {
+	trackSnapshot("snapshot list", nil)
+
+	snapshots, err := getSnapshotStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get snapshot store")
+	}
+	infos, err := snapshots.List()
+	if err != nil {
+		return errors.Wrap(err, "Failed to list snapshots")
+	}
+
+	w := newTableWriter(os.Stdout)
+	w.Header("Snapshot", "Created", "Size")
+	for _, info := range infos {
+		fmt.Fprintf(w, "%s\t%s\t%d\n", info.Id, info.Created.Local().Format(ShortTimeFormat), info.Size)
+	}
+	return w.Flush()
+}
+
+func snapshotRestore(cmd *cobra.Command, args []string) error {
+	id := args[0]
+	services, err := snapshotServiceArgs(args[1:])
+	if err != nil {
+		return err
+	}
+	trackSnapshot("snapshot restore", services)
+
+	snapshots, err := getSnapshotStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get snapshot store")
+	}
+	snapshot, err := snapshots.Get(id)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to read snapshot %s", id)
+	}
+	if len(services) == 0 {
+		for service := range snapshot.Services {
+			services = append(services, service)
+		}
+		sort.Strings(services)
+	}
+	for _, service := range services {
+		if _, ok := snapshot.Services[service]; !ok {
+			return fmt.Errorf("snapshot %s has no service %s", id, service)
+		}
+	}
+
+	secretStore, err := getSecretStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get secret store")
+	}
+	if err := requireCapabilities(secretStore, store.Capabilities{Write: !snapshotDryRun}); err != nil {
+		return err
+	}
+
+	event := HookEvent{Command: "snapshot restore", Services: services}
+	if !snapshotDryRun {
+		if err := runHooks(withEvent(event, PreWriteHook)); err != nil {
+			return err
+		}
+	}
+
+	w := newTableWriter(os.Stdout)
+	if len(services) > 1 {
+		w.Header("Service", "Key", "Status")
+	} else {
+		w.Header("Key", "Status")
+	}
+	var restored, unchanged int
+	for _, service := range services {
+		results, err := restoreService(secretStore, service, snapshot.Services[service], snapshotDryRun)
+		if err != nil {
+			w.Flush()
+			return errors.Wrapf(err, "Failed to restore %s", service)
+		}
+		for _, result := range results {
+			switch result.Status {
+			case syncCreated:
+				w.Role(addedRole)
+			case syncUpdated:
+				w.Role(changedRole)
+			}
+			if len(services) > 1 {
+				fmt.Fprintf(w, "%s\t", service)
+			}
+			fmt.Fprintf(w, "%s\t%s\n", result.Key, result.Status)
+			if result.Status == syncUnchanged {
+				unchanged++
+			} else {
+				restored++
+			}
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if !snapshotDryRun {
+		runPostHooks(withEvent(event, PostWriteHook))
+	}
+
+	verb := "Restored"
+	if snapshotDryRun {
+		verb = "Would restore"
+	}
+	infof("%s %d keys from snapshot %s, %d already up to date\n", verb, restored, id, unchanged)
+	return nil
+}
+
+// restoreService writes the values of a snapshot of service that differ from
+// the current ones, all or none of them, and returns what it did by key, with
+// the statuses of sync. Keys created since the snapshot are left alone.
+func restoreService(secretStore store.Store, service string, values map[string]string, dryRun bool) ([]syncResult, error) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var results []syncResult
+	var changed []string
+	for _, key := range keys {
+		result := syncResult{Service: service, Key: key, Status: syncCreated}
+		current, err := secretStore.Read(store.SecretId{Service: service, Key: key}, -1)
+		switch {
+		case err == store.ErrSecretNotFound:
+		case err != nil:
+			return nil, errors.Wrapf(err, "Failed to read %s", key)
+		case *current.Value == values[key]:
+			result.Status = syncUnchanged
+		default:
+			result.Status = syncUpdated
+		}
+		results = append(results, result)
+		if result.Status != syncUnchanged {
+			changed = append(changed, key)
+		}
+	}
+	if dryRun || len(changed) == 0 {
+		return results, nil
+	}
+	if err := writeAll(secretStore, service, changed, values); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
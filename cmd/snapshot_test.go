@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTakeSnapshot(t *testing.T) {
+	s := store.NewStaticStore(map[string]map[string]string{
+		"app/prod": {"db_password": "hunter22", "log_level": "info"},
+	})
+
+	t.Run("Should copy the latest values by key", func(t *testing.T) {
+		snapshot, err := takeSnapshot(s, []string{"app/prod"}, time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC))
+		assert.Nil(t, err)
+		assert.Equal(t, "20240610T120000Z", snapshot.Id)
+		assert.Equal(t, map[string]map[string]string{
+			"app/prod": {"db_password": "hunter22", "log_level": "info"},
+		}, snapshot.Services)
+	})
+}
+
+func TestRestoreService(t *testing.T) {
+	values := map[string]string{"db_password": "hunter22", "db_username": "app", "log_level": "info"}
+
+	t.Run("Should only write the keys that changed", func(t *testing.T) {
+		s := newAppStore(map[string]string{"db_password": "hunter23", "log_level": "info", "new_key": "x"})
+		results, err := restoreService(s, "app", values, false)
+		assert.Nil(t, err)
+		assert.Equal(t, []syncResult{
+			{Service: "app", Key: "db_password", Status: syncUpdated},
+			{Service: "app", Key: "db_username", Status: syncCreated},
+			{Service: "app", Key: "log_level", Status: syncUnchanged},
+		}, results)
+		assert.Equal(t, map[string]string{"db_password": "hunter22", "db_username": "app", "log_level": "info", "new_key": "x"}, s.latest("app"))
+	})
+
+	t.Run("Should write nothing on a dry run", func(t *testing.T) {
+		s := newAppStore(map[string]string{"db_password": "hunter23"})
+		_, err := restoreService(s, "app", values, true)
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"db_password": "hunter23"}, s.latest("app"))
+	})
+
+	t.Run("Should restore nothing when a write fails", func(t *testing.T) {
+		s := newAppStore(map[string]string{"db_password": "hunter23"})
+		s.failKey = "log_level"
+		_, err := restoreService(s, "app", values, false)
+		assert.Error(t, err)
+		assert.Equal(t, map[string]string{"db_password": "hunter23"}, s.latest("app"))
+	})
+}
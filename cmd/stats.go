@@ -0,0 +1,288 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+)
+
+// statsLatencySamples is how many of its latest run times are kept for each
+// command, to compute percentiles from
+const statsLatencySamples = 1000
+
+var (
+	statsReset  bool
+	statsSubmit bool
+
+	// statsCmd represents the stats command
+	statsCmd = &cobra.Command{
+		Use:   "stats",
+		Short: "Print the usage summary recorded with --stats",
+		Args:  cobra.NoArgs,
+		RunE:  statsRun,
+	}
+)
+
+func init() {
+	statsCmd.Flags().BoolVarP(&statsReset, "reset", "", false, "Delete the recorded summary")
+	statsCmd.Flags().BoolVarP(&statsSubmit, "submit", "", false, "Send the summary to the maintainers, in builds of chamber with analytics")
+	RootCmd.AddCommand(statsCmd)
+}
+
+// usageStats summarizes the commands run, without their arguments, services
+// or keys
+type usageStats struct {
+	Since    time.Time                `json:"since"`
+	Commands map[string]*commandStats `json:"commands"`
+	Backends map[string]int           `json:"backends"`
+}
+
+type commandStats struct {
+	Runs     int `json:"runs"`
+	Failures int `json:"failures"`
+
+	// LatenciesMs are the latest run times, in milliseconds
+	LatenciesMs []int64 `json:"latencies_ms"`
+}
+
+func newUsageStats(now time.Time) *usageStats {
+	return &usageStats{Since: now.UTC(), Commands: map[string]*commandStats{}, Backends: map[string]int{}}
+}
+
+// add counts a run of command with backend, which is empty for commands that
+// didn't use one
+func (s *usageStats) add(command, backend string, latency time.Duration, failed bool) {
+	c, ok := s.Commands[command]
+	if !ok {
+		c = &commandStats{}
+		s.Commands[command] = c
+	}
+	c.Runs++
+	if failed {
+		c.Failures++
+	}
+	c.LatenciesMs = append(c.LatenciesMs, latency.Milliseconds())
+	if len(c.LatenciesMs) > statsLatencySamples {
+		c.LatenciesMs = c.LatenciesMs[len(c.LatenciesMs)-statsLatencySamples:]
+	}
+	if backend != "" {
+		s.Backends[backend]++
+	}
+}
+
+// percentile returns the run time that p (0 to 1) of the kept runs took at
+// most, by the nearest rank
+func (c *commandStats) percentile(p float64) time.Duration {
+	if len(c.LatenciesMs) == 0 {
+		return 0
+	}
+	sorted := append([]int64(nil), c.LatenciesMs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := int(p*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return time.Duration(sorted[rank]) * time.Millisecond
+}
+
+// statsEnabled reports whether --stats or $CHAMBER_STATS opted in to
+// recording the summary
+func statsEnabled() bool {
+	enabled := statsFlag
+	if v := os.Getenv(StatsEnvVar); !RootCmd.PersistentFlags().Changed("stats") && v != "" {
+		enabled = v == "true" || v == "1"
+	}
+	return enabled
+}
+
+// statsPath is where the summary is kept, in the user's config directory
+func statsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "chamber", "stats.json"), nil
+}
+
+// loadStats reads the summary at path, or starts a new one if there is none
+func loadStats(path string, now time.Time) (*usageStats, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return newUsageStats(now), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := newUsageStats(now)
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("invalid stats in %s: %s", path, err)
+	}
+	return s, nil
+}
+
+// saveStats replaces the summary at path, so concurrent runs of chamber never
+// read a partial file
+func saveStats(path string, s *usageStats) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".stats-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+var statsRecorded bool
+
+// recordStats adds the command run since commandStart to the summary when
+// opted in, at most once per process. It warns instead of failing, so that
+// recording never gets in the way of the command.
+func recordStats(cmd *cobra.Command, err error) {
+	if statsRecorded || cmd == nil || cmd == RootCmd || cmd == statsCmd || !statsEnabled() {
+		return
+	}
+	statsRecorded = true
+
+	path, pathErr := statsPath()
+	if pathErr != nil {
+		fmt.Fprintf(os.Stderr, "warning: unable to record stats: %s\n", pathErr)
+		return
+	}
+	now := time.Now()
+	s, loadErr := loadStats(path, now)
+	if loadErr != nil {
+		fmt.Fprintf(os.Stderr, "warning: unable to record stats: %s\n", loadErr)
+		return
+	}
+	s.add(strings.TrimPrefix(cmd.CommandPath(), RootCmd.Name()+" "), backend, now.Sub(commandStart), err != nil)
+	if saveErr := saveStats(path, s); saveErr != nil {
+		fmt.Fprintf(os.Stderr, "warning: unable to record stats: %s\n", saveErr)
+	}
+}
+
+func statsRun(cmd *cobra.Command, args []string) error {
+	if statsReset && statsSubmit {
+		return errors.New("--reset and --submit can't be combined")
+	}
+	path, err := statsPath()
+	if err != nil {
+		return errors.Wrap(err, "Failed to find stats")
+	}
+
+	if statsReset {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "Failed to reset stats")
+		}
+		return nil
+	}
+
+	s, err := loadStats(path, time.Now())
+	if err != nil {
+		return errors.Wrap(err, "Failed to read stats")
+	}
+	if len(s.Commands) == 0 {
+		infof("No stats recorded; opt in with --stats or %s=1\n", StatsEnvVar)
+		return nil
+	}
+
+	if statsSubmit {
+		if !analyticsEnabled || analyticsClient == nil {
+			return errors.New("this build of chamber has no analytics key to submit stats with")
+		}
+		analyticsClient.Enqueue(analytics.Track{
+			UserId:     username,
+			Event:      "Submitted Stats",
+			Properties: statsProperties(s),
+		})
+		infof("Submitted stats\n")
+		return nil
+	}
+
+	printStats(os.Stdout, s)
+	return nil
+}
+
+// statsProperties summarizes s for submission, with percentiles instead of
+// every run time
+func statsProperties(s *usageStats) analytics.Properties {
+	commands := map[string]interface{}{}
+	for name, c := range s.Commands {
+		commands[name] = map[string]interface{}{
+			"runs":     c.Runs,
+			"failures": c.Failures,
+			"p50-ms":   c.percentile(0.5).Milliseconds(),
+			"p90-ms":   c.percentile(0.9).Milliseconds(),
+			"p99-ms":   c.percentile(0.99).Milliseconds(),
+		}
+	}
+	return analytics.NewProperties().
+		Set("chamber-version", chamberVersion).
+		Set("since", s.Since).
+		Set("commands", commands).
+		Set("backends", s.Backends)
+}
+
+func printStats(out io.Writer, s *usageStats) {
+	fmt.Fprintf(out, "Since %s\n\n", s.Since.Local().Format(ShortTimeFormat))
+
+	names := make([]string, 0, len(s.Commands))
+	for name := range s.Commands {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if s.Commands[names[i]].Runs != s.Commands[names[j]].Runs {
+			return s.Commands[names[i]].Runs > s.Commands[names[j]].Runs
+		}
+		return names[i] < names[j]
+	})
+	w := tabwriter.NewWriter(out, 0, 8, 2, '\t', 0)
+	fmt.Fprintln(w, "Command\tRuns\tFailures\tp50\tp90\tp99")
+	for _, name := range names {
+		c := s.Commands[name]
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\n", name, c.Runs, c.Failures, c.percentile(0.5), c.percentile(0.9), c.percentile(0.99))
+	}
+	w.Flush()
+
+	if len(s.Backends) == 0 {
+		return
+	}
+	backends := make([]string, 0, len(s.Backends))
+	for name := range s.Backends {
+		backends = append(backends, name)
+	}
+	sort.Strings(backends)
+	fmt.Fprintln(out)
+	w = tabwriter.NewWriter(out, 0, 8, 2, '\t', 0)
+	fmt.Fprintln(w, "Backend\tRuns")
+	for _, name := range backends {
+		fmt.Fprintf(w, "%s\t%d\n", name, s.Backends[name])
+	}
+	w.Flush()
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsageStats(t *testing.T) {
+	t.Run("Should count runs, failures and backends", func(t *testing.T) {
+		s := newUsageStats(time.Now())
+		s.add("read", "SSM", 100*time.Millisecond, false)
+		s.add("read", "SSM", 200*time.Millisecond, true)
+		s.add("version", "", time.Millisecond, false)
+		assert.Equal(t, 2, s.Commands["read"].Runs)
+		assert.Equal(t, 1, s.Commands["read"].Failures)
+		assert.Equal(t, map[string]int{"SSM": 2}, s.Backends)
+	})
+
+	t.Run("Should keep only the latest run times", func(t *testing.T) {
+		s := newUsageStats(time.Now())
+		for i := 0; i < statsLatencySamples+10; i++ {
+			s.add("read", "SSM", time.Duration(i)*time.Millisecond, false)
+		}
+		assert.Len(t, s.Commands["read"].LatenciesMs, statsLatencySamples)
+		assert.Equal(t, int64(10), s.Commands["read"].LatenciesMs[0])
+		assert.Equal(t, statsLatencySamples+10, s.Commands["read"].Runs)
+	})
+
+	t.Run("Should compute percentiles by nearest rank", func(t *testing.T) {
+		c := &commandStats{}
+		for i := int64(100); i >= 1; i-- {
+			c.LatenciesMs = append(c.LatenciesMs, i)
+		}
+		assert.Equal(t, 50*time.Millisecond, c.percentile(0.5))
+		assert.Equal(t, 90*time.Millisecond, c.percentile(0.9))
+		assert.Equal(t, 99*time.Millisecond, c.percentile(0.99))
+		assert.Equal(t, time.Duration(0), (&commandStats{}).percentile(0.5))
+	})
+}
+
+func TestStatsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chamber-stats")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "chamber", "stats.json")
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Should start a new summary without a file", func(t *testing.T) {
+		s, err := loadStats(path, now)
+		assert.Nil(t, err)
+		assert.Equal(t, now, s.Since)
+		assert.Empty(t, s.Commands)
+	})
+
+	t.Run("Should read back what was saved", func(t *testing.T) {
+		s := newUsageStats(now)
+		s.add("exec", "SSM", 1500*time.Millisecond, false)
+		assert.Nil(t, saveStats(path, s))
+
+		loaded, err := loadStats(path, now.Add(time.Hour))
+		assert.Nil(t, err)
+		assert.Equal(t, s, loaded)
+
+		var out bytes.Buffer
+		printStats(&out, loaded)
+		assert.Contains(t, out.String(), "exec\t\t1\t0\t\t1.5s\t1.5s\t1.5s\n")
+		assert.Contains(t, out.String(), "SSM\t\t1\n")
+	})
+}
@@ -0,0 +1,429 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+)
+
+// The outcomes of syncing a key
+const (
+	syncCreated   = "created"
+	syncUpdated   = "updated"
+	syncUnchanged = "unchanged"
+	syncDeleted   = "deleted"
+)
+
+var (
+	syncFrom       string
+	syncTo         string
+	syncFromRole   string
+	syncToRole     string
+	syncFromRegion string
+	syncToRegion   string
+	syncLatestOnly bool
+	syncDryRun     bool
+	syncDelete     bool
+	syncJSON       bool
+
+	// syncCmd represents the sync command
+	syncCmd = &cobra.Command{
+		Use:   "sync --from <backend> --to <backend> <service...>",
+		Short: "Copy the secrets of services from one backend to another",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  syncRun,
+		Example: `
+	$ chamber sync --from ssm --to s3-kms app/prod
+	Key          Status     Versions
+	db_password  created    3
+	db_username  unchanged  0
+
+	$ chamber sync --from ssm --to ssm --to-role arn:aws:iam::123456789012:role/dr-writer --to-region us-west-2 --delete --json app/prod
+`,
+	}
+)
+
+func init() {
+	syncCmd.Flags().StringVarP(&syncFrom, "from", "", "", "Backend to copy from, like --backend")
+	syncCmd.Flags().StringVarP(&syncTo, "to", "", "", "Backend to copy to, like --backend")
+	syncCmd.Flags().BoolVarP(&syncLatestOnly, "latest-only", "", false, "Only copy the latest version of each key, rather than replaying its history")
+	syncCmd.Flags().StringVarP(&syncFromRole, "from-role", "", "", "IAM role to assume to read the source, e.g. in another account (default is --role-arn)")
+	syncCmd.Flags().StringVarP(&syncToRole, "to-role", "", "", "IAM role to assume to write the destination, e.g. in another account (default is --role-arn)")
+	syncCmd.Flags().StringVarP(&syncFromRegion, "from-region", "", "", "Region of the source (default is the usual region)")
+	syncCmd.Flags().StringVarP(&syncToRegion, "to-region", "", "", "Region of the destination (default is the usual region)")
+	syncCmd.Flags().BoolVarP(&syncDryRun, "dry-run", "", false, "Only print what would be copied")
+	syncCmd.Flags().BoolVarP(&syncDelete, "delete", "", false, "Delete the keys of the destination that the source doesn't have")
+	syncCmd.Flags().BoolVarP(&syncJSON, "json", "", false, "Print a JSON report of what was synced instead of a table")
+	syncCmd.MarkFlagRequired("from")
+	syncCmd.MarkFlagRequired("to")
+	RootCmd.AddCommand(syncCmd)
+}
+
+// syncResult is the outcome of syncing a key
+type syncResult struct {
+	Service  string `json:"service"`
+	Key      string `json:"key"`
+	Status   string `json:"status"`
+	Versions int    `json:"versions"`
+}
+
+// syncSide is where sync copies from or to
+type syncSide struct {
+	Backend string `json:"backend"`
+	Role    string `json:"role,omitempty"`
+	Region  string `json:"region,omitempty"`
+}
+
+// syncReport is what sync --json prints, for jobs replicating secrets to keep
+// or alert on
+type syncReport struct {
+	From    syncSide     `json:"from"`
+	To      syncSide     `json:"to"`
+	DryRun  bool         `json:"dry_run"`
+	Time    time.Time    `json:"time"`
+	Results []syncResult `json:"results"`
+	Error   string       `json:"error,omitempty"`
+}
+
+func syncRun(cmd *cobra.Command, args []string) error {
+	from := syncSide{Backend: strings.ToUpper(syncFrom), Role: syncFromRole, Region: syncFromRegion}
+	to := syncSide{Backend: strings.ToUpper(syncTo), Role: syncToRole, Region: syncToRegion}
+	if from == to {
+		return errors.New("--from and --to must be different backends, or be given different roles or regions")
+	}
+
+	services := make([]string, 0, len(args))
+	for _, arg := range args {
+		service, err := expandService(arg)
+		if err != nil {
+			return errors.Wrap(err, "Failed to expand service")
+		}
+		service = strings.ToLower(service)
+		if err := validateService(service); err != nil {
+			return errors.Wrap(err, "Failed to validate service")
+		}
+		services = append(services, service)
+	}
+
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", "sync").
+				Set("chamber-version", chamberVersion).
+				Set("services", services).
+				Set("from", strings.ToUpper(syncFrom)).
+				Set("to", strings.ToUpper(syncTo)).
+				Set("cross-account", syncFromRole != syncToRole).
+				Set("cross-region", syncFromRegion != syncToRegion).
+				Set("latest-only", syncLatestOnly).
+				Set("dry-run", syncDryRun).
+				Set("delete", syncDelete).
+				Set("json", syncJSON),
+		})
+	}
+
+	// Each store is built like that of --backend, and checked while the
+	// backend is still the one it was built for
+	defaultRole := roleARN
+	source, err := syncStore(syncFrom, syncFromRole, defaultRole, syncFromRegion)
+	if err != nil {
+		return errors.Wrap(err, "Failed to get source secret store")
+	}
+	withHistory := !syncLatestOnly
+	if lacking := store.CapabilitiesOf(source).Lacks(store.Capabilities{History: true, Versions: true}); withHistory && len(lacking) > 0 {
+		fmt.Fprintf(os.Stderr, "warning: the %s backend doesn't support %s, only copying the latest versions\n", backend, strings.Join(lacking, " or "))
+		withHistory = false
+	}
+
+	destination, err := syncStore(syncTo, syncToRole, defaultRole, syncToRegion)
+	if err != nil {
+		return errors.Wrap(err, "Failed to get destination secret store")
+	}
+	if err := requireCapabilities(destination, store.Capabilities{Write: !syncDryRun}); err != nil {
+		return err
+	}
+
+	event := HookEvent{Command: "sync", Services: services}
+	if !syncDryRun {
+		if err := runHooks(withEvent(event, PreWriteHook)); err != nil {
+			return err
+		}
+	}
+
+	report := syncReport{From: from, To: to, DryRun: syncDryRun, Time: time.Now().UTC()}
+	sourceName := syncFrom
+	if syncFromRegion != "" {
+		sourceName += ":" + syncFromRegion
+	}
+	var syncErr error
+	for _, service := range services {
+		results, err := syncService(source, destination, sourceName+":"+service, service, withHistory, syncDryRun)
+		if err == nil && syncDelete {
+			var deleted []syncResult
+			deleted, err = syncDeletions(destination, service, results, syncDryRun)
+			results = append(results, deleted...)
+		}
+		report.Results = append(report.Results, results...)
+		if err != nil {
+			syncErr = errors.Wrapf(err, "Failed to sync %s", service)
+			break
+		}
+	}
+
+	if syncJSON {
+		if syncErr != nil {
+			report.Error = syncErr.Error()
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else if err := printSyncResults(report.Results, len(services) > 1); err != nil {
+		return err
+	}
+	if syncErr != nil {
+		return syncErr
+	}
+
+	if !syncDryRun {
+		runPostHooks(withEvent(event, PostWriteHook))
+	}
+
+	var copied, unchanged, deleted int
+	for _, result := range report.Results {
+		switch result.Status {
+		case syncUnchanged:
+			unchanged++
+		case syncDeleted:
+			deleted++
+		default:
+			copied++
+		}
+	}
+	verb := "Copied"
+	if syncDryRun {
+		verb = "Would copy"
+	}
+	infof("%s %d keys from %s to %s, %d already up to date", verb, copied, describeSyncSide(from), describeSyncSide(to), unchanged)
+	switch {
+	case syncDelete && syncDryRun:
+		infof(", %d to delete", deleted)
+	case syncDelete:
+		infof(", %d deleted", deleted)
+	}
+	infof("\n")
+	return nil
+}
+
+// syncStore returns the store of one side of a sync, built like that of
+// --backend while assuming role, or defaultRole, in region if set
+func syncStore(backendName, role, defaultRole, region string) (store.Store, error) {
+	if err := RootCmd.PersistentFlags().Set("backend", backendName); err != nil {
+		return nil, errors.Wrap(err, "Failed to set backend")
+	}
+	roleARN = defaultRole
+	if role != "" {
+		roleARN = role
+	}
+	if region != "" {
+		previous, ok := os.LookupEnv(store.RegionEnvVar)
+		os.Setenv(store.RegionEnvVar, region)
+		if ok {
+			defer os.Setenv(store.RegionEnvVar, previous)
+		} else {
+			defer os.Unsetenv(store.RegionEnvVar)
+		}
+	}
+	return getSecretStore()
+}
+
+// describeSyncSide names a side of a sync for humans, like SSM or
+// SSM in us-west-2 as arn:aws:iam::123456789012:role/dr-writer
+func describeSyncSide(side syncSide) string {
+	description := side.Backend
+	if side.Region != "" {
+		description += " in " + side.Region
+	}
+	if side.Role != "" {
+		description += " as " + side.Role
+	}
+	return description
+}
+
+// printSyncResults prints the results of a sync as a table, with the service
+// of each key if more than one was synced
+func printSyncResults(results []syncResult, withService bool) error {
+	w := newTableWriter(os.Stdout)
+	if withService {
+		w.Header("Service", "Key", "Status", "Versions")
+	} else {
+		w.Header("Key", "Status", "Versions")
+	}
+	for _, result := range results {
+		switch result.Status {
+		case syncCreated:
+			w.Role(addedRole)
+		case syncUpdated:
+			w.Role(changedRole)
+		}
+		if withService {
+			fmt.Fprintf(w, "%s\t", result.Service)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\n", result.Key, result.Status, result.Versions)
+	}
+	return w.Flush()
+}
+
+// syncService copies the keys of service from source to destination. Keys
+// the destination lacks get the history of the source replayed, oldest first,
+// when withHistory is set; keys it has with another value get the latest value
+// written over them, as replaying the history would interleave it with theirs.
+// The metadata of copied keys is copied too, with the times of the replayed
+// versions, which the destination records as the times they were written. The
+// results of the keys synced before an error are returned with it.
+func syncService(source, destination store.Store, sourceName, service string, withHistory, dryRun bool) ([]syncResult, error) {
+	secrets, err := source.List(service, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to list source secrets")
+	}
+	sort.Slice(secrets, func(i, j int) bool { return secrets[i].Meta.Key < secrets[j].Meta.Key })
+
+	var results []syncResult
+	for _, secret := range secrets {
+		key := key(secret.Meta.Key)
+		id := store.SecretId{Service: service, Key: key}
+		latest := *secret.Value
+
+		result := syncResult{Service: service, Key: key, Status: syncCreated}
+		current, err := destination.Read(id, -1)
+		switch {
+		case err == store.ErrSecretNotFound:
+		case err != nil:
+			return results, errors.Wrapf(err, "Failed to read %s", key)
+		case *current.Value == latest:
+			results = append(results, syncResult{Service: service, Key: key, Status: syncUnchanged})
+			continue
+		default:
+			result.Status = syncUpdated
+		}
+
+		steps := []migrationStep{{key: key, value: latest, version: secret.Meta.Version, created: secret.Meta.Created}}
+		if withHistory && result.Status == syncCreated {
+			if steps, err = syncHistory(source, id, steps[0]); err != nil {
+				return results, err
+			}
+		}
+		result.Versions = len(steps)
+		if dryRun {
+			results = append(results, result)
+			continue
+		}
+
+		var imported []store.ImportedVersion
+		for _, step := range steps {
+			if err := destination.Write(id, step.value); err != nil {
+				return results, errors.Wrapf(err, "Failed to write version %d of %s", step.version, key)
+			}
+			imported = append(imported, store.ImportedVersion{Version: step.version, Created: step.created})
+		}
+		results = append(results, result)
+
+		m, err := store.ReadKeyMetadata(source, service, key)
+		if err != nil {
+			return results, errors.Wrapf(err, "Failed to read metadata of %s", key)
+		}
+		if m == nil {
+			m = &store.KeyMetadata{}
+		}
+		if m.Imported == nil {
+			m.Imported = &store.ImportedHistory{Source: sourceName, Versions: imported}
+		}
+		if err := store.WriteKeyMetadata(destination, service, key, *m); err != nil {
+			return results, errors.Wrapf(err, "Failed to write metadata of %s", key)
+		}
+	}
+	return results, nil
+}
+
+// syncDeletions deletes the keys of service in destination that weren't among
+// the results of syncing it from the source, along with their metadata. It
+// refuses to delete every key, as a source listing no keys more likely names
+// the wrong service or account than one that was emptied.
+func syncDeletions(destination store.Store, service string, synced []syncResult, dryRun bool) ([]syncResult, error) {
+	secrets, err := destination.List(service, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to list destination secrets")
+	}
+	if len(synced) == 0 && len(secrets) > 0 {
+		return nil, errors.New("the source has no keys, so --delete would delete them all from the destination")
+	}
+	kept := map[string]bool{}
+	for _, result := range synced {
+		kept[result.Key] = true
+	}
+	sort.Slice(secrets, func(i, j int) bool { return secrets[i].Meta.Key < secrets[j].Meta.Key })
+
+	var results []syncResult
+	for _, secret := range secrets {
+		key := key(secret.Meta.Key)
+		if kept[key] {
+			continue
+		}
+		if !dryRun {
+			if err := destination.Delete(store.SecretId{Service: service, Key: key}); err != nil {
+				return results, errors.Wrapf(err, "Failed to delete %s", key)
+			}
+			if err := store.DeleteKeyMetadata(destination, service, key); err != nil && err != store.ErrSecretNotFound {
+				return results, errors.Wrapf(err, "Failed to delete metadata of %s", key)
+			}
+		}
+		results = append(results, syncResult{Service: service, Key: key, Status: syncDeleted})
+	}
+	return results, nil
+}
+
+// syncHistory returns the writes replaying the history of id in source,
+// oldest first, ending with the latest version. Versions the source no longer keeps, and
+// those leaving the value unchanged, are skipped.
+func syncHistory(source store.Store, id store.SecretId, latest migrationStep) ([]migrationStep, error) {
+	events, err := source.History(id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to read history of %s", id.Key)
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Version < events[j].Version })
+
+	var steps []migrationStep
+	seen := map[int]bool{}
+	for _, event := range events {
+		if event.Version <= 0 || seen[event.Version] {
+			continue
+		}
+		seen[event.Version] = true
+		secret, err := source.Read(id, event.Version)
+		if err == store.ErrSecretNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to read version %d of %s", event.Version, id.Key)
+		}
+		if len(steps) > 0 && steps[len(steps)-1].value == *secret.Value {
+			continue
+		}
+		steps = append(steps, migrationStep{key: id.Key, value: *secret.Value, version: event.Version, created: event.Time})
+	}
+	if len(steps) == 0 || steps[len(steps)-1].value != latest.value {
+		steps = append(steps, latest)
+	}
+	return steps, nil
+}
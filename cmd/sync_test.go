@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncService(t *testing.T) {
+	app := func(key string) store.SecretId { return store.SecretId{Service: "app", Key: key} }
+	newSource := func() *fakeStore {
+		source := newFakeStore()
+		source.set(app("a"), "1", "1", "2")
+		source.set(app("b"), "x")
+		source.set(app("c"), "old", "new")
+		return source
+	}
+
+	t.Run("Should replay the history of new keys and overwrite changed keys", func(t *testing.T) {
+		destination := newFakeStore()
+		destination.set(app("b"), "x")
+		destination.set(app("c"), "other")
+
+		results, err := syncService(newSource(), destination, "ssm:app", "app", true, false)
+		assert.Nil(t, err)
+		assert.Equal(t, []syncResult{
+			{Service: "app", Key: "a", Status: syncCreated, Versions: 2},
+			{Service: "app", Key: "b", Status: syncUnchanged},
+			{Service: "app", Key: "c", Status: syncUpdated, Versions: 1},
+		}, results)
+		assert.Equal(t, []string{"1", "2"}, destination.values(app("a")))
+		assert.Equal(t, []string{"x"}, destination.values(app("b")))
+		assert.Equal(t, []string{"other", "new"}, destination.values(app("c")))
+
+		m, err := store.ReadKeyMetadata(destination, "app", "a")
+		assert.Nil(t, err)
+		assert.Equal(t, "ssm:app", m.Imported.Source)
+		assert.Equal(t, []store.ImportedVersion{{Version: 1, Created: time.Unix(0, 0).UTC()}, {Version: 3, Created: time.Unix(2, 0).UTC()}}, m.Imported.Versions)
+	})
+
+	t.Run("Should only copy the latest versions without history", func(t *testing.T) {
+		destination := newFakeStore()
+		_, err := syncService(newSource(), destination, "ssm:app", "app", false, false)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"2"}, destination.values(app("a")))
+		assert.Equal(t, []string{"new"}, destination.values(app("c")))
+	})
+
+	t.Run("Should not write on dry runs", func(t *testing.T) {
+		destination := newFakeStore()
+		results, err := syncService(newSource(), destination, "ssm:app", "app", true, true)
+		assert.Nil(t, err)
+		assert.Len(t, results, 3)
+		assert.Empty(t, destination.versions)
+	})
+}
+
+func TestSyncDeletions(t *testing.T) {
+	app := func(key string) store.SecretId { return store.SecretId{Service: "app", Key: key} }
+	synced := []syncResult{{Service: "app", Key: "a", Status: syncUnchanged}}
+
+	t.Run("Should delete the keys the source doesn't have", func(t *testing.T) {
+		destination := newFakeStore()
+		destination.set(app("a"), "1")
+		destination.set(app("b"), "x")
+		destination.set(store.SecretId{Service: "other", Key: "c"}, "y")
+
+		results, err := syncDeletions(destination, "app", synced, false)
+		assert.Nil(t, err)
+		assert.Equal(t, []syncResult{{Service: "app", Key: "b", Status: syncDeleted}}, results)
+		assert.Contains(t, destination.versions, app("a"))
+		assert.NotContains(t, destination.versions, app("b"))
+		assert.Contains(t, destination.versions, store.SecretId{Service: "other", Key: "c"})
+	})
+
+	t.Run("Should not delete on dry runs", func(t *testing.T) {
+		destination := newFakeStore()
+		destination.set(app("b"), "x")
+
+		results, err := syncDeletions(destination, "app", synced, true)
+		assert.Nil(t, err)
+		assert.Len(t, results, 1)
+		assert.Contains(t, destination.versions, app("b"))
+	})
+
+	t.Run("Should refuse to delete every key", func(t *testing.T) {
+		destination := newFakeStore()
+		destination.set(app("b"), "x")
+
+		_, err := syncDeletions(destination, "app", nil, false)
+		assert.Error(t, err)
+		assert.Contains(t, destination.versions, app("b"))
+	})
+}
+
+func TestDescribeSyncSide(t *testing.T) {
+	assert.Equal(t, "SSM", describeSyncSide(syncSide{Backend: "SSM"}))
+	assert.Equal(t, "SSM in us-west-2 as arn:aws:iam::123456789012:role/dr-writer", describeSyncSide(syncSide{
+		Backend: "SSM",
+		Role:    "arn:aws:iam::123456789012:role/dr-writer",
+		Region:  "us-west-2",
+	}))
+}
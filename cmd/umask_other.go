@@ -0,0 +1,10 @@
+// +build !linux,!darwin
+
+package cmd
+
+import "errors"
+
+// setUmask is only implemented for Linux and macOS
+func setUmask(umask int) error {
+	return errors.New("--umask is not supported on this platform")
+}
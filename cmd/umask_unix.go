@@ -0,0 +1,11 @@
+// +build linux darwin
+
+package cmd
+
+import "syscall"
+
+// setUmask sets the umask chamber, and so the command, creates files with
+func setUmask(umask int) error {
+	syscall.Umask(umask)
+	return nil
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/chamber/v2/store"
+	"github.com/spf13/cobra"
+	analytics "gopkg.in/segmentio/analytics-go.v3"
+)
+
+// unfreezeCmd represents the unfreeze command
+var unfreezeCmd = &cobra.Command{
+	Use:   "unfreeze <service>",
+	Short: "Allow writes to a frozen service again",
+	Args:  cobra.ExactArgs(1),
+	RunE:  unfreeze,
+}
+
+func init() {
+	RootCmd.AddCommand(unfreezeCmd)
+}
+
+func unfreeze(cmd *cobra.Command, args []string) error {
+	service, err := expandService(args[0])
+	if err != nil {
+		return errors.Wrap(err, "Failed to expand service")
+	}
+	service = strings.ToLower(service)
+	if err := validateService(service); err != nil {
+		return errors.Wrap(err, "Failed to validate service")
+	}
+
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", "unfreeze").
+				Set("chamber-version", chamberVersion).
+				Set("service", service).
+				Set("backend", backend),
+		})
+	}
+
+	secretStore, err := getSecretStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get secret store")
+	}
+	f, err := store.ReadFreeze(secretStore, service)
+	if err != nil {
+		return errors.Wrap(err, "Failed to read freeze")
+	}
+	if f == nil {
+		return fmt.Errorf("service %s is not frozen", service)
+	}
+
+	if f.Active(time.Now()) {
+		identity, _, err := store.CallerIdentity(numRetries)
+		if err != nil {
+			return errors.Wrap(err, "Failed to determine caller identity")
+		}
+		if !f.Allows(identity) {
+			return fmt.Errorf("%s is not allowed to unfreeze %s; ask %s", identity, service, f.By)
+		}
+	}
+	return store.DeleteFreeze(secretStore, service)
+}
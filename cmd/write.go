@@ -2,9 +2,16 @@ package cmd
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"sort"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/segmentio/chamber/v2/store"
@@ -14,29 +21,70 @@ import (
 
 var (
 	singleline    bool
+	stripNewline  bool
 	skipUnchanged bool
+	writeTemplate string
+	writeTTL      time.Duration
+	writeVerify   bool
+	writeJSON     string
+	writeFromJSON string
 
 	// writeCmd represents the write command
 	writeCmd = &cobra.Command{
 		Use:   "write <service> <key> [--] <value|->",
 		Short: "write a secret",
-		Args:  cobra.ExactArgs(3),
-		RunE:  write,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if writeJSON != "" || writeFromJSON != "" {
+				return cobra.ExactArgs(1)(cmd, args)
+			}
+			if writeTemplate != "" {
+				return cobra.ExactArgs(2)(cmd, args)
+			}
+			return cobra.ExactArgs(3)(cmd, args)
+		},
+		RunE: write,
+		Example: `
+Derive a value from other secrets of the same service:
+
+	$ chamber write app database_url --template 'postgres://{{ .DB_USER }}:{{ .DB_PASS }}@{{ .DB_HOST }}/app'
+
+Write several keys at once, the inverse of export --format json:
+
+	$ chamber write app --json '{"db_user": "root", "db_pass": "hunter22"}'
+	$ chamber export other | chamber write app --from-json -
+`,
 	}
 )
 
 func init() {
 	writeCmd.Flags().BoolVarP(&singleline, "singleline", "s", false, "Insert single line parameter (end with \\n)")
+	writeCmd.Flags().BoolVarP(&stripNewline, "strip-newline", "", false, "Remove one trailing newline (\\n or \\r\\n) from a value read from stdin")
 	writeCmd.Flags().BoolVarP(&skipUnchanged, "skip-unchanged", "", false, "Skip writing secret if value is unchanged")
+	writeCmd.Flags().StringVarP(&writeTemplate, "template", "", "", "Go template rendered with the service's other secrets, by upper-cased key, instead of a value")
+	writeCmd.Flags().BoolVarP(&writeVerify, "verify", "", false, "Read the secret back after writing it, and fail unless it holds the value written")
+	writeCmd.Flags().StringVarP(&writeJSON, "json", "", "", "JSON object of keys and values to write instead of a single key")
+	writeCmd.Flags().StringVarP(&writeFromJSON, "from-json", "", "", "File holding a JSON object of keys and values to write instead of a single key, or - for stdin")
+	writeCmd.Flags().DurationVarP(&writeTTL, "ttl", "", 0, "Stop serving the secret after this long, e.g. 1h, and let gc delete it; default is never")
 	RootCmd.AddCommand(writeCmd)
 }
 
 func write(cmd *cobra.Command, args []string) error {
-	service := strings.ToLower(args[0])
+	service, err := expandService(args[0])
+	if err != nil {
+		return errors.Wrap(err, "Failed to expand service")
+	}
+	service = strings.ToLower(service)
 	if err := validateService(service); err != nil {
 		return errors.Wrap(err, "Failed to validate service")
 	}
 
+	if writeTTL < 0 {
+		return errors.New("--ttl must be positive")
+	}
+	if writeJSON != "" || writeFromJSON != "" {
+		return writeMany(service)
+	}
+
 	key := strings.ToLower(args[1])
 	if err := validateKey(key); err != nil {
 		return errors.Wrap(err, "Failed to validate key")
@@ -51,32 +99,65 @@ func write(cmd *cobra.Command, args []string) error {
 				Set("chamber-version", chamberVersion).
 				Set("service", service).
 				Set("backend", backend).
-				Set("key", key),
+				Set("key", key).
+				Set("template", writeTemplate != "").
+				Set("ttl", writeTTL > 0).
+				Set("verify", writeVerify),
 		})
 	}
 
-	value := args[2]
-	if value == "-" {
+	secretStore, err := getSecretStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get secret store")
+	}
+	if err := requireCapabilities(secretStore, store.Capabilities{Write: true}); err != nil {
+		return err
+	}
+
+	var value string
+	switch {
+	case writeTemplate != "":
+		vars, err := envVars(secretStore, service)
+		if err != nil {
+			return errors.Wrap(err, "Failed to list store contents")
+		}
+		if value, err = renderTemplate(writeTemplate, vars); err != nil {
+			return errors.Wrap(err, "Failed to render template")
+		}
+	case args[2] == "-":
 		// Read value from standard input
 		if singleline {
 			buf := bufio.NewReader(os.Stdin)
 			v, err := buf.ReadString('\n')
-			if err != nil {
+			if err != nil && (err != io.EOF || v == "") {
 				return err
 			}
-			value = strings.TrimSuffix(v, "\n")
+			value = trimNewline(v)
 		} else {
 			v, err := ioutil.ReadAll(os.Stdin)
 			if err != nil {
 				return err
 			}
 			value = string(v)
+			if stripNewline {
+				value = trimNewline(value)
+			}
 		}
+	default:
+		// Keep a copy, since masking the value on the command line
+		// overwrites the argument's memory
+		value = string([]byte(args[2]))
+		maskArgs(os.Args, args[2])
 	}
 
-	secretStore, err := getSecretStore()
-	if err != nil {
-		return errors.Wrap(err, "Failed to get secret store")
+	if err := store.ValidateValue(value); err != nil {
+		return errors.Wrap(err, "Failed to validate value")
+	}
+	if err := validateEnum(secretStore, service, key, value); err != nil {
+		return errors.Wrap(err, "Failed to validate value")
+	}
+	if err := checkQuotas(service, map[string]string{key: value}, os.Stderr); err != nil {
+		return errors.Wrap(err, "Failed to check quotas")
 	}
 
 	secretId := store.SecretId{
@@ -87,9 +168,233 @@ func write(cmd *cobra.Command, args []string) error {
 	if skipUnchanged {
 		currentSecret, err := secretStore.Read(secretId, -1)
 		if err == nil && value == *currentSecret.Value {
-			return nil
+			return setExpiry(secretStore, service, key, writeTTL, time.Now())
 		}
 	}
 
-	return secretStore.Write(secretId, value)
+	event := HookEvent{Command: "write", Services: []string{service}, Keys: []string{key}}
+	if err := runHooks(withEvent(event, PreWriteHook)); err != nil {
+		return err
+	}
+	if err := secretStore.Write(secretId, value); err != nil {
+		return err
+	}
+	defer runPostHooks(withEvent(event, PostWriteHook))
+	if writeVerify {
+		if err := store.VerifyWrite(secretStore, secretId, value); err != nil {
+			return errors.Wrap(err, "Failed to verify write")
+		}
+	}
+	return setExpiry(secretStore, service, key, writeTTL, time.Now())
+}
+
+// writeMany writes every key of the JSON object of --json or --from-json.
+// All keys and values are validated before anything is written, and the keys
+// already written are restored if a later write fails.
+func writeMany(service string) error {
+	if writeJSON != "" && writeFromJSON != "" {
+		return errors.New("--json and --from-json can't be combined")
+	}
+	if writeTemplate != "" {
+		return errors.New("--template writes a single key, so it can't be combined with --json or --from-json")
+	}
+
+	data := []byte(writeJSON)
+	if writeFromJSON != "" {
+		var err error
+		if writeFromJSON == "-" {
+			data, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			data, err = ioutil.ReadFile(writeFromJSON)
+		}
+		if err != nil {
+			return errors.Wrap(err, "Failed to read file")
+		}
+	}
+	values, err := parseJSONValues(data)
+	if err != nil {
+		return errors.Wrap(err, "Failed to decode input as json")
+	}
+
+	if analyticsEnabled && analyticsClient != nil {
+		analyticsClient.Enqueue(analytics.Track{
+			UserId: username,
+			Event:  "Ran Command",
+			Properties: analytics.NewProperties().
+				Set("command", "write").
+				Set("chamber-version", chamberVersion).
+				Set("service", service).
+				Set("backend", backend).
+				Set("keys", len(values)).
+				Set("ttl", writeTTL > 0).
+				Set("verify", writeVerify),
+		})
+	}
+
+	secretStore, err := getSecretStore()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get secret store")
+	}
+	if err := requireCapabilities(secretStore, store.Capabilities{Write: true}); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := store.ValidateValue(values[key]); err != nil {
+			return errors.Wrapf(err, "Failed to validate value of %s", key)
+		}
+		if err := validateEnum(secretStore, service, key, values[key]); err != nil {
+			return errors.Wrap(err, "Failed to validate value")
+		}
+	}
+	if err := checkQuotas(service, values, os.Stderr); err != nil {
+		return errors.Wrap(err, "Failed to check quotas")
+	}
+
+	event := HookEvent{Command: "write", Services: []string{service}, Keys: keys}
+	if err := runHooks(withEvent(event, PreWriteHook)); err != nil {
+		return err
+	}
+	if err := writeAll(secretStore, service, keys, values); err != nil {
+		return err
+	}
+	defer runPostHooks(withEvent(event, PostWriteHook))
+
+	now := time.Now()
+	for _, key := range keys {
+		if err := setExpiry(secretStore, service, key, writeTTL, now); err != nil {
+			return err
+		}
+	}
+	infof("Successfully wrote %d secrets\n", len(keys))
+	return nil
+}
+
+// parseJSONValues decodes a JSON object of keys and string values, as
+// written by export --format json
+func parseJSONValues(data []byte) (map[string]string, error) {
+	var values map[string]string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	if values == nil {
+		return nil, errors.New("expected a JSON object")
+	}
+	lowered := make(map[string]string, len(values))
+	for key, value := range values {
+		key = strings.ToLower(key)
+		if err := validateKey(key); err != nil {
+			return nil, err
+		}
+		if _, ok := lowered[key]; ok {
+			return nil, fmt.Errorf("%s is given more than once", key)
+		}
+		lowered[key] = value
+	}
+	return lowered, nil
+}
+
+// writeAll writes values to keys of service in order. When a write fails, the
+// keys written before it are restored to their previous values, or deleted
+// if they didn't exist.
+func writeAll(secretStore store.Store, service string, keys []string, values map[string]string) error {
+	previous := map[string]*string{}
+	for _, key := range keys {
+		secret, err := secretStore.Read(store.SecretId{Service: service, Key: key}, -1)
+		switch {
+		case err == store.ErrSecretNotFound:
+			previous[key] = nil
+		case err != nil:
+			return errors.Wrapf(err, "Failed to read %s", key)
+		default:
+			previous[key] = secret.Value
+		}
+	}
+
+	for i, key := range keys {
+		secretId := store.SecretId{Service: service, Key: key}
+		if skipUnchanged && previous[key] != nil && *previous[key] == values[key] {
+			continue
+		}
+		err := secretStore.Write(secretId, values[key])
+		if err == nil && writeVerify {
+			err = store.VerifyWrite(secretStore, secretId, values[key])
+		}
+		if err == nil {
+			continue
+		}
+
+		err = errors.Wrapf(err, "Failed to write %s", key)
+		for j := i; j >= 0; j-- {
+			written := keys[j]
+			restoreId := store.SecretId{Service: service, Key: written}
+			var restoreErr error
+			if previous[written] == nil {
+				restoreErr = secretStore.Delete(restoreId)
+				if restoreErr == store.ErrSecretNotFound {
+					restoreErr = nil
+				}
+			} else if skipUnchanged && *previous[written] == values[written] {
+				continue
+			} else {
+				restoreErr = secretStore.Write(restoreId, *previous[written])
+			}
+			if restoreErr != nil {
+				fmt.Fprintf(os.Stderr, "warning: failed to restore %s: %s\n", written, restoreErr)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// setExpiry makes key of service expire ttl after now, or never if ttl is
+// zero, keeping the rest of its metadata
+func setExpiry(secretStore store.Store, service, key string, ttl time.Duration, now time.Time) error {
+	m, err := store.ReadKeyMetadata(secretStore, service, key)
+	if err != nil {
+		return errors.Wrap(err, "Failed to read metadata")
+	}
+	switch {
+	case ttl > 0:
+		if m == nil {
+			m = &store.KeyMetadata{}
+		}
+		m.Expires = now.Add(ttl).UTC()
+	case m != nil && !m.Expires.IsZero():
+		m.Expires = time.Time{}
+	default:
+		return nil
+	}
+	if err := store.WriteKeyMetadata(secretStore, service, key, *m); err != nil {
+		return errors.Wrap(err, "Failed to write metadata")
+	}
+	return nil
+}
+
+// renderTemplate executes tmpl with vars, failing on keys that don't exist
+// rather than rendering them as "<no value>".
+func renderTemplate(tmpl string, vars map[string]string) (string, error) {
+	t, err := template.New("value").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// trimNewline removes one trailing line ending, LF or CRLF
+func trimNewline(s string) string {
+	if strings.HasSuffix(s, "\r\n") {
+		return strings.TrimSuffix(s, "\r\n")
+	}
+	return strings.TrimSuffix(s, "\n")
 }
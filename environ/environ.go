@@ -83,11 +83,20 @@ func normalizeEnvVarName(k string) string {
 	return strings.Replace(strings.ToUpper(k), "-", "_", -1)
 }
 
+// serviceName lowercases service, as chamber's services are, unless it names
+// a Secrets Manager secret, whose names are case sensitive
+func serviceName(service string) string {
+	if store.IsSecretsManagerReference(service) {
+		return service
+	}
+	return strings.ToLower(service)
+}
+
 // load loads environment variables into e from s given a service
 // collisions will be populated with any keys that get overwritten
 // noPaths enables the behavior as if CHAMBER_NO_PATHS had been set
 func (e *Environ) load(s store.Store, service string, collisions *[]string, noPaths bool) error {
-	rawSecrets, err := s.ListRaw(strings.ToLower(service))
+	rawSecrets, err := s.ListRaw(serviceName(service))
 	if err != nil {
 		return err
 	}
@@ -134,7 +143,7 @@ func (e *Environ) LoadStrictNoPaths(s store.Store, valueExpected string, pristin
 
 func (e *Environ) loadStrict(s store.Store, valueExpected string, pristine bool, noPaths bool, services ...string) error {
 	for _, service := range services {
-		rawSecrets, err := s.ListRaw(strings.ToLower(service))
+		rawSecrets, err := s.ListRaw(serviceName(service))
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,95 @@
+package store
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// ErrMaxAPICalls is returned for AWS requests issued after the number of
+// requests set by SessionOptions.MaxAPICalls has been made
+var ErrMaxAPICalls = errors.New("exceeded the maximum number of AWS API calls")
+
+// APICall is an AWS request made by the process. Its duration includes its
+// retries and the delays between them.
+type APICall struct {
+	Service   string
+	Operation string
+	Duration  time.Duration
+	Failed    bool
+}
+
+// apiCallRecorder records every AWS request made by the process, and fails
+// those beyond its limit
+type apiCallRecorder struct {
+	mu       sync.Mutex
+	limit    int
+	started  int
+	exceeded bool
+	calls    []APICall
+	inFlight map[*request.Request]time.Time
+}
+
+var apiCalls = &apiCallRecorder{}
+
+// reset starts recording anew with limit; zero disables the limit.
+func (r *apiCallRecorder) reset(limit int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.limit = limit
+	r.started = 0
+	r.exceeded = false
+	r.calls = nil
+	r.inFlight = map[*request.Request]time.Time{}
+}
+
+func (r *apiCallRecorder) install(handlers *request.Handlers) {
+	handlers.Validate.PushFront(r.start)
+	handlers.Complete.PushBack(r.finish)
+}
+
+func (r *apiCallRecorder) start(req *request.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.limit > 0 && r.started >= r.limit {
+		r.exceeded = true
+		req.Error = ErrMaxAPICalls
+		return
+	}
+	r.started++
+	r.inFlight[req] = time.Now()
+}
+
+func (r *apiCallRecorder) finish(req *request.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	started, ok := r.inFlight[req]
+	if !ok {
+		return
+	}
+	delete(r.inFlight, req)
+	r.calls = append(r.calls, APICall{
+		Service:   req.ClientInfo.ServiceName,
+		Operation: req.Operation.Name,
+		Duration:  time.Since(started),
+		Failed:    req.Error != nil,
+	})
+}
+
+// APICalls returns the AWS requests made so far, when recorded with
+// SessionOptions.RecordAPICalls, in the order they completed
+func APICalls() []APICall {
+	apiCalls.mu.Lock()
+	defer apiCalls.mu.Unlock()
+	return append([]APICall(nil), apiCalls.calls...)
+}
+
+// MaxAPICallsExceeded reports whether any AWS request failed because the
+// number of requests set by SessionOptions.MaxAPICalls had been made.
+func MaxAPICallsExceeded() bool {
+	apiCalls.mu.Lock()
+	defer apiCalls.mu.Unlock()
+	return apiCalls.exceeded
+}
@@ -0,0 +1,44 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPICalls(t *testing.T) {
+	id := SecretId{Service: "service", Key: "key"}
+
+	t.Run("Should record every request", func(t *testing.T) {
+		defer newSlowSSMEndpoint(0)()
+		ConfigureSessions(SessionOptions{RecordAPICalls: true})
+		defer ConfigureSessions(SessionOptions{})
+
+		s, err := NewSSMStore(0)
+		assert.Nil(t, err)
+		for i := 0; i < 2; i++ {
+			_, err = s.Read(id, -1)
+			assert.Equal(t, ErrSecretNotFound, err)
+		}
+
+		calls := APICalls()
+		assert.Len(t, calls, 2)
+		assert.Equal(t, "ssm", calls[0].Service)
+		assert.Equal(t, "GetParameters", calls[0].Operation)
+		assert.False(t, MaxAPICallsExceeded())
+	})
+
+	t.Run("Should fail requests beyond the limit", func(t *testing.T) {
+		defer newSlowSSMEndpoint(0)()
+		ConfigureSessions(SessionOptions{MaxAPICalls: 1})
+		defer ConfigureSessions(SessionOptions{})
+
+		s, err := NewSSMStore(0)
+		assert.Nil(t, err)
+		_, err = s.Read(id, -1)
+		assert.Equal(t, ErrSecretNotFound, err)
+		_, err = s.Read(id, -1)
+		assert.Equal(t, ErrMaxAPICalls, err)
+		assert.True(t, MaxAPICallsExceeded())
+	})
+}
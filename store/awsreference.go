@@ -0,0 +1,76 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// IsSecretsManagerReference reports whether service names a Secrets Manager
+// secret through SSM, e.g. aws/reference/secretsmanager/prod/db. Such
+// parameters are managed by AWS: they can only be read one at a time by
+// name, with decryption, and can't be listed, described or written.
+func IsSecretsManagerReference(service string) bool {
+	name := "/" + strings.TrimPrefix(service, "/")
+	return strings.HasPrefix(name, SecretsManagerReferencePath) && len(name) > len(SecretsManagerReferencePath)
+}
+
+// listReference reads the Secrets Manager secret named by service. A secret
+// holding a JSON object of strings, numbers and booleans, as the console
+// writes key/value secrets, is listed as one secret per field; any other
+// secret is listed as a single secret named after the last segment of its
+// name.
+func (s *SSMStore) listReference(service string) ([]RawSecret, error) {
+	name := "/" + strings.TrimPrefix(service, "/")
+	resp, err := s.svc.GetParameters(&ssm.GetParametersInput{
+		Names:          []*string{aws.String(name)},
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Parameters) == 0 {
+		return nil, fmt.Errorf("unable to read %s: %s", name, ErrSecretNotFound)
+	}
+	value := aws.StringValue(resp.Parameters[0].Value)
+
+	fields, ok := referenceFields(value)
+	if !ok {
+		return []RawSecret{{Key: name, Value: value}}, nil
+	}
+	rawSecrets := make([]RawSecret, 0, len(fields))
+	for field, fieldValue := range fields {
+		rawSecrets = append(rawSecrets, RawSecret{Key: name + "/" + field, Value: fieldValue})
+	}
+	sort.Slice(rawSecrets, func(i, j int) bool { return rawSecrets[i].Key < rawSecrets[j].Key })
+	return rawSecrets, nil
+}
+
+// referenceFields returns the fields of value if it is a flat JSON object
+// whose field names are valid keys
+func referenceFields(value string) (map[string]string, bool) {
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &object); err != nil || len(object) == 0 {
+		return nil, false
+	}
+	fields := make(map[string]string, len(object))
+	for field, fieldValue := range object {
+		if !validKeyFormat.MatchString(field) {
+			return nil, false
+		}
+		switch v := fieldValue.(type) {
+		case string:
+			fields[field] = v
+		case float64, bool:
+			encoded, _ := json.Marshal(v)
+			fields[field] = string(encoded)
+		default:
+			return nil, false
+		}
+	}
+	return fields, true
+}
@@ -0,0 +1,73 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/stretchr/testify/assert"
+)
+
+func mockReference(mock *mockSSMClient, name, value string) {
+	mock.parameters[name] = mockParameter{
+		currentParam: &ssm.Parameter{Name: aws.String(name), Value: aws.String(value)},
+		meta:         &ssm.ParameterMetadata{Name: aws.String(name)},
+	}
+}
+
+func TestIsSecretsManagerReference(t *testing.T) {
+	t.Run("Should recognize secrets under the reference path", func(t *testing.T) {
+		assert.True(t, IsSecretsManagerReference("aws/reference/secretsmanager/prod/db"))
+		assert.True(t, IsSecretsManagerReference("/aws/reference/secretsmanager/Prod-DB"))
+	})
+
+	t.Run("Should not recognize other services", func(t *testing.T) {
+		assert.False(t, IsSecretsManagerReference("aws/reference/secretsmanager/"))
+		assert.False(t, IsSecretsManagerReference("app/aws/reference/secretsmanager/db"))
+		assert.False(t, IsSecretsManagerReference("app"))
+	})
+}
+
+func TestListReference(t *testing.T) {
+	mock := &mockSSMClient{parameters: map[string]mockParameter{}}
+	s := NewTestSSMStoreWithPaths(mock)
+	mockReference(mock, "/aws/reference/secretsmanager/prod/Token", "hunter22")
+	mockReference(mock, "/aws/reference/secretsmanager/prod/db", `{"username":"app","password":"hunter22","port":5432,"tls":true}`)
+	mockReference(mock, "/aws/reference/secretsmanager/prod/nested", `{"db":{"password":"hunter22"}}`)
+
+	t.Run("Should list plain secrets under their name", func(t *testing.T) {
+		rawSecrets, err := s.ListRaw("aws/reference/secretsmanager/prod/Token")
+		assert.Nil(t, err)
+		assert.Equal(t, []RawSecret{{Key: "/aws/reference/secretsmanager/prod/Token", Value: "hunter22"}}, rawSecrets)
+	})
+
+	t.Run("Should list the fields of key/value secrets", func(t *testing.T) {
+		rawSecrets, err := s.ListRaw("aws/reference/secretsmanager/prod/db")
+		assert.Nil(t, err)
+		assert.Equal(t, []RawSecret{
+			{Key: "/aws/reference/secretsmanager/prod/db/password", Value: "hunter22"},
+			{Key: "/aws/reference/secretsmanager/prod/db/port", Value: "5432"},
+			{Key: "/aws/reference/secretsmanager/prod/db/tls", Value: "true"},
+			{Key: "/aws/reference/secretsmanager/prod/db/username", Value: "app"},
+		}, rawSecrets)
+	})
+
+	t.Run("Should list nested JSON as one secret", func(t *testing.T) {
+		rawSecrets, err := s.ListRaw("aws/reference/secretsmanager/prod/nested")
+		assert.Nil(t, err)
+		assert.Len(t, rawSecrets, 1)
+		assert.Equal(t, "/aws/reference/secretsmanager/prod/nested", rawSecrets[0].Key)
+	})
+
+	t.Run("Should fail for missing secrets", func(t *testing.T) {
+		_, err := s.ListRaw("aws/reference/secretsmanager/prod/missing")
+		assert.Error(t, err)
+	})
+
+	t.Run("Should read references outside of namespaces and profiles", func(t *testing.T) {
+		wrapped := NewProfileStore(NewNamespacedStore(s, "tenants/acme"), "dev")
+		rawSecrets, err := wrapped.ListRaw("aws/reference/secretsmanager/prod/Token")
+		assert.Nil(t, err)
+		assert.Equal(t, []RawSecret{{Key: "/aws/reference/secretsmanager/prod/Token", Value: "hunter22"}}, rawSecrets)
+	})
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+const (
+	// SSMURIScheme marks a value naming an SSM parameter to read instead,
+	// e.g. ssm:///shared/prod/db_password
+	SSMURIScheme = "ssm://"
+
+	// SecretsManagerURIScheme marks a value naming a Secrets Manager secret
+	// to read instead, e.g. secretsmanager://prod/db
+	SecretsManagerURIScheme = "secretsmanager://"
+
+	// SecretsManagerReferencePath is where SSM serves Secrets Manager secrets
+	// as parameters
+	SecretsManagerReferencePath = "/aws/reference/secretsmanager/"
+)
+
+// BridgedParameter returns the name of the SSM parameter holding the value
+// that value names, if it is an ssm:// or secretsmanager:// URI. Secrets
+// Manager secrets are read through their SSM references, so one SSM client
+// resolves both.
+func BridgedParameter(value string) (string, bool, error) {
+	var name string
+	switch {
+	case strings.HasPrefix(value, SSMURIScheme):
+		name = strings.TrimPrefix(value, SSMURIScheme)
+		if strings.Contains(name, "/") && !strings.HasPrefix(name, "/") {
+			name = "/" + name
+		}
+	case strings.HasPrefix(value, SecretsManagerURIScheme):
+		name = strings.TrimPrefix(value, SecretsManagerURIScheme)
+		if name != "" {
+			name = SecretsManagerReferencePath + name
+		}
+	default:
+		return "", false, nil
+	}
+	if strings.Trim(name, "/") == "" {
+		return "", true, fmt.Errorf("invalid URI %s: expected ssm://<parameter> or secretsmanager://<secret>", value)
+	}
+	return name, true, nil
+}
+
+// ResolveBridged reads the values named by uris, ssm:// or secretsmanager://
+// URIs, ten at a time, and returns them by URI. It fails, naming them, if
+// any can't be read.
+func (s *SSMStore) ResolveBridged(uris []string) (map[string]string, error) {
+	byName := map[string][]string{}
+	for _, uri := range uris {
+		name, ok, err := BridgedParameter(uri)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("%s is not an ssm:// or secretsmanager:// URI", uri)
+		}
+		byName[name] = append(byName[name], uri)
+	}
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	values := map[string]string{}
+	var missing []string
+	for i := 0; i < len(names); i += 10 {
+		batchEnd := i + 10
+		if batchEnd > len(names) {
+			batchEnd = len(names)
+		}
+		resp, err := s.svc.GetParameters(&ssm.GetParametersInput{
+			Names:          stringsToAWSStrings(names[i:batchEnd]),
+			WithDecryption: aws.Bool(true),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, param := range resp.Parameters {
+			for _, uri := range byName[aws.StringValue(param.Name)] {
+				values[uri] = aws.StringValue(param.Value)
+			}
+		}
+		for _, name := range resp.InvalidParameters {
+			missing = append(missing, byName[aws.StringValue(name)]...)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("unable to resolve %s: %s", strings.Join(missing, ", "), ErrSecretNotFound)
+	}
+	return values, nil
+}
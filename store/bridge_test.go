@@ -0,0 +1,53 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBridgedParameter(t *testing.T) {
+	t.Run("Should name the parameters of URIs", func(t *testing.T) {
+		for value, expected := range map[string]string{
+			"ssm:///shared/prod/token": "/shared/prod/token",
+			"ssm://shared/prod/token":  "/shared/prod/token",
+			"ssm://shared.token":       "shared.token",
+			"secretsmanager://prod/db": "/aws/reference/secretsmanager/prod/db",
+		} {
+			name, ok, err := BridgedParameter(value)
+			assert.Nil(t, err, value)
+			assert.True(t, ok, value)
+			assert.Equal(t, expected, name, value)
+		}
+	})
+
+	t.Run("Should leave other values alone", func(t *testing.T) {
+		_, ok, err := BridgedParameter("hunter22")
+		assert.Nil(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("Should reject URIs without a name", func(t *testing.T) {
+		for _, value := range []string{"ssm://", "ssm:///", "secretsmanager://"} {
+			_, _, err := BridgedParameter(value)
+			assert.Error(t, err, value)
+		}
+	})
+}
+
+func TestResolveBridged(t *testing.T) {
+	mock := &mockSSMClient{parameters: map[string]mockParameter{}}
+	s := NewTestSSMStoreWithPaths(mock)
+	assert.Nil(t, s.Write(SecretId{Service: "shared", Key: "token"}, "secret"))
+
+	t.Run("Should resolve URIs naming the same parameter", func(t *testing.T) {
+		values, err := s.ResolveBridged([]string{"ssm:///shared/token", "ssm://shared/token"})
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"ssm:///shared/token": "secret", "ssm://shared/token": "secret"}, values)
+	})
+
+	t.Run("Should fail for parameters that can't be read", func(t *testing.T) {
+		_, err := s.ResolveBridged([]string{"ssm:///shared/missing"})
+		assert.Error(t, err)
+	})
+}
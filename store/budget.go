@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// ErrMaxElapsed is returned for AWS requests issued after the time budget set
+// by SessionOptions.MaxElapsed has been used up
+var ErrMaxElapsed = errors.New("exceeded the maximum time allowed for AWS requests")
+
+// elapsedBudget bounds the time spent in AWS requests, summed over every
+// request made by the process. A request's time includes its retries and the
+// delays between them, but not the time the caller spends between requests.
+type elapsedBudget struct {
+	mu       sync.Mutex
+	limit    time.Duration
+	spent    time.Duration
+	exceeded bool
+	inFlight map[*request.Request]inFlightRequest
+}
+
+type inFlightRequest struct {
+	started time.Time
+	cancel  context.CancelFunc
+}
+
+var budget = &elapsedBudget{}
+
+// reset starts a new budget of limit; zero disables the budget.
+func (b *elapsedBudget) reset(limit time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.limit = limit
+	b.spent = 0
+	b.exceeded = false
+	b.inFlight = map[*request.Request]inFlightRequest{}
+}
+
+func (b *elapsedBudget) install(handlers *request.Handlers) {
+	handlers.Validate.PushFront(b.start)
+	handlers.Complete.PushBack(b.finish)
+}
+
+// start gives r whatever is left of the budget as its deadline
+func (b *elapsedBudget) start(r *request.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.limit <= 0 {
+		return
+	}
+
+	remaining := b.limit - b.spent
+	if remaining <= 0 {
+		b.exceeded = true
+		r.Error = ErrMaxElapsed
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), remaining)
+	r.SetContext(ctx)
+	b.inFlight[r] = inFlightRequest{started: time.Now(), cancel: cancel}
+}
+
+func (b *elapsedBudget) finish(r *request.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	f, ok := b.inFlight[r]
+	if !ok {
+		return
+	}
+	delete(b.inFlight, r)
+
+	b.spent += time.Since(f.started)
+	if r.Error != nil && r.Context().Err() == context.DeadlineExceeded {
+		b.exceeded = true
+	}
+	f.cancel()
+}
+
+// MaxElapsedExceeded reports whether any AWS request failed because the time
+// budget set by SessionOptions.MaxElapsed ran out.
+func MaxElapsedExceeded() bool {
+	budget.mu.Lock()
+	defer budget.mu.Unlock()
+	return budget.exceeded
+}
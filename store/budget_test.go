@@ -0,0 +1,68 @@
+package store
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newSlowSSMEndpoint serves empty GetParameters responses after delay
+func newSlowSSMEndpoint(delay time.Duration) func() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.Write([]byte(`{"Parameters":[],"InvalidParameters":["/service/key"]}`))
+	}))
+
+	os.Setenv("CHAMBER_AWS_SSM_ENDPOINT", server.URL)
+	os.Setenv("AWS_REGION", "us-east-1")
+	os.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	return func() {
+		server.Close()
+		os.Unsetenv("CHAMBER_AWS_SSM_ENDPOINT")
+		os.Unsetenv("AWS_REGION")
+		os.Unsetenv("AWS_ACCESS_KEY_ID")
+		os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+	}
+}
+
+func TestMaxElapsed(t *testing.T) {
+	id := SecretId{Service: "service", Key: "key"}
+
+	t.Run("Slow requests should be aborted once the budget is spent", func(t *testing.T) {
+		defer newSlowSSMEndpoint(300 * time.Millisecond)()
+		ConfigureSessions(SessionOptions{MaxElapsed: 50 * time.Millisecond})
+		defer ConfigureSessions(SessionOptions{})
+
+		s, err := NewSSMStore(0)
+		assert.Nil(t, err)
+
+		_, err = s.Read(id, -1)
+		assert.Error(t, err)
+		assert.True(t, MaxElapsedExceeded())
+
+		_, err = s.Read(id, -1)
+		assert.Equal(t, ErrMaxElapsed, err)
+	})
+
+	t.Run("Time spent between requests should not count against the budget", func(t *testing.T) {
+		defer newSlowSSMEndpoint(0)()
+		ConfigureSessions(SessionOptions{MaxElapsed: 200 * time.Millisecond})
+		defer ConfigureSessions(SessionOptions{})
+
+		s, err := NewSSMStore(0)
+		assert.Nil(t, err)
+
+		for i := 0; i < 3; i++ {
+			_, err = s.Read(id, -1)
+			assert.Equal(t, ErrSecretNotFound, err)
+			time.Sleep(100 * time.Millisecond)
+		}
+		assert.False(t, MaxElapsedExceeded())
+	})
+}
@@ -0,0 +1,170 @@
+package store
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// CacheMode is how a CachingStore uses its cache
+type CacheMode int
+
+const (
+	// CacheRead serves listings from the cache while they are fresh, and
+	// from the backend otherwise
+	CacheRead CacheMode = iota + 1
+
+	// CacheRefresh always lists from the backend, and caches the result
+	CacheRefresh
+)
+
+// cachedListing is the listing of a service kept in the keyring
+type cachedListing struct {
+	Secrets []cachedSecret `json:"secrets"`
+	Expires time.Time      `json:"expires"`
+}
+
+type cachedSecret struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+var _ Store = &CachingStore{}
+
+// CachingStore keeps the listings of services in the OS keyring, so that they
+// can be fetched ahead of time and served while AWS is slow or unavailable.
+// Only ListRaw, as used by exec, is cached; everything else goes to the
+// backend.
+type CachingStore struct {
+	store   Store
+	keyring keyring
+	scope   string
+	mode    CacheMode
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+// NewCachingStore returns a store caching the listings of s in the OS keyring
+// for ttl. scope identifies the backend, region and identity s serves, so
+// that listings are never served to a different one.
+func NewCachingStore(s Store, scope string, mode CacheMode, ttl time.Duration) (*CachingStore, error) {
+	kr, err := newKeyring()
+	if err != nil {
+		return nil, err
+	}
+	return &CachingStore{store: s, keyring: kr, scope: scope, mode: mode, ttl: ttl, now: time.Now}, nil
+}
+
+func (s *CachingStore) cacheKey(service string) string {
+	return "secrets:" + s.scope + ":" + service
+}
+
+// cached returns the fresh listing of service from the cache, if there is one
+func (s *CachingStore) cached(service string) ([]RawSecret, bool) {
+	value, err := s.keyring.Get(s.cacheKey(service))
+	if err != nil {
+		return nil, false
+	}
+	var listing cachedListing
+	if json.Unmarshal([]byte(value), &listing) != nil || !s.now().Before(listing.Expires) {
+		return nil, false
+	}
+	rawSecrets := make([]RawSecret, 0, len(listing.Secrets))
+	for _, secret := range listing.Secrets {
+		rawSecrets = append(rawSecrets, RawSecret{Key: secret.Key, Value: secret.Value})
+	}
+	return rawSecrets, true
+}
+
+func (s *CachingStore) ListRaw(service string) ([]RawSecret, error) {
+	if s.mode == CacheRead {
+		if rawSecrets, ok := s.cached(service); ok {
+			return rawSecrets, nil
+		}
+		return s.store.ListRaw(service)
+	}
+
+	rawSecrets, err := s.store.ListRaw(service)
+	if err != nil {
+		return nil, err
+	}
+	listing := cachedListing{Expires: s.now().Add(s.ttl).UTC()}
+	for _, secret := range rawSecrets {
+		listing.Secrets = append(listing.Secrets, cachedSecret{Key: secret.Key, Value: secret.Value})
+	}
+	value, err := json.Marshal(listing)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.keyring.Set(s.cacheKey(service), string(value)); err != nil {
+		return nil, err
+	}
+	return rawSecrets, nil
+}
+
+func (s *CachingStore) Write(id SecretId, value string) error {
+	return s.store.Write(id, value)
+}
+
+func (s *CachingStore) WriteMany(service string, values map[string]string) error {
+	return WriteMany(s.store, service, values)
+}
+
+func (s *CachingStore) Read(id SecretId, version int) (Secret, error) {
+	return s.store.Read(id, version)
+}
+
+func (s *CachingStore) ReadNativeVersion(id SecretId, version int64) (Secret, error) {
+	return ReadNativeVersion(s.store, id, version)
+}
+
+func (s *CachingStore) ReadLabel(id SecretId, label string) (Secret, error) {
+	return ReadLabel(s.store, id, label)
+}
+
+func (s *CachingStore) ListNative(service string) (map[string]NativeAttributes, error) {
+	return ListNative(s.store, service)
+}
+
+func (s *CachingStore) Rekey(id SecretId, kmsKey string) (bool, error) {
+	return Rekey(s.store, id, kmsKey)
+}
+
+func (s *CachingStore) List(service string, includeValues bool) ([]Secret, error) {
+	return s.store.List(service, includeValues)
+}
+
+func (s *CachingStore) ListServices(service string, includeSecretName bool) ([]string, error) {
+	return s.store.ListServices(service, includeSecretName)
+}
+
+func (s *CachingStore) StreamList(service string, includeValues bool, fn func([]Secret) error) error {
+	return StreamList(s.store, service, includeValues, fn)
+}
+
+func (s *CachingStore) StreamServices(service string, includeSecretName bool, fn func([]string) error) error {
+	return StreamServices(s.store, service, includeSecretName, fn)
+}
+
+func (s *CachingStore) ListRefs(service string) ([]SecretRef, error) {
+	return ListRefs(s.store, service)
+}
+
+func (s *CachingStore) Capabilities() Capabilities {
+	return CapabilitiesOf(s.store)
+}
+
+func (s *CachingStore) History(id SecretId) ([]ChangeEvent, error) {
+	return s.store.History(id)
+}
+
+func (s *CachingStore) Rename(id SecretId, newKey string) error {
+	return Rename(s.store, id, newKey)
+}
+
+func (s *CachingStore) Renumber(id SecretId, version int) error {
+	return Renumber(s.store, id, version)
+}
+
+func (s *CachingStore) Delete(id SecretId) error {
+	return s.store.Delete(id)
+}
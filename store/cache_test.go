@@ -0,0 +1,59 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingStore counts the listings it serves
+type countingStore struct {
+	*StaticStore
+	listings int
+}
+
+func (s *countingStore) ListRaw(service string) ([]RawSecret, error) {
+	s.listings++
+	return s.StaticStore.ListRaw(service)
+}
+
+func TestCachingStore(t *testing.T) {
+	backend := &countingStore{StaticStore: NewStaticStore(map[string]map[string]string{"app": {"db_password": "hunter22"}})}
+	kr := memoryKeyring{}
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	newStore := func(mode CacheMode) *CachingStore {
+		return &CachingStore{store: backend, keyring: kr, scope: "SSM:us-east-1", mode: mode, ttl: time.Hour, now: func() time.Time { return now }}
+	}
+	expected := []RawSecret{{Key: "/app/db_password", Value: "hunter22"}}
+
+	t.Run("Should list from the backend before anything was cached", func(t *testing.T) {
+		rawSecrets, err := newStore(CacheRead).ListRaw("app")
+		assert.Nil(t, err)
+		assert.Equal(t, expected, rawSecrets)
+		assert.Equal(t, 1, backend.listings)
+		assert.Empty(t, kr)
+	})
+
+	t.Run("Should cache listings when refreshing", func(t *testing.T) {
+		rawSecrets, err := newStore(CacheRefresh).ListRaw("app")
+		assert.Nil(t, err)
+		assert.Equal(t, expected, rawSecrets)
+		assert.Equal(t, 2, backend.listings)
+		assert.Contains(t, kr, "secrets:SSM:us-east-1:app")
+	})
+
+	t.Run("Should serve fresh listings from the cache", func(t *testing.T) {
+		rawSecrets, err := newStore(CacheRead).ListRaw("app")
+		assert.Nil(t, err)
+		assert.Equal(t, expected, rawSecrets)
+		assert.Equal(t, 2, backend.listings)
+	})
+
+	t.Run("Should list from the backend once the cache expired", func(t *testing.T) {
+		now = now.Add(time.Hour)
+		_, err := newStore(CacheRead).ListRaw("app")
+		assert.Nil(t, err)
+		assert.Equal(t, 3, backend.listings)
+	})
+}
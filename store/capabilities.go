@@ -0,0 +1,116 @@
+package store
+
+import "strings"
+
+// Capabilities describes what a store supports beyond reading the latest
+// values of a service, so commands can fail before they start rather than
+// halfway through.
+type Capabilities struct {
+	// Write is whether secrets can be written and deleted
+	Write bool
+
+	// History is whether the store keeps the history of every key
+	History bool
+
+	// Versions is whether versions other than the latest can be read
+	Versions bool
+
+	// ListServices is whether the services of the store can be listed
+	ListServices bool
+
+	// References is whether secrets can be referenced by ARN, see ListRefs
+	References bool
+
+	// Rekey is whether secrets can be re-encrypted under another KMS key
+	Rekey bool
+}
+
+// AllCapabilities is what stores that don't describe their capabilities are
+// assumed to support
+var AllCapabilities = Capabilities{Write: true, History: true, Versions: true, ListServices: true, References: true, Rekey: true}
+
+// CapabilityReporter is implemented by stores that describe their
+// capabilities
+type CapabilityReporter interface {
+	Capabilities() Capabilities
+}
+
+// CapabilitiesOf returns the capabilities of s, or AllCapabilities if it
+// doesn't describe them
+func CapabilitiesOf(s Store) Capabilities {
+	if r, ok := s.(CapabilityReporter); ok {
+		return r.Capabilities()
+	}
+	return AllCapabilities
+}
+
+// Lacks returns the names of the capabilities of required that c lacks
+func (c Capabilities) Lacks(required Capabilities) []string {
+	var lacking []string
+	if required.Write && !c.Write {
+		lacking = append(lacking, "writes")
+	}
+	if required.History && !c.History {
+		lacking = append(lacking, "history")
+	}
+	if required.Versions && !c.Versions {
+		lacking = append(lacking, "reading older versions")
+	}
+	if required.ListServices && !c.ListServices {
+		lacking = append(lacking, "listing services")
+	}
+	if required.References && !c.References {
+		lacking = append(lacking, "references")
+	}
+	if required.Rekey && !c.Rekey {
+		lacking = append(lacking, "re-encrypting under another KMS key")
+	}
+	return lacking
+}
+
+// String lists the capabilities c has
+func (c Capabilities) String() string {
+	var has []string
+	for _, capability := range []struct {
+		name string
+		has  bool
+	}{
+		{"write", c.Write},
+		{"history", c.History},
+		{"versions", c.Versions},
+		{"list-services", c.ListServices},
+		{"references", c.References},
+		{"rekey", c.Rekey},
+	} {
+		if capability.has {
+			has = append(has, capability.name)
+		}
+	}
+	if len(has) == 0 {
+		return "read-only"
+	}
+	return strings.Join(has, ", ")
+}
+
+func (s *SSMStore) Capabilities() Capabilities {
+	return AllCapabilities
+}
+
+func (s *S3Store) Capabilities() Capabilities {
+	return Capabilities{Write: true, History: true, Versions: true}
+}
+
+// VaultStore has no ARNs to reference
+func (s *VaultStore) Capabilities() Capabilities {
+	return Capabilities{Write: true, History: true, Versions: true, ListServices: true}
+}
+
+// StaticStore is read-only and has a single version of every key
+func (s *StaticStore) Capabilities() Capabilities {
+	return Capabilities{History: true, ListServices: true}
+}
+
+// NullStore has nothing to write to or read, but answers every listing
+func (s *NullStore) Capabilities() Capabilities {
+	return Capabilities{History: true, ListServices: true}
+}
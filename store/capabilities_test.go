@@ -0,0 +1,32 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapabilities(t *testing.T) {
+	static := NewStaticStore(map[string]map[string]string{"app": {"key": "value"}})
+
+	t.Run("Should report the capabilities of the backend through wrappers", func(t *testing.T) {
+		wrapped := NewScrubbingStore(NewReferenceStore(NewFreezeGuardStore(NewNamespacedStore(static, "team"))))
+		assert.Equal(t, CapabilitiesOf(static), CapabilitiesOf(wrapped))
+		assert.False(t, CapabilitiesOf(wrapped).Write)
+	})
+
+	t.Run("Should assume stores that don't report support everything", func(t *testing.T) {
+		assert.Equal(t, AllCapabilities, CapabilitiesOf(struct{ Store }{static}))
+	})
+
+	t.Run("Should name what is lacking", func(t *testing.T) {
+		lacking := CapabilitiesOf(static).Lacks(Capabilities{Write: true, History: true, Versions: true})
+		assert.Equal(t, []string{"writes", "reading older versions"}, lacking)
+		assert.Empty(t, AllCapabilities.Lacks(AllCapabilities))
+	})
+
+	t.Run("Should list the capabilities a store has", func(t *testing.T) {
+		assert.Equal(t, "history, list-services", CapabilitiesOf(static).String())
+		assert.Equal(t, "read-only", Capabilities{}.String())
+	})
+}
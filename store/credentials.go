@@ -0,0 +1,207 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+const (
+	// DefaultRoleSessionName is the session name used when assuming roles
+	DefaultRoleSessionName = "chamber"
+
+	// githubOIDCAudience is the audience AWS expects of GitHub's OIDC tokens
+	githubOIDCAudience = "sts.amazonaws.com"
+)
+
+// RoleOptions configures the role chamber assumes instead of using the
+// credentials found by the AWS SDK. The SDK already assumes AWS_ROLE_ARN
+// with AWS_WEB_IDENTITY_TOKEN_FILE by itself, as used by EKS IRSA.
+type RoleOptions struct {
+	// RoleARN is the role to assume; nothing is assumed when empty
+	RoleARN string
+
+	// SessionName is the role session name, DefaultRoleSessionName if empty
+	SessionName string
+
+	// WebIdentityTokenFile is a file holding an OIDC token to assume RoleARN
+	// with, instead of the current credentials
+	WebIdentityTokenFile string
+
+	// GitHubOIDC requests an OIDC token from the GitHub Actions runner to
+	// assume RoleARN with
+	GitHubOIDC bool
+}
+
+// roleCredentials returns the credentials for the configured role, or nil
+// to keep the credentials of sess.
+func roleCredentials(sess *session.Session, opts RoleOptions, httpClient *http.Client) (*credentials.Credentials, error) {
+	if opts.RoleARN == "" {
+		if opts.WebIdentityTokenFile != "" || opts.GitHubOIDC {
+			return nil, fmt.Errorf("a role ARN is required to use a web identity")
+		}
+		return nil, nil
+	}
+	sessionName := opts.SessionName
+	if sessionName == "" {
+		sessionName = DefaultRoleSessionName
+	}
+
+	switch {
+	case opts.WebIdentityTokenFile != "":
+		return stscreds.NewWebIdentityCredentials(sess, opts.RoleARN, sessionName, opts.WebIdentityTokenFile), nil
+	case opts.GitHubOIDC:
+		fetcher, err := newGitHubOIDCTokenFetcher(httpClient)
+		if err != nil {
+			return nil, err
+		}
+		provider := stscreds.NewWebIdentityRoleProviderWithToken(sts.New(sess), opts.RoleARN, sessionName, fetcher)
+		return credentials.NewCredentials(provider), nil
+	default:
+		return stscreds.NewCredentials(sess, opts.RoleARN, func(p *stscreds.AssumeRoleProvider) {
+			p.RoleSessionName = sessionName
+		}), nil
+	}
+}
+
+// githubOIDCTokenFetcher requests OIDC tokens from the GitHub Actions runner.
+// It requires the job to have the `id-token: write` permission.
+type githubOIDCTokenFetcher struct {
+	client       *http.Client
+	requestURL   string
+	requestToken string
+}
+
+func newGitHubOIDCTokenFetcher(client *http.Client) (*githubOIDCTokenFetcher, error) {
+	requestURL, requestToken := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL"), os.Getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN")
+	if requestURL == "" || requestToken == "" {
+		return nil, fmt.Errorf("GitHub OIDC requires a GitHub Actions job with the id-token: write permission")
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &githubOIDCTokenFetcher{client: client, requestURL: requestURL, requestToken: requestToken}, nil
+}
+
+// FetchToken implements stscreds.TokenFetcher
+func (f *githubOIDCTokenFetcher) FetchToken(ctx credentials.Context) ([]byte, error) {
+	u, err := url.Parse(f.requestURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ACTIONS_ID_TOKEN_REQUEST_URL: %s", err)
+	}
+	q := u.Query()
+	q.Set("audience", githubOIDCAudience)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "bearer "+f.requestToken)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to request GitHub OIDC token: %s", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to request GitHub OIDC token: %s", resp.Status)
+	}
+
+	var body struct {
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("unable to decode GitHub OIDC token: %s", err)
+	}
+	return []byte(body.Value), nil
+}
+
+// cachedCredentialsWindow is how long before they expire cached credentials
+// are no longer used, so they don't expire while chamber runs
+const cachedCredentialsWindow = 5 * time.Minute
+
+// cachedCredentials is stored in the keyring for each role and profile
+type cachedCredentials struct {
+	AccessKeyID     string    `json:"access_key_id"`
+	SecretAccessKey string    `json:"secret_access_key"`
+	SessionToken    string    `json:"session_token"`
+	Expiration      time.Time `json:"expiration"`
+}
+
+// keyringProvider serves the credentials of creds from the keyring while they
+// are valid, so that assuming roles and MFA prompts happen once per session
+// instead of once per chamber invocation.
+type keyringProvider struct {
+	credentials.Expiry
+
+	keyring keyring
+	key     string
+	creds   *credentials.Credentials
+}
+
+// credentialsCacheKey identifies the credentials of a role and AWS profile,
+// or is empty if there is nothing to cache
+func credentialsCacheKey(roleARN string) string {
+	profile := os.Getenv("AWS_PROFILE")
+	if profile == "" {
+		profile = os.Getenv("AWS_DEFAULT_PROFILE")
+	}
+	if roleARN == "" && profile == "" {
+		return ""
+	}
+	return fmt.Sprintf("credentials:%s:%s", roleARN, profile)
+}
+
+func newKeyringCredentials(kr keyring, key string, creds *credentials.Credentials) *credentials.Credentials {
+	return credentials.NewCredentials(&keyringProvider{keyring: kr, key: key, creds: creds})
+}
+
+// Retrieve implements credentials.Provider
+func (p *keyringProvider) Retrieve() (credentials.Value, error) {
+	if value, err := p.keyring.Get(p.key); err == nil {
+		var cached cachedCredentials
+		if json.Unmarshal([]byte(value), &cached) == nil && time.Now().Add(cachedCredentialsWindow).Before(cached.Expiration) {
+			p.SetExpiration(cached.Expiration, cachedCredentialsWindow)
+			return credentials.Value{
+				AccessKeyID:     cached.AccessKeyID,
+				SecretAccessKey: cached.SecretAccessKey,
+				SessionToken:    cached.SessionToken,
+				ProviderName:    "KeyringProvider",
+			}, nil
+		}
+	}
+
+	value, err := p.creds.Get()
+	if err != nil {
+		return value, err
+	}
+	expires, err := p.creds.ExpiresAt()
+	if err != nil {
+		// long-lived credentials aren't worth caching
+		return value, nil
+	}
+	p.SetExpiration(expires, cachedCredentialsWindow)
+
+	cached, err := json.Marshal(cachedCredentials{
+		AccessKeyID:     value.AccessKeyID,
+		SecretAccessKey: value.SecretAccessKey,
+		SessionToken:    value.SessionToken,
+		Expiration:      expires,
+	})
+	if err == nil {
+		if err := p.keyring.Set(p.key, string(cached)); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: unable to cache credentials in keyring: %s\n", err)
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,135 @@
+package store
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleCredentials(t *testing.T) {
+	sess := session.Must(session.NewSession(&aws.Config{Region: aws.String("us-east-1")}))
+
+	t.Run("Should keep the session's credentials without a role", func(t *testing.T) {
+		creds, err := roleCredentials(sess, RoleOptions{}, nil)
+		assert.Nil(t, err)
+		assert.Nil(t, creds)
+	})
+
+	t.Run("Should require a role for web identities", func(t *testing.T) {
+		_, err := roleCredentials(sess, RoleOptions{WebIdentityTokenFile: "/var/run/token"}, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("Should require a GitHub Actions job for GitHub OIDC", func(t *testing.T) {
+		os.Unsetenv("ACTIONS_ID_TOKEN_REQUEST_URL")
+		_, err := roleCredentials(sess, RoleOptions{RoleARN: "arn:aws:iam::123456789012:role/ci", GitHubOIDC: true}, nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestGitHubOIDCTokenFetcher(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "bearer request-token" || r.URL.Query().Get("audience") != "sts.amazonaws.com" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"value": "oidc-token"}`))
+	}))
+	defer server.Close()
+
+	os.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", server.URL+"/token?api-version=2.0")
+	os.Setenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN", "request-token")
+	defer os.Unsetenv("ACTIONS_ID_TOKEN_REQUEST_URL")
+	defer os.Unsetenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN")
+
+	fetcher, err := newGitHubOIDCTokenFetcher(nil)
+	assert.Nil(t, err)
+	token, err := fetcher.FetchToken(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "oidc-token", string(token))
+}
+
+func TestCredentialProcess(t *testing.T) {
+	ConfigureSessions(SessionOptions{
+		CredentialProcess: `echo '{"Version": 1, "AccessKeyId": "AKID", "SecretAccessKey": "secret", "SessionToken": "token", "Expiration": "2030-01-02T03:04:05Z"}'`,
+	})
+	defer ConfigureSessions(SessionOptions{})
+	os.Setenv(RegionEnvVar, "us-east-1")
+	defer os.Unsetenv(RegionEnvVar)
+
+	value, expires, err := SessionCredentials(1)
+	assert.Nil(t, err)
+	assert.Equal(t, "AKID", value.AccessKeyID)
+	assert.Equal(t, "token", value.SessionToken)
+	assert.Equal(t, 2030, expires.Year())
+}
+
+type memoryKeyring map[string]string
+
+func (k memoryKeyring) Get(key string) (string, error) {
+	value, ok := k[key]
+	if !ok {
+		return "", errKeyringItemNotFound
+	}
+	return value, nil
+}
+
+func (k memoryKeyring) Set(key, value string) error {
+	k[key] = value
+	return nil
+}
+
+type countingProvider struct {
+	credentials.Expiry
+	calls int
+}
+
+func (p *countingProvider) Retrieve() (credentials.Value, error) {
+	p.calls++
+	p.SetExpiration(time.Now().Add(time.Hour), 0)
+	return credentials.Value{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "token"}, nil
+}
+
+func TestKeyringCredentials(t *testing.T) {
+	kr := memoryKeyring{}
+	provider := &countingProvider{}
+	key := "credentials:arn:aws:iam::123456789012:role/app:"
+
+	t.Run("Should cache temporary credentials", func(t *testing.T) {
+		value, err := newKeyringCredentials(kr, key, credentials.NewCredentials(provider)).Get()
+		assert.Nil(t, err)
+		assert.Equal(t, "AKID", value.AccessKeyID)
+		assert.Equal(t, 1, provider.calls)
+		assert.Contains(t, kr, key)
+	})
+
+	t.Run("Should reuse cached credentials in later invocations", func(t *testing.T) {
+		value, err := newKeyringCredentials(kr, key, credentials.NewCredentials(provider)).Get()
+		assert.Nil(t, err)
+		assert.Equal(t, "token", value.SessionToken)
+		assert.Equal(t, 1, provider.calls)
+	})
+
+	t.Run("Should refresh credentials about to expire", func(t *testing.T) {
+		kr[key] = `{"access_key_id": "OLD", "expiration": "` + time.Now().Add(time.Minute).Format(time.RFC3339) + `"}`
+		value, err := newKeyringCredentials(kr, key, credentials.NewCredentials(provider)).Get()
+		assert.Nil(t, err)
+		assert.Equal(t, "AKID", value.AccessKeyID)
+		assert.Equal(t, 2, provider.calls)
+	})
+
+	t.Run("Should not cache long-lived credentials", func(t *testing.T) {
+		static := credentials.NewStaticCredentials("AKID", "secret", "")
+		_, err := newKeyringCredentials(kr, "static", static).Get()
+		assert.Nil(t, err)
+		assert.NotContains(t, kr, "static")
+	})
+}
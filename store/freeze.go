@@ -0,0 +1,232 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"time"
+)
+
+// freezeService is the service under which freezes of other services are
+// recorded, so they work the same way with every backend.
+const freezeService = "_chamber-freeze"
+
+// freezeKey is the key holding the freeze of a service
+const freezeKey = "freeze"
+
+// Freeze blocks all writes to a service, e.g. during a change freeze window.
+type Freeze struct {
+	Reason string `json:"reason"`
+
+	// By is the identity that froze the service
+	By string `json:"by"`
+
+	// Until is when the freeze ends by itself; zero means never
+	Until time.Time `json:"until,omitempty"`
+
+	// Allow lists the identities, as path.Match patterns, that may lift the
+	// freeze before it ends
+	Allow []string `json:"allow"`
+}
+
+// Active reports whether the freeze is still in effect at now
+func (f Freeze) Active(now time.Time) bool {
+	return f.Until.IsZero() || now.Before(f.Until)
+}
+
+// Allows reports whether identity may lift the freeze
+func (f Freeze) Allows(identity string) bool {
+	for _, pattern := range f.Allow {
+		if ok, _ := path.Match(pattern, identity); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// FrozenError is returned when writing to a frozen service
+type FrozenError struct {
+	Service string
+	Freeze  Freeze
+}
+
+func (e *FrozenError) Error() string {
+	until := "unfrozen"
+	if !e.Freeze.Until.IsZero() {
+		until = e.Freeze.Until.Local().Format("2006-01-02 15:04:05")
+	}
+	return fmt.Sprintf("service %s is frozen by %s until %s: %s", e.Service, e.Freeze.By, until, e.Freeze.Reason)
+}
+
+func freezeId(service string) SecretId {
+	sep := "/"
+	if _, noPaths := os.LookupEnv("CHAMBER_NO_PATHS"); noPaths {
+		sep = "."
+	}
+	return SecretId{Service: inRegion(service, func(service string) string { return freezeService + sep + service }), Key: freezeKey}
+}
+
+// ReadFreeze returns the freeze recorded for service, or nil if there is none.
+// Freezes that have ended are returned as well; see Freeze.Active.
+func ReadFreeze(s Store, service string) (*Freeze, error) {
+	secret, err := s.Read(freezeId(service), -1)
+	if err == ErrSecretNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f Freeze
+	if err := json.Unmarshal([]byte(*secret.Value), &f); err != nil {
+		return nil, fmt.Errorf("invalid freeze recorded for %s: %s", service, err)
+	}
+	return &f, nil
+}
+
+// WriteFreeze records f for service
+func WriteFreeze(s Store, service string, f Freeze) error {
+	value, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return WriteReserved(s, freezeId(service), string(value))
+}
+
+// DeleteFreeze removes the freeze recorded for service
+func DeleteFreeze(s Store, service string) error {
+	return DeleteReserved(s, freezeId(service))
+}
+
+var _ Store = &FreezeGuardStore{}
+
+// FreezeGuardStore rejects writes to services with an active Freeze, and
+// writes to the reserved services other than through WriteReserved and
+// DeleteReserved, so freezes can't be lifted or forged with plain writes
+type FreezeGuardStore struct {
+	store Store
+}
+
+// NewFreezeGuardStore returns a store that enforces the freezes recorded in s
+func NewFreezeGuardStore(s Store) *FreezeGuardStore {
+	return &FreezeGuardStore{store: s}
+}
+
+func (s *FreezeGuardStore) checkFrozen(service string) error {
+	if IsReservedService(service) {
+		return &ReservedError{Service: service}
+	}
+	f, err := ReadFreeze(s.store, service)
+	if err != nil {
+		return err
+	}
+	if f != nil && f.Active(time.Now()) {
+		return &FrozenError{Service: service, Freeze: *f}
+	}
+	return nil
+}
+
+func (s *FreezeGuardStore) Write(id SecretId, value string) error {
+	if err := s.checkFrozen(id.Service); err != nil {
+		return err
+	}
+	return s.store.Write(id, value)
+}
+
+func (s *FreezeGuardStore) WriteReserved(id SecretId, value string) error {
+	if !IsReservedService(id.Service) {
+		return &ReservedError{Service: id.Service}
+	}
+	return WriteReserved(s.store, id, value)
+}
+
+func (s *FreezeGuardStore) DeleteReserved(id SecretId) error {
+	if !IsReservedService(id.Service) {
+		return &ReservedError{Service: id.Service}
+	}
+	return DeleteReserved(s.store, id)
+}
+
+func (s *FreezeGuardStore) WriteMany(service string, values map[string]string) error {
+	if err := s.checkFrozen(service); err != nil {
+		return err
+	}
+	return WriteMany(s.store, service, values)
+}
+
+func (s *FreezeGuardStore) Read(id SecretId, version int) (Secret, error) {
+	return s.store.Read(id, version)
+}
+
+func (s *FreezeGuardStore) ReadNativeVersion(id SecretId, version int64) (Secret, error) {
+	return ReadNativeVersion(s.store, id, version)
+}
+
+func (s *FreezeGuardStore) ReadLabel(id SecretId, label string) (Secret, error) {
+	return ReadLabel(s.store, id, label)
+}
+
+func (s *FreezeGuardStore) ListNative(service string) (map[string]NativeAttributes, error) {
+	return ListNative(s.store, service)
+}
+
+func (s *FreezeGuardStore) Rekey(id SecretId, kmsKey string) (bool, error) {
+	if err := s.checkFrozen(id.Service); err != nil {
+		return false, err
+	}
+	return Rekey(s.store, id, kmsKey)
+}
+
+func (s *FreezeGuardStore) List(service string, includeValues bool) ([]Secret, error) {
+	return s.store.List(service, includeValues)
+}
+
+func (s *FreezeGuardStore) ListRaw(service string) ([]RawSecret, error) {
+	return s.store.ListRaw(service)
+}
+
+func (s *FreezeGuardStore) ListServices(service string, includeSecretName bool) ([]string, error) {
+	return s.store.ListServices(service, includeSecretName)
+}
+
+func (s *FreezeGuardStore) StreamList(service string, includeValues bool, fn func([]Secret) error) error {
+	return StreamList(s.store, service, includeValues, fn)
+}
+
+func (s *FreezeGuardStore) StreamServices(service string, includeSecretName bool, fn func([]string) error) error {
+	return StreamServices(s.store, service, includeSecretName, fn)
+}
+
+func (s *FreezeGuardStore) ListRefs(service string) ([]SecretRef, error) {
+	return ListRefs(s.store, service)
+}
+
+func (s *FreezeGuardStore) Capabilities() Capabilities {
+	return CapabilitiesOf(s.store)
+}
+
+func (s *FreezeGuardStore) History(id SecretId) ([]ChangeEvent, error) {
+	return s.store.History(id)
+}
+
+func (s *FreezeGuardStore) Rename(id SecretId, newKey string) error {
+	if err := s.checkFrozen(id.Service); err != nil {
+		return err
+	}
+	return Rename(s.store, id, newKey)
+}
+
+func (s *FreezeGuardStore) Renumber(id SecretId, version int) error {
+	if err := s.checkFrozen(id.Service); err != nil {
+		return err
+	}
+	return Renumber(s.store, id, version)
+}
+
+func (s *FreezeGuardStore) Delete(id SecretId) error {
+	if err := s.checkFrozen(id.Service); err != nil {
+		return err
+	}
+	return s.store.Delete(id)
+}
@@ -0,0 +1,77 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFreeze(t *testing.T) {
+	t.Run("Freezes should end at their deadline", func(t *testing.T) {
+		now := time.Now()
+		assert.True(t, Freeze{}.Active(now))
+		assert.True(t, Freeze{Until: now.Add(time.Hour)}.Active(now))
+		assert.False(t, Freeze{Until: now.Add(-time.Hour)}.Active(now))
+	})
+
+	t.Run("Only allowed identities may lift a freeze", func(t *testing.T) {
+		f := Freeze{Allow: []string{"arn:aws:iam::123456789012:user/alice", "arn:aws:sts::123456789012:assumed-role/release/*"}}
+		assert.True(t, f.Allows("arn:aws:iam::123456789012:user/alice"))
+		assert.True(t, f.Allows("arn:aws:sts::123456789012:assumed-role/release/bob"))
+		assert.False(t, f.Allows("arn:aws:iam::123456789012:user/mallory"))
+	})
+}
+
+func TestFreezeGuardStore(t *testing.T) {
+	mock := &mockSSMClient{parameters: map[string]mockParameter{}}
+	backing := NewTestSSMStore(mock)
+	s := NewFreezeGuardStore(backing)
+	secretId := SecretId{Service: "app/prod", Key: "key"}
+	assert.Nil(t, s.Write(secretId, "before"))
+
+	assert.Nil(t, WriteFreeze(s, "app/prod", Freeze{Reason: "change freeze", By: "alice"}))
+
+	t.Run("Writes to a frozen service should fail", func(t *testing.T) {
+		err := s.Write(secretId, "during")
+		assert.IsType(t, &FrozenError{}, err)
+		assert.Contains(t, err.Error(), "change freeze")
+		assert.IsType(t, &FrozenError{}, s.Delete(secretId))
+		assert.IsType(t, &FrozenError{}, s.Rename(secretId, "other"))
+	})
+
+	t.Run("Plain writes should not lift or forge freezes", func(t *testing.T) {
+		freeze := freezeId("app/prod")
+		assert.IsType(t, &ReservedError{}, s.Delete(freeze))
+		assert.IsType(t, &ReservedError{}, s.Write(freeze, `{"reason":"forged"}`))
+		assert.IsType(t, &ReservedError{}, NewReferenceStore(s).Delete(freeze))
+		assert.IsType(t, &ReservedError{}, s.WriteMany(freeze.Service, map[string]string{"freeze": "{}"}))
+		f, err := ReadFreeze(s, "app/prod")
+		assert.Nil(t, err)
+		assert.Equal(t, "change freeze", f.Reason)
+	})
+
+	t.Run("Reserved writes should only reach reserved services", func(t *testing.T) {
+		assert.IsType(t, &ReservedError{}, WriteReserved(s, secretId, "during"))
+		assert.IsType(t, &ReservedError{}, DeleteReserved(s, secretId))
+	})
+
+	t.Run("Other services should stay writable", func(t *testing.T) {
+		assert.Nil(t, s.Write(SecretId{Service: "app/staging", Key: "key"}, "value"))
+	})
+
+	t.Run("Writes should succeed after unfreezing", func(t *testing.T) {
+		f, err := ReadFreeze(s, "app/prod")
+		assert.Nil(t, err)
+		assert.Equal(t, "alice", f.By)
+
+		assert.Nil(t, DeleteFreeze(s, "app/prod"))
+		assert.Nil(t, s.Write(secretId, "after"))
+	})
+
+	t.Run("Expired freezes should not block writes", func(t *testing.T) {
+		wrapped := NewScrubbingStore(NewReferenceStore(s))
+		assert.Nil(t, WriteFreeze(wrapped, "app/prod", Freeze{Reason: "over", Until: time.Now().Add(-time.Minute)}))
+		assert.Nil(t, s.Write(secretId, "later"))
+	})
+}
@@ -0,0 +1,136 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Renumberer is implemented by stores that can record the current value of a
+// secret again under a version number of the caller's choosing.
+type Renumberer interface {
+	Renumber(id SecretId, version int) error
+}
+
+// Renumber records the current value of the secret id again as version, to
+// repair version numbers that don't follow the history of the secret.
+func Renumber(s Store, id SecretId, version int) error {
+	if r, ok := s.(Renumberer); ok {
+		return r.Renumber(id, version)
+	}
+	return fmt.Errorf("backend can't renumber versions")
+}
+
+// Inconsistency is a disagreement between what chamber records about a key and
+// what the backend holds
+type Inconsistency struct {
+	Id      SecretId
+	Problem string
+
+	// Fixable reports whether Fix can repair the inconsistency. The history of
+	// a key can't be rewritten, so only its current version can be repaired.
+	Fixable bool
+
+	// renumber is the version to record the current value as, or 0 to delete
+	// the record
+	renumber int
+}
+
+// Fix repairs the inconsistency, if it is Fixable
+func (i Inconsistency) Fix(s Store) error {
+	if !i.Fixable {
+		return fmt.Errorf("%s can't be fixed", i.Problem)
+	}
+	if i.renumber == 0 {
+		if IsReservedService(i.Id.Service) {
+			return DeleteReserved(s, i.Id)
+		}
+		return s.Delete(i.Id)
+	}
+	return Renumber(s, i.Id, i.renumber)
+}
+
+// CheckConsistency cross-references the versions chamber recorded for every
+// key of service with the backend's history of the key, and the metadata of
+// service with its keys.
+func CheckConsistency(s Store, service string) ([]Inconsistency, error) {
+	var inconsistencies []Inconsistency
+
+	secrets, err := s.List(service, false)
+	if err != nil {
+		return nil, err
+	}
+	existing := map[string]bool{}
+	for _, secret := range secrets {
+		key := lastSegment(secret.Meta.Key)
+		existing[key] = true
+
+		id := SecretId{Service: service, Key: key}
+		events, err := s.History(id)
+		if err == ErrSecretNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		inconsistencies = append(inconsistencies, checkVersions(id, events)...)
+	}
+
+	metadata, err := ListKeyMetadata(s, service)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		// metadata may be declared ahead of a key's first write, so only
+		// that of deleted keys is left over
+		if !existing[key] && !metadata[key].Deleted.IsZero() {
+			inconsistencies = append(inconsistencies, Inconsistency{
+				Id:      metadataId(service, key),
+				Problem: fmt.Sprintf("metadata of deleted key %s", key),
+				Fixable: true,
+			})
+		}
+	}
+
+	return inconsistencies, nil
+}
+
+// checkVersions checks that the versions of the history of id, oldest first,
+// count up by one from each version to the next
+func checkVersions(id SecretId, events []ChangeEvent) []Inconsistency {
+	var inconsistencies []Inconsistency
+	highest := 0
+	for i, event := range events {
+		latest := i == len(events)-1
+		var problem string
+		switch {
+		case event.Version == 0:
+			problem = fmt.Sprintf("%s: version written without chamber at %s", id.Key, event.Time.Local().Format("2006-01-02 15:04:05"))
+		case event.Version <= highest:
+			problem = fmt.Sprintf("%s: version %d recorded after version %d", id.Key, event.Version, highest)
+		case i > 0 && event.Version > highest+1:
+			// gaps are harmless for the current version, which is still
+			// numbered after every other
+			inconsistencies = append(inconsistencies, Inconsistency{
+				Id:      id,
+				Problem: fmt.Sprintf("%s: versions %d to %d are missing", id.Key, highest+1, event.Version-1),
+			})
+		}
+		if problem != "" {
+			inconsistency := Inconsistency{Id: id, Problem: problem}
+			if latest {
+				inconsistency.Fixable = true
+				inconsistency.renumber = highest + 1
+			}
+			inconsistencies = append(inconsistencies, inconsistency)
+		}
+		if event.Version > highest {
+			highest = event.Version
+		}
+	}
+	return inconsistencies
+}
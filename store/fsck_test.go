@@ -0,0 +1,92 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckVersions(t *testing.T) {
+	id := SecretId{Service: "app", Key: "key"}
+	events := func(versions ...int) []ChangeEvent {
+		result := make([]ChangeEvent, len(versions))
+		for i, v := range versions {
+			result[i] = ChangeEvent{Version: v, Time: time.Now()}
+		}
+		return result
+	}
+
+	t.Run("Should accept consecutive versions", func(t *testing.T) {
+		assert.Empty(t, checkVersions(id, events(1, 2, 3)))
+		assert.Empty(t, checkVersions(id, events(41, 42)))
+	})
+
+	t.Run("Should report missing versions as unfixable", func(t *testing.T) {
+		inconsistencies := checkVersions(id, events(1, 4))
+		assert.Len(t, inconsistencies, 1)
+		assert.Equal(t, "key: versions 2 to 3 are missing", inconsistencies[0].Problem)
+		assert.False(t, inconsistencies[0].Fixable)
+	})
+
+	t.Run("Should renumber a wrong current version", func(t *testing.T) {
+		inconsistencies := checkVersions(id, events(1, 2, 2))
+		assert.Len(t, inconsistencies, 1)
+		assert.True(t, inconsistencies[0].Fixable)
+		assert.Equal(t, 3, inconsistencies[0].renumber)
+
+		inconsistencies = checkVersions(id, events(1, 0, 1))
+		assert.Len(t, inconsistencies, 2)
+		assert.False(t, inconsistencies[0].Fixable)
+		assert.True(t, inconsistencies[1].Fixable)
+	})
+}
+
+func TestCheckConsistency(t *testing.T) {
+	mock := &mockSSMClient{parameters: map[string]mockParameter{}}
+	s := NewTestSSMStoreWithPaths(mock)
+	secretId := SecretId{Service: "app", Key: "key"}
+	assert.Nil(t, s.Write(secretId, "one"))
+	assert.Nil(t, s.Write(secretId, "two"))
+	assert.Nil(t, WriteKeyMetadata(s, "app", "gone", KeyMetadata{Enum: []string{"a"}}))
+	assert.Nil(t, MarkKeyDeleted(s, "app", "gone", time.Now()))
+	assert.Nil(t, WriteKeyMetadata(s, "app", "declared", KeyMetadata{Enum: []string{"b"}}))
+
+	// a write without chamber leaves no version in the description
+	name := s.idToName(secretId)
+	_, err := mock.PutParameter(&ssm.PutParameterInput{Name: aws.String(name), Value: aws.String("three"), Overwrite: aws.Bool(true)})
+	assert.Nil(t, err)
+
+	inconsistencies, err := CheckConsistency(s, "app")
+	assert.Nil(t, err)
+	assert.Len(t, inconsistencies, 2)
+
+	t.Run("Should fix the current version and orphaned metadata", func(t *testing.T) {
+		for _, i := range inconsistencies {
+			assert.True(t, i.Fixable)
+			assert.Nil(t, i.Fix(s))
+		}
+		secret, err := s.Read(secretId, -1)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, secret.Meta.Version)
+		assert.Equal(t, "three", *secret.Value)
+
+		m, err := ReadKeyMetadata(s, "app", "gone")
+		assert.Nil(t, err)
+		assert.Nil(t, m)
+
+		// metadata declared ahead of the key's first write is kept
+		m, err = ReadKeyMetadata(s, "app", "declared")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"b"}, m.Enum)
+	})
+
+	t.Run("Should only report the history that can't be rewritten after fixing", func(t *testing.T) {
+		inconsistencies, err := CheckConsistency(s, "app")
+		assert.Nil(t, err)
+		assert.Len(t, inconsistencies, 1)
+		assert.False(t, inconsistencies[0].Fixable)
+	})
+}
@@ -0,0 +1,142 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Garbage is a record chamber keeps about other secrets or services that no
+// longer serves any purpose
+type Garbage struct {
+	Id     SecretId
+	Reason string
+}
+
+// Collect deletes the garbage from s
+func (g Garbage) Collect(s Store) error {
+	if IsReservedService(g.Id.Service) {
+		return DeleteReserved(s, g.Id)
+	}
+	return s.Delete(g.Id)
+}
+
+// FindGarbage returns the key metadata of keys that were deleted, the keys
+// that have expired or are past the removal date of their deprecation along
+// with their metadata, and the freezes that have ended.
+func FindGarbage(s Store, now time.Time) ([]Garbage, error) {
+	var garbage []Garbage
+
+	metaServices, err := chamberServices(s, metadataService)
+	if err != nil {
+		return nil, err
+	}
+	for _, service := range metaServices {
+		existing := map[string]bool{}
+		secrets, err := s.List(service, false)
+		if err != nil {
+			return nil, err
+		}
+		for _, secret := range secrets {
+			existing[lastSegment(secret.Meta.Key)] = true
+		}
+
+		metadata, err := ListKeyMetadata(s, service)
+		if err != nil {
+			return nil, err
+		}
+		keys := make([]string, 0, len(metadata))
+		for key := range metadata {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			m := metadata[key]
+			switch {
+			case !existing[key] && m.Deleted.IsZero():
+				// declared ahead of the key's first write
+				continue
+			case !existing[key]:
+				garbage = append(garbage, Garbage{
+					Id:     metadataId(service, key),
+					Reason: fmt.Sprintf("metadata of deleted key %s/%s", service, key),
+				})
+			case m.Expired(now):
+				reason := fmt.Sprintf("key %s/%s expired %s", service, key, m.Expires.Local().Format("2006-01-02 15:04:05"))
+				garbage = append(garbage,
+					Garbage{Id: SecretId{Service: service, Key: key}, Reason: reason},
+					Garbage{Id: metadataId(service, key), Reason: reason},
+				)
+			case m.Removable(now):
+				reason := fmt.Sprintf("key %s/%s deprecated until %s", service, key, m.Deprecation.RemoveAfter.Format("2006-01-02"))
+				garbage = append(garbage,
+					Garbage{Id: SecretId{Service: service, Key: key}, Reason: reason},
+					Garbage{Id: metadataId(service, key), Reason: reason},
+				)
+			}
+		}
+	}
+
+	frozenServices, err := chamberServices(s, freezeService)
+	if err != nil {
+		return nil, err
+	}
+	for _, service := range frozenServices {
+		f, err := ReadFreeze(s, service)
+		if err != nil {
+			return nil, err
+		}
+		if f != nil && !f.Active(now) {
+			garbage = append(garbage, Garbage{
+				Id:     freezeId(service),
+				Reason: fmt.Sprintf("freeze of %s ended %s", service, f.Until.Local().Format("2006-01-02 15:04:05")),
+			})
+		}
+	}
+
+	return garbage, nil
+}
+
+// chamberServices returns the services that chamber keeps records about under
+// the given internal service
+func chamberServices(s Store, internal string) ([]string, error) {
+	_, noPaths := os.LookupEnv("CHAMBER_NO_PATHS")
+	names, err := s.ListServices(internal, noPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	sep := "/"
+	if noPaths {
+		sep = "."
+	}
+	unique := map[string]bool{}
+	for _, name := range names {
+		name = strings.TrimPrefix(name, "/")
+		if i := strings.LastIndex(name, sep); noPaths && i >= 0 {
+			// without paths, names include the key
+			name = name[:i]
+		}
+		if service := strings.TrimPrefix(name, internal+sep); service != name {
+			unique[service] = true
+		}
+	}
+
+	services := make([]string, 0, len(unique))
+	for service := range unique {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	return services, nil
+}
+
+// lastSegment returns the key of the full name of a secret
+func lastSegment(name string) string {
+	sep := "/"
+	if _, noPaths := os.LookupEnv("CHAMBER_NO_PATHS"); noPaths {
+		sep = "."
+	}
+	return name[strings.LastIndex(name, sep)+1:]
+}
@@ -0,0 +1,46 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindGarbage(t *testing.T) {
+	mock := &mockSSMClient{parameters: map[string]mockParameter{}}
+	s := NewTestSSMStoreWithPaths(mock)
+	now := time.Now()
+
+	assert.Nil(t, s.Write(SecretId{Service: "app", Key: "log_level"}, "info"))
+	assert.Nil(t, WriteKeyMetadata(s, "app", "log_level", KeyMetadata{Enum: []string{"debug", "info"}}))
+	assert.Nil(t, s.Write(SecretId{Service: "app", Key: "color"}, "red"))
+	assert.Nil(t, WriteKeyMetadata(s, "app", "color", KeyMetadata{Enum: []string{"red", "blue"}}))
+	assert.Nil(t, s.Delete(SecretId{Service: "app", Key: "color"}))
+	assert.Nil(t, MarkKeyDeleted(s, "app", "color", now))
+	assert.Nil(t, WriteKeyMetadata(s, "app", "size", KeyMetadata{Enum: []string{"small", "large"}}))
+	assert.Nil(t, s.Write(SecretId{Service: "app", Key: "ci_token"}, "token"))
+	assert.Nil(t, WriteKeyMetadata(s, "app", "ci_token", KeyMetadata{Expires: now.Add(-time.Minute)}))
+	assert.Nil(t, s.Write(SecretId{Service: "app", Key: "db_pass"}, "old"))
+	assert.Nil(t, WriteKeyMetadata(s, "app", "db_pass", KeyMetadata{Deprecation: &Deprecation{Replacement: "db_password", RemoveAfter: now.Add(-time.Hour)}}))
+	assert.Nil(t, s.Write(SecretId{Service: "app", Key: "db_user"}, "old"))
+	assert.Nil(t, WriteKeyMetadata(s, "app", "db_user", KeyMetadata{Deprecation: &Deprecation{RemoveAfter: now.Add(time.Hour)}}))
+	assert.Nil(t, WriteFreeze(s, "app", Freeze{Reason: "release", Until: now.Add(time.Hour)}))
+	assert.Nil(t, WriteFreeze(s, "api", Freeze{Reason: "release", Until: now.Add(-time.Hour)}))
+
+	garbage, err := FindGarbage(s, now)
+	assert.Nil(t, err)
+
+	ids := []SecretId{}
+	for _, g := range garbage {
+		ids = append(ids, g.Id)
+	}
+	assert.ElementsMatch(t, []SecretId{
+		{Service: "_chamber-meta/app", Key: "color"},
+		{Service: "app", Key: "ci_token"},
+		{Service: "_chamber-meta/app", Key: "ci_token"},
+		{Service: "app", Key: "db_pass"},
+		{Service: "_chamber-meta/app", Key: "db_pass"},
+		{Service: "_chamber-freeze/api", Key: "freeze"},
+	}, ids)
+}
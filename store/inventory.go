@@ -0,0 +1,111 @@
+package store
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// ServiceSummary describes the chamber-managed parameters of one service
+type ServiceSummary struct {
+	Service string    `json:"service"`
+	Keys    int       `json:"keys"`
+	Oldest  time.Time `json:"oldest"`
+	Newest  time.Time `json:"newest"`
+	Owner   string    `json:"owner,omitempty"`
+}
+
+// NewSSMStoreForAccount creates an SSMStore for region, assuming roleARN
+// with the current credentials if it is not empty
+func NewSSMStoreForAccount(numRetries int, minThrottleDelay time.Duration, region, roleARN string) (*SSMStore, error) {
+	session, _, err := getSession(numRetries)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &aws.Config{
+		Retryer: newRetryer(numRetries, minThrottleDelay),
+		Region:  aws.String(region),
+	}
+	if roleARN != "" {
+		config.Credentials = stscreds.NewCredentials(session.Copy(&aws.Config{Region: aws.String(region)}), roleARN)
+	}
+	_, noPaths := os.LookupEnv("CHAMBER_NO_PATHS")
+	return &SSMStore{
+		svc:      ssm.New(session, config),
+		usePaths: !noPaths,
+	}, nil
+}
+
+// Inventory summarizes every service of the store, with its owner in the
+// store's owner registry. Parameters are considered chamber-managed when their
+// description holds a version number, as written by Write; chamber's own
+// records in reserved services are left out.
+func (s *SSMStore) Inventory() ([]ServiceSummary, error) {
+	registry, err := ReadOwnerRegistry(s)
+	if err != nil {
+		return nil, err
+	}
+	summaries := map[string]*ServiceSummary{}
+
+	err = s.svc.DescribeParametersPages(&ssm.DescribeParametersInput{}, func(resp *ssm.DescribeParametersOutput, lastPage bool) bool {
+		for _, meta := range resp.Parameters {
+			if !s.validateName(*meta.Name) || meta.Description == nil {
+				continue
+			}
+			if _, err := strconv.Atoi(*meta.Description); err != nil {
+				continue
+			}
+			secretMeta := parameterMetaToSecretMeta(meta)
+			service := serviceName(secretMeta.Key)
+			if !s.usePaths {
+				service = noPathsServiceName(secretMeta.Key)
+			}
+			if IsReservedService(service) {
+				continue
+			}
+
+			summary, ok := summaries[service]
+			if !ok {
+				summary = &ServiceSummary{Service: service, Oldest: secretMeta.Created, Newest: secretMeta.Created}
+				if owner := registry.Owner(service); owner != nil {
+					summary.Owner = owner.String()
+				}
+				summaries[service] = summary
+			}
+			summary.Keys++
+			if secretMeta.Created.Before(summary.Oldest) {
+				summary.Oldest = secretMeta.Created
+			}
+			if secretMeta.Created.After(summary.Newest) {
+				summary.Newest = secretMeta.Created
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ServiceSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		result = append(result, *summary)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Service < result[j].Service })
+	return result, nil
+}
+
+// noPathsServiceName returns the service of a parameter named service.key
+func noPathsServiceName(name string) string {
+	for i := len(name) - 1; i >= 0; i-- {
+		if name[i] == '.' {
+			return name[:i]
+		}
+	}
+	return name
+}
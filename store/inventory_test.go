@@ -0,0 +1,31 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInventory(t *testing.T) {
+	mock := &mockSSMClient{parameters: map[string]mockParameter{}}
+	s := NewTestSSMStoreWithPaths(mock)
+	assert.Nil(t, s.Write(SecretId{Service: "app/prod", Key: "db_url"}, "postgres://db"))
+	assert.Nil(t, s.Write(SecretId{Service: "app/prod", Key: "api_token"}, "token"))
+	assert.Nil(t, s.Write(SecretId{Service: "worker", Key: "queue_url"}, "sqs://queue"))
+	assert.Nil(t, WriteOwnerRegistry(s, []byte("owners:\n  - prefix: app\n    team: apps\n    slack: \"#apps\"\n")))
+	assert.Nil(t, WriteKeyMetadata(s, "app/prod", "db_url", KeyMetadata{Enum: []string{"postgres://db"}}))
+	// parameters written by other tools have no version in their description
+	mock.PutParameter(&ssm.PutParameterInput{Name: aws.String("/infra/ami_id"), Type: aws.String("String"), Value: aws.String("ami-123")})
+
+	summaries, err := s.Inventory()
+	assert.Nil(t, err)
+	assert.Len(t, summaries, 2)
+	assert.Equal(t, "app/prod", summaries[0].Service)
+	assert.Equal(t, 2, summaries[0].Keys)
+	assert.Equal(t, "apps (#apps)", summaries[0].Owner)
+	assert.Equal(t, "worker", summaries[1].Service)
+	assert.Equal(t, 1, summaries[1].Keys)
+	assert.Equal(t, "", summaries[1].Owner)
+}
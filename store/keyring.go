@@ -0,0 +1,89 @@
+package store
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// keyringService is the service the items of chamber are filed under in the
+// OS keyring
+const keyringService = "chamber"
+
+var errKeyringItemNotFound = errors.New("keyring item not found")
+
+// keyring stores small secrets in the keyring of the OS
+type keyring interface {
+	Get(key string) (string, error)
+	Set(key, value string) error
+}
+
+// newKeyring is replaced in tests
+var newKeyring = func() (keyring, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return macKeychain{}, nil
+	case "linux":
+		if _, err := exec.LookPath("secret-tool"); err != nil {
+			return nil, errors.New("caching requires secret-tool from libsecret")
+		}
+		return secretService{}, nil
+	default:
+		return nil, fmt.Errorf("caching is not supported on %s", runtime.GOOS)
+	}
+}
+
+// macKeychain uses the login keychain through the security tool. Values are
+// passed on stdin, base64 encoded so they need no quoting, to keep them out of
+// the process list.
+type macKeychain struct{}
+
+func (macKeychain) Get(key string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", keyringService, "-a", key, "-w").Output()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return "", errKeyringItemNotFound
+		}
+		return "", err
+	}
+	value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(out)))
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+func (macKeychain) Set(key, value string) error {
+	if strings.ContainsAny(key, "\" \n") {
+		return fmt.Errorf("invalid keyring key %q", key)
+	}
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a \"%s\" -w %s\n",
+		keyringService, key, base64.StdEncoding.EncodeToString([]byte(value))))
+	return cmd.Run()
+}
+
+// secretService uses the Secret Service API, e.g. GNOME Keyring or KWallet,
+// through secret-tool
+type secretService struct{}
+
+func (secretService) Get(key string) (string, error) {
+	out, err := exec.Command("secret-tool", "lookup", "service", keyringService, "account", key).Output()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return "", errKeyringItemNotFound
+		}
+		return "", err
+	}
+	return string(out), nil
+}
+
+func (secretService) Set(key, value string) error {
+	cmd := exec.Command("secret-tool", "store", "--label", keyringService+" "+key, "service", keyringService, "account", key)
+	cmd.Stdin = bytes.NewBufferString(value)
+	return cmd.Run()
+}
@@ -0,0 +1,212 @@
+package store
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+const (
+	layoutLower = "lower"
+	layoutUpper = "upper"
+)
+
+// validLayoutPrefix is the format of the prefix of a Layout, which must only
+// use characters SSM allows in parameter names
+var validLayoutPrefix = regexp.MustCompile(`^[\w\-\./]*$`)
+
+// Layout maps services and keys onto SSM parameter names other than
+// chamber's /service/key, to adopt parameters that follow an existing naming
+// convention, e.g. /application.environment.KEY for service
+// application/environment.
+type Layout struct {
+	// Prefix begins every parameter name
+	Prefix string
+
+	// Separator separates the segments of the service, and the service from
+	// the key
+	Separator string
+
+	// ServiceCase and KeyCase are "lower" or "upper"
+	ServiceCase string
+	KeyCase     string
+}
+
+// DefaultLayout is chamber's own layout, /service/key
+var DefaultLayout = Layout{Prefix: "/", Separator: "/", ServiceCase: layoutLower, KeyCase: layoutLower}
+
+// ParseLayout parses a layout spec of comma-separated settings, such as
+// prefix=/,separator=.,key-case=upper. Settings left out keep their default.
+func ParseLayout(spec string) (Layout, error) {
+	l := DefaultLayout
+	for _, setting := range strings.Split(spec, ",") {
+		parts := strings.SplitN(setting, "=", 2)
+		if len(parts) != 2 {
+			return l, fmt.Errorf("invalid layout setting %q; must be name=value", setting)
+		}
+		name, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		switch name {
+		case "prefix":
+			if !validLayoutPrefix.MatchString(value) {
+				return l, fmt.Errorf("invalid layout prefix %q", value)
+			}
+			l.Prefix = value
+		case "separator":
+			if value != "/" && value != "." && value != "-" {
+				return l, fmt.Errorf("invalid layout separator %q; must be /, . or -", value)
+			}
+			l.Separator = value
+		case "service-case", "key-case":
+			if value != layoutLower && value != layoutUpper {
+				return l, fmt.Errorf("invalid layout %s %q; must be lower or upper", name, value)
+			}
+			if name == "service-case" {
+				l.ServiceCase = value
+			} else {
+				l.KeyCase = value
+			}
+		default:
+			return l, fmt.Errorf("unknown layout setting %q", name)
+		}
+	}
+	return l, nil
+}
+
+func applyCase(s, c string) string {
+	if c == layoutUpper {
+		return strings.ToUpper(s)
+	}
+	return s
+}
+
+// servicePrefix is the beginning of the names of the keys of service, and of
+// the services nested in it
+func (l Layout) servicePrefix(service string) string {
+	return l.Prefix + applyCase(strings.Replace(service, "/", l.Separator, -1), l.ServiceCase)
+}
+
+// name returns the parameter name of id
+func (l Layout) name(id SecretId) string {
+	return l.servicePrefix(id.Service) + l.Separator + applyCase(id.Key, l.KeyCase)
+}
+
+// id returns the secret a parameter name belongs to, or false if the name
+// doesn't follow the layout
+func (l Layout) id(name string) (SecretId, bool) {
+	if !strings.HasPrefix(name, l.Prefix) {
+		return SecretId{}, false
+	}
+	rest := name[len(l.Prefix):]
+	i := strings.LastIndex(rest, l.Separator)
+	if i <= 0 || i == len(rest)-1 {
+		return SecretId{}, false
+	}
+	id := SecretId{
+		Service: strings.ToLower(strings.Replace(rest[:i], l.Separator, "/", -1)),
+		Key:     strings.ToLower(rest[i+1:]),
+	}
+	if !validPathKeyFormat.MatchString("/" + id.Service + "/" + id.Key) {
+		return SecretId{}, false
+	}
+	return id, true
+}
+
+// SetLayout names parameters after l instead of chamber's default layout.
+// Secrets are still reported under chamber's /service/key names.
+func (s *SSMStore) SetLayout(l Layout) error {
+	if !s.usePaths {
+		return fmt.Errorf("layouts require path-based services; unset CHAMBER_NO_PATHS")
+	}
+	if l == DefaultLayout {
+		s.layout = nil
+		return nil
+	}
+	s.layout = &l
+	return nil
+}
+
+// secretKey returns the key chamber reports for the parameter name: the
+// name itself in the default layout, or the /service/key it maps to
+func (s *SSMStore) secretKey(name string) string {
+	if s.layout == nil {
+		return name
+	}
+	if id, ok := s.layout.id(name); ok {
+		return fmt.Sprintf("/%s/%s", id.Service, id.Key)
+	}
+	return name
+}
+
+// listWithLayout lists the keys of service whose names follow the layout.
+// Unlike paths, the names of a service's keys share a prefix with those of
+// the services nested in it, which are left out by their service.
+func (s *SSMStore) listWithLayout(service string, includeValues bool) ([]Secret, error) {
+	parameters, err := s.describeParametersWithPrefix(s.layout.servicePrefix(service) + s.layout.Separator)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := map[string]Secret{}
+	for _, meta := range parameters {
+		if id, ok := s.layout.id(*meta.Name); !ok || id.Service != service {
+			continue
+		}
+		secretMeta := parameterMetaToSecretMeta(meta)
+		secrets[secretMeta.Key] = Secret{Meta: secretMeta}
+	}
+
+	if includeValues {
+		names := keys(secrets)
+		for i := 0; i < len(names); i += 10 {
+			end := i + 10
+			if end > len(names) {
+				end = len(names)
+			}
+			resp, err := s.svc.GetParameters(&ssm.GetParametersInput{
+				Names:          stringsToAWSStrings(names[i:end]),
+				WithDecryption: aws.Bool(true),
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, param := range resp.Parameters {
+				secret := secrets[*param.Name]
+				secret.Value = param.Value
+				secrets[*param.Name] = secret
+			}
+		}
+	}
+
+	result := make([]Secret, 0, len(secrets))
+	for _, secret := range secrets {
+		secret.Meta.Key = s.secretKey(secret.Meta.Key)
+		result = append(result, secret)
+	}
+	return result, nil
+}
+
+// listServicesWithLayout lists the services beginning with service whose
+// names follow the layout
+func (s *SSMStore) listServicesWithLayout(service string, includeSecretName bool) ([]string, error) {
+	parameters, err := s.describeParametersWithPrefix(s.layout.servicePrefix(service))
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, meta := range parameters {
+		id, ok := s.layout.id(*meta.Name)
+		if !ok {
+			continue
+		}
+		if includeSecretName {
+			names = append(names, fmt.Sprintf("/%s/%s", id.Service, id.Key))
+		} else {
+			names = append(names, id.Service)
+		}
+	}
+	return uniqueStringSlice(names), nil
+}
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLayout(t *testing.T) {
+	t.Run("Should keep defaults for settings left out", func(t *testing.T) {
+		l, err := ParseLayout("separator=.,key-case=upper")
+		assert.Nil(t, err)
+		assert.Equal(t, Layout{Prefix: "/", Separator: ".", ServiceCase: "lower", KeyCase: "upper"}, l)
+	})
+
+	t.Run("Should reject invalid settings", func(t *testing.T) {
+		for _, spec := range []string{"separator=_", "key-case=title", "prefix=/a b", "color=red", "prefix"} {
+			_, err := ParseLayout(spec)
+			assert.Error(t, err, spec)
+		}
+	})
+}
+
+func TestLayoutNames(t *testing.T) {
+	l := Layout{Prefix: "/", Separator: ".", ServiceCase: "lower", KeyCase: "upper"}
+
+	t.Run("Should name parameters after the layout", func(t *testing.T) {
+		assert.Equal(t, "/application.environment.DB_PASSWORD", l.name(SecretId{Service: "application/environment", Key: "db_password"}))
+	})
+
+	t.Run("Should map names back to services and keys", func(t *testing.T) {
+		id, ok := l.id("/application.environment.DB_PASSWORD")
+		assert.True(t, ok)
+		assert.Equal(t, SecretId{Service: "application/environment", Key: "db_password"}, id)
+	})
+
+	t.Run("Should reject names that don't follow the layout", func(t *testing.T) {
+		for _, name := range []string{"application.environment.KEY", "/KEY", "/app.", "/app..KEY"} {
+			_, ok := l.id(name)
+			assert.False(t, ok, name)
+		}
+	})
+}
+
+func TestSSMStoreWithLayout(t *testing.T) {
+	mock := &mockSSMClient{parameters: map[string]mockParameter{}}
+	s := NewTestSSMStoreWithPaths(mock)
+	assert.Nil(t, s.SetLayout(Layout{Prefix: "/", Separator: ".", ServiceCase: "lower", KeyCase: "upper"}))
+
+	assert.Nil(t, s.Write(SecretId{Service: "app/prod", Key: "db_password"}, "hunter2"))
+	assert.Nil(t, s.Write(SecretId{Service: "app/prod", Key: "db_password"}, "hunter22"))
+	assert.Nil(t, s.Write(SecretId{Service: "app/prod/worker", Key: "queue"}, "jobs"))
+
+	t.Run("Should write parameters named after the layout", func(t *testing.T) {
+		_, ok := mock.parameters["/app.prod.DB_PASSWORD"]
+		assert.True(t, ok)
+	})
+
+	t.Run("Should read under chamber's names", func(t *testing.T) {
+		secret, err := s.Read(SecretId{Service: "app/prod", Key: "db_password"}, -1)
+		assert.Nil(t, err)
+		assert.Equal(t, "hunter22", *secret.Value)
+		assert.Equal(t, 2, secret.Meta.Version)
+		assert.Equal(t, "/app/prod/db_password", secret.Meta.Key)
+
+		secret, err = s.Read(SecretId{Service: "app/prod", Key: "db_password"}, 1)
+		assert.Nil(t, err)
+		assert.Equal(t, "hunter2", *secret.Value)
+		assert.Equal(t, "/app/prod/db_password", secret.Meta.Key)
+	})
+
+	t.Run("Should list only the keys of the service", func(t *testing.T) {
+		rawSecrets, err := s.ListRaw("app/prod")
+		assert.Nil(t, err)
+		assert.Equal(t, []RawSecret{{Key: "/app/prod/db_password", Value: "hunter22"}}, rawSecrets)
+	})
+
+	t.Run("Should list services", func(t *testing.T) {
+		services, err := s.ListServices("app", false)
+		assert.Nil(t, err)
+		sort.Strings(services)
+		assert.Equal(t, []string{"app/prod", "app/prod/worker"}, services)
+	})
+
+	t.Run("Should reject labels", func(t *testing.T) {
+		_, err := s.ListRaw("/app/prod:current")
+		assert.Error(t, err)
+	})
+}
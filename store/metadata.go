@@ -0,0 +1,213 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// metadataService is the service under which the metadata of the keys of
+// other services is recorded, so it works the same way with every backend.
+const metadataService = "_chamber-meta"
+
+// KeyMetadata describes the values a key may hold
+type KeyMetadata struct {
+	// Enum lists the values the key may hold; any value is allowed if empty
+	Enum []string `json:"enum,omitempty"`
+
+	// Expires is when the key stops being served by read and exec, and
+	// becomes garbage; zero means never
+	Expires time.Time `json:"expires,omitempty"`
+
+	// Deprecation marks the key as replaced by another; nil unless deprecated
+	Deprecation *Deprecation `json:"deprecation,omitempty"`
+
+	// Imported records the history of the key before it was migrated to
+	// chamber, since backends can't be told when a version was created
+	Imported *ImportedHistory `json:"imported,omitempty"`
+
+	// Deleted is when the key was deleted, which leaves the rest of the
+	// metadata as garbage. It's zero while the key exists, and for metadata
+	// declared before the key is first written.
+	Deleted time.Time `json:"deleted,omitempty"`
+}
+
+// ImportedHistory is the history of a key in the backend it was migrated from
+type ImportedHistory struct {
+	// Source identifies where the key was migrated from, e.g. vault:secret/app
+	Source string `json:"source"`
+
+	// Versions are the source versions replayed into chamber, oldest first
+	Versions []ImportedVersion `json:"versions"`
+}
+
+// ImportedVersion is one version of a key in the backend it was migrated from
+type ImportedVersion struct {
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+}
+
+// Deprecation records that consumers of a key should move to another key
+type Deprecation struct {
+	// Replacement is the key that replaces the deprecated key, if any
+	Replacement string `json:"replacement,omitempty"`
+
+	// RemoveAfter is when the key may be deleted; zero means never
+	RemoveAfter time.Time `json:"remove_after,omitempty"`
+}
+
+// String describes the deprecation for warnings
+func (d Deprecation) String() string {
+	s := "deprecated"
+	if d.Replacement != "" {
+		s += ", use " + d.Replacement + " instead"
+	}
+	if !d.RemoveAfter.IsZero() {
+		s += "; it will be removed after " + d.RemoveAfter.Format("2006-01-02")
+	}
+	return s
+}
+
+// Removable reports whether the key is deprecated and may be deleted at now
+func (m KeyMetadata) Removable(now time.Time) bool {
+	return m.Deprecation != nil && !m.Deprecation.RemoveAfter.IsZero() && now.After(m.Deprecation.RemoveAfter)
+}
+
+// Allows reports whether value may be written to the key
+func (m KeyMetadata) Allows(value string) bool {
+	if len(m.Enum) == 0 {
+		return true
+	}
+	for _, allowed := range m.Enum {
+		if value == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// Empty reports whether m records nothing, so it needn't be kept
+func (m KeyMetadata) Empty() bool {
+	return len(m.Enum) == 0 && m.Expires.IsZero() && m.Deprecation == nil && m.Imported == nil
+}
+
+// Expired reports whether the key has expired at now
+func (m KeyMetadata) Expired(now time.Time) bool {
+	return !m.Expires.IsZero() && !now.Before(m.Expires)
+}
+
+// ExpiredError is returned when reading a key that has expired
+type ExpiredError struct {
+	Service string
+	Key     string
+	Expires time.Time
+}
+
+func (e *ExpiredError) Error() string {
+	return fmt.Sprintf("%s/%s expired at %s", e.Service, e.Key, e.Expires.Local().Format("2006-01-02 15:04:05"))
+}
+
+func metadataId(service, key string) SecretId {
+	return reservedId(metadataService, service, key)
+}
+
+// ReadKeyMetadata returns the metadata recorded for key of service, or nil if
+// there is none.
+func ReadKeyMetadata(s Store, service, key string) (*KeyMetadata, error) {
+	secret, err := s.Read(metadataId(service, key), -1)
+	if err == ErrSecretNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var m KeyMetadata
+	if err := json.Unmarshal([]byte(*secret.Value), &m); err != nil {
+		return nil, fmt.Errorf("invalid metadata recorded for %s/%s: %s", service, key, err)
+	}
+	return &m, nil
+}
+
+// WriteKeyMetadata records m for key of service. Metadata written again
+// applies to the key from now on, even if it was deleted.
+func WriteKeyMetadata(s Store, service, key string, m KeyMetadata) error {
+	m.Deleted = time.Time{}
+	return writeKeyMetadata(s, service, key, m)
+}
+
+// MarkKeyDeleted records that key of service was deleted at now in its
+// metadata, if it has any, so that gc can tell the metadata of deleted keys
+// from metadata declared ahead of a key's first write
+func MarkKeyDeleted(s Store, service, key string, now time.Time) error {
+	m, err := ReadKeyMetadata(s, service, key)
+	if err != nil || m == nil {
+		return err
+	}
+	m.Deleted = now.UTC()
+	return writeKeyMetadata(s, service, key, *m)
+}
+
+func writeKeyMetadata(s Store, service, key string, m KeyMetadata) error {
+	value, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return WriteReserved(s, metadataId(service, key), string(value))
+}
+
+// RenameKeyMetadata moves the metadata recorded for key of service to newKey,
+// as renaming a key leaves its metadata behind
+func RenameKeyMetadata(s Store, service, key, newKey string) error {
+	m, err := ReadKeyMetadata(s, service, key)
+	if err != nil || m == nil {
+		return err
+	}
+	if err := WriteKeyMetadata(s, service, newKey, *m); err != nil {
+		return err
+	}
+	return DeleteKeyMetadata(s, service, key)
+}
+
+// DeleteKeyMetadata removes the metadata recorded for key of service
+func DeleteKeyMetadata(s Store, service, key string) error {
+	return DeleteReserved(s, metadataId(service, key))
+}
+
+// RekeyKeyMetadata re-encrypts the metadata recorded for key of service under
+// kmsKey, see Rekey
+func RekeyKeyMetadata(s Store, service, key, kmsKey string) (bool, error) {
+	return Rekey(s, metadataId(service, key), kmsKey)
+}
+
+// ListKeyMetadata returns the metadata recorded for the keys of service, by
+// key, with a single listing
+func ListKeyMetadata(s Store, service string) (map[string]KeyMetadata, error) {
+	service, _ = parseServiceLabel(service)
+	rawSecrets, err := s.ListRaw(metadataId(service, "").Service)
+	if err != nil {
+		return nil, err
+	}
+	metadata := make(map[string]KeyMetadata, len(rawSecrets))
+	for _, rawSecret := range rawSecrets {
+		key := lastSegment(rawSecret.Key)
+		var m KeyMetadata
+		if err := json.Unmarshal([]byte(rawSecret.Value), &m); err != nil {
+			return nil, fmt.Errorf("invalid metadata recorded for %s/%s: %s", service, key, err)
+		}
+		metadata[key] = m
+	}
+	return metadata, nil
+}
+
+// CheckExpiry returns an ExpiredError if key of service has expired at now
+func CheckExpiry(s Store, service, key string, now time.Time) error {
+	m, err := ReadKeyMetadata(s, service, key)
+	if err != nil {
+		return err
+	}
+	if m != nil && m.Expired(now) {
+		return &ExpiredError{Service: service, Key: key, Expires: m.Expires}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyMetadata(t *testing.T) {
+	mock := &mockSSMClient{parameters: map[string]mockParameter{}}
+	s := NewTestSSMStore(mock)
+
+	t.Run("Keys without metadata should allow any value", func(t *testing.T) {
+		m, err := ReadKeyMetadata(s, "app", "log_level")
+		assert.Nil(t, err)
+		assert.Nil(t, m)
+		assert.True(t, KeyMetadata{}.Allows("anything"))
+	})
+
+	t.Run("Enumerated keys should only allow their values", func(t *testing.T) {
+		assert.Nil(t, WriteKeyMetadata(s, "app", "log_level", KeyMetadata{Enum: []string{"debug", "info"}}))
+		m, err := ReadKeyMetadata(s, "app", "log_level")
+		assert.Nil(t, err)
+		assert.True(t, m.Allows("info"))
+		assert.False(t, m.Allows("verbose"))
+	})
+
+	t.Run("Deleted metadata should no longer apply", func(t *testing.T) {
+		assert.Nil(t, DeleteKeyMetadata(s, "app", "log_level"))
+		m, err := ReadKeyMetadata(s, "app", "log_level")
+		assert.Nil(t, err)
+		assert.Nil(t, m)
+	})
+
+	t.Run("Keys should expire at their deadline", func(t *testing.T) {
+		s := NewTestSSMStoreWithPaths(&mockSSMClient{parameters: map[string]mockParameter{}})
+		now := time.Now()
+		assert.False(t, KeyMetadata{}.Expired(now))
+		assert.False(t, KeyMetadata{Expires: now.Add(time.Hour)}.Expired(now))
+		assert.True(t, KeyMetadata{Expires: now}.Expired(now))
+
+		assert.Nil(t, WriteKeyMetadata(s, "app", "ci_token", KeyMetadata{Expires: now.Add(time.Hour)}))
+		assert.Nil(t, CheckExpiry(s, "app", "ci_token", now))
+		assert.IsType(t, &ExpiredError{}, CheckExpiry(s, "app", "ci_token", now.Add(2*time.Hour)))
+
+		metadata, err := ListKeyMetadata(s, "app")
+		assert.Nil(t, err)
+		assert.True(t, metadata["ci_token"].Expired(now.Add(2*time.Hour)))
+	})
+
+	t.Run("Metadata should follow renamed keys", func(t *testing.T) {
+		expires := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+		assert.Nil(t, WriteKeyMetadata(s, "app", "old_token", KeyMetadata{Expires: expires}))
+		assert.Nil(t, RenameKeyMetadata(s, "app", "old_token", "new_token"))
+		m, err := ReadKeyMetadata(s, "app", "new_token")
+		assert.Nil(t, err)
+		assert.Equal(t, expires, m.Expires)
+		m, err = ReadKeyMetadata(s, "app", "old_token")
+		assert.Nil(t, err)
+		assert.Nil(t, m)
+
+		assert.Nil(t, RenameKeyMetadata(s, "app", "undeclared", "other"))
+	})
+
+	t.Run("Metadata should record deleted keys until written again", func(t *testing.T) {
+		now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+		assert.Nil(t, MarkKeyDeleted(s, "app", "undeclared", now))
+		m, err := ReadKeyMetadata(s, "app", "undeclared")
+		assert.Nil(t, err)
+		assert.Nil(t, m)
+
+		assert.Nil(t, WriteKeyMetadata(s, "app", "color", KeyMetadata{Enum: []string{"red"}}))
+		assert.Nil(t, MarkKeyDeleted(s, "app", "color", now))
+		m, err = ReadKeyMetadata(s, "app", "color")
+		assert.Nil(t, err)
+		assert.Equal(t, now, m.Deleted)
+		assert.Equal(t, []string{"red"}, m.Enum)
+
+		assert.Nil(t, WriteKeyMetadata(s, "app", "color", *m))
+		m, err = ReadKeyMetadata(s, "app", "color")
+		assert.Nil(t, err)
+		assert.True(t, m.Deleted.IsZero())
+	})
+
+	t.Run("Metadata recording nothing should be empty", func(t *testing.T) {
+		assert.True(t, KeyMetadata{}.Empty())
+		assert.False(t, KeyMetadata{Enum: []string{"a"}}.Empty())
+		assert.False(t, KeyMetadata{Expires: time.Now()}.Empty())
+		assert.False(t, KeyMetadata{Deprecation: &Deprecation{}}.Empty())
+	})
+
+	t.Run("Deprecated keys should be removable after their removal date", func(t *testing.T) {
+		now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+		d := &Deprecation{Replacement: "db_password", RemoveAfter: now}
+		assert.False(t, KeyMetadata{}.Removable(now))
+		assert.False(t, KeyMetadata{Deprecation: &Deprecation{}}.Removable(now))
+		assert.False(t, KeyMetadata{Deprecation: d}.Removable(now))
+		assert.True(t, KeyMetadata{Deprecation: d}.Removable(now.Add(time.Hour)))
+		assert.Equal(t, "deprecated, use db_password instead; it will be removed after 2025-03-01", d.String())
+	})
+}
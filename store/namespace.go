@@ -0,0 +1,197 @@
+package store
+
+import (
+	"os"
+	"strings"
+)
+
+var _ Store = &NamespacedStore{}
+
+// NamespacedStore prefixes every service with a namespace before handing it
+// to the underlying store, and removes the namespace from the keys and
+// services it returns, so callers never see the full path.
+type NamespacedStore struct {
+	store     Store
+	namespace string
+	sep       string
+}
+
+// NewNamespacedStore returns a store that keeps all services of s under
+// namespace, e.g. "tenants/acme".
+func NewNamespacedStore(s Store, namespace string) *NamespacedStore {
+	sep := "/"
+	if _, noPaths := os.LookupEnv("CHAMBER_NO_PATHS"); noPaths {
+		sep = "."
+	}
+	return &NamespacedStore{
+		store:     s,
+		namespace: strings.Trim(namespace, sep),
+		sep:       sep,
+	}
+}
+
+func (s *NamespacedStore) service(service string) string {
+	return Namespaced(s.namespace, service)
+}
+
+// Namespaced returns the name of service inside namespace, as
+// NamespacedStore names it for the underlying store
+func Namespaced(namespace, service string) string {
+	sep := "/"
+	if _, noPaths := os.LookupEnv("CHAMBER_NO_PATHS"); noPaths {
+		sep = "."
+	}
+	return strings.Trim(namespace, sep) + sep + service
+}
+
+func (s *NamespacedStore) id(id SecretId) SecretId {
+	return SecretId{Service: s.service(id.Service), Key: id.Key}
+}
+
+// strip removes the namespace from a service or key name, with or without a
+// leading separator, and reports whether name was inside the namespace.
+func (s *NamespacedStore) strip(name string) (string, bool) {
+	prefix := s.namespace + s.sep
+	if strings.HasPrefix(name, s.sep+prefix) {
+		return s.sep + strings.TrimPrefix(name, s.sep+prefix), true
+	}
+	if strings.HasPrefix(name, prefix) {
+		return strings.TrimPrefix(name, prefix), true
+	}
+	return name, false
+}
+
+func (s *NamespacedStore) Write(id SecretId, value string) error {
+	return s.store.Write(s.id(id), value)
+}
+
+func (s *NamespacedStore) WriteMany(service string, values map[string]string) error {
+	return WriteMany(s.store, s.service(service), values)
+}
+
+func (s *NamespacedStore) Read(id SecretId, version int) (Secret, error) {
+	secret, err := s.store.Read(s.id(id), version)
+	if err != nil {
+		return secret, err
+	}
+	secret.Meta.Key, _ = s.strip(secret.Meta.Key)
+	return secret, nil
+}
+
+func (s *NamespacedStore) ReadNativeVersion(id SecretId, version int64) (Secret, error) {
+	secret, err := ReadNativeVersion(s.store, s.id(id), version)
+	if err != nil {
+		return secret, err
+	}
+	secret.Meta.Key, _ = s.strip(secret.Meta.Key)
+	return secret, nil
+}
+
+func (s *NamespacedStore) ReadLabel(id SecretId, label string) (Secret, error) {
+	secret, err := ReadLabel(s.store, s.id(id), label)
+	if err != nil {
+		return secret, err
+	}
+	secret.Meta.Key, _ = s.strip(secret.Meta.Key)
+	return secret, nil
+}
+
+func (s *NamespacedStore) ListNative(service string) (map[string]NativeAttributes, error) {
+	return ListNative(s.store, s.service(service))
+}
+
+func (s *NamespacedStore) Rekey(id SecretId, kmsKey string) (bool, error) {
+	return Rekey(s.store, s.id(id), kmsKey)
+}
+
+func (s *NamespacedStore) List(service string, includeValues bool) ([]Secret, error) {
+	secrets, err := s.store.List(s.service(service), includeValues)
+	if err != nil {
+		return nil, err
+	}
+	for i := range secrets {
+		secrets[i].Meta.Key, _ = s.strip(secrets[i].Meta.Key)
+	}
+	return secrets, nil
+}
+
+func (s *NamespacedStore) ListRaw(service string) ([]RawSecret, error) {
+	if IsSecretsManagerReference(service) {
+		// AWS keeps these at a fixed path, outside any namespace
+		return s.store.ListRaw(service)
+	}
+	secrets, err := s.store.ListRaw(s.service(service))
+	if err != nil {
+		return nil, err
+	}
+	for i := range secrets {
+		secrets[i].Key, _ = s.strip(secrets[i].Key)
+	}
+	return secrets, nil
+}
+
+func (s *NamespacedStore) ListServices(service string, includeSecretName bool) ([]string, error) {
+	names, err := s.store.ListServices(s.service(service), includeSecretName)
+	if err != nil {
+		return nil, err
+	}
+	stripped := make([]string, 0, len(names))
+	for _, name := range names {
+		if name, ok := s.strip(name); ok {
+			stripped = append(stripped, name)
+		}
+	}
+	return stripped, nil
+}
+
+func (s *NamespacedStore) StreamList(service string, includeValues bool, fn func([]Secret) error) error {
+	return StreamList(s.store, s.service(service), includeValues, func(secrets []Secret) error {
+		for i := range secrets {
+			secrets[i].Meta.Key, _ = s.strip(secrets[i].Meta.Key)
+		}
+		return fn(secrets)
+	})
+}
+
+func (s *NamespacedStore) StreamServices(service string, includeSecretName bool, fn func([]string) error) error {
+	return StreamServices(s.store, s.service(service), includeSecretName, func(names []string) error {
+		stripped := make([]string, 0, len(names))
+		for _, name := range names {
+			if name, ok := s.strip(name); ok {
+				stripped = append(stripped, name)
+			}
+		}
+		return fn(stripped)
+	})
+}
+
+func (s *NamespacedStore) ListRefs(service string) ([]SecretRef, error) {
+	refs, err := ListRefs(s.store, s.service(service))
+	if err != nil {
+		return nil, err
+	}
+	for i := range refs {
+		refs[i].Key, _ = s.strip(refs[i].Key)
+	}
+	return refs, nil
+}
+
+func (s *NamespacedStore) Capabilities() Capabilities {
+	return CapabilitiesOf(s.store)
+}
+
+func (s *NamespacedStore) History(id SecretId) ([]ChangeEvent, error) {
+	return s.store.History(s.id(id))
+}
+
+func (s *NamespacedStore) Rename(id SecretId, newKey string) error {
+	return Rename(s.store, s.id(id), newKey)
+}
+
+func (s *NamespacedStore) Renumber(id SecretId, version int) error {
+	return Renumber(s.store, s.id(id), version)
+}
+
+func (s *NamespacedStore) Delete(id SecretId) error {
+	return s.store.Delete(s.id(id))
+}
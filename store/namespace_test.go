@@ -0,0 +1,42 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespacedStore(t *testing.T) {
+	backing := NewStaticStore(map[string]map[string]string{
+		"tenants/acme/app":  {"db_password": "acme"},
+		"tenants/other/app": {"db_password": "other"},
+	})
+	s := NewNamespacedStore(backing, "tenants/acme/")
+
+	t.Run("Should read from the namespaced service", func(t *testing.T) {
+		secret, err := s.Read(SecretId{Service: "app", Key: "db_password"}, -1)
+		assert.Nil(t, err)
+		assert.Equal(t, "acme", *secret.Value)
+		assert.Equal(t, "/app/db_password", secret.Meta.Key)
+	})
+
+	t.Run("Should hide the namespace from listed keys", func(t *testing.T) {
+		secrets, err := s.List("app", false)
+		assert.Nil(t, err)
+		assert.Equal(t, "/app/db_password", secrets[0].Meta.Key)
+
+		raw, err := s.ListRaw("app")
+		assert.Nil(t, err)
+		assert.Equal(t, []RawSecret{{Key: "/app/db_password", Value: "acme"}}, raw)
+	})
+
+	t.Run("Should only list services inside the namespace", func(t *testing.T) {
+		services, err := s.ListServices("", false)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"app"}, services)
+
+		names, err := s.ListServices("app", true)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"app/db_password"}, names)
+	})
+}
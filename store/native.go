@@ -0,0 +1,91 @@
+package store
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// NativeAttributes are what the backend itself records about a secret,
+// beyond the metadata chamber keeps
+type NativeAttributes struct {
+	// Type is the SSM parameter type, e.g. SecureString
+	Type string `json:"type,omitempty"`
+
+	// Tier is the SSM parameter tier, e.g. Standard or Advanced
+	Tier string `json:"tier,omitempty"`
+
+	// KMSKeyId is the KMS key the value is encrypted with
+	KMSKeyId string `json:"kms_key_id,omitempty"`
+
+	// DataType is the SSM data type, e.g. text or aws:ec2:image
+	DataType string `json:"data_type,omitempty"`
+
+	// ParameterVersion is the backend's own version, which can drift from
+	// chamber's
+	ParameterVersion int64 `json:"parameter_version,omitempty"`
+
+	// Policies are the SSM parameter policies, as JSON
+	Policies []string `json:"policies,omitempty"`
+}
+
+// NativeLister is implemented by stores that can describe the backend's own
+// attributes of secrets
+type NativeLister interface {
+	// ListNative returns the native attributes of the secrets of service,
+	// by key
+	ListNative(service string) (map[string]NativeAttributes, error)
+}
+
+// ListNative returns the native attributes of the secrets of service by key,
+// see NativeLister
+func ListNative(s Store, service string) (map[string]NativeAttributes, error) {
+	if l, ok := s.(NativeLister); ok {
+		return l.ListNative(service)
+	}
+	return nil, errors.New("backend has no native attributes")
+}
+
+var _ NativeLister = &SSMStore{}
+
+// ListNative describes the parameters of service, in the same calls List
+// makes
+func (s *SSMStore) ListNative(serviceName string) (map[string]NativeAttributes, error) {
+	service, _ := parseServiceLabel(serviceName)
+	if s.layout != nil {
+		return nil, errors.New("native attributes require chamber's default layout")
+	}
+
+	sep := "."
+	if s.usePaths {
+		sep = "/"
+	}
+	attributes := map[string]NativeAttributes{}
+	err := s.svc.DescribeParametersPages(s.listInput(service), func(resp *ssm.DescribeParametersOutput, lastPage bool) bool {
+		for _, meta := range resp.Parameters {
+			if !s.validateName(*meta.Name) {
+				continue
+			}
+			var policies []string
+			for _, policy := range meta.Policies {
+				policies = append(policies, aws.StringValue(policy.PolicyText))
+			}
+			key := (*meta.Name)[strings.LastIndex(*meta.Name, sep)+1:]
+			attributes[key] = NativeAttributes{
+				Type:             aws.StringValue(meta.Type),
+				Tier:             aws.StringValue(meta.Tier),
+				KMSKeyId:         aws.StringValue(meta.KeyId),
+				DataType:         aws.StringValue(meta.DataType),
+				ParameterVersion: aws.Int64Value(meta.Version),
+				Policies:         policies,
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return attributes, nil
+}
@@ -0,0 +1,39 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListNative(t *testing.T) {
+	mock := &mockSSMClient{parameters: map[string]mockParameter{}}
+	s := NewTestSSMStoreWithPaths(mock)
+	assert.Nil(t, s.Write(SecretId{Service: "app", Key: "db_password"}, "hunter2"))
+	assert.Nil(t, s.Write(SecretId{Service: "app", Key: "api_key"}, "abc"))
+	assert.Nil(t, s.Write(SecretId{Service: "other", Key: "token"}, "xyz"))
+
+	t.Run("Should describe the parameters of a service by key", func(t *testing.T) {
+		attributes, err := ListNative(s, "app")
+		assert.Nil(t, err)
+		assert.Len(t, attributes, 2)
+		assert.Equal(t, "SecureString", attributes["db_password"].Type)
+		assert.Equal(t, s.KMSKey(), attributes["db_password"].KMSKeyId)
+		assert.Contains(t, attributes, "api_key")
+	})
+
+	t.Run("Should describe the parameters of a namespaced service", func(t *testing.T) {
+		namespaced := NewNamespacedStore(s, "team")
+		assert.Nil(t, namespaced.Write(SecretId{Service: "app", Key: "db_password"}, "hunter3"))
+
+		attributes, err := ListNative(namespaced, "app")
+		assert.Nil(t, err)
+		assert.Len(t, attributes, 1)
+		assert.Equal(t, "SecureString", attributes["db_password"].Type)
+	})
+
+	t.Run("Should fail for backends without native attributes", func(t *testing.T) {
+		_, err := ListNative(&NullStore{}, "app")
+		assert.Error(t, err)
+	})
+}
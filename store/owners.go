@@ -0,0 +1,90 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ownersId is the well-known secret holding the owner registry, so that every
+// user of a backend sees the same owners without extra configuration.
+var ownersId = SecretId{Service: "_chamber-owners", Key: "registry"}
+
+// Owner is the team responsible for the services under Prefix
+type Owner struct {
+	Prefix     string `yaml:"prefix" json:"prefix"`
+	Team       string `yaml:"team" json:"team"`
+	Slack      string `yaml:"slack,omitempty" json:"slack,omitempty"`
+	Escalation string `yaml:"escalation,omitempty" json:"escalation,omitempty"`
+}
+
+func (o Owner) String() string {
+	s := o.Team
+	if o.Slack != "" {
+		s += " (" + o.Slack + ")"
+	}
+	return s
+}
+
+// OwnerRegistry maps service prefixes to their owners
+type OwnerRegistry struct {
+	Owners []Owner `yaml:"owners"`
+}
+
+// ParseOwnerRegistry reads a registry in YAML (or JSON) like
+//
+//	owners:
+//	  - prefix: payments
+//	    team: payments
+//	    slack: "#payments-oncall"
+func ParseOwnerRegistry(data []byte) (*OwnerRegistry, error) {
+	var r OwnerRegistry
+	if err := yaml.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+	for i, o := range r.Owners {
+		if o.Prefix == "" || o.Team == "" {
+			return nil, fmt.Errorf("owner %d: prefix and team are required", i+1)
+		}
+		r.Owners[i].Prefix = strings.Trim(strings.ToLower(o.Prefix), "/.")
+	}
+	return &r, nil
+}
+
+// ReadOwnerRegistry returns the registry kept in s, which is empty if there
+// is none
+func ReadOwnerRegistry(s Store) (*OwnerRegistry, error) {
+	secret, err := s.Read(ownersId, -1)
+	if err == ErrSecretNotFound {
+		return &OwnerRegistry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseOwnerRegistry([]byte(*secret.Value))
+}
+
+// WriteOwnerRegistry keeps data, a registry in YAML, in s
+func WriteOwnerRegistry(s Store, data []byte) error {
+	if _, err := ParseOwnerRegistry(data); err != nil {
+		return err
+	}
+	return WriteReserved(s, ownersId, string(data))
+}
+
+// Owner returns the owner with the longest prefix of service, or nil.
+// Prefixes match whole path segments: app matches app/prod but not apple.
+func (r *OwnerRegistry) Owner(service string) *Owner {
+	service, _ = parseServiceLabel(service)
+	var best *Owner
+	for i, o := range r.Owners {
+		if service != o.Prefix && !strings.HasPrefix(service, o.Prefix+"/") && !strings.HasPrefix(service, o.Prefix+".") {
+			continue
+		}
+		if best == nil || len(o.Prefix) > len(best.Prefix) {
+			best = &r.Owners[i]
+		}
+	}
+	return best
+}
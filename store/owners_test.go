@@ -0,0 +1,48 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOwnerRegistry(t *testing.T) {
+	r, err := ParseOwnerRegistry([]byte(`
+owners:
+  - prefix: app
+    team: web
+    slack: "#web"
+  - prefix: app/payments/
+    team: payments
+    escalation: https://example.com/payments-oncall
+`))
+	assert.Nil(t, err)
+
+	t.Run("Should pick the longest matching prefix", func(t *testing.T) {
+		assert.Equal(t, "payments", r.Owner("app/payments/prod").Team)
+		assert.Equal(t, "web (#web)", r.Owner("app/prod").String())
+		assert.Equal(t, "web", r.Owner("app").Team)
+	})
+
+	t.Run("Should match whole path segments", func(t *testing.T) {
+		assert.Nil(t, r.Owner("apple"))
+	})
+
+	t.Run("Should require a team", func(t *testing.T) {
+		_, err := ParseOwnerRegistry([]byte("owners:\n  - prefix: app\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("Should be kept in the store", func(t *testing.T) {
+		mock := &mockSSMClient{parameters: map[string]mockParameter{}}
+		s := NewTestSSMStore(mock)
+		empty, err := ReadOwnerRegistry(s)
+		assert.Nil(t, err)
+		assert.Nil(t, empty.Owner("app"))
+
+		assert.Nil(t, WriteOwnerRegistry(s, []byte("owners:\n  - prefix: app\n    team: web\n")))
+		stored, err := ReadOwnerRegistry(s)
+		assert.Nil(t, err)
+		assert.Equal(t, "web", stored.Owner("app/prod").Team)
+	})
+}
@@ -0,0 +1,191 @@
+package store
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+const (
+	// VersionPinSeparator separates a key from the version pinned to it, as
+	// in db_password@3
+	VersionPinSeparator = "@"
+
+	latestPin      = "latest"
+	labelPinPrefix = "label:"
+)
+
+// validLabelFormat is the format of labels SSM accepts
+var validLabelFormat = regexp.MustCompile(`^[\w\-\.]+$`)
+
+// VersionPin selects a version of a secret other than its latest one, as
+// written after the @ of db_password@3, db_password@latest-1 or
+// db_password@label:blessed
+type VersionPin struct {
+	// Version is the version chamber recorded, when set
+	Version int
+
+	// Back counts versions back from the latest one, as in latest-1
+	Back int
+
+	// Label is a label the backend gave a version, as SSM does
+	Label string
+}
+
+func (p VersionPin) String() string {
+	switch {
+	case p.Label != "":
+		return labelPinPrefix + p.Label
+	case p.Version > 0:
+		return strconv.Itoa(p.Version)
+	case p.Back > 0:
+		return fmt.Sprintf("%s-%d", latestPin, p.Back)
+	default:
+		return latestPin
+	}
+}
+
+// ParseVersionPin parses the expression after the @ of a pinned key: a
+// version number, latest, latest-N or label:<label>
+func ParseVersionPin(expr string) (*VersionPin, error) {
+	switch {
+	case expr == latestPin:
+		return &VersionPin{}, nil
+	case strings.HasPrefix(expr, latestPin+"-"):
+		back, err := strconv.Atoi(strings.TrimPrefix(expr, latestPin+"-"))
+		if err != nil || back < 0 {
+			return nil, fmt.Errorf("invalid version pin %s; expected latest-N with N a number of versions", expr)
+		}
+		return &VersionPin{Back: back}, nil
+	case strings.HasPrefix(expr, labelPinPrefix):
+		label := strings.TrimPrefix(expr, labelPinPrefix)
+		if !validLabelFormat.MatchString(label) {
+			return nil, fmt.Errorf("invalid version pin %s; labels may only contain alphanumerics, dashes, fullstops and underscores", expr)
+		}
+		return &VersionPin{Label: label}, nil
+	}
+	version, err := strconv.Atoi(expr)
+	if err != nil || version <= 0 {
+		return nil, fmt.Errorf("invalid version pin %s; expected a version, latest, latest-N or label:<label>", expr)
+	}
+	return &VersionPin{Version: version}, nil
+}
+
+// SplitVersionPin splits a name like db_password@3 into the name before the
+// @ and the version pinned after it, or returns name and nil if it has no pin
+func SplitVersionPin(name string) (string, *VersionPin, error) {
+	i := strings.Index(name, VersionPinSeparator)
+	if i < 0 {
+		return name, nil, nil
+	}
+	pin, err := ParseVersionPin(name[i+1:])
+	if err != nil {
+		return "", nil, err
+	}
+	return name[:i], pin, nil
+}
+
+// LabelReader is implemented by stores whose backend labels versions of
+// secrets
+type LabelReader interface {
+	// ReadLabel reads the version of id labeled label
+	ReadLabel(id SecretId, label string) (Secret, error)
+}
+
+// ReadLabel reads the version of the secret id labeled label, see LabelReader
+func ReadLabel(s Store, id SecretId, label string) (Secret, error) {
+	if r, ok := s.(LabelReader); ok {
+		return r.ReadLabel(id, label)
+	}
+	return Secret{}, fmt.Errorf("backend has no version labels")
+}
+
+// ReadPinned reads the version of the secret id that pin selects, or its
+// latest version if pin is nil. Versions back from the latest are counted
+// among the versions History reports.
+func ReadPinned(s Store, id SecretId, pin *VersionPin) (Secret, error) {
+	switch {
+	case pin == nil:
+		return s.Read(id, -1)
+	case pin.Label != "":
+		return ReadLabel(s, id, pin.Label)
+	case pin.Version > 0:
+		return s.Read(id, pin.Version)
+	case pin.Back == 0:
+		return s.Read(id, -1)
+	}
+
+	events, err := s.History(id)
+	if err != nil {
+		return Secret{}, err
+	}
+	seen := map[int]bool{}
+	var versions []int
+	for _, event := range events {
+		if event.Version > 0 && !seen[event.Version] {
+			seen[event.Version] = true
+			versions = append(versions, event.Version)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+	if pin.Back >= len(versions) {
+		return Secret{}, fmt.Errorf("%s has %d versions, so none is %s", id.Key, len(versions), pin)
+	}
+	return s.Read(id, versions[pin.Back])
+}
+
+var _ LabelReader = &SSMStore{}
+
+// ReadLabel reads the version of the parameter of id labeled label, with
+// LabelParameterVersion or in the console
+func (s *SSMStore) ReadLabel(id SecretId, label string) (Secret, error) {
+	getParameterHistoryInput := &ssm.GetParameterHistoryInput{
+		Name:           aws.String(s.idToName(id)),
+		WithDecryption: aws.Bool(true),
+	}
+
+	var result Secret
+	if err := s.svc.GetParameterHistoryPages(getParameterHistoryInput, func(o *ssm.GetParameterHistoryOutput, lastPage bool) bool {
+		for _, history := range o.Parameters {
+			if !labeled(history, label) {
+				continue
+			}
+			thisVersion := 0
+			if history.Description != nil {
+				thisVersion, _ = strconv.Atoi(*history.Description)
+			}
+			result = Secret{
+				Value: history.Value,
+				Meta: SecretMetadata{
+					Created:   aws.TimeValue(history.LastModifiedDate),
+					CreatedBy: aws.StringValue(history.LastModifiedUser),
+					Version:   thisVersion,
+					Key:       s.secretKey(*history.Name),
+				},
+			}
+			return false
+		}
+		return true
+	}); err != nil {
+		return Secret{}, ErrSecretNotFound
+	}
+	if result.Value != nil {
+		return result, nil
+	}
+
+	return Secret{}, ErrSecretNotFound
+}
+
+func labeled(history *ssm.ParameterHistory, label string) bool {
+	for _, l := range history.Labels {
+		if aws.StringValue(l) == label {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVersionPin(t *testing.T) {
+	t.Run("Should parse pin expressions", func(t *testing.T) {
+		for expr, expected := range map[string]VersionPin{
+			"3":             {Version: 3},
+			"latest":        {},
+			"latest-0":      {},
+			"latest-2":      {Back: 2},
+			"label:blessed": {Label: "blessed"},
+			"label:v1.2-rc": {Label: "v1.2-rc"},
+		} {
+			pin, err := ParseVersionPin(expr)
+			assert.Nil(t, err, expr)
+			assert.Equal(t, expected, *pin, expr)
+		}
+	})
+
+	t.Run("Should reject malformed pin expressions", func(t *testing.T) {
+		for _, expr := range []string{"", "0", "-1", "latest-", "latest-x", "latest--1", "label:", "label:a b", "newest"} {
+			_, err := ParseVersionPin(expr)
+			assert.Error(t, err, expr)
+		}
+	})
+
+	t.Run("Should format pins as they're written", func(t *testing.T) {
+		for _, expr := range []string{"3", "latest", "latest-2", "label:blessed"} {
+			pin, err := ParseVersionPin(expr)
+			assert.Nil(t, err)
+			assert.Equal(t, expr, pin.String())
+		}
+	})
+}
+
+func TestSplitVersionPin(t *testing.T) {
+	t.Run("Should split the pin off a key", func(t *testing.T) {
+		name, pin, err := SplitVersionPin("app/prod/db_password@latest-1")
+		assert.Nil(t, err)
+		assert.Equal(t, "app/prod/db_password", name)
+		assert.Equal(t, &VersionPin{Back: 1}, pin)
+	})
+
+	t.Run("Should return names without a pin unchanged", func(t *testing.T) {
+		name, pin, err := SplitVersionPin("app/prod")
+		assert.Nil(t, err)
+		assert.Equal(t, "app/prod", name)
+		assert.Nil(t, pin)
+	})
+
+	t.Run("Should fail on a malformed pin", func(t *testing.T) {
+		_, _, err := SplitVersionPin("db_password@soon")
+		assert.Error(t, err)
+	})
+}
+
+func TestReadPinned(t *testing.T) {
+	mock := &mockSSMClient{parameters: map[string]mockParameter{}}
+	s := NewTestSSMStore(mock)
+	id := SecretId{Service: "app", Key: "db_password"}
+	for _, value := range []string{"one", "two", "three"} {
+		assert.Nil(t, s.Write(id, value))
+	}
+	_, err := mock.LabelParameterVersion(&ssm.LabelParameterVersionInput{
+		Name:             aws.String(s.idToName(id)),
+		ParameterVersion: aws.Int64(2),
+		Labels:           []*string{aws.String("blessed")},
+	})
+	assert.Nil(t, err)
+
+	t.Run("Should read the latest version without a pin", func(t *testing.T) {
+		secret, err := ReadPinned(s, id, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, "three", *secret.Value)
+	})
+
+	t.Run("Should read a pinned version", func(t *testing.T) {
+		secret, err := ReadPinned(s, id, &VersionPin{Version: 1})
+		assert.Nil(t, err)
+		assert.Equal(t, "one", *secret.Value)
+	})
+
+	t.Run("Should count versions back from the latest", func(t *testing.T) {
+		for back, expected := range []string{"three", "two", "one"} {
+			secret, err := ReadPinned(s, id, &VersionPin{Back: back})
+			assert.Nil(t, err)
+			assert.Equal(t, expected, *secret.Value)
+		}
+	})
+
+	t.Run("Should fail counting back past the first version", func(t *testing.T) {
+		_, err := ReadPinned(s, id, &VersionPin{Back: 3})
+		assert.Error(t, err)
+	})
+
+	t.Run("Should read a labeled version", func(t *testing.T) {
+		secret, err := ReadPinned(s, id, &VersionPin{Label: "blessed"})
+		assert.Nil(t, err)
+		assert.Equal(t, "two", *secret.Value)
+		assert.Equal(t, 2, secret.Meta.Version)
+	})
+
+	t.Run("Should not find a label no version has", func(t *testing.T) {
+		_, err := ReadPinned(s, id, &VersionPin{Label: "cursed"})
+		assert.Equal(t, ErrSecretNotFound, err)
+	})
+
+	t.Run("Should fail on labels for backends without them", func(t *testing.T) {
+		_, err := ReadPinned(&NullStore{}, id, &VersionPin{Label: "blessed"})
+		assert.Error(t, err)
+	})
+
+	t.Run("Should read labels through namespaces", func(t *testing.T) {
+		namespaced := NewNamespacedStore(s, "team")
+		nid := SecretId{Service: "app", Key: "api_key"}
+		assert.Nil(t, namespaced.Write(nid, "first"))
+		assert.Nil(t, namespaced.Write(nid, "second"))
+		_, err := mock.LabelParameterVersion(&ssm.LabelParameterVersionInput{
+			Name:             aws.String(s.idToName(SecretId{Service: "team/app", Key: "api_key"})),
+			ParameterVersion: aws.Int64(1),
+			Labels:           []*string{aws.String("blessed")},
+		})
+		assert.Nil(t, err)
+
+		secret, err := ReadPinned(namespaced, nid, &VersionPin{Label: "blessed"})
+		assert.Nil(t, err)
+		assert.Equal(t, "first", *secret.Value)
+	})
+}
@@ -0,0 +1,64 @@
+package store
+
+import (
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+const (
+	// ParameterTypeSecure is the type of parameters encrypted with KMS
+	ParameterTypeSecure = "SecureString"
+
+	// ParameterTypePlaintext is the type of parameters stored in plain text
+	ParameterTypePlaintext = "String"
+)
+
+// SetPlaintextKeys makes the store write the keys matching any of patterns as
+// String parameters instead of SecureString, e.g. for non-sensitive settings
+// that don't need KMS. Patterns are path.Match patterns of the key, or of the
+// service and key when they contain a slash, e.g. app/*/log_level.
+func (s *SSMStore) SetPlaintextKeys(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return err
+		}
+	}
+	s.plaintextKeys = patterns
+	return nil
+}
+
+// IsPlaintextKey reports whether id matches any of the patterns given to
+// SetPlaintextKeys
+func IsPlaintextKey(patterns []string, id SecretId) bool {
+	service, _ := parseServiceLabel(id.Service)
+	for _, pattern := range patterns {
+		name := id.Key
+		if strings.Contains(pattern, "/") {
+			name = service + "/" + id.Key
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parameterType returns the type of parameter id is written as
+func (s *SSMStore) parameterType(id SecretId) string {
+	if IsPlaintextKey(s.plaintextKeys, id) {
+		return ParameterTypePlaintext
+	}
+	return ParameterTypeSecure
+}
+
+// putParameterType sets the type of parameter id in input, along with the KMS
+// key for encrypted parameters
+func (s *SSMStore) putParameterType(input *ssm.PutParameterInput, id SecretId) {
+	input.Type = aws.String(s.parameterType(id))
+	if *input.Type == ParameterTypeSecure {
+		input.KeyId = aws.String(s.KMSKey())
+	}
+}
@@ -0,0 +1,41 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlaintextKeys(t *testing.T) {
+	mock := &mockSSMClient{parameters: map[string]mockParameter{}}
+	s := NewTestSSMStoreWithPaths(mock)
+	assert.Nil(t, s.SetPlaintextKeys([]string{"log_*", "app/*/port"}))
+
+	t.Run("Should write matching keys as String parameters", func(t *testing.T) {
+		assert.Nil(t, s.Write(SecretId{Service: "app/prod", Key: "log_level"}, "debug"))
+		assert.Nil(t, s.Write(SecretId{Service: "app/prod", Key: "port"}, "8080"))
+		for _, name := range []string{"/app/prod/log_level", "/app/prod/port"} {
+			assert.Equal(t, "String", *mock.parameters[name].meta.Type)
+			assert.Nil(t, mock.parameters[name].meta.KeyId)
+		}
+	})
+
+	t.Run("Should encrypt other keys", func(t *testing.T) {
+		assert.Nil(t, s.Write(SecretId{Service: "app/prod", Key: "db_password"}, "secret"))
+		assert.Nil(t, s.Write(SecretId{Service: "other/prod", Key: "port"}, "8080"))
+		for _, name := range []string{"/app/prod/db_password", "/other/prod/port"} {
+			assert.Equal(t, "SecureString", *mock.parameters[name].meta.Type)
+			assert.NotNil(t, mock.parameters[name].meta.KeyId)
+		}
+	})
+
+	t.Run("Should list both types alike", func(t *testing.T) {
+		secrets, err := s.ListRaw("app/prod")
+		assert.Nil(t, err)
+		assert.Len(t, secrets, 3)
+	})
+
+	t.Run("Should reject invalid patterns", func(t *testing.T) {
+		assert.Error(t, s.SetPlaintextKeys([]string{"["}))
+	})
+}
@@ -0,0 +1,242 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// profileSegment separates a service from the profiles layered over it. The
+// values of profile canary of service app live in service app/_profile/canary,
+// which path-based listings of app don't descend into.
+const profileSegment = "_profile"
+
+var _ Store = &ProfileStore{}
+
+// ProfileStore layers the values of a profile over the default values of
+// each service: reads prefer the profile's value of a key and fall back to
+// the service's own, while writes, deletes and history go to the profile.
+type ProfileStore struct {
+	store   Store
+	profile string
+}
+
+// NewProfileStore returns a store that overlays profile on every service
+func NewProfileStore(s Store, profile string) *ProfileStore {
+	return &ProfileStore{store: s, profile: profile}
+}
+
+func (s *ProfileStore) overlay(serviceAndLabel string) string {
+	service, label := parseServiceLabel(serviceAndLabel)
+	overlay := fmt.Sprintf("%s/%s/%s", service, profileSegment, s.profile)
+	if label != "" {
+		overlay += ":" + label
+	}
+	return overlay
+}
+
+func (s *ProfileStore) overlayId(id SecretId) SecretId {
+	return SecretId{Service: s.overlay(id.Service), Key: id.Key}
+}
+
+// baseKey returns the key a secret of the profile overrides in service
+func baseKey(service, key string) string {
+	service, _ = parseServiceLabel(service)
+	parts := strings.Split(key, "/")
+	return fmt.Sprintf("/%s/%s", service, parts[len(parts)-1])
+}
+
+func (s *ProfileStore) Write(id SecretId, value string) error {
+	return s.store.Write(s.overlayId(id), value)
+}
+
+func (s *ProfileStore) WriteMany(service string, values map[string]string) error {
+	return WriteMany(s.store, s.overlay(service), values)
+}
+
+func (s *ProfileStore) Read(id SecretId, version int) (Secret, error) {
+	secret, err := s.store.Read(s.overlayId(id), version)
+	if err == ErrSecretNotFound {
+		return s.store.Read(id, version)
+	}
+	if err != nil {
+		return secret, err
+	}
+	secret.Meta.Key = baseKey(id.Service, secret.Meta.Key)
+	return secret, nil
+}
+
+func (s *ProfileStore) ReadNativeVersion(id SecretId, version int64) (Secret, error) {
+	secret, err := ReadNativeVersion(s.store, s.overlayId(id), version)
+	if err == ErrSecretNotFound {
+		return ReadNativeVersion(s.store, id, version)
+	}
+	if err != nil {
+		return secret, err
+	}
+	secret.Meta.Key = baseKey(id.Service, secret.Meta.Key)
+	return secret, nil
+}
+
+func (s *ProfileStore) ReadLabel(id SecretId, label string) (Secret, error) {
+	secret, err := ReadLabel(s.store, s.overlayId(id), label)
+	if err == ErrSecretNotFound {
+		return ReadLabel(s.store, id, label)
+	}
+	if err != nil {
+		return secret, err
+	}
+	secret.Meta.Key = baseKey(id.Service, secret.Meta.Key)
+	return secret, nil
+}
+
+func (s *ProfileStore) ListNative(service string) (map[string]NativeAttributes, error) {
+	attributes, err := ListNative(s.store, service)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := ListNative(s.store, s.overlay(service))
+	if err != nil {
+		return nil, err
+	}
+	for key, a := range overrides {
+		attributes[key] = a
+	}
+	return attributes, nil
+}
+
+// Rekey re-encrypts both the profile's override of id and the secret it
+// overrides, so no version current for any profile is left under the old key
+func (s *ProfileStore) Rekey(id SecretId, kmsKey string) (bool, error) {
+	overlay, err := Rekey(s.store, s.overlayId(id), kmsKey)
+	overlayFound := err != ErrSecretNotFound
+	if err != nil && overlayFound {
+		return overlay, err
+	}
+	base, err := Rekey(s.store, id, kmsKey)
+	if err == ErrSecretNotFound && overlayFound {
+		return overlay, nil
+	}
+	return overlay || base, err
+}
+
+func (s *ProfileStore) List(service string, includeValues bool) ([]Secret, error) {
+	secrets, err := s.store.List(service, includeValues)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := s.store.List(s.overlay(service), includeValues)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := map[string]Secret{}
+	for _, secret := range secrets {
+		merged[secret.Meta.Key] = secret
+	}
+	for _, secret := range overrides {
+		secret.Meta.Key = baseKey(service, secret.Meta.Key)
+		merged[secret.Meta.Key] = secret
+	}
+
+	result := make([]Secret, 0, len(merged))
+	for _, secret := range merged {
+		result = append(result, secret)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Meta.Key < result[j].Meta.Key })
+	return result, nil
+}
+
+func (s *ProfileStore) ListRaw(service string) ([]RawSecret, error) {
+	secrets, err := s.store.ListRaw(service)
+	if err != nil || IsSecretsManagerReference(service) {
+		// Secrets Manager secrets have no overlays
+		return secrets, err
+	}
+	overrides, err := s.store.ListRaw(s.overlay(service))
+	if err != nil {
+		return nil, err
+	}
+
+	merged := map[string]string{}
+	for _, secret := range secrets {
+		merged[secret.Key] = secret.Value
+	}
+	for _, secret := range overrides {
+		merged[baseKey(service, secret.Key)] = secret.Value
+	}
+
+	result := make([]RawSecret, 0, len(merged))
+	for key, value := range merged {
+		result = append(result, RawSecret{Key: key, Value: value})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result, nil
+}
+
+func (s *ProfileStore) ListServices(service string, includeSecretName bool) ([]string, error) {
+	return s.store.ListServices(service, includeSecretName)
+}
+
+// StreamList hands out the whole listing as one page, as the values of the
+// profile can only be layered over the service's once both are listed
+func (s *ProfileStore) StreamList(service string, includeValues bool, fn func([]Secret) error) error {
+	secrets, err := s.List(service, includeValues)
+	if err != nil {
+		return err
+	}
+	return fn(secrets)
+}
+
+func (s *ProfileStore) StreamServices(service string, includeSecretName bool, fn func([]string) error) error {
+	return StreamServices(s.store, service, includeSecretName, fn)
+}
+
+// ListRefs points the keys the profile overrides at its overrides, like
+// ListRaw merges their values
+func (s *ProfileStore) ListRefs(service string) ([]SecretRef, error) {
+	refs, err := ListRefs(s.store, service)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := ListRefs(s.store, s.overlay(service))
+	if err != nil {
+		return nil, err
+	}
+
+	merged := map[string]SecretRef{}
+	for _, ref := range refs {
+		merged[ref.Key] = ref
+	}
+	for _, ref := range overrides {
+		ref.Key = baseKey(service, ref.Key)
+		merged[ref.Key] = ref
+	}
+
+	result := make([]SecretRef, 0, len(merged))
+	for _, ref := range merged {
+		result = append(result, ref)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result, nil
+}
+
+func (s *ProfileStore) Capabilities() Capabilities {
+	return CapabilitiesOf(s.store)
+}
+
+func (s *ProfileStore) History(id SecretId) ([]ChangeEvent, error) {
+	return s.store.History(s.overlayId(id))
+}
+
+func (s *ProfileStore) Rename(id SecretId, newKey string) error {
+	return Rename(s.store, s.overlayId(id), newKey)
+}
+
+func (s *ProfileStore) Renumber(id SecretId, version int) error {
+	return Renumber(s.store, s.overlayId(id), version)
+}
+
+func (s *ProfileStore) Delete(id SecretId) error {
+	return s.store.Delete(s.overlayId(id))
+}
@@ -0,0 +1,46 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfileStore(t *testing.T) {
+	s := NewProfileStore(NewStaticStore(map[string]map[string]string{
+		"app":                 {"db_host": "db", "replicas": "3"},
+		"app/_profile/canary": {"replicas": "1", "feature_flag": "on"},
+	}), "canary")
+
+	t.Run("Reads should prefer the profile's value", func(t *testing.T) {
+		secret, err := s.Read(SecretId{Service: "app", Key: "replicas"}, -1)
+		assert.Nil(t, err)
+		assert.Equal(t, "1", *secret.Value)
+		assert.Equal(t, "/app/replicas", secret.Meta.Key)
+
+		secret, err = s.Read(SecretId{Service: "app", Key: "db_host"}, -1)
+		assert.Nil(t, err)
+		assert.Equal(t, "db", *secret.Value)
+	})
+
+	t.Run("Listings should overlay the profile on the defaults", func(t *testing.T) {
+		raw, err := s.ListRaw("app")
+		assert.Nil(t, err)
+		assert.Equal(t, []RawSecret{
+			{Key: "/app/db_host", Value: "db"},
+			{Key: "/app/feature_flag", Value: "on"},
+			{Key: "/app/replicas", Value: "1"},
+		}, raw)
+
+		secrets, err := s.List("app", true)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, len(secrets))
+		assert.Equal(t, "/app/replicas", secrets[2].Meta.Key)
+		assert.Equal(t, "1", *secrets[2].Value)
+	})
+
+	t.Run("Writes should go to the profile", func(t *testing.T) {
+		assert.Equal(t, ErrStaticStoreReadOnly, s.Write(SecretId{Service: "app", Key: "db_host"}, "canary-db"))
+		assert.Equal(t, "app/_profile/canary", s.overlayId(SecretId{Service: "app", Key: "db_host"}).Service)
+	})
+}
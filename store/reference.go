@@ -0,0 +1,279 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// ReferencePrefix marks a secret value as a reference to another secret,
+	// e.g. chamber-ref://shared/prod/db_password
+	ReferencePrefix = "chamber-ref://"
+
+	// MaxReferenceDepth is how many references are followed before giving up
+	MaxReferenceDepth = 8
+)
+
+var _ Store = &ReferenceStore{}
+
+// ReferenceStore resolves secret values that reference other secrets, so a
+// secret owned by one service can be consumed by many without duplication.
+// Only values are resolved; metadata and history describe the referencing
+// secret itself.
+type ReferenceStore struct {
+	store Store
+}
+
+// NewReferenceStore returns a store that dereferences the values read from s
+func NewReferenceStore(s Store) *ReferenceStore {
+	return &ReferenceStore{store: s}
+}
+
+// UnresolvedLister is implemented by stores that resolve references, to list
+// values as they're stored instead
+type UnresolvedLister interface {
+	ListUnresolved(service string) ([]RawSecret, error)
+}
+
+// ListUnresolved lists the values of service as they're stored, with
+// references as chamber-ref:// values rather than the values they reference
+func ListUnresolved(s Store, service string) ([]RawSecret, error) {
+	if l, ok := s.(UnresolvedLister); ok {
+		return l.ListUnresolved(service)
+	}
+	return s.ListRaw(service)
+}
+
+// ParseReference returns the secret referenced by value, and whether value
+// is a reference at all.
+func ParseReference(value string) (SecretId, bool, error) {
+	if !strings.HasPrefix(value, ReferencePrefix) {
+		return SecretId{}, false, nil
+	}
+	path := strings.Trim(strings.TrimPrefix(value, ReferencePrefix), "/")
+	i := strings.LastIndex(path, "/")
+	if i <= 0 || i == len(path)-1 {
+		return SecretId{}, true, fmt.Errorf("invalid reference %s: expected %s<service>/<key>", value, ReferencePrefix)
+	}
+	return SecretId{
+		Service: strings.ToLower(path[:i]),
+		Key:     strings.ToLower(path[i+1:]),
+	}, true, nil
+}
+
+// resolve follows value through any chain of references
+func (s *ReferenceStore) resolve(value string) (string, error) {
+	_, value, err := s.follow(value)
+	return value, err
+}
+
+// follow follows value through any chain of references, returning the last
+// secret referenced, or a zero SecretId if value is no reference, and its value
+func (s *ReferenceStore) follow(value string) (SecretId, string, error) {
+	seen := map[SecretId]bool{}
+	var last SecretId
+	for depth := 0; ; depth++ {
+		id, ok, err := ParseReference(value)
+		if err != nil || !ok {
+			return last, value, err
+		}
+		if seen[id] {
+			return SecretId{}, "", fmt.Errorf("reference cycle through %s/%s", id.Service, id.Key)
+		}
+		if depth == MaxReferenceDepth {
+			return SecretId{}, "", fmt.Errorf("more than %d nested references", MaxReferenceDepth)
+		}
+		seen[id] = true
+
+		secret, err := s.store.Read(id, -1)
+		if err != nil {
+			return SecretId{}, "", fmt.Errorf("unable to resolve reference to %s/%s: %s", id.Service, id.Key, err)
+		}
+		last, value = id, *secret.Value
+	}
+}
+
+func (s *ReferenceStore) Write(id SecretId, value string) error {
+	return s.store.Write(id, value)
+}
+
+func (s *ReferenceStore) WriteReserved(id SecretId, value string) error {
+	return WriteReserved(s.store, id, value)
+}
+
+func (s *ReferenceStore) DeleteReserved(id SecretId) error {
+	return DeleteReserved(s.store, id)
+}
+
+func (s *ReferenceStore) WriteMany(service string, values map[string]string) error {
+	return WriteMany(s.store, service, values)
+}
+
+func (s *ReferenceStore) Read(id SecretId, version int) (Secret, error) {
+	secret, err := s.store.Read(id, version)
+	if err != nil || secret.Value == nil {
+		return secret, err
+	}
+	value, err := s.resolve(*secret.Value)
+	if err != nil {
+		return Secret{}, err
+	}
+	secret.Value = &value
+	return secret, nil
+}
+
+// ReadNativeVersion returns the value as stored, without resolving references,
+// as it's meant for debugging the backend
+func (s *ReferenceStore) ReadNativeVersion(id SecretId, version int64) (Secret, error) {
+	return ReadNativeVersion(s.store, id, version)
+}
+
+func (s *ReferenceStore) ReadLabel(id SecretId, label string) (Secret, error) {
+	secret, err := ReadLabel(s.store, id, label)
+	if err != nil || secret.Value == nil {
+		return secret, err
+	}
+	value, err := s.resolve(*secret.Value)
+	if err != nil {
+		return Secret{}, err
+	}
+	secret.Value = &value
+	return secret, nil
+}
+
+func (s *ReferenceStore) ListNative(service string) (map[string]NativeAttributes, error) {
+	return ListNative(s.store, service)
+}
+
+// Rekey re-encrypts the value as stored, like ReadNativeVersion reads it
+func (s *ReferenceStore) Rekey(id SecretId, kmsKey string) (bool, error) {
+	return Rekey(s.store, id, kmsKey)
+}
+
+func (s *ReferenceStore) List(service string, includeValues bool) ([]Secret, error) {
+	secrets, err := s.store.List(service, includeValues)
+	if err != nil || !includeValues {
+		return secrets, err
+	}
+	for i, secret := range secrets {
+		if secret.Value == nil {
+			continue
+		}
+		value, err := s.resolve(*secret.Value)
+		if err != nil {
+			return nil, err
+		}
+		secrets[i].Value = &value
+	}
+	return secrets, nil
+}
+
+func (s *ReferenceStore) ListRaw(service string) ([]RawSecret, error) {
+	secrets, err := s.store.ListRaw(service)
+	if err != nil {
+		return nil, err
+	}
+	for i := range secrets {
+		if secrets[i].Value, err = s.resolve(secrets[i].Value); err != nil {
+			return nil, err
+		}
+	}
+	return secrets, nil
+}
+
+func (s *ReferenceStore) ListUnresolved(service string) ([]RawSecret, error) {
+	return ListUnresolved(s.store, service)
+}
+
+// ListRefs points secrets that reference others at the secrets they
+// reference, as systems resolving the references would otherwise read the
+// chamber-ref:// value itself
+func (s *ReferenceStore) ListRefs(service string) ([]SecretRef, error) {
+	refs, err := ListRefs(s.store, service)
+	if err != nil {
+		return nil, err
+	}
+	secrets, err := s.store.ListRaw(service)
+	if err != nil {
+		return nil, err
+	}
+	targets := map[string]SecretId{}
+	for _, secret := range secrets {
+		target, _, err := s.follow(secret.Value)
+		if err != nil {
+			return nil, err
+		}
+		if target != (SecretId{}) {
+			targets[secret.Key] = target
+		}
+	}
+
+	for i, ref := range refs {
+		target, ok := targets[ref.Key]
+		if !ok {
+			continue
+		}
+		targetRefs, err := ListRefs(s.store, target.Service)
+		if err != nil {
+			return nil, err
+		}
+		found := false
+		for _, targetRef := range targetRefs {
+			if lastSegment(targetRef.Key) == target.Key {
+				refs[i].ARN, refs[i].Version, found = targetRef.ARN, targetRef.Version, true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unable to resolve reference to %s/%s: %s", target.Service, target.Key, ErrSecretNotFound)
+		}
+	}
+	return refs, nil
+}
+
+func (s *ReferenceStore) ListServices(service string, includeSecretName bool) ([]string, error) {
+	return s.store.ListServices(service, includeSecretName)
+}
+
+func (s *ReferenceStore) StreamList(service string, includeValues bool, fn func([]Secret) error) error {
+	return StreamList(s.store, service, includeValues, func(secrets []Secret) error {
+		if !includeValues {
+			return fn(secrets)
+		}
+		for i, secret := range secrets {
+			if secret.Value == nil {
+				continue
+			}
+			value, err := s.resolve(*secret.Value)
+			if err != nil {
+				return err
+			}
+			secrets[i].Value = &value
+		}
+		return fn(secrets)
+	})
+}
+
+func (s *ReferenceStore) StreamServices(service string, includeSecretName bool, fn func([]string) error) error {
+	return StreamServices(s.store, service, includeSecretName, fn)
+}
+
+func (s *ReferenceStore) Capabilities() Capabilities {
+	return CapabilitiesOf(s.store)
+}
+
+func (s *ReferenceStore) History(id SecretId) ([]ChangeEvent, error) {
+	return s.store.History(id)
+}
+
+func (s *ReferenceStore) Rename(id SecretId, newKey string) error {
+	return Rename(s.store, id, newKey)
+}
+
+func (s *ReferenceStore) Renumber(id SecretId, version int) error {
+	return Renumber(s.store, id, version)
+}
+
+func (s *ReferenceStore) Delete(id SecretId) error {
+	return s.store.Delete(id)
+}
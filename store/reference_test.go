@@ -0,0 +1,69 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReferenceStore(t *testing.T) {
+	s := NewReferenceStore(NewStaticStore(map[string]map[string]string{
+		"shared/prod": {"db_password": "hunter22", "alias": "chamber-ref://shared/prod/DB_PASSWORD"},
+		"app":         {"db_password": "chamber-ref://shared/prod/alias", "plain": "value"},
+		"loop":        {"a": "chamber-ref://loop/b", "b": "chamber-ref://loop/a"},
+		"broken":      {"a": "chamber-ref://nokey", "b": "chamber-ref://shared/prod/missing"},
+	}))
+
+	t.Run("Should follow chains of references", func(t *testing.T) {
+		secret, err := s.Read(SecretId{Service: "app", Key: "db_password"}, -1)
+		assert.Nil(t, err)
+		assert.Equal(t, "hunter22", *secret.Value)
+		assert.Equal(t, "/app/db_password", secret.Meta.Key)
+	})
+
+	t.Run("Should resolve listed values", func(t *testing.T) {
+		raw, err := s.ListRaw("app")
+		assert.Nil(t, err)
+		assert.Equal(t, []RawSecret{{Key: "/app/db_password", Value: "hunter22"}, {Key: "/app/plain", Value: "value"}}, raw)
+
+		secrets, err := s.List("app", true)
+		assert.Nil(t, err)
+		assert.Equal(t, "hunter22", *secrets[0].Value)
+	})
+
+	t.Run("Should list values as stored without resolving them", func(t *testing.T) {
+		raw, err := ListUnresolved(NewScrubbingStore(s), "app")
+		assert.Nil(t, err)
+		assert.Equal(t, []RawSecret{{Key: "/app/db_password", Value: "chamber-ref://shared/prod/alias"}, {Key: "/app/plain", Value: "value"}}, raw)
+	})
+
+	t.Run("Should detect cycles", func(t *testing.T) {
+		_, err := s.Read(SecretId{Service: "loop", Key: "a"}, -1)
+		assert.Error(t, err)
+	})
+
+	t.Run("Should fail on invalid or dangling references", func(t *testing.T) {
+		_, err := s.Read(SecretId{Service: "broken", Key: "a"}, -1)
+		assert.Error(t, err)
+		_, err = s.Read(SecretId{Service: "broken", Key: "b"}, -1)
+		assert.Error(t, err)
+	})
+}
+
+func TestReferenceDepth(t *testing.T) {
+	services := map[string]map[string]string{"chain": {keyN(0): "value"}}
+	for i := 1; i <= MaxReferenceDepth+1; i++ {
+		services["chain"][keyN(i)] = "chamber-ref://chain/" + keyN(i-1)
+	}
+	s := NewReferenceStore(NewStaticStore(services))
+
+	_, err := s.Read(SecretId{Service: "chain", Key: keyN(MaxReferenceDepth)}, -1)
+	assert.Nil(t, err)
+	_, err = s.Read(SecretId{Service: "chain", Key: keyN(MaxReferenceDepth + 1)}, -1)
+	assert.Error(t, err)
+}
+
+func keyN(i int) string {
+	return fmt.Sprintf("k%d", i)
+}
@@ -0,0 +1,89 @@
+package store
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// SecretRef points at where a backend keeps a secret, for systems like ECS,
+// Lambda and CloudFormation that resolve secrets themselves and so never need
+// to see the value.
+type SecretRef struct {
+	// Key is the name of the secret, like RawSecret.Key
+	Key string
+
+	// ARN is the ARN of the resource holding the secret
+	ARN string
+
+	// Version is the backend's own identifier of the version current when
+	// the secret was listed
+	Version string
+}
+
+// String returns the ARN of r qualified by its version, e.g.
+// arn:aws:ssm:us-east-1:123456789012:parameter/app/db_password:3
+func (r SecretRef) String() string {
+	if r.Version == "" {
+		return r.ARN
+	}
+	return r.ARN + ":" + r.Version
+}
+
+// RefLister is implemented by stores whose secrets can be referenced by ARN
+type RefLister interface {
+	ListRefs(service string) ([]SecretRef, error)
+}
+
+// ListRefs returns references to the secrets of service, without reading
+// their values
+func ListRefs(s Store, service string) ([]SecretRef, error) {
+	if l, ok := s.(RefLister); ok {
+		return l.ListRefs(service)
+	}
+	return nil, fmt.Errorf("backend has no references")
+}
+
+var _ RefLister = &SSMStore{}
+
+// ListRefs reads the ARNs and parameter versions of the parameters of
+// service ten at a time, like readValues, but without decrypting them
+func (s *SSMStore) ListRefs(serviceName string) ([]SecretRef, error) {
+	service, label := parseServiceLabel(serviceName)
+	if label != "" {
+		return nil, fmt.Errorf("references are to the latest versions and can't be labeled")
+	}
+	secrets, err := s.List(service, false)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		names = append(names, s.idToName(SecretId{Service: service, Key: lastSegment(secret.Meta.Key)}))
+	}
+
+	var refs []SecretRef
+	for i := 0; i < len(names); i += 10 {
+		batchEnd := i + 10
+		if batchEnd > len(names) {
+			batchEnd = len(names)
+		}
+		resp, err := s.svc.GetParameters(&ssm.GetParametersInput{
+			Names:          stringsToAWSStrings(names[i:batchEnd]),
+			WithDecryption: aws.Bool(false),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, param := range resp.Parameters {
+			refs = append(refs, SecretRef{
+				Key:     s.secretKey(*param.Name),
+				ARN:     aws.StringValue(param.ARN),
+				Version: strconv.FormatInt(aws.Int64Value(param.Version), 10),
+			})
+		}
+	}
+	return refs, nil
+}
@@ -0,0 +1,49 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListRefs(t *testing.T) {
+	mock := &mockSSMClient{parameters: map[string]mockParameter{}}
+	backing := NewTestSSMStoreWithPaths(mock)
+	assert.Nil(t, backing.Write(SecretId{Service: "app", Key: "db_password"}, "one"))
+	assert.Nil(t, backing.Write(SecretId{Service: "app", Key: "db_password"}, "two"))
+	assert.Nil(t, backing.Write(SecretId{Service: "app", Key: "shared"}, ReferencePrefix+"shared/token"))
+	assert.Nil(t, backing.Write(SecretId{Service: "shared", Key: "token"}, "secret"))
+	assert.Nil(t, backing.Write(SecretId{Service: "team/app", Key: "key"}, "value"))
+
+	t.Run("Should list the ARN and parameter version of each secret", func(t *testing.T) {
+		refs, err := ListRefs(backing, "shared")
+		assert.Nil(t, err)
+		assert.Equal(t, []SecretRef{{Key: "/shared/token", ARN: "arn:aws:ssm:us-east-1:123456789012:parameter/shared/token", Version: "1"}}, refs)
+		assert.Equal(t, "arn:aws:ssm:us-east-1:123456789012:parameter/shared/token:1", refs[0].String())
+	})
+
+	t.Run("Should point references at the secrets they reference", func(t *testing.T) {
+		refs, err := ListRefs(NewReferenceStore(backing), "app")
+		assert.Nil(t, err)
+		byKey := map[string]string{}
+		for _, ref := range refs {
+			byKey[ref.Key] = ref.String()
+		}
+		assert.Equal(t, map[string]string{
+			"/app/db_password": "arn:aws:ssm:us-east-1:123456789012:parameter/app/db_password:2",
+			"/app/shared":      "arn:aws:ssm:us-east-1:123456789012:parameter/shared/token:1",
+		}, byKey)
+	})
+
+	t.Run("Should strip namespaces from keys", func(t *testing.T) {
+		refs, err := ListRefs(NewNamespacedStore(backing, "team"), "app")
+		assert.Nil(t, err)
+		assert.Equal(t, []SecretRef{{Key: "/app/key", ARN: "arn:aws:ssm:us-east-1:123456789012:parameter/team/app/key", Version: "1"}}, refs)
+	})
+
+	t.Run("Should fail for backends without references", func(t *testing.T) {
+		_, err := ListRefs(NewStaticStore(map[string]map[string]string{"app": {"key": "value"}}), "app")
+		assert.Error(t, err)
+		assert.False(t, CapabilitiesOf(NewStaticStore(nil)).References)
+	})
+}
@@ -0,0 +1,246 @@
+package store
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// regionFormat matches AWS region names, like us-west-2 or us-gov-east-1
+var regionFormat = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d+$`)
+
+// SplitRegion splits a service like us-west-2:app/prod into its region and
+// the service within it. The region is empty for services without one.
+func SplitRegion(service string) (string, string) {
+	i := strings.Index(service, ":")
+	if i == -1 || !regionFormat.MatchString(service[:i]) {
+		return "", service
+	}
+	return service[:i], service[i+1:]
+}
+
+// inRegion applies f to a service, keeping the region it is in, so chamber's
+// own records of a service are kept in the same region
+func inRegion(service string, f func(string) string) string {
+	region, service := SplitRegion(service)
+	if region == "" {
+		return f(service)
+	}
+	return region + ":" + f(service)
+}
+
+var _ Store = &RegionalStore{}
+
+// RegionalStore serves services prefixed with a region, like
+// us-west-2:app/prod, from a store for that region, and all others from the
+// default store. The stores of other regions are created when first used.
+type RegionalStore struct {
+	store    Store
+	newStore func(region string) (Store, error)
+
+	mu     sync.Mutex
+	stores map[string]Store
+}
+
+// NewRegionalStore returns a store serving services without a region from s
+// and those with one from the store newStore creates for it. A nil newStore
+// rejects services with a region.
+func NewRegionalStore(s Store, newStore func(region string) (Store, error)) *RegionalStore {
+	return &RegionalStore{store: s, newStore: newStore, stores: map[string]Store{}}
+}
+
+// storeFor returns the store of the region of service, and the service
+// within it
+func (s *RegionalStore) storeFor(service string) (Store, string, error) {
+	region, service := SplitRegion(service)
+	if region == "" {
+		return s.store, service, nil
+	}
+	if s.newStore == nil {
+		return nil, "", fmt.Errorf("this backend doesn't support regions, as in %s:%s", region, service)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if regional, ok := s.stores[region]; ok {
+		return regional, service, nil
+	}
+	regional, err := s.newStore(region)
+	if err != nil {
+		return nil, "", err
+	}
+	s.stores[region] = regional
+	return regional, service, nil
+}
+
+func (s *RegionalStore) storeForId(id SecretId) (Store, SecretId, error) {
+	regional, service, err := s.storeFor(id.Service)
+	return regional, SecretId{Service: service, Key: id.Key}, err
+}
+
+func (s *RegionalStore) Write(id SecretId, value string) error {
+	regional, id, err := s.storeForId(id)
+	if err != nil {
+		return err
+	}
+	return regional.Write(id, value)
+}
+
+func (s *RegionalStore) WriteReserved(id SecretId, value string) error {
+	regional, id, err := s.storeForId(id)
+	if err != nil {
+		return err
+	}
+	return WriteReserved(regional, id, value)
+}
+
+func (s *RegionalStore) DeleteReserved(id SecretId) error {
+	regional, id, err := s.storeForId(id)
+	if err != nil {
+		return err
+	}
+	return DeleteReserved(regional, id)
+}
+
+func (s *RegionalStore) WriteMany(service string, values map[string]string) error {
+	regional, service, err := s.storeFor(service)
+	if err != nil {
+		return err
+	}
+	return WriteMany(regional, service, values)
+}
+
+func (s *RegionalStore) Read(id SecretId, version int) (Secret, error) {
+	regional, id, err := s.storeForId(id)
+	if err != nil {
+		return Secret{}, err
+	}
+	return regional.Read(id, version)
+}
+
+func (s *RegionalStore) ReadNativeVersion(id SecretId, version int64) (Secret, error) {
+	regional, id, err := s.storeForId(id)
+	if err != nil {
+		return Secret{}, err
+	}
+	return ReadNativeVersion(regional, id, version)
+}
+
+func (s *RegionalStore) ReadLabel(id SecretId, label string) (Secret, error) {
+	regional, id, err := s.storeForId(id)
+	if err != nil {
+		return Secret{}, err
+	}
+	return ReadLabel(regional, id, label)
+}
+
+func (s *RegionalStore) ListNative(service string) (map[string]NativeAttributes, error) {
+	regional, service, err := s.storeFor(service)
+	if err != nil {
+		return nil, err
+	}
+	return ListNative(regional, service)
+}
+
+func (s *RegionalStore) Rekey(id SecretId, kmsKey string) (bool, error) {
+	regional, id, err := s.storeForId(id)
+	if err != nil {
+		return false, err
+	}
+	return Rekey(regional, id, kmsKey)
+}
+
+func (s *RegionalStore) List(service string, includeValues bool) ([]Secret, error) {
+	regional, service, err := s.storeFor(service)
+	if err != nil {
+		return nil, err
+	}
+	return regional.List(service, includeValues)
+}
+
+func (s *RegionalStore) ListRaw(service string) ([]RawSecret, error) {
+	regional, service, err := s.storeFor(service)
+	if err != nil {
+		return nil, err
+	}
+	return regional.ListRaw(service)
+}
+
+func (s *RegionalStore) ListUnresolved(service string) ([]RawSecret, error) {
+	regional, service, err := s.storeFor(service)
+	if err != nil {
+		return nil, err
+	}
+	return ListUnresolved(regional, service)
+}
+
+func (s *RegionalStore) ListServices(service string, includeSecretName bool) ([]string, error) {
+	regional, service, err := s.storeFor(service)
+	if err != nil {
+		return nil, err
+	}
+	return regional.ListServices(service, includeSecretName)
+}
+
+func (s *RegionalStore) StreamList(service string, includeValues bool, fn func([]Secret) error) error {
+	regional, service, err := s.storeFor(service)
+	if err != nil {
+		return err
+	}
+	return StreamList(regional, service, includeValues, fn)
+}
+
+func (s *RegionalStore) StreamServices(service string, includeSecretName bool, fn func([]string) error) error {
+	regional, service, err := s.storeFor(service)
+	if err != nil {
+		return err
+	}
+	return StreamServices(regional, service, includeSecretName, fn)
+}
+
+func (s *RegionalStore) ListRefs(service string) ([]SecretRef, error) {
+	regional, service, err := s.storeFor(service)
+	if err != nil {
+		return nil, err
+	}
+	return ListRefs(regional, service)
+}
+
+// Capabilities are those of the default region's store, as every region has
+// the same backend
+func (s *RegionalStore) Capabilities() Capabilities {
+	return CapabilitiesOf(s.store)
+}
+
+func (s *RegionalStore) History(id SecretId) ([]ChangeEvent, error) {
+	regional, id, err := s.storeForId(id)
+	if err != nil {
+		return nil, err
+	}
+	return regional.History(id)
+}
+
+func (s *RegionalStore) Rename(id SecretId, newKey string) error {
+	regional, id, err := s.storeForId(id)
+	if err != nil {
+		return err
+	}
+	return Rename(regional, id, newKey)
+}
+
+func (s *RegionalStore) Renumber(id SecretId, version int) error {
+	regional, id, err := s.storeForId(id)
+	if err != nil {
+		return err
+	}
+	return Renumber(regional, id, version)
+}
+
+func (s *RegionalStore) Delete(id SecretId) error {
+	regional, id, err := s.storeForId(id)
+	if err != nil {
+		return err
+	}
+	return regional.Delete(id)
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitRegion(t *testing.T) {
+	for service, expected := range map[string][2]string{
+		"us-west-2:app/prod":     {"us-west-2", "app/prod"},
+		"us-gov-east-1:app":      {"us-gov-east-1", "app"},
+		"app/prod":               {"", "app/prod"},
+		"/app/prod:current":      {"", "/app/prod:current"},
+		"staging:app":            {"", "staging:app"},
+		"ap-southeast-1:app:x-1": {"ap-southeast-1", "app:x-1"},
+	} {
+		region, rest := SplitRegion(service)
+		assert.Equal(t, expected, [2]string{region, rest}, service)
+	}
+}
+
+func TestRegionalStore(t *testing.T) {
+	created := map[string]int{}
+	s := NewRegionalStore(
+		NewStaticStore(map[string]map[string]string{"app": {"region": "default"}}),
+		func(region string) (Store, error) {
+			created[region]++
+			return NewStaticStore(map[string]map[string]string{"app": {"region": region}}), nil
+		},
+	)
+
+	t.Run("Should serve services without a region from the default store", func(t *testing.T) {
+		secret, err := s.Read(SecretId{Service: "app", Key: "region"}, -1)
+		assert.Nil(t, err)
+		assert.Equal(t, "default", *secret.Value)
+	})
+
+	t.Run("Should serve services with a region from a store of the region", func(t *testing.T) {
+		for _, region := range []string{"us-west-2", "eu-central-1", "us-west-2"} {
+			rawSecrets, err := s.ListRaw(region + ":app")
+			assert.Nil(t, err)
+			assert.Equal(t, []RawSecret{{Key: "/app/region", Value: region}}, rawSecrets)
+		}
+		assert.Equal(t, map[string]int{"us-west-2": 1, "eu-central-1": 1}, created)
+	})
+
+	t.Run("Should keep chamber's records in the region of the service", func(t *testing.T) {
+		assert.Equal(t, "us-west-2:_chamber-meta/app", metadataId("us-west-2:app", "key").Service)
+		assert.Equal(t, "us-west-2:_chamber-freeze/app", freezeId("us-west-2:app").Service)
+	})
+
+	t.Run("Should reject regions without a way to create their store", func(t *testing.T) {
+		_, err := NewRegionalStore(NewNullStore(), nil).ListRaw("us-west-2:app")
+		assert.Error(t, err)
+	})
+}
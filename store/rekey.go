@@ -0,0 +1,84 @@
+package store
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// Rekeyer is implemented by stores that can re-encrypt a secret under
+// another KMS key without changing its value
+type Rekeyer interface {
+	// Rekey writes the latest value of id as a new version encrypted under
+	// kmsKey, and reads it back to verify it. It reports false, writing
+	// nothing, for secrets already encrypted under kmsKey or not encrypted
+	// with KMS at all.
+	Rekey(id SecretId, kmsKey string) (bool, error)
+}
+
+// Rekey re-encrypts the secret id under kmsKey, see Rekeyer
+func Rekey(s Store, id SecretId, kmsKey string) (bool, error) {
+	if r, ok := s.(Rekeyer); ok {
+		return r.Rekey(id, kmsKey)
+	}
+	return false, fmt.Errorf("backend can't re-encrypt secrets")
+}
+
+var _ Rekeyer = &SSMStore{}
+
+// Rekey rewrites a SecureString parameter under kmsKey. String parameters,
+// like those of --plaintext-keys, have no key and are left alone.
+func (s *SSMStore) Rekey(id SecretId, kmsKey string) (bool, error) {
+	name := s.idToName(id)
+	resp, err := s.svc.DescribeParameters(&ssm.DescribeParametersInput{
+		ParameterFilters: []*ssm.ParameterStringFilter{
+			{
+				Key:    aws.String("Name"),
+				Option: aws.String("Equals"),
+				Values: []*string{aws.String(name)},
+			},
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	var meta *ssm.ParameterMetadata
+	for _, p := range resp.Parameters {
+		if aws.StringValue(p.Name) == name {
+			meta = p
+		}
+	}
+	if meta == nil {
+		return false, ErrSecretNotFound
+	}
+	if aws.StringValue(meta.Type) != ParameterTypeSecure || aws.StringValue(meta.KeyId) == kmsKey {
+		return false, nil
+	}
+
+	current, err := s.Read(id, -1)
+	if err != nil {
+		return false, err
+	}
+	version := current.Meta.Version + 1
+	if _, err := s.svc.PutParameter(&ssm.PutParameterInput{
+		Name:        aws.String(name),
+		Value:       current.Value,
+		Overwrite:   aws.Bool(true),
+		Description: aws.String(strconv.Itoa(version)),
+		Type:        aws.String(ParameterTypeSecure),
+		KeyId:       aws.String(kmsKey),
+	}); err != nil {
+		return false, err
+	}
+
+	written, err := s.Read(id, -1)
+	if err != nil {
+		return true, err
+	}
+	if written.Value == nil || *written.Value != *current.Value || written.Meta.Version != version {
+		return true, &VerifyError{Id: id, Reason: fmt.Sprintf("version %d doesn't hold the value of version %d", version, current.Meta.Version)}
+	}
+	return true, nil
+}
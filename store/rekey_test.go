@@ -0,0 +1,60 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRekey(t *testing.T) {
+	mock := &mockSSMClient{parameters: map[string]mockParameter{}}
+	s := NewTestSSMStoreWithPaths(mock)
+	assert.Nil(t, s.SetPlaintextKeys([]string{"log_level"}))
+	password := SecretId{Service: "app", Key: "db_password"}
+	assert.Nil(t, s.Write(password, "one"))
+	assert.Nil(t, s.Write(password, "two"))
+	assert.Nil(t, s.Write(SecretId{Service: "app", Key: "log_level"}, "debug"))
+
+	t.Run("Should write the latest value as a new version under the key", func(t *testing.T) {
+		rekeyed, err := Rekey(s, password, "alias/new")
+		assert.Nil(t, err)
+		assert.True(t, rekeyed)
+		assert.Equal(t, "alias/new", aws.StringValue(mock.parameters["/app/db_password"].meta.KeyId))
+
+		secret, err := s.Read(password, -1)
+		assert.Nil(t, err)
+		assert.Equal(t, "two", *secret.Value)
+		assert.Equal(t, 3, secret.Meta.Version)
+	})
+
+	t.Run("Should skip secrets already under the key", func(t *testing.T) {
+		rekeyed, err := Rekey(s, password, "alias/new")
+		assert.Nil(t, err)
+		assert.False(t, rekeyed)
+		assert.Len(t, mock.parameters["/app/db_password"].history, 3)
+	})
+
+	t.Run("Should skip plaintext secrets", func(t *testing.T) {
+		rekeyed, err := Rekey(s, SecretId{Service: "app", Key: "log_level"}, "alias/new")
+		assert.Nil(t, err)
+		assert.False(t, rekeyed)
+	})
+
+	t.Run("Should report missing secrets", func(t *testing.T) {
+		_, err := Rekey(s, SecretId{Service: "app", Key: "missing"}, "alias/new")
+		assert.Equal(t, ErrSecretNotFound, err)
+	})
+
+	t.Run("Should refuse frozen services", func(t *testing.T) {
+		guarded := NewFreezeGuardStore(s)
+		assert.Nil(t, WriteFreeze(guarded, "app", Freeze{Reason: "audit"}))
+		_, err := Rekey(guarded, password, "alias/newer")
+		assert.IsType(t, &FrozenError{}, err)
+	})
+
+	t.Run("Should fail for backends that can't re-encrypt", func(t *testing.T) {
+		_, err := Rekey(NewStaticStore(nil), password, "alias/new")
+		assert.Error(t, err)
+	})
+}
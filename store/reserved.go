@@ -0,0 +1,62 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// reservedPrefix begins the names of the services chamber keeps its own
+// records in, like freezes, metadata and owners
+const reservedPrefix = "_chamber-"
+
+// IsReservedService reports whether service is one chamber keeps its own
+// records in, rather than an application's
+func IsReservedService(service string) bool {
+	_, service = SplitRegion(service)
+	return strings.HasPrefix(service, reservedPrefix)
+}
+
+// reservedId is where the record of key of service is kept in reserved, in
+// the same region as service
+func reservedId(reserved, service, key string) SecretId {
+	sep := "/"
+	if _, noPaths := os.LookupEnv("CHAMBER_NO_PATHS"); noPaths {
+		sep = "."
+	}
+	return SecretId{Service: inRegion(service, func(service string) string { return reserved + sep + service }), Key: key}
+}
+
+// ReservedError is returned when writing to a reserved service other than
+// through the functions that keep chamber's records there
+type ReservedError struct {
+	Service string
+}
+
+func (e *ReservedError) Error() string {
+	return fmt.Sprintf("service %s is reserved for chamber's own records and can't be written directly", e.Service)
+}
+
+// ReservedWriter is implemented by stores that guard the reserved services,
+// to write chamber's records there
+type ReservedWriter interface {
+	WriteReserved(id SecretId, value string) error
+	DeleteReserved(id SecretId) error
+}
+
+// WriteReserved writes a record of chamber's own to id, in a reserved service
+func WriteReserved(s Store, id SecretId, value string) error {
+	if w, ok := s.(ReservedWriter); ok {
+		return w.WriteReserved(id, value)
+	}
+	return s.Write(id, value)
+}
+
+// DeleteReserved deletes a record of chamber's own from id, in a reserved
+// service
+func DeleteReserved(s Store, id SecretId) error {
+	if w, ok := s.(ReservedWriter); ok {
+		return w.DeleteReserved(id)
+	}
+	return s.Delete(id)
+}
@@ -0,0 +1,189 @@
+package store
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+const (
+	// RetryModeStandard retries failed requests with capped exponential
+	// backoff and jitter.
+	RetryModeStandard = "standard"
+
+	// RetryModeAdaptive behaves like RetryModeStandard, and additionally slows
+	// down every request made by the process once AWS starts throttling, so
+	// large parallel fetches back off as a whole instead of request by request.
+	RetryModeAdaptive = "adaptive"
+)
+
+const (
+	// DefaultRetryBaseDelay is the default delay before the first retry
+	DefaultRetryBaseDelay = client.DefaultRetryerMinRetryDelay
+
+	// DefaultRetryMaxDelay is the default upper bound for a single retry delay
+	DefaultRetryMaxDelay = client.DefaultRetryerMaxRetryDelay
+)
+
+// RetryOptions configures how failed AWS requests are retried. When Mode is
+// empty, the AWS SDK's default retryer is used.
+type RetryOptions struct {
+	Mode string
+
+	// BaseDelay is the delay before the first retry; it doubles on every
+	// subsequent attempt up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	// Jitter is the fraction (0 to 1) of each delay that is randomized. 0
+	// disables jitter, 1 is "full jitter".
+	Jitter float64
+}
+
+// Validate checks that o describes a usable retry policy.
+func (o RetryOptions) Validate() error {
+	switch o.Mode {
+	case "", RetryModeStandard, RetryModeAdaptive:
+	default:
+		return fmt.Errorf("invalid retry mode `%s`", o.Mode)
+	}
+	if o.Jitter < 0 || o.Jitter > 1 {
+		return fmt.Errorf("retry jitter must be between 0 and 1, got %v", o.Jitter)
+	}
+	if o.MaxDelay > 0 && o.BaseDelay > o.MaxDelay {
+		return fmt.Errorf("retry base delay %s is larger than max delay %s", o.BaseDelay, o.MaxDelay)
+	}
+	return nil
+}
+
+// newRetryer returns the retryer for clients making at most numRetries
+// retries, honoring the configured RetryOptions.
+func newRetryer(numRetries int, minThrottleDelay time.Duration) request.Retryer {
+	opts := sessionOptions.Retry
+	if opts.Mode == "" {
+		return client.DefaultRetryer{NumMaxRetries: numRetries, MinThrottleDelay: minThrottleDelay}
+	}
+
+	r := backoffRetryer{
+		DefaultRetryer: client.DefaultRetryer{
+			NumMaxRetries:    numRetries,
+			MinRetryDelay:    opts.BaseDelay,
+			MaxRetryDelay:    opts.MaxDelay,
+			MinThrottleDelay: minThrottleDelay,
+			MaxThrottleDelay: opts.MaxDelay,
+		},
+		jitter: opts.Jitter,
+	}
+	if r.MinRetryDelay <= 0 {
+		r.MinRetryDelay = DefaultRetryBaseDelay
+	}
+	if r.MaxRetryDelay <= 0 {
+		r.MaxRetryDelay = DefaultRetryMaxDelay
+		r.MaxThrottleDelay = DefaultRetryMaxDelay
+	}
+	if r.MinThrottleDelay <= 0 {
+		r.MinThrottleDelay = client.DefaultRetryerMinThrottleDelay
+	}
+	return r
+}
+
+// backoffRetryer uses the SDK's rules for deciding whether to retry, but
+// computes the delay itself so the amount of jitter can be configured.
+type backoffRetryer struct {
+	client.DefaultRetryer
+	jitter float64
+}
+
+// RetryRules returns the delay before the next attempt of r
+func (b backoffRetryer) RetryRules(r *request.Request) time.Duration {
+	if b.NumMaxRetries == 0 {
+		return 0
+	}
+
+	minDelay, maxDelay := b.MinRetryDelay, b.MaxRetryDelay
+	if r.IsErrorThrottle() {
+		minDelay, maxDelay = b.MinThrottleDelay, b.MaxThrottleDelay
+	}
+	return backoffDelay(minDelay, maxDelay, r.RetryCount, b.jitter)
+}
+
+// backoffDelay returns base*2^attempt, capped at max, with the given fraction
+// of it randomized.
+func backoffDelay(base, max time.Duration, attempt int, jitter float64) time.Duration {
+	delay := max
+	if attempt < 62 && base < max>>uint(attempt) {
+		delay = base << uint(attempt)
+	}
+	if jitter > 0 {
+		spread := int64(float64(delay) * jitter)
+		if spread > 0 {
+			delay -= time.Duration(rand.Int63n(spread + 1))
+		}
+	}
+	return delay
+}
+
+// adaptiveLimiter delays every request issued by this process while AWS is
+// throttling us, doubling the delay on each throttle and halving it on each
+// success.
+type adaptiveLimiter struct {
+	mu    sync.Mutex
+	delay time.Duration
+	min   time.Duration
+	max   time.Duration
+}
+
+var limiter = &adaptiveLimiter{}
+
+func (l *adaptiveLimiter) install(handlers *request.Handlers, min, max time.Duration) {
+	l.mu.Lock()
+	l.min, l.max = min, max
+	l.mu.Unlock()
+
+	handlers.Send.PushFront(l.wait)
+	handlers.Retry.PushFront(l.observeFailure)
+	handlers.Complete.PushBack(l.observeSuccess)
+}
+
+func (l *adaptiveLimiter) wait(r *request.Request) {
+	l.mu.Lock()
+	delay := l.delay
+	l.mu.Unlock()
+	if delay > 0 {
+		if err := aws.SleepWithContext(r.Context(), delay); err != nil {
+			r.Error = err
+		}
+	}
+}
+
+func (l *adaptiveLimiter) observeFailure(r *request.Request) {
+	if !r.IsErrorThrottle() {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.delay *= 2
+	if l.delay < l.min {
+		l.delay = l.min
+	}
+	if l.delay > l.max {
+		l.delay = l.max
+	}
+}
+
+func (l *adaptiveLimiter) observeSuccess(r *request.Request) {
+	if r.Error != nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.delay /= 2
+	if l.delay < l.min {
+		l.delay = 0
+	}
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	t.Run("Delay should double on every attempt without jitter", func(t *testing.T) {
+		assert.Equal(t, 100*time.Millisecond, backoffDelay(100*time.Millisecond, time.Second, 0, 0))
+		assert.Equal(t, 400*time.Millisecond, backoffDelay(100*time.Millisecond, time.Second, 2, 0))
+	})
+
+	t.Run("Delay should be capped at the max delay", func(t *testing.T) {
+		assert.Equal(t, time.Second, backoffDelay(100*time.Millisecond, time.Second, 4, 0))
+		assert.Equal(t, time.Second, backoffDelay(100*time.Millisecond, time.Second, 100, 0))
+	})
+
+	t.Run("Jitter should only ever shorten the delay", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			d := backoffDelay(100*time.Millisecond, time.Second, 1, 0.5)
+			assert.True(t, d >= 100*time.Millisecond && d <= 200*time.Millisecond, "delay %s out of range", d)
+		}
+	})
+}
+
+func TestRetryOptions(t *testing.T) {
+	t.Run("Unknown retry modes should be rejected", func(t *testing.T) {
+		assert.Error(t, RetryOptions{Mode: "legacy"}.Validate())
+	})
+
+	t.Run("Jitter should be a fraction", func(t *testing.T) {
+		assert.Error(t, RetryOptions{Mode: RetryModeStandard, Jitter: 2}.Validate())
+	})
+
+	t.Run("Configured stores should use the backoff retryer", func(t *testing.T) {
+		ConfigureSessions(SessionOptions{Retry: RetryOptions{Mode: RetryModeStandard, BaseDelay: time.Second}})
+		defer ConfigureSessions(SessionOptions{})
+
+		s, err := NewSSMStore(3)
+		assert.Nil(t, err)
+		r := s.svc.(*ssm.SSM).Config.Retryer.(backoffRetryer)
+		assert.Equal(t, 3, r.NumMaxRetries)
+		assert.Equal(t, time.Second, r.MinRetryDelay)
+	})
+
+	t.Run("Stores should keep the SDK retryer by default", func(t *testing.T) {
+		s, err := NewSSMStore(3)
+		assert.Nil(t, err)
+		_, ok := s.svc.(*ssm.SSM).Config.Retryer.(client.DefaultRetryer)
+		assert.True(t, ok)
+	})
+
+	t.Run("S3 stores should honor the configured min throttle delay", func(t *testing.T) {
+		ConfigureSessions(SessionOptions{MinThrottleDelay: 2 * time.Second, Retry: RetryOptions{Mode: RetryModeStandard}})
+		defer ConfigureSessions(SessionOptions{})
+
+		s, err := NewS3StoreWithBucket(3, "bucket")
+		assert.Nil(t, err)
+		r := s.svc.(*s3.S3).Config.Retryer.(backoffRetryer)
+		assert.Equal(t, 2*time.Second, r.MinThrottleDelay)
+	})
+}
+
+func TestAdaptiveLimiter(t *testing.T) {
+	throttled := &request.Request{Error: awserr.New("ThrottlingException", "Rate exceeded", nil)}
+	succeeded := &request.Request{}
+
+	t.Run("Throttles should start at the min delay and double up to the max", func(t *testing.T) {
+		l := &adaptiveLimiter{min: 100 * time.Millisecond, max: time.Second}
+
+		l.observeFailure(throttled)
+		assert.Equal(t, 100*time.Millisecond, l.delay)
+		l.observeFailure(throttled)
+		assert.Equal(t, 200*time.Millisecond, l.delay)
+		for i := 0; i < 5; i++ {
+			l.observeFailure(throttled)
+		}
+		assert.Equal(t, time.Second, l.delay)
+	})
+
+	t.Run("Other failures should not change the delay", func(t *testing.T) {
+		l := &adaptiveLimiter{min: 100 * time.Millisecond, max: time.Second}
+
+		l.observeFailure(&request.Request{Error: awserr.New("ParameterNotFound", "", nil)})
+		assert.Equal(t, time.Duration(0), l.delay)
+	})
+
+	t.Run("Successes should halve the delay and drop it below the min", func(t *testing.T) {
+		l := &adaptiveLimiter{delay: 400 * time.Millisecond, min: 100 * time.Millisecond, max: time.Second}
+
+		l.observeSuccess(succeeded)
+		assert.Equal(t, 200*time.Millisecond, l.delay)
+		l.observeSuccess(succeeded)
+		assert.Equal(t, 100*time.Millisecond, l.delay)
+		l.observeSuccess(succeeded)
+		assert.Equal(t, time.Duration(0), l.delay)
+	})
+
+	t.Run("Failed requests should not count as successes", func(t *testing.T) {
+		l := &adaptiveLimiter{delay: 400 * time.Millisecond, min: 100 * time.Millisecond, max: time.Second}
+
+		l.observeSuccess(throttled)
+		assert.Equal(t, 400*time.Millisecond, l.delay)
+	})
+}
@@ -91,15 +91,48 @@ func NewS3StoreWithBucket(numRetries int, bucket string) (*S3Store, error) {
 }
 
 func (s *S3Store) Write(id SecretId, value string) error {
-	index, err := s.readLatest(id.Service)
+	return s.WriteMany(id.Service, map[string]string{id.Key: value})
+}
+
+var _ BatchWriter = &S3Store{}
+
+// WriteMany writes each value as a new version of the object of its key, and
+// updates the index of the service once rather than once per key
+func (s *S3Store) WriteMany(service string, values map[string]string) error {
+	for _, value := range values {
+		if err := ValidateValue(value); err != nil {
+			return err
+		}
+	}
+	index, err := s.readLatest(service)
+	if err != nil {
+		return err
+	}
+	user, err := s.getCurrentUser()
 	if err != nil {
 		return err
 	}
 
+	for i, key := range sortedKeys(values) {
+		if _, err := s.putVersion(SecretId{Service: service, Key: key}, values[key], user); err != nil {
+			if i > 0 {
+				// Keep the index in step with the keys already written
+				s.writeLatest(service, index)
+			}
+			return err
+		}
+		index.Latest[key] = values[key]
+	}
+	return s.writeLatest(service, index)
+}
+
+// putVersion adds value as the next version of the object of id, and
+// returns that version
+func (s *S3Store) putVersion(id SecretId, value, user string) (int, error) {
 	objPath := getObjectPath(id)
 	existing, ok, err := s.readObjectById(id)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	var obj secretObject
@@ -114,10 +147,6 @@ func (s *S3Store) Write(id SecretId, value string) error {
 	}
 
 	thisVersion := getLatestVersion(obj.Values) + 1
-	user, err := s.getCurrentUser()
-	if err != nil {
-		return err
-	}
 	obj.Values[thisVersion] = secretVersion{
 		Version:   thisVersion,
 		Value:     value,
@@ -129,7 +158,7 @@ func (s *S3Store) Write(id SecretId, value string) error {
 
 	contents, err := json.Marshal(obj)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	putObjectInput := &s3.PutObjectInput{
@@ -142,11 +171,9 @@ func (s *S3Store) Write(id SecretId, value string) error {
 	_, err = s.svc.PutObject(putObjectInput)
 	if err != nil {
 		// TODO: catch specific awserr
-		return err
+		return 0, err
 	}
-
-	index.Latest[id.Key] = value
-	return s.writeLatest(id.Service, index)
+	return thisVersion, nil
 }
 
 func (s *S3Store) Read(id SecretId, version int) (Secret, error) {
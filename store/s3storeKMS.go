@@ -76,19 +76,61 @@ func NewS3KMSStore(numRetries int, bucket string, kmsKeyAlias string) (*S3KMSSto
 }
 
 func (s *S3KMSStore) Write(id SecretId, value string) error {
-	index, err := s.readLatest(id.Service)
+	return s.WriteMany(id.Service, map[string]string{id.Key: value})
+}
+
+var _ BatchWriter = &S3KMSStore{}
+
+// WriteMany writes each value as a new version of the object of its key, and
+// updates the index of the service once rather than once per key. Nothing is
+// written if a key was written under another KMS key.
+func (s *S3KMSStore) WriteMany(service string, values map[string]string) error {
+	for _, value := range values {
+		if err := ValidateValue(value); err != nil {
+			return err
+		}
+	}
+	index, err := s.readLatest(service)
 	if err != nil {
 		return err
 	}
 
-	if val, ok := index.Latest[id.Key]; val.KMSAlias != s.kmsKeyAlias && ok {
-		return fmt.Errorf("Unable to overwrite secret %s using new KMS key %s; mismatch with existing key %s", id.Key, s.kmsKeyAlias, val.KMSAlias)
+	keys := sortedKeys(values)
+	for _, key := range keys {
+		if val, ok := index.Latest[key]; val.KMSAlias != s.kmsKeyAlias && ok {
+			return fmt.Errorf("Unable to overwrite secret %s using new KMS key %s; mismatch with existing key %s", key, s.kmsKeyAlias, val.KMSAlias)
+		}
+	}
+	user, err := s.getCurrentUser()
+	if err != nil {
+		return err
 	}
 
+	for i, key := range keys {
+		thisVersion, err := s.putVersion(SecretId{Service: service, Key: key}, values[key], user)
+		if err != nil {
+			if i > 0 {
+				// Keep the index in step with the keys already written
+				s.writeLatest(service, index)
+			}
+			return err
+		}
+		index.Latest[key] = LatestValue{
+			Version:  thisVersion,
+			Value:    values[key],
+			KMSAlias: s.kmsKeyAlias,
+		}
+	}
+	return s.writeLatest(service, index)
+}
+
+// putVersion adds value as the next version of the object of id, encrypted
+// with the store's KMS key, and returns that version
+func (s *S3KMSStore) putVersion(id SecretId, value, user string) (int, error) {
 	objPath := getObjectPath(id)
 	existing, ok, err := s.readObjectById(id)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	var obj secretObject
@@ -103,10 +145,6 @@ func (s *S3KMSStore) Write(id SecretId, value string) error {
 	}
 
 	thisVersion := getLatestVersion(obj.Values) + 1
-	user, err := s.getCurrentUser()
-	if err != nil {
-		return err
-	}
 	obj.Values[thisVersion] = secretVersion{
 		Version:   thisVersion,
 		Value:     value,
@@ -118,7 +156,7 @@ func (s *S3KMSStore) Write(id SecretId, value string) error {
 
 	contents, err := json.Marshal(obj)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	putObjectInput := &s3.PutObjectInput{
@@ -132,15 +170,9 @@ func (s *S3KMSStore) Write(id SecretId, value string) error {
 	_, err = s.svc.PutObject(putObjectInput)
 	if err != nil {
 		// TODO: catch specific awserr
-		return err
+		return 0, err
 	}
-
-	index.Latest[id.Key] = LatestValue{
-		Version:  thisVersion,
-		Value:    value,
-		KMSAlias: s.kmsKeyAlias,
-	}
-	return s.writeLatest(id.Service, index)
+	return thisVersion, nil
 }
 
 func (s *S3KMSStore) ListServices(service string, includeSecretName bool) ([]string, error) {
@@ -0,0 +1,181 @@
+package store
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// minScrubLength is the length below which values aren't scrubbed, since
+// replacing every "1" or "true" would garble messages without protecting
+// anything.
+const minScrubLength = 6
+
+const scrubbedValue = "*****"
+
+var knownSecrets struct {
+	sync.Mutex
+	values map[string]struct{}
+}
+
+// RegisterSecretValue makes Scrub hide value from now on
+func RegisterSecretValue(value string) {
+	if len(value) < minScrubLength {
+		return
+	}
+	knownSecrets.Lock()
+	defer knownSecrets.Unlock()
+	if knownSecrets.values == nil {
+		knownSecrets.values = map[string]struct{}{}
+	}
+	knownSecrets.values[value] = struct{}{}
+}
+
+// Scrub replaces the secret values read or written so far in s, so that s can
+// be printed in errors, panics and logs.
+func Scrub(s string) string {
+	knownSecrets.Lock()
+	values := make([]string, 0, len(knownSecrets.values))
+	for value := range knownSecrets.values {
+		values = append(values, value)
+	}
+	knownSecrets.Unlock()
+
+	// replace longer values first, so values containing others are replaced
+	// as a whole
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	for _, value := range values {
+		s = strings.Replace(s, value, scrubbedValue, -1)
+	}
+	return s
+}
+
+var _ Store = &ScrubbingStore{}
+
+// ScrubbingStore registers every secret value that passes through it with
+// RegisterSecretValue
+type ScrubbingStore struct {
+	store Store
+}
+
+// NewScrubbingStore returns a store that registers the values of s for Scrub
+func NewScrubbingStore(s Store) *ScrubbingStore {
+	return &ScrubbingStore{store: s}
+}
+
+func registerSecret(secret Secret) {
+	if secret.Value != nil {
+		RegisterSecretValue(*secret.Value)
+	}
+}
+
+func (s *ScrubbingStore) Write(id SecretId, value string) error {
+	RegisterSecretValue(value)
+	return s.store.Write(id, value)
+}
+
+func (s *ScrubbingStore) WriteReserved(id SecretId, value string) error {
+	return WriteReserved(s.store, id, value)
+}
+
+func (s *ScrubbingStore) DeleteReserved(id SecretId) error {
+	return DeleteReserved(s.store, id)
+}
+
+func (s *ScrubbingStore) WriteMany(service string, values map[string]string) error {
+	for _, value := range values {
+		RegisterSecretValue(value)
+	}
+	return WriteMany(s.store, service, values)
+}
+
+func (s *ScrubbingStore) Read(id SecretId, version int) (Secret, error) {
+	secret, err := s.store.Read(id, version)
+	registerSecret(secret)
+	return secret, err
+}
+
+func (s *ScrubbingStore) ReadNativeVersion(id SecretId, version int64) (Secret, error) {
+	secret, err := ReadNativeVersion(s.store, id, version)
+	registerSecret(secret)
+	return secret, err
+}
+
+func (s *ScrubbingStore) ReadLabel(id SecretId, label string) (Secret, error) {
+	secret, err := ReadLabel(s.store, id, label)
+	registerSecret(secret)
+	return secret, err
+}
+
+func (s *ScrubbingStore) ListNative(service string) (map[string]NativeAttributes, error) {
+	return ListNative(s.store, service)
+}
+
+func (s *ScrubbingStore) Rekey(id SecretId, kmsKey string) (bool, error) {
+	return Rekey(s.store, id, kmsKey)
+}
+
+func (s *ScrubbingStore) List(service string, includeValues bool) ([]Secret, error) {
+	secrets, err := s.store.List(service, includeValues)
+	for _, secret := range secrets {
+		registerSecret(secret)
+	}
+	return secrets, err
+}
+
+func (s *ScrubbingStore) ListRaw(service string) ([]RawSecret, error) {
+	secrets, err := s.store.ListRaw(service)
+	for _, secret := range secrets {
+		RegisterSecretValue(secret.Value)
+	}
+	return secrets, err
+}
+
+func (s *ScrubbingStore) ListUnresolved(service string) ([]RawSecret, error) {
+	secrets, err := ListUnresolved(s.store, service)
+	for _, secret := range secrets {
+		RegisterSecretValue(secret.Value)
+	}
+	return secrets, err
+}
+
+func (s *ScrubbingStore) ListServices(service string, includeSecretName bool) ([]string, error) {
+	return s.store.ListServices(service, includeSecretName)
+}
+
+func (s *ScrubbingStore) StreamList(service string, includeValues bool, fn func([]Secret) error) error {
+	return StreamList(s.store, service, includeValues, func(secrets []Secret) error {
+		for _, secret := range secrets {
+			registerSecret(secret)
+		}
+		return fn(secrets)
+	})
+}
+
+func (s *ScrubbingStore) StreamServices(service string, includeSecretName bool, fn func([]string) error) error {
+	return StreamServices(s.store, service, includeSecretName, fn)
+}
+
+func (s *ScrubbingStore) ListRefs(service string) ([]SecretRef, error) {
+	return ListRefs(s.store, service)
+}
+
+func (s *ScrubbingStore) Capabilities() Capabilities {
+	return CapabilitiesOf(s.store)
+}
+
+func (s *ScrubbingStore) History(id SecretId) ([]ChangeEvent, error) {
+	return s.store.History(id)
+}
+
+func (s *ScrubbingStore) Rename(id SecretId, newKey string) error {
+	return Rename(s.store, id, newKey)
+}
+
+func (s *ScrubbingStore) Renumber(id SecretId, version int) error {
+	return Renumber(s.store, id, version)
+}
+
+func (s *ScrubbingStore) Delete(id SecretId) error {
+	return s.store.Delete(id)
+}
@@ -0,0 +1,29 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScrub(t *testing.T) {
+	s := NewScrubbingStore(NewStaticStore(map[string]map[string]string{
+		"app": {"db_password": "hunter2hunter2", "db_url": "postgres://app:hunter2hunter2@db", "replicas": "3"},
+	}))
+
+	t.Run("Should hide values read from the store", func(t *testing.T) {
+		_, err := s.ListRaw("app")
+		assert.Nil(t, err)
+		assert.Equal(t, "invalid value *****", Scrub("invalid value postgres://app:hunter2hunter2@db"))
+		assert.Equal(t, "password ***** rejected", Scrub("password hunter2hunter2 rejected"))
+	})
+
+	t.Run("Should hide values written to the store", func(t *testing.T) {
+		s.Write(SecretId{Service: "app", Key: "token"}, "s3cr3t-token")
+		assert.Equal(t, "bad token *****", Scrub("bad token s3cr3t-token"))
+	})
+
+	t.Run("Should leave short values alone", func(t *testing.T) {
+		assert.Equal(t, "retrying 3 times", Scrub("retrying 3 times"))
+	})
+}
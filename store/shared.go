@@ -1,12 +1,24 @@
 package store
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/processcreds"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/ec2metadata"
 	"github.com/aws/aws-sdk-go/aws/endpoints"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
 )
 
 const (
@@ -14,6 +26,94 @@ const (
 	CustomSSMEndpointEnvVar = "CHAMBER_AWS_SSM_ENDPOINT"
 )
 
+const (
+	// maxIdleConnsPerHost allows commands that make many concurrent requests
+	// to a single AWS endpoint to keep their connections open
+	maxIdleConnsPerHost = 32
+
+	ec2MetadataTimeout = 1 * time.Second
+)
+
+// SessionOptions tunes the AWS sessions created by the stores in this
+// package. The zero value keeps the AWS SDK defaults.
+type SessionOptions struct {
+	// RequestTimeout bounds each individual HTTP request made to AWS,
+	// including reading the response body. Zero means no limit.
+	RequestTimeout time.Duration
+
+	// MaxElapsed bounds the total time spent in AWS requests, summed over all
+	// requests and including retries and the delays between them. Once it is
+	// used up, in-flight requests are aborted and further requests fail with
+	// ErrMaxElapsed. Zero means no limit.
+	MaxElapsed time.Duration
+
+	// RecordAPICalls records every AWS request, for APICalls
+	RecordAPICalls bool
+
+	// MaxAPICalls bounds the number of AWS requests, not counting retries.
+	// Further requests fail with ErrMaxAPICalls. Zero means no limit.
+	MaxAPICalls int
+
+	// MinThrottleDelay is the minimum delay before retrying a throttled
+	// request. Zero means DefaultMinThrottleDelay.
+	MinThrottleDelay time.Duration
+
+	// Retry configures how failed requests are retried.
+	Retry RetryOptions
+
+	// Proxy is the URL of the proxy used for all AWS requests. When empty,
+	// HTTPS_PROXY, HTTP_PROXY and NO_PROXY are honored.
+	Proxy string
+
+	// CABundle is the path to a PEM file with the certificate authorities
+	// trusted for AWS endpoints, replacing the system roots.
+	CABundle string
+
+	// TLSMinVersion is the minimum TLS version accepted, e.g.
+	// tls.VersionTLS12. Zero keeps the Go default.
+	TLSMinVersion uint16
+
+	// CredentialProcess is a command printing credentials in the
+	// credential_process format of the AWS CLI, used instead of the
+	// credentials found by the AWS SDK. Any Role is assumed with them.
+	CredentialProcess string
+
+	// Role configures an IAM role to assume for all requests.
+	Role RoleOptions
+
+	// DisableHTTP2 restricts AWS requests to HTTP/1.1, e.g. for proxies
+	// that mishandle HTTP/2.
+	DisableHTTP2 bool
+
+	// CacheCredentials caches temporary credentials, e.g. of assumed roles,
+	// in the keyring of the OS until they expire.
+	CacheCredentials bool
+}
+
+// TLSVersions maps the accepted names of TLS versions to their values
+var TLSVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+var sessionOptions SessionOptions
+
+// ConfigureSessions sets the options used by every store created afterwards.
+// Configuring them again with the same HTTP options keeps the shared HTTP
+// client and its open connections.
+func ConfigureSessions(opts SessionOptions) {
+	if opts.httpOptions() != sessionOptions.httpOptions() {
+		sharedHTTPClient.Lock()
+		sharedHTTPClient.current = nil
+		sharedHTTPClient.Unlock()
+	}
+	sessionOptions = opts
+	budget.reset(opts.MaxElapsed)
+	apiCalls.reset(opts.MaxAPICalls)
+}
+
 func getSession(numRetries int) (*session.Session, *string, error) {
 	var region *string
 
@@ -31,32 +131,232 @@ func getSession(numRetries int) (*session.Session, *string, error) {
 	if regionOverride, ok := os.LookupEnv(RegionEnvVar); ok {
 		region = aws.String(regionOverride)
 	}
+	config := aws.Config{
+		Region:           region,
+		MaxRetries:       aws.Int(numRetries),
+		EndpointResolver: endpoints.ResolverFunc(endpointResolver),
+		Logger: aws.LoggerFunc(func(args ...interface{}) {
+			fmt.Fprintln(os.Stderr, Scrub(fmt.Sprint(args...)))
+		}),
+	}
+	httpClient, err := newHTTPClient()
+	if err != nil {
+		return nil, nil, err
+	}
+	config.HTTPClient = httpClient
+	minThrottleDelay := sessionOptions.MinThrottleDelay
+	if minThrottleDelay <= 0 {
+		minThrottleDelay = DefaultMinThrottleDelay
+	}
+	if sessionOptions.Retry.Mode != "" {
+		config.Retryer = newRetryer(numRetries, minThrottleDelay)
+	}
 	retSession, err := session.NewSessionWithOptions(
 		session.Options{
-			Config: aws.Config{
-				Region:           region,
-				MaxRetries:       aws.Int(numRetries),
-				EndpointResolver: endpoints.ResolverFunc(endpointResolver),
-			},
-			SharedConfigState: session.SharedConfigEnable,
+			Config:                  config,
+			SharedConfigState:       session.SharedConfigEnable,
+			AssumeRoleTokenProvider: stscreds.StdinTokenProvider,
 		},
 	)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	if sessionOptions.Retry.Mode == RetryModeAdaptive {
+		maxDelay := sessionOptions.Retry.MaxDelay
+		if maxDelay <= 0 {
+			maxDelay = DefaultRetryMaxDelay
+		}
+		limiter.install(&retSession.Handlers, minThrottleDelay, maxDelay)
+	}
+
+	if sessionOptions.MaxElapsed > 0 {
+		budget.install(&retSession.Handlers)
+	}
+
+	if sessionOptions.RecordAPICalls || sessionOptions.MaxAPICalls > 0 {
+		apiCalls.install(&retSession.Handlers)
+	}
+
 	// If region is still not set, attempt to determine it via ec2 metadata API
 	if aws.StringValue(retSession.Config.Region) == "" {
-		session := session.New()
+		// the metadata service is only reachable on EC2, so don't wait for it
+		// longer than the SDK would
+		metadataClient := &http.Client{Transport: httpClient.Transport, Timeout: ec2MetadataTimeout}
+		session := session.New(&aws.Config{HTTPClient: metadataClient})
 		ec2metadataSvc := ec2metadata.New(session)
 		if regionOverride, err := ec2metadataSvc.Region(); err == nil {
 			region = aws.String(regionOverride)
 		}
 	}
 
+	if sessionOptions.CredentialProcess != "" {
+		retSession.Config.Credentials = processcreds.NewCredentials(sessionOptions.CredentialProcess)
+	}
+
+	creds, err := roleCredentials(retSession.Copy(&aws.Config{Region: region}), sessionOptions.Role, httpClient)
+	if err != nil {
+		return nil, nil, err
+	}
+	if creds != nil {
+		retSession.Config.Credentials = creds
+	}
+
+	if sessionOptions.CacheCredentials {
+		if key := credentialsCacheKey(sessionOptions.Role.RoleARN); key != "" {
+			kr, err := newKeyring()
+			if err != nil {
+				return nil, nil, err
+			}
+			retSession.Config.Credentials = newKeyringCredentials(kr, key, retSession.Config.Credentials)
+		}
+	}
+
 	return retSession, region, nil
 }
 
+// httpOptions returns the options the shared HTTP client is built from
+func (o SessionOptions) httpOptions() SessionOptions {
+	return SessionOptions{
+		RequestTimeout: o.RequestTimeout,
+		Proxy:          o.Proxy,
+		CABundle:       o.CABundle,
+		TLSMinVersion:  o.TLSMinVersion,
+		DisableHTTP2:   o.DisableHTTP2,
+	}
+}
+
+// sharedClient is the HTTP client of one configuration of the sessions,
+// built when first used
+type sharedClient struct {
+	once   sync.Once
+	client *http.Client
+	err    error
+}
+
+func (c *sharedClient) get() (*http.Client, error) {
+	c.once.Do(func() {
+		transport, err := newTransport()
+		if err != nil {
+			c.err = err
+			return
+		}
+		c.client = &http.Client{
+			Transport: transport,
+			Timeout:   sessionOptions.RequestTimeout,
+		}
+	})
+	return c.client, c.err
+}
+
+// sharedHTTPClient is reused by every session of an invocation, so that
+// commands touching several services or many secrets reuse connections
+// instead of paying for a TLS handshake each time.
+var sharedHTTPClient struct {
+	sync.Mutex
+	current *sharedClient
+}
+
+// newHTTPClient returns the shared HTTP client for AWS requests
+func newHTTPClient() (*http.Client, error) {
+	sharedHTTPClient.Lock()
+	if sharedHTTPClient.current == nil {
+		sharedHTTPClient.current = &sharedClient{}
+	}
+	c := sharedHTTPClient.current
+	sharedHTTPClient.Unlock()
+	return c.get()
+}
+
+func newTransport() (*http.Transport, error) {
+	opts := sessionOptions
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	if opts.DisableHTTP2 {
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	if opts.Proxy != "" {
+		proxyURL, err := url.Parse(opts.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL %s: %s", opts.Proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if opts.CABundle != "" || opts.TLSMinVersion != 0 {
+		tlsConfig := &tls.Config{MinVersion: opts.TLSMinVersion}
+		if opts.CABundle != "" {
+			pem, err := ioutil.ReadFile(opts.CABundle)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read CA bundle: %s", err)
+			}
+			roots := x509.NewCertPool()
+			if !roots.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in CA bundle %s", opts.CABundle)
+			}
+			tlsConfig.RootCAs = roots
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return transport, nil
+}
+
+// ProxyFor returns the proxy that requests to endpoint go through, or nil if
+// they are sent directly.
+func ProxyFor(endpoint string) (*url.URL, error) {
+	transport, err := newTransport()
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return transport.Proxy(&http.Request{URL: u})
+}
+
+// CallerIdentity returns the ARN of the AWS principal that chamber runs as,
+// along with the STS endpoint used to determine it. It is the cheapest way of
+// checking that AWS is reachable with the current configuration, and creates
+// no session besides the one for STS.
+func CallerIdentity(numRetries int) (arn string, endpoint string, err error) {
+	session, region, err := getSession(numRetries)
+	if err != nil {
+		return "", "", err
+	}
+
+	svc := sts.New(session, &aws.Config{Region: region})
+	resp, err := svc.GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", svc.Endpoint, err
+	}
+	return aws.StringValue(resp.Arn), svc.Endpoint, nil
+}
+
+// SessionCredentials returns the credentials chamber makes AWS requests with,
+// after any role has been assumed, along with when they expire. The
+// expiration is zero for credentials that don't expire.
+func SessionCredentials(numRetries int) (credentials.Value, time.Time, error) {
+	session, _, err := getSession(numRetries)
+	if err != nil {
+		return credentials.Value{}, time.Time{}, err
+	}
+
+	value, err := session.Config.Credentials.Get()
+	if err != nil {
+		return credentials.Value{}, time.Time{}, err
+	}
+	expires, err := session.Config.Credentials.ExpiresAt()
+	if err != nil {
+		// the provider doesn't expire its credentials
+		expires = time.Time{}
+	}
+	return value, expires, nil
+}
+
 func uniqueStringSlice(slice []string) []string {
 	unique := make(map[string]struct{}, len(slice))
 	j := 0
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTransport(t *testing.T) {
+	defer ConfigureSessions(SessionOptions{})
+
+	t.Run("Should reject an invalid proxy URL", func(t *testing.T) {
+		ConfigureSessions(SessionOptions{Proxy: "http://%zz"})
+		_, err := newTransport()
+		assert.Error(t, err)
+	})
+
+	t.Run("Should route requests through the configured proxy", func(t *testing.T) {
+		ConfigureSessions(SessionOptions{Proxy: "http://proxy.example.com:3128"})
+		proxy, err := ProxyFor("https://ssm.us-east-1.amazonaws.com")
+		assert.Nil(t, err)
+		assert.Equal(t, "proxy.example.com:3128", proxy.Host)
+	})
+
+	t.Run("Should reject a CA bundle without certificates", func(t *testing.T) {
+		f, err := ioutil.TempFile("", "chamber-ca")
+		assert.Nil(t, err)
+		defer os.Remove(f.Name())
+		f.WriteString("not a certificate\n")
+		f.Close()
+
+		ConfigureSessions(SessionOptions{CABundle: f.Name()})
+		_, err = newTransport()
+		assert.Error(t, err)
+	})
+
+	t.Run("Should apply the minimum TLS version", func(t *testing.T) {
+		ConfigureSessions(SessionOptions{TLSMinVersion: tls.VersionTLS12})
+		transport, err := newTransport()
+		assert.Nil(t, err)
+		assert.Equal(t, uint16(tls.VersionTLS12), transport.TLSClientConfig.MinVersion)
+	})
+
+	t.Run("Should allow HTTP/2 to be disabled", func(t *testing.T) {
+		ConfigureSessions(SessionOptions{DisableHTTP2: true})
+		transport, err := newTransport()
+		assert.Nil(t, err)
+		assert.False(t, transport.ForceAttemptHTTP2)
+		assert.NotNil(t, transport.TLSNextProto)
+	})
+}
+
+func TestSharedHTTPClient(t *testing.T) {
+	defer ConfigureSessions(SessionOptions{})
+	ConfigureSessions(SessionOptions{})
+
+	t.Run("Should reuse one client across sessions", func(t *testing.T) {
+		first, err := newHTTPClient()
+		assert.Nil(t, err)
+		second, err := newHTTPClient()
+		assert.Nil(t, err)
+		assert.True(t, first == second)
+		assert.Equal(t, maxIdleConnsPerHost, first.Transport.(*http.Transport).MaxIdleConnsPerHost)
+	})
+
+	t.Run("Should keep the client when configured again with the same HTTP options", func(t *testing.T) {
+		first, err := newHTTPClient()
+		assert.Nil(t, err)
+		ConfigureSessions(SessionOptions{MaxAPICalls: 10})
+		second, err := newHTTPClient()
+		assert.Nil(t, err)
+		assert.True(t, first == second)
+		ConfigureSessions(SessionOptions{})
+	})
+
+	t.Run("Should create a new client when reconfigured", func(t *testing.T) {
+		first, err := newHTTPClient()
+		assert.Nil(t, err)
+		ConfigureSessions(SessionOptions{RequestTimeout: time.Second})
+		second, err := newHTTPClient()
+		assert.Nil(t, err)
+		assert.False(t, first == second)
+		assert.Equal(t, time.Second, second.Timeout)
+	})
+}
@@ -0,0 +1,158 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// snapshotIdFormat names snapshots by the time they were taken, so they sort
+// oldest first
+const snapshotIdFormat = "20060102T150405Z"
+
+// ErrSnapshotNotFound is returned for snapshots that don't exist, or have
+// been expired by the bucket's lifecycle rules
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+// Snapshot is a copy of the latest values of the keys of some services,
+// taken at one time
+type Snapshot struct {
+	Id       string                       `json:"id"`
+	Created  time.Time                    `json:"created"`
+	Services map[string]map[string]string `json:"services"`
+}
+
+// SnapshotInfo describes a stored snapshot without its values
+type SnapshotInfo struct {
+	Id      string
+	Created time.Time
+	Size    int64
+}
+
+// NewSnapshot returns an empty snapshot taken at now
+func NewSnapshot(now time.Time) *Snapshot {
+	now = now.UTC().Truncate(time.Second)
+	return &Snapshot{
+		Id:       now.Format(snapshotIdFormat),
+		Created:  now,
+		Services: map[string]map[string]string{},
+	}
+}
+
+// S3SnapshotStore keeps Snapshots as single objects in S3, encrypted with
+// KMS, one per snapshot, and leaves expiring them to the lifecycle rules of
+// the bucket.
+type S3SnapshotStore struct {
+	svc    s3iface.S3API
+	bucket string
+	prefix string
+	kmsKey string
+}
+
+// NewS3SnapshotStore returns a store for a location like s3://bucket/prefix,
+// encrypting snapshots with kmsKey
+func NewS3SnapshotStore(numRetries int, location, kmsKey string) (*S3SnapshotStore, error) {
+	u, err := url.Parse(location)
+	if err != nil || u.Scheme != "s3" || u.Host == "" {
+		return nil, fmt.Errorf("invalid snapshot location %q; expected s3://bucket/prefix", location)
+	}
+
+	session, region, err := getSession(numRetries)
+	if err != nil {
+		return nil, err
+	}
+	svc := s3.New(session, &aws.Config{
+		MaxRetries: aws.Int(numRetries),
+		Region:     region,
+	})
+	return &S3SnapshotStore{svc: svc, bucket: u.Host, prefix: strings.Trim(u.Path, "/"), kmsKey: kmsKey}, nil
+}
+
+func (s *S3SnapshotStore) objectPrefix() string {
+	if s.prefix == "" {
+		return ""
+	}
+	return s.prefix + "/"
+}
+
+func (s *S3SnapshotStore) objectName(id string) string {
+	return s.objectPrefix() + id + ".json"
+}
+
+// Put stores snapshot
+func (s *S3SnapshotStore) Put(snapshot *Snapshot) error {
+	body, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	_, err = s.svc.PutObject(&s3.PutObjectInput{
+		Bucket:               aws.String(s.bucket),
+		Key:                  aws.String(s.objectName(snapshot.Id)),
+		Body:                 bytes.NewReader(body),
+		ContentType:          aws.String("application/json"),
+		ServerSideEncryption: aws.String(s3.ServerSideEncryptionAwsKms),
+		SSEKMSKeyId:          aws.String(s.kmsKey),
+	})
+	return err
+}
+
+// List returns the stored snapshots, oldest first
+func (s *S3SnapshotStore) List() ([]SnapshotInfo, error) {
+	var snapshots []SnapshotInfo
+	err := s.svc.ListObjectsPages(&s3.ListObjectsInput{
+		Bucket:    aws.String(s.bucket),
+		Prefix:    aws.String(s.objectPrefix()),
+		Delimiter: aws.String("/"),
+	}, func(page *s3.ListObjectsOutput, lastPage bool) bool {
+		for _, obj := range page.Contents {
+			name := strings.TrimPrefix(aws.StringValue(obj.Key), s.objectPrefix())
+			if !strings.HasSuffix(name, ".json") {
+				continue
+			}
+			id := strings.TrimSuffix(name, ".json")
+			created, err := time.Parse(snapshotIdFormat, id)
+			if err != nil {
+				// not a snapshot
+				continue
+			}
+			snapshots = append(snapshots, SnapshotInfo{Id: id, Created: created, Size: aws.Int64Value(obj.Size)})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Id < snapshots[j].Id })
+	return snapshots, nil
+}
+
+// Get reads the snapshot id
+func (s *S3SnapshotStore) Get(id string) (*Snapshot, error) {
+	resp, err := s.svc.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.objectName(id)),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+			return nil, ErrSnapshotNotFound
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var snapshot Snapshot
+	if err := json.NewDecoder(resp.Body).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("invalid snapshot %s: %s", id, err)
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,42 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestS3SnapshotStore(t *testing.T) {
+	mock := &mockS3Client{objects: map[string][]byte{}}
+	snapshots := &S3SnapshotStore{svc: mock, bucket: "backups", prefix: "chamber/snapshots", kmsKey: "alias/backups"}
+	now := time.Date(2024, 6, 10, 12, 0, 0, 500, time.UTC)
+
+	older := NewSnapshot(now.Add(-24 * time.Hour))
+	older.Services["app/prod"] = map[string]string{"db_password": "one"}
+	latest := NewSnapshot(now)
+	latest.Services["app/prod"] = map[string]string{"db_password": "two"}
+	assert.Nil(t, snapshots.Put(latest))
+	assert.Nil(t, snapshots.Put(older))
+	mock.objects["chamber/snapshots/README.txt"] = []byte("not a snapshot")
+
+	t.Run("Snapshots should be named by the time they were taken", func(t *testing.T) {
+		assert.Equal(t, "20240610T120000Z", latest.Id)
+		assert.Contains(t, mock.objects, "chamber/snapshots/20240610T120000Z.json")
+	})
+
+	t.Run("Should list snapshots oldest first", func(t *testing.T) {
+		infos, err := snapshots.List()
+		assert.Nil(t, err)
+		assert.Len(t, infos, 2)
+		assert.Equal(t, older.Id, infos[0].Id)
+		assert.Equal(t, latest.Id, infos[1].Id)
+		assert.Equal(t, latest.Created, infos[1].Created)
+	})
+
+	t.Run("Should read snapshots back", func(t *testing.T) {
+		snapshot, err := snapshots.Get(older.Id)
+		assert.Nil(t, err)
+		assert.Equal(t, older, snapshot)
+	})
+}
@@ -21,6 +21,14 @@ const (
 
 	// DefaultMinThrottleDelay is the default delay before retrying throttled requests
 	DefaultMinThrottleDelay = client.DefaultRetryerMinThrottleDelay
+
+	// renamedFromLabelPrefix, followed by the old key, labels the first
+	// version of a renamed parameter
+	renamedFromLabelPrefix = "renamed-from."
+
+	// describeParametersPageSize is the most parameters DescribeParameters
+	// returns at once; it returns 10 unless told otherwise
+	describeParametersPageSize = 50
 )
 
 // validPathKeyFormat is the format that is expected for key names inside parameter store
@@ -40,8 +48,12 @@ var labelMatchRegex = regexp.MustCompile(`^(\/[\w\-\.]+)+:(.+)$`)
 // SSMStore implements the Store interface for storing secrets in SSM Parameter
 // Store
 type SSMStore struct {
-	svc      ssmiface.SSMAPI
-	usePaths bool
+	svc           ssmiface.SSMAPI
+	usePaths      bool
+	plaintextKeys []string
+
+	// layout names parameters other than /service/key when set
+	layout *Layout
 }
 
 // NewSSMStore creates a new SSMStore
@@ -61,7 +73,7 @@ func ssmStoreUsingRetryer(numRetries int, minThrottleDelay time.Duration) (*SSMS
 		return nil, err
 	}
 
-	retryer := client.DefaultRetryer{NumMaxRetries: numRetries, MinThrottleDelay: minThrottleDelay}
+	retryer := newRetryer(numRetries, minThrottleDelay)
 
 	usePaths := true
 	_, ok := os.LookupEnv("CHAMBER_NO_PATHS")
@@ -95,6 +107,9 @@ func (s *SSMStore) KMSKey() string {
 // Write writes a given value to a secret identified by id.  If the secret
 // already exists, then write a new version.
 func (s *SSMStore) Write(id SecretId, value string) error {
+	if err := ValidateValue(value); err != nil {
+		return err
+	}
 	version := 1
 	// first read to get the current version
 	current, err := s.Read(id, -1)
@@ -106,13 +121,12 @@ func (s *SSMStore) Write(id SecretId, value string) error {
 	}
 
 	putParameterInput := &ssm.PutParameterInput{
-		KeyId:       aws.String(s.KMSKey()),
 		Name:        aws.String(s.idToName(id)),
-		Type:        aws.String("SecureString"),
 		Value:       aws.String(value),
 		Overwrite:   aws.Bool(true),
 		Description: aws.String(strconv.Itoa(version)),
 	}
+	s.putParameterType(putParameterInput, id)
 
 	// This API call returns an empty struct
 	_, err = s.svc.PutParameter(putParameterInput)
@@ -123,6 +137,25 @@ func (s *SSMStore) Write(id SecretId, value string) error {
 	return nil
 }
 
+// Renumber records the current value of the secret id again, with version as
+// the version number in its description
+func (s *SSMStore) Renumber(id SecretId, version int) error {
+	current, err := s.readLatest(id)
+	if err != nil {
+		return err
+	}
+
+	putParameterInput := &ssm.PutParameterInput{
+		Name:        aws.String(s.idToName(id)),
+		Value:       current.Value,
+		Overwrite:   aws.Bool(true),
+		Description: aws.String(strconv.Itoa(version)),
+	}
+	s.putParameterType(putParameterInput, id)
+	_, err = s.svc.PutParameter(putParameterInput)
+	return err
+}
+
 // Read reads a secret from the parameter store at a specific version.
 // To grab the latest version, use -1 as the version number.
 func (s *SSMStore) Read(id SecretId, version int) (Secret, error) {
@@ -133,6 +166,46 @@ func (s *SSMStore) Read(id SecretId, version int) (Secret, error) {
 	return s.readVersion(id, version)
 }
 
+// Rename moves a secret to newKey. Versions continue to be numbered from the
+// old key, and the first version of the new key is labeled with the old key so
+// that History reports the rename. The history of the old key itself is lost,
+// as SSM deletes it along with the parameter.
+func (s *SSMStore) Rename(id SecretId, newKey string) error {
+	newId := SecretId{Service: id.Service, Key: newKey}
+	if _, err := s.Read(newId, -1); err != ErrSecretNotFound {
+		if err == nil {
+			return fmt.Errorf("secret %s already exists", s.idToName(newId))
+		}
+		return err
+	}
+	current, err := s.Read(id, -1)
+	if err != nil {
+		return err
+	}
+
+	putParameterInput := &ssm.PutParameterInput{
+		Name:        aws.String(s.idToName(newId)),
+		Value:       current.Value,
+		Overwrite:   aws.Bool(false),
+		Description: aws.String(strconv.Itoa(current.Meta.Version + 1)),
+	}
+	s.putParameterType(putParameterInput, newId)
+	if _, err := s.svc.PutParameter(putParameterInput); err != nil {
+		return err
+	}
+
+	labelParameterVersionInput := &ssm.LabelParameterVersionInput{
+		Name:             aws.String(s.idToName(newId)),
+		ParameterVersion: aws.Int64(1),
+		Labels:           []*string{aws.String(renamedFromLabelPrefix + id.Key)},
+	}
+	if _, err := s.svc.LabelParameterVersion(labelParameterVersionInput); err != nil {
+		return err
+	}
+
+	return s.Delete(id)
+}
+
 // Delete removes a secret from the parameter store. Note this removes all
 // versions of the secret.
 func (s *SSMStore) Delete(id SecretId) error {
@@ -171,10 +244,10 @@ func (s *SSMStore) readVersion(id SecretId, version int) (Secret, error) {
 				result = Secret{
 					Value: history.Value,
 					Meta: SecretMetadata{
-						Created:   *history.LastModifiedDate,
-						CreatedBy: *history.LastModifiedUser,
+						Created:   aws.TimeValue(history.LastModifiedDate),
+						CreatedBy: aws.StringValue(history.LastModifiedUser),
 						Version:   thisVersion,
-						Key:       *history.Name,
+						Key:       s.secretKey(*history.Name),
 					},
 				}
 				return false
@@ -191,6 +264,46 @@ func (s *SSMStore) readVersion(id SecretId, version int) (Secret, error) {
 	return Secret{}, ErrSecretNotFound
 }
 
+// ReadNativeVersion reads the secret id as of the SSM parameter version
+// version. The version of the result is the one chamber recorded for it.
+func (s *SSMStore) ReadNativeVersion(id SecretId, version int64) (Secret, error) {
+	getParameterHistoryInput := &ssm.GetParameterHistoryInput{
+		Name:           aws.String(s.idToName(id)),
+		WithDecryption: aws.Bool(true),
+	}
+
+	var result Secret
+	if err := s.svc.GetParameterHistoryPages(getParameterHistoryInput, func(o *ssm.GetParameterHistoryOutput, lastPage bool) bool {
+		for _, history := range o.Parameters {
+			if history.Version == nil || *history.Version != version {
+				continue
+			}
+			thisVersion := 0
+			if history.Description != nil {
+				thisVersion, _ = strconv.Atoi(*history.Description)
+			}
+			result = Secret{
+				Value: history.Value,
+				Meta: SecretMetadata{
+					Created:   aws.TimeValue(history.LastModifiedDate),
+					CreatedBy: aws.StringValue(history.LastModifiedUser),
+					Version:   thisVersion,
+					Key:       s.secretKey(*history.Name),
+				},
+			}
+			return false
+		}
+		return true
+	}); err != nil {
+		return Secret{}, ErrSecretNotFound
+	}
+	if result.Value != nil {
+		return result, nil
+	}
+
+	return Secret{}, ErrSecretNotFound
+}
+
 func (s *SSMStore) readLatest(id SecretId) (Secret, error) {
 	getParametersInput := &ssm.GetParametersInput{
 		Names:          []*string{aws.String(s.idToName(id))},
@@ -211,7 +324,18 @@ func (s *SSMStore) readLatest(id SecretId) (Secret, error) {
 
 	// To get metadata, we need to use describe parameters
 
-	if s.usePaths {
+	switch {
+	case s.layout != nil:
+		describeParametersInput = &ssm.DescribeParametersInput{
+			ParameterFilters: []*ssm.ParameterStringFilter{
+				{
+					Key:    aws.String("Name"),
+					Option: aws.String("Equals"),
+					Values: []*string{aws.String(s.idToName(id))},
+				},
+			},
+		}
+	case s.usePaths:
 		// There is no way to use describe parameters to get a single key
 		// if that key uses paths, so instead get all the keys for a path,
 		// then find the one you are looking for :(
@@ -224,7 +348,7 @@ func (s *SSMStore) readLatest(id SecretId) (Secret, error) {
 				},
 			},
 		}
-	} else {
+	default:
 		describeParametersInput = &ssm.DescribeParametersInput{
 			Filters: []*ssm.ParametersFilter{
 				{
@@ -252,6 +376,7 @@ func (s *SSMStore) readLatest(id SecretId) (Secret, error) {
 	}
 
 	secretMeta := parameterMetaToSecretMeta(parameter)
+	secretMeta.Key = s.secretKey(secretMeta.Key)
 
 	return Secret{
 		Value: param.Value,
@@ -260,57 +385,106 @@ func (s *SSMStore) readLatest(id SecretId) (Secret, error) {
 }
 
 func (s *SSMStore) ListServices(service string, includeSecretName bool) ([]string, error) {
+	if s.layout != nil {
+		return s.listServicesWithLayout(service, includeSecretName)
+	}
+	prefix := service + "."
+	if s.usePaths {
+		prefix = "/" + service
+	}
+	parameters, err := s.describeParametersWithPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+
 	secrets := map[string]Secret{}
-	var describeParametersInput *ssm.DescribeParametersInput
+	for _, meta := range parameters {
+		if !s.validateName(*meta.Name) {
+			continue
+		}
+		secretMeta := parameterMetaToSecretMeta(meta)
+		secrets[secretMeta.Key] = Secret{
+			Value: nil,
+			Meta:  secretMeta,
+		}
+	}
+
+	if includeSecretName {
+		return keys(secrets), nil
+	}
 
+	var services []string
+	for key := range secrets {
+		services = append(services, serviceName(key))
+	}
+
+	return uniqueStringSlice(services), nil
+}
+
+// describeParametersInput filters DescribeParameters by names beginning with
+// prefix, with as many parameters per page as SSM allows. Names in a path are
+// found by SSM's index of the hierarchy the prefix lies in, and only narrowed
+// by name when the prefix ends within a segment of that hierarchy.
+func (s *SSMStore) describeParametersInput(prefix string) *ssm.DescribeParametersInput {
+	if s.usePaths && strings.HasPrefix(prefix, "/") {
+		path := prefix[:strings.LastIndex(prefix, "/")]
+		if path == "" {
+			path = "/"
+		}
+		filters := []*ssm.ParameterStringFilter{
+			{
+				Key:    aws.String("Path"),
+				Option: aws.String("Recursive"),
+				Values: []*string{aws.String(path)},
+			},
+		}
+		if prefix != path && prefix != path+"/" {
+			filters = append(filters, &ssm.ParameterStringFilter{
+				Key:    aws.String("Name"),
+				Option: aws.String("BeginsWith"),
+				Values: []*string{aws.String(prefix)},
+			})
+		}
+		return &ssm.DescribeParametersInput{
+			MaxResults:       aws.Int64(describeParametersPageSize),
+			ParameterFilters: filters,
+		}
+	}
 	if s.usePaths {
-		describeParametersInput = &ssm.DescribeParametersInput{
+		return &ssm.DescribeParametersInput{
+			MaxResults: aws.Int64(describeParametersPageSize),
 			ParameterFilters: []*ssm.ParameterStringFilter{
 				{
 					Key:    aws.String("Name"),
 					Option: aws.String("BeginsWith"),
-					Values: []*string{aws.String("/" + service)},
+					Values: []*string{aws.String(prefix)},
 				},
 			},
 		}
-	} else {
-		describeParametersInput = &ssm.DescribeParametersInput{
-			Filters: []*ssm.ParametersFilter{
-				{
-					Key:    aws.String("Name"),
-					Values: []*string{aws.String(service + ".")},
-				},
+	}
+	return &ssm.DescribeParametersInput{
+		MaxResults: aws.Int64(describeParametersPageSize),
+		Filters: []*ssm.ParametersFilter{
+			{
+				Key:    aws.String("Name"),
+				Values: []*string{aws.String(prefix)},
 			},
-		}
+		},
 	}
+}
 
-	err := s.svc.DescribeParametersPages(describeParametersInput, func(resp *ssm.DescribeParametersOutput, lastPage bool) bool {
-		for _, meta := range resp.Parameters {
-			if !s.validateName(*meta.Name) {
-				continue
-			}
-			secretMeta := parameterMetaToSecretMeta(meta)
-			secrets[secretMeta.Key] = Secret{
-				Value: nil,
-				Meta:  secretMeta,
-			}
-		}
+// describeParametersWithPrefix returns the metadata of the parameters whose
+// names begin with prefix
+func (s *SSMStore) describeParametersWithPrefix(prefix string) ([]*ssm.ParameterMetadata, error) {
+	var parameters []*ssm.ParameterMetadata
+	err := s.svc.DescribeParametersPages(s.describeParametersInput(prefix), func(resp *ssm.DescribeParametersOutput, lastPage bool) bool {
+		parameters = append(parameters, resp.Parameters...)
 		return true
 	})
 	if err != nil {
 		return nil, err
 	}
-
-	if includeSecretName {
-		return keys(secrets), nil
-	}
-
-	var services []string
-	for key := range secrets {
-		services = append(services, serviceName(key))
-	}
-
-	return uniqueStringSlice(services), nil
+	return parameters, nil
 }
 
 // List lists all secrets for a given service.  If includeValues is true,
@@ -319,32 +493,12 @@ func (s *SSMStore) ListServices(service string, includeSecretName bool) ([]strin
 func (s *SSMStore) List(serviceName string, includeValues bool) ([]Secret, error) {
 	secrets := map[string]Secret{}
 
-	var describeParametersInput *ssm.DescribeParametersInput
-
 	service, _ := parseServiceLabel(serviceName)
-
-	if s.usePaths {
-		describeParametersInput = &ssm.DescribeParametersInput{
-			ParameterFilters: []*ssm.ParameterStringFilter{
-				{
-					Key:    aws.String("Path"),
-					Option: aws.String("OneLevel"),
-					Values: []*string{aws.String("/" + service)},
-				},
-			},
-		}
-	} else {
-		describeParametersInput = &ssm.DescribeParametersInput{
-			Filters: []*ssm.ParametersFilter{
-				{
-					Key:    aws.String("Name"),
-					Values: []*string{aws.String(service + ".")},
-				},
-			},
-		}
+	if s.layout != nil {
+		return s.listWithLayout(service, includeValues)
 	}
 
-	err := s.svc.DescribeParametersPages(describeParametersInput, func(resp *ssm.DescribeParametersOutput, lastPage bool) bool {
+	err := s.svc.DescribeParametersPages(s.listInput(service), func(resp *ssm.DescribeParametersOutput, lastPage bool) bool {
 		for _, meta := range resp.Parameters {
 			if !s.validateName(*meta.Name) {
 				continue
@@ -362,41 +516,79 @@ func (s *SSMStore) List(serviceName string, includeValues bool) ([]Secret, error
 	}
 
 	if includeValues {
-		secretKeys := keys(secrets)
-		for i := 0; i < len(secretKeys); i += 10 {
-			batchEnd := i + 10
-			if i+10 > len(secretKeys) {
-				batchEnd = len(secretKeys)
-			}
-			batch := secretKeys[i:batchEnd]
-
-			getParametersInput := &ssm.GetParametersInput{
-				Names:          stringsToAWSStrings(batch),
-				WithDecryption: aws.Bool(true),
-			}
+		if err := s.readValues(secrets); err != nil {
+			return nil, err
+		}
+	}
 
-			resp, err := s.svc.GetParameters(getParametersInput)
-			if err != nil {
-				return nil, err
-			}
+	return values(secrets), nil
+}
 
-			for _, param := range resp.Parameters {
-				secret := secrets[*param.Name]
-				secret.Value = param.Value
-				secrets[*param.Name] = secret
-			}
+// listInput filters DescribeParameters by the parameters of service
+func (s *SSMStore) listInput(service string) *ssm.DescribeParametersInput {
+	if s.usePaths {
+		return &ssm.DescribeParametersInput{
+			ParameterFilters: []*ssm.ParameterStringFilter{
+				{
+					Key:    aws.String("Path"),
+					Option: aws.String("OneLevel"),
+					Values: []*string{aws.String("/" + service)},
+				},
+			},
 		}
 	}
+	return &ssm.DescribeParametersInput{
+		Filters: []*ssm.ParametersFilter{
+			{
+				Key:    aws.String("Name"),
+				Values: []*string{aws.String(service + ".")},
+			},
+		},
+	}
+}
 
-	return values(secrets), nil
+// readValues sets the values of secrets, keyed by parameter name, ten
+// parameters at a time as GetParameters allows
+func (s *SSMStore) readValues(secrets map[string]Secret) error {
+	secretKeys := keys(secrets)
+	for i := 0; i < len(secretKeys); i += 10 {
+		batchEnd := i + 10
+		if i+10 > len(secretKeys) {
+			batchEnd = len(secretKeys)
+		}
+		batch := secretKeys[i:batchEnd]
+
+		getParametersInput := &ssm.GetParametersInput{
+			Names:          stringsToAWSStrings(batch),
+			WithDecryption: aws.Bool(true),
+		}
+
+		resp, err := s.svc.GetParameters(getParametersInput)
+		if err != nil {
+			return err
+		}
+
+		for _, param := range resp.Parameters {
+			secret := secrets[*param.Name]
+			secret.Value = param.Value
+			secrets[*param.Name] = secret
+		}
+	}
+	return nil
 }
 
 // ListRaw lists all secrets keys and values for a given service. Does not include any
 // other meta-data. Uses faster AWS APIs with much higher rate-limits. Suitable for
 // use in production environments.
 func (s *SSMStore) ListRaw(serviceName string) ([]RawSecret, error) {
+	if IsSecretsManagerReference(serviceName) {
+		return s.listReference(serviceName)
+	}
 	service, label := parseServiceLabel(serviceName)
-	if s.usePaths {
+	if label != "" && s.layout != nil {
+		return nil, fmt.Errorf("labels require chamber's default layout")
+	}
+	if s.usePaths && s.layout == nil {
 		secrets := map[string]RawSecret{}
 		getParametersByPathInput := &ssm.GetParametersByPathInput{
 			Path:           aws.String("/" + service + "/"),
@@ -478,12 +670,25 @@ func (s *SSMStore) History(id SecretId) ([]ChangeEvent, error) {
 			if history.Description != nil {
 				version, _ = strconv.Atoi(*history.Description)
 			}
-			events = append(events, ChangeEvent{
-				Type:    getChangeType(version),
-				Time:    *history.LastModifiedDate,
-				User:    *history.LastModifiedUser,
-				Version: version,
-			})
+			event := ChangeEvent{
+				Type:          getChangeType(version),
+				Time:          aws.TimeValue(history.LastModifiedDate),
+				User:          aws.StringValue(history.LastModifiedUser),
+				Version:       version,
+				NativeVersion: aws.Int64Value(history.Version),
+			}
+			if version == 0 && len(events) == 0 {
+				// Parameters adopted from outside of chamber carry no version
+				// of chamber's, so take their first event as the creation
+				event.Type = Created
+			}
+			for _, label := range history.Labels {
+				if strings.HasPrefix(aws.StringValue(label), renamedFromLabelPrefix) {
+					event.Type = Renamed
+					event.RenamedFrom = strings.TrimPrefix(*label, renamedFromLabelPrefix)
+				}
+			}
+			events = append(events, event)
 		}
 		return true
 	}); err != nil {
@@ -516,7 +721,17 @@ func (s *SSMStore) listRawViaList(service string) ([]RawSecret, error) {
 }
 
 func (s *SSMStore) idToName(id SecretId) string {
-	if s.usePaths {
+	return ParameterName(s.layout, s.usePaths, id)
+}
+
+// ParameterName returns the name SSMStore gives the parameter of id, under
+// layout, or chamber's own naming when layout is nil. With an empty key, it's
+// the beginning of the names of the parameters of the service.
+func ParameterName(layout *Layout, usePaths bool, id SecretId) string {
+	if layout != nil {
+		return layout.name(id)
+	}
+	if usePaths {
 		return fmt.Sprintf("/%s/%s", id.Service, id.Key)
 	}
 
@@ -524,6 +739,10 @@ func (s *SSMStore) idToName(id SecretId) string {
 }
 
 func (s *SSMStore) validateName(name string) bool {
+	if s.layout != nil {
+		_, ok := s.layout.id(name)
+		return ok
+	}
 	if s.usePaths {
 		return validPathKeyFormat.MatchString(name)
 	}
@@ -554,8 +773,8 @@ func parameterMetaToSecretMeta(p *ssm.ParameterMetadata) SecretMetadata {
 		version, _ = strconv.Atoi(*p.Description)
 	}
 	return SecretMetadata{
-		Created:   *p.LastModifiedDate,
-		CreatedBy: *p.LastModifiedUser,
+		Created:   aws.TimeValue(p.LastModifiedDate),
+		CreatedBy: aws.StringValue(p.LastModifiedUser),
 		Version:   version,
 		Key:       *p.Name,
 	}
@@ -36,9 +36,11 @@ func (m *mockSSMClient) PutParameter(i *ssm.PutParameterInput) (*ssm.PutParamete
 	}
 
 	current.currentParam = &ssm.Parameter{
-		Name:  i.Name,
-		Type:  i.Type,
-		Value: i.Value,
+		ARN:     aws.String("arn:aws:ssm:us-east-1:123456789012:parameter/" + strings.TrimPrefix(*i.Name, "/")),
+		Name:    i.Name,
+		Type:    i.Type,
+		Value:   i.Value,
+		Version: aws.Int64(int64(len(current.history) + 1)),
 	}
 	current.meta = &ssm.ParameterMetadata{
 		Description:      i.Description,
@@ -56,6 +58,7 @@ func (m *mockSSMClient) PutParameter(i *ssm.PutParameterInput) (*ssm.PutParamete
 		Name:             current.meta.Name,
 		Type:             current.meta.Type,
 		Value:            current.currentParam.Value,
+		Version:          aws.Int64(int64(len(current.history) + 1)),
 	}
 	current.history = append(current.history, history)
 
@@ -71,8 +74,10 @@ func (m *mockSSMClient) GetParameters(i *ssm.GetParametersInput) (*ssm.GetParame
 		if paramNameInSlice(param.meta.Name, i.Names) {
 			if *i.WithDecryption == false {
 				parameters = append(parameters, &ssm.Parameter{
-					Name:  param.meta.Name,
-					Value: nil,
+					ARN:     param.currentParam.ARN,
+					Name:    param.meta.Name,
+					Value:   nil,
+					Version: param.currentParam.Version,
 				})
 			} else {
 				parameters = append(parameters, param.currentParam)
@@ -118,6 +123,8 @@ func (m *mockSSMClient) GetParameterHistory(i *ssm.GetParameterHistoryInput) (*s
 			Name:             hist.Name,
 			Type:             hist.Type,
 			Value:            nil,
+			Labels:           hist.Labels,
+			Version:          hist.Version,
 		})
 	}
 	return &ssm.GetParameterHistoryOutput{
@@ -200,6 +207,18 @@ func (m *mockSSMClient) GetParameterHistoryPages(i *ssm.GetParameterHistoryInput
 	return nil
 }
 
+func (m *mockSSMClient) LabelParameterVersion(i *ssm.LabelParameterVersionInput) (*ssm.LabelParameterVersionOutput, error) {
+	param, ok := m.parameters[*i.Name]
+	if !ok || int(*i.ParameterVersion) > len(param.history) {
+		return &ssm.LabelParameterVersionOutput{}, errors.New("parameter version not found")
+	}
+
+	hist := param.history[*i.ParameterVersion-1]
+	hist.Labels = append(hist.Labels, i.Labels...)
+
+	return &ssm.LabelParameterVersionOutput{}, nil
+}
+
 func (m *mockSSMClient) DeleteParameter(i *ssm.DeleteParameterInput) (*ssm.DeleteParameterOutput, error) {
 	_, ok := m.parameters[*i.Name]
 	if !ok {
@@ -229,14 +248,15 @@ func prefixInSlice(val *string, prefixes []*string) bool {
 	return false
 }
 
-func pathInSlice(val *string, paths []*string) bool {
-	tokens := strings.Split(*val, "/")
-	if len(tokens) < 2 {
-		return false
-	}
-	matchPath := "/" + tokens[1]
+// pathInSlice reports whether the parameter name is in one of paths, directly
+// for the OneLevel option SSM defaults to, or at any depth for Recursive
+func pathInSlice(name *string, option *string, paths []*string) bool {
+	parent := (*name)[:strings.LastIndex(*name, "/")]
 	for _, path := range paths {
-		if matchPath == *path {
+		if parent == strings.TrimSuffix(*path, "/") {
+			return true
+		}
+		if aws.StringValue(option) == "Recursive" && strings.HasPrefix(*name, strings.TrimSuffix(*path, "/")+"/") {
 			return true
 		}
 	}
@@ -265,16 +285,12 @@ func matchFilters(filters []*ssm.ParametersFilter, param mockParameter) (bool, e
 
 func matchStringFilters(filters []*ssm.ParameterStringFilter, param mockParameter) (bool, error) {
 	for _, filter := range filters {
-		var compareTo *string
 		switch *filter.Key {
 		case "Path":
-			tokens := strings.Split(*param.meta.Name, "/")
-			if len(tokens) < 2 {
+			if !strings.HasPrefix(*param.meta.Name, "/") {
 				return false, errors.New("path filter used on non path value")
 			}
-			compareTo = aws.String("/" + tokens[1] + "/")
-
-			if !pathInSlice(compareTo, filter.Values) {
+			if !pathInSlice(param.meta.Name, filter.Option, filter.Values) {
 				return false, nil
 			}
 
@@ -286,8 +302,9 @@ func matchStringFilters(filters []*ssm.ParameterStringFilter, param mockParamete
 						result = true
 					}
 				}
-
-				return result, nil
+				if !result {
+					return false, nil
+				}
 			}
 		}
 	}
@@ -350,6 +367,15 @@ func TestNewSSMStore(t *testing.T) {
 		assert.Equal(t, DefaultMinThrottleDelay, s.svc.(*ssm.SSM).Config.Retryer.(client.DefaultRetryer).MinThrottleDelay)
 	})
 
+	t.Run("Should apply the configured request timeout", func(t *testing.T) {
+		ConfigureSessions(SessionOptions{RequestTimeout: 5 * time.Second})
+		defer ConfigureSessions(SessionOptions{})
+
+		s, err := NewSSMStore(1)
+		assert.Nil(t, err)
+		assert.Equal(t, 5*time.Second, s.svc.(*ssm.SSM).Config.HTTPClient.Timeout)
+	})
+
 }
 
 func TestNewSSMStoreMinThrottleDelay(t *testing.T) {
@@ -365,6 +391,21 @@ func TestWrite(t *testing.T) {
 	mock := &mockSSMClient{parameters: map[string]mockParameter{}}
 	store := NewTestSSMStore(mock)
 
+	t.Run("Values should be stored byte for byte", func(t *testing.T) {
+		secretId := SecretId{Service: "test", Key: "certificate"}
+		value := "-----BEGIN CERTIFICATE-----\r\nMIIB\r\n-----END CERTIFICATE-----\r\n\n"
+		err := store.Write(secretId, value)
+		assert.Nil(t, err)
+		secret, err := store.Read(secretId, -1)
+		assert.Nil(t, err)
+		assert.Equal(t, value, *secret.Value)
+	})
+
+	t.Run("Values that aren't UTF-8 should be rejected", func(t *testing.T) {
+		err := store.Write(SecretId{Service: "test", Key: "binary"}, "\xff\xfe")
+		assert.Equal(t, ErrInvalidEncoding, err)
+	})
+
 	t.Run("Setting a new key should work", func(t *testing.T) {
 		secretId := SecretId{Service: "test", Key: "mykey"}
 		err := store.Write(secretId, "value")
@@ -591,6 +632,63 @@ func TestHistory(t *testing.T) {
 	})
 }
 
+func TestHistoryAdopted(t *testing.T) {
+	mock := &mockSSMClient{parameters: map[string]mockParameter{}}
+	store := NewTestSSMStore(mock)
+
+	// parameters written without chamber have neither chamber's version in
+	// their description nor, e.g. for some AWS services, a modifying user
+	name := aws.String("test.adopted")
+	mock.parameters[*name] = mockParameter{
+		currentParam: &ssm.Parameter{Name: name, Value: aws.String("v2")},
+		meta:         &ssm.ParameterMetadata{Name: name},
+		history: []*ssm.ParameterHistory{
+			{Name: name, Value: aws.String("v1"), Version: aws.Int64(1), LastModifiedDate: aws.Time(time.Now()), LastModifiedUser: aws.String("alice")},
+			{Name: name, Value: aws.String("v2"), Version: aws.Int64(2), LastModifiedDate: aws.Time(time.Now())},
+		},
+	}
+
+	t.Run("History should be synthesized from the parameter's own versions", func(t *testing.T) {
+		events, err := store.History(SecretId{Service: "test", Key: "adopted"})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(events))
+		assert.Equal(t, Created, events[0].Type)
+		assert.Equal(t, "alice", events[0].User)
+		assert.Equal(t, int64(1), events[0].NativeVersion)
+		assert.Equal(t, Updated, events[1].Type)
+		assert.Equal(t, "", events[1].User)
+		assert.Equal(t, int64(2), events[1].NativeVersion)
+		assert.Equal(t, 0, events[1].Version)
+	})
+
+	t.Run("Versions of the history should be readable", func(t *testing.T) {
+		secret, err := store.ReadNativeVersion(SecretId{Service: "test", Key: "adopted"}, 2)
+		assert.Nil(t, err)
+		assert.Equal(t, "v2", *secret.Value)
+	})
+
+	t.Run("Reading and listing should not need a modifying user or date", func(t *testing.T) {
+		bare := aws.String("test.bare")
+		mock.parameters[*bare] = mockParameter{
+			currentParam: &ssm.Parameter{Name: bare, Value: aws.String("v1")},
+			meta:         &ssm.ParameterMetadata{Name: bare},
+			history:      []*ssm.ParameterHistory{{Name: bare, Value: aws.String("v1"), Version: aws.Int64(1)}},
+		}
+		secret, err := store.Read(SecretId{Service: "test", Key: "bare"}, 0)
+		assert.Nil(t, err)
+		assert.Equal(t, "v1", *secret.Value)
+		assert.Equal(t, "", secret.Meta.CreatedBy)
+
+		secrets, err := store.List("test", false)
+		assert.Nil(t, err)
+		assert.Len(t, secrets, 2)
+		for _, secret := range secrets {
+			assert.Equal(t, "", secret.Meta.CreatedBy)
+			assert.True(t, secret.Meta.Created.IsZero())
+		}
+	})
+}
+
 func NewTestSSMStoreWithPaths(mock ssmiface.SSMAPI) *SSMStore {
 	return &SSMStore{
 		svc:      mock,
@@ -772,6 +870,48 @@ func TestDelete(t *testing.T) {
 	})
 }
 
+func TestRename(t *testing.T) {
+	mock := &mockSSMClient{parameters: map[string]mockParameter{}}
+	store := NewTestSSMStore(mock)
+
+	oldId := SecretId{Service: "test", Key: "old"}
+	newId := SecretId{Service: "test", Key: "new"}
+	store.Write(oldId, "value1")
+	store.Write(oldId, "value2")
+	store.Write(SecretId{Service: "test", Key: "taken"}, "value")
+
+	t.Run("Renaming should move the latest value and continue its versions", func(t *testing.T) {
+		err := store.Rename(oldId, "new")
+		assert.Nil(t, err)
+
+		secret, err := store.Read(newId, -1)
+		assert.Nil(t, err)
+		assert.Equal(t, "value2", *secret.Value)
+		assert.Equal(t, 3, secret.Meta.Version)
+
+		_, err = store.Read(oldId, -1)
+		assert.Equal(t, ErrSecretNotFound, err)
+	})
+
+	t.Run("History should show the rename", func(t *testing.T) {
+		events, err := store.History(newId)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(events))
+		assert.Equal(t, Renamed, events[0].Type)
+		assert.Equal(t, "old", events[0].RenamedFrom)
+	})
+
+	t.Run("Renaming onto an existing key should fail", func(t *testing.T) {
+		err := store.Rename(newId, "taken")
+		assert.Error(t, err)
+	})
+
+	t.Run("Renaming a missing secret should fail", func(t *testing.T) {
+		err := store.Rename(SecretId{Service: "test", Key: "missing"}, "other")
+		assert.Equal(t, ErrSecretNotFound, err)
+	})
+}
+
 func TestValidations(t *testing.T) {
 	mock := &mockSSMClient{parameters: map[string]mockParameter{}}
 	pathStore := NewTestSSMStore(mock)
@@ -884,3 +1024,98 @@ type ByKeyRaw []RawSecret
 func (a ByKeyRaw) Len() int           { return len(a) }
 func (a ByKeyRaw) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a ByKeyRaw) Less(i, j int) bool { return a[i].Key < a[j].Key }
+
+func TestSSMReadNativeVersion(t *testing.T) {
+	mock := &mockSSMClient{parameters: map[string]mockParameter{}}
+	s := NewTestSSMStore(mock)
+	secretId := SecretId{Service: "test", Key: "key"}
+	assert.Nil(t, s.Write(secretId, "first"))
+	assert.Nil(t, s.Write(secretId, "second"))
+
+	// make the recorded versions drift from SSM's own
+	for _, h := range mock.parameters[s.idToName(secretId)].history {
+		h.Description = aws.String("7")
+	}
+
+	t.Run("Should read by the SSM parameter version", func(t *testing.T) {
+		secret, err := ReadNativeVersion(s, secretId, 1)
+		assert.Nil(t, err)
+		assert.Equal(t, "first", *secret.Value)
+		assert.Equal(t, 7, secret.Meta.Version)
+	})
+
+	t.Run("Should fail for unknown versions", func(t *testing.T) {
+		_, err := ReadNativeVersion(s, secretId, 3)
+		assert.Equal(t, ErrSecretNotFound, err)
+	})
+
+	t.Run("Should fail for stores without native versions", func(t *testing.T) {
+		_, err := ReadNativeVersion(NewNullStore(), secretId, 1)
+		assert.NotNil(t, err)
+	})
+}
+
+// pagingSSMClient returns one parameter per page from DescribeParametersPages
+// and records the inputs it was called with
+type pagingSSMClient struct {
+	*mockSSMClient
+	inputs []*ssm.DescribeParametersInput
+}
+
+func (m *pagingSSMClient) DescribeParametersPages(i *ssm.DescribeParametersInput, fn func(*ssm.DescribeParametersOutput, bool) bool) error {
+	m.inputs = append(m.inputs, i)
+	o, err := m.mockSSMClient.DescribeParameters(i)
+	if err != nil {
+		return err
+	}
+	for n, param := range o.Parameters {
+		if !fn(&ssm.DescribeParametersOutput{Parameters: []*ssm.ParameterMetadata{param}}, n == len(o.Parameters)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func TestSSMListServicesPaginated(t *testing.T) {
+	mock := &pagingSSMClient{mockSSMClient: &mockSSMClient{parameters: map[string]mockParameter{}}}
+	s := NewTestSSMStoreWithPaths(mock)
+	for _, service := range []string{"api", "app", "app-canary", "app/nested", "batch", "web"} {
+		assert.Nil(t, s.Write(SecretId{Service: service, Key: "key"}, "value"))
+	}
+
+	t.Run("Should list every service from a single listing", func(t *testing.T) {
+		mock.inputs = nil
+		services, err := s.ListServices("", false)
+		assert.Nil(t, err)
+		sort.Strings(services)
+		assert.Equal(t, []string{"api", "app", "app-canary", "app/nested", "batch", "web"}, services)
+		assert.Equal(t, 1, len(mock.inputs))
+		assert.Equal(t, []*ssm.ParameterStringFilter{
+			{Key: aws.String("Path"), Option: aws.String("Recursive"), Values: []*string{aws.String("/")}},
+		}, mock.inputs[0].ParameterFilters)
+	})
+
+	t.Run("Should only list services with the prefix", func(t *testing.T) {
+		mock.inputs = nil
+		services, err := s.ListServices("app", false)
+		assert.Nil(t, err)
+		sort.Strings(services)
+		assert.Equal(t, []string{"app", "app-canary", "app/nested"}, services)
+		assert.Equal(t, 1, len(mock.inputs))
+		assert.Equal(t, []*ssm.ParameterStringFilter{
+			{Key: aws.String("Path"), Option: aws.String("Recursive"), Values: []*string{aws.String("/")}},
+			{Key: aws.String("Name"), Option: aws.String("BeginsWith"), Values: []*string{aws.String("/app")}},
+		}, mock.inputs[0].ParameterFilters)
+	})
+
+	t.Run("Should list a nested hierarchy by its path", func(t *testing.T) {
+		mock.inputs = nil
+		services, err := s.ListServices("app/", false)
+		assert.Nil(t, err)
+		sort.Strings(services)
+		assert.Equal(t, []string{"app", "app/nested"}, services)
+		assert.Equal(t, []*ssm.ParameterStringFilter{
+			{Key: aws.String("Path"), Option: aws.String("Recursive"), Values: []*string{aws.String("/app")}},
+		}, mock.inputs[0].ParameterFilters)
+	})
+}
@@ -0,0 +1,11 @@
+package store
+
+// stagedService is the service under which a value being rotated in is
+// staged, until it is promoted to its key or dropped.
+const stagedService = "_chamber-staged"
+
+// StagedId is where the value being rotated in to key of service is staged.
+// It is written with WriteReserved, like chamber's other records.
+func StagedId(service, key string) SecretId {
+	return reservedId(stagedService, service, key)
+}
@@ -0,0 +1,215 @@
+package store
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var _ Store = &StaticStore{}
+
+// ErrStaticStoreReadOnly is returned when modifying a StaticStore
+var ErrStaticStoreReadOnly = errors.New("static store is read-only")
+
+// StaticStore serves a fixed set of secrets loaded up front, without talking
+// to AWS. It is meant for unit tests, demos and air-gapped environments.
+type StaticStore struct {
+	// services maps service names to their secrets
+	services map[string]map[string]string
+
+	// shared secrets are served for every service, as read from an env file
+	shared map[string]string
+
+	usePaths bool
+}
+
+// NewStaticStore creates a StaticStore serving the given secrets, keyed by
+// service and then by key.
+func NewStaticStore(services map[string]map[string]string) *StaticStore {
+	_, noPaths := os.LookupEnv("CHAMBER_NO_PATHS")
+	s := &StaticStore{services: map[string]map[string]string{}, usePaths: !noPaths}
+	for service, secrets := range services {
+		normalized := make(map[string]string, len(secrets))
+		for key, value := range secrets {
+			normalized[strings.ToLower(key)] = value
+		}
+		s.services[strings.ToLower(service)] = normalized
+	}
+	return s
+}
+
+// NewStaticStoreFromFile creates a StaticStore from a file, or from stdin if
+// path is "-". Files ending in .env hold KEY=value lines that are served for
+// every service; anything else is read as a JSON or YAML object of services
+// to objects of keys and values.
+func NewStaticStoreFromFile(path string) (*StaticStore, error) {
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	if filepath.Ext(path) == ".env" {
+		shared, err := ParseEnvFile(in)
+		if err != nil {
+			return nil, err
+		}
+		s := NewStaticStore(nil)
+		s.shared = map[string]string{}
+		for key, value := range shared {
+			s.shared[strings.ToLower(key)] = value
+		}
+		return s, nil
+	}
+
+	services := map[string]map[string]string{}
+	if err := yaml.NewDecoder(in).Decode(&services); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("unable to decode static secrets: %s", err)
+	}
+	return NewStaticStore(services), nil
+}
+
+// ParseEnvFile reads KEY=value lines as written by `chamber export --format
+// dotenv`, skipping blank lines and comments.
+func ParseEnvFile(in io.Reader) (map[string]string, error) {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(in)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimPrefix(text, "export ")
+
+		parts := strings.SplitN(text, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("line %d: expected KEY=value", line)
+		}
+		key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+			value = doubleQuoteUnescape(value[1 : len(value)-1])
+		} else if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+			value = value[1 : len(value)-1]
+		}
+		values[key] = value
+	}
+	return values, scanner.Err()
+}
+
+// doubleQuoteUnescape reverses the escaping of double-quoted dotenv values
+func doubleQuoteUnescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+func (s *StaticStore) secrets(service string) map[string]string {
+	if s.shared != nil {
+		return s.shared
+	}
+	return s.services[service]
+}
+
+func (s *StaticStore) Write(id SecretId, value string) error {
+	return ErrStaticStoreReadOnly
+}
+
+func (s *StaticStore) Read(id SecretId, version int) (Secret, error) {
+	if version != -1 && version != 1 {
+		return Secret{}, ErrSecretNotFound
+	}
+	value, ok := s.secrets(id.Service)[id.Key]
+	if !ok {
+		return Secret{}, ErrSecretNotFound
+	}
+	return s.secret(id.Service, id.Key, value, true), nil
+}
+
+func (s *StaticStore) ListServices(service string, includeSecretName bool) ([]string, error) {
+	var names []string
+	for name, secrets := range s.services {
+		if !strings.HasPrefix(name, service) {
+			continue
+		}
+		if !includeSecretName {
+			names = append(names, name)
+			continue
+		}
+		for key := range secrets {
+			names = append(names, fmt.Sprintf("%s/%s", name, key))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *StaticStore) List(service string, includeValues bool) ([]Secret, error) {
+	secrets := []Secret{}
+	for key, value := range s.secrets(service) {
+		secrets = append(secrets, s.secret(service, key, value, includeValues))
+	}
+	sort.Slice(secrets, func(i, j int) bool { return secrets[i].Meta.Key < secrets[j].Meta.Key })
+	return secrets, nil
+}
+
+func (s *StaticStore) ListRaw(service string) ([]RawSecret, error) {
+	secrets, _ := s.List(service, true)
+	rawSecrets := make([]RawSecret, 0, len(secrets))
+	for _, secret := range secrets {
+		rawSecrets = append(rawSecrets, RawSecret{Key: secret.Meta.Key, Value: *secret.Value})
+	}
+	return rawSecrets, nil
+}
+
+func (s *StaticStore) History(id SecretId) ([]ChangeEvent, error) {
+	if _, ok := s.secrets(id.Service)[id.Key]; !ok {
+		return nil, ErrSecretNotFound
+	}
+	return []ChangeEvent{{Type: Created, Version: 1}}, nil
+}
+
+func (s *StaticStore) Delete(id SecretId) error {
+	return ErrStaticStoreReadOnly
+}
+
+// secret returns a static secret as version 1 of the key
+func (s *StaticStore) secret(service, key, value string, includeValue bool) Secret {
+	secret := Secret{
+		Meta: SecretMetadata{
+			Version: 1,
+			Key:     fmt.Sprintf("/%s/%s", service, key),
+		},
+	}
+	if !s.usePaths {
+		secret.Meta.Key = fmt.Sprintf("%s.%s", service, key)
+	}
+	if includeValue {
+		secret.Value = &value
+	}
+	return secret
+}
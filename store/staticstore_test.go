@@ -0,0 +1,69 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeStaticFile(t *testing.T, name, contents string) string {
+	dir, err := ioutil.TempDir("", "chamber-static")
+	assert.Nil(t, err)
+	path := filepath.Join(dir, name)
+	assert.Nil(t, ioutil.WriteFile(path, []byte(contents), 0600))
+	return path
+}
+
+func TestStaticStore(t *testing.T) {
+	t.Run("Should serve secrets from a JSON file", func(t *testing.T) {
+		path := writeStaticFile(t, "secrets.json", `{"app": {"DB_PASSWORD": "hunter22"}, "other": {"key": "value"}}`)
+		defer os.RemoveAll(filepath.Dir(path))
+
+		s, err := NewStaticStoreFromFile(path)
+		assert.Nil(t, err)
+
+		secret, err := s.Read(SecretId{Service: "app", Key: "db_password"}, -1)
+		assert.Nil(t, err)
+		assert.Equal(t, "hunter22", *secret.Value)
+
+		secrets, err := s.List("app", true)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(secrets))
+		assert.Equal(t, "/app/db_password", secrets[0].Meta.Key)
+
+		services, err := s.ListServices("", false)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"app", "other"}, services)
+	})
+
+	t.Run("Should serve an env file for every service", func(t *testing.T) {
+		path := writeStaticFile(t, "secrets.env", "# comment\nexport API_KEY=abc\nMULTI=\"line\\none\"\n")
+		defer os.RemoveAll(filepath.Dir(path))
+
+		s, err := NewStaticStoreFromFile(path)
+		assert.Nil(t, err)
+
+		secret, err := s.Read(SecretId{Service: "anything", Key: "multi"}, -1)
+		assert.Nil(t, err)
+		assert.Equal(t, "line\none", *secret.Value)
+
+		raw, err := s.ListRaw("app")
+		assert.Nil(t, err)
+		assert.Equal(t, []RawSecret{{Key: "/app/api_key", Value: "abc"}, {Key: "/app/multi", Value: "line\none"}}, raw)
+	})
+
+	t.Run("Should be read-only", func(t *testing.T) {
+		s := NewStaticStore(map[string]map[string]string{"app": {"key": "value"}})
+		assert.Equal(t, ErrStaticStoreReadOnly, s.Write(SecretId{Service: "app", Key: "key"}, "new"))
+		assert.Equal(t, ErrStaticStoreReadOnly, s.Delete(SecretId{Service: "app", Key: "key"}))
+	})
+
+	t.Run("Should report missing secrets", func(t *testing.T) {
+		s := NewStaticStore(map[string]map[string]string{"app": {"key": "value"}})
+		_, err := s.Read(SecretId{Service: "app", Key: "missing"}, -1)
+		assert.Equal(t, ErrSecretNotFound, err)
+	})
+}
@@ -2,7 +2,9 @@ package store
 
 import (
 	"errors"
+	"fmt"
 	"time"
+	"unicode/utf8"
 )
 
 type ChangeEventType int
@@ -10,6 +12,7 @@ type ChangeEventType int
 const (
 	Created ChangeEventType = iota
 	Updated
+	Renamed
 )
 
 func (c ChangeEventType) String() string {
@@ -18,6 +21,8 @@ func (c ChangeEventType) String() string {
 		return "Created"
 	case Updated:
 		return "Updated"
+	case Renamed:
+		return "Renamed"
 	}
 	return "unknown"
 }
@@ -26,8 +31,21 @@ var (
 	// ErrSecretNotFound is returned if the specified secret is not found in the
 	// parameter store
 	ErrSecretNotFound = errors.New("secret not found")
+
+	// ErrInvalidEncoding is returned when writing a value that isn't UTF-8
+	ErrInvalidEncoding = errors.New("secret values must be valid UTF-8")
 )
 
+// ValidateValue checks that value can be stored by every backend. Values are
+// UTF-8 text, and backends store them byte for byte: trailing newlines and
+// CRLF line endings read back exactly as they were written.
+func ValidateValue(value string) error {
+	if !utf8.ValidString(value) {
+		return ErrInvalidEncoding
+	}
+	return nil
+}
+
 type SecretId struct {
 	Service string
 	Key     string
@@ -56,8 +74,18 @@ type ChangeEvent struct {
 	Time    time.Time
 	User    string
 	Version int
+
+	// RenamedFrom is the previous key of Renamed events
+	RenamedFrom string
+
+	// NativeVersion is the backend's own version of the event, e.g. the SSM
+	// parameter version, or 0 if it has none. Unlike Version, it is also set
+	// for secrets written without chamber.
+	NativeVersion int64
 }
 
+// Store is implemented by the secret backends. Values passed to Write must
+// pass ValidateValue, and Read returns them unchanged.
 type Store interface {
 	Write(id SecretId, value string) error
 	Read(id SecretId, version int) (Secret, error)
@@ -67,3 +95,50 @@ type Store interface {
 	History(id SecretId) ([]ChangeEvent, error)
 	Delete(id SecretId) error
 }
+
+// Renamer is implemented by stores that can rename a secret within its
+// service while keeping its history linked to the previous key.
+type Renamer interface {
+	Rename(id SecretId, newKey string) error
+}
+
+// NativeVersionReader is implemented by stores that can read a version of a
+// secret by the version number of the backend itself, rather than by the
+// version chamber records.
+type NativeVersionReader interface {
+	ReadNativeVersion(id SecretId, version int64) (Secret, error)
+}
+
+// ReadNativeVersion reads the backend's own version of the secret id, for
+// debugging stores whose recorded versions have drifted from the backend's.
+func ReadNativeVersion(s Store, id SecretId, version int64) (Secret, error) {
+	if r, ok := s.(NativeVersionReader); ok {
+		return r.ReadNativeVersion(id, version)
+	}
+	return Secret{}, fmt.Errorf("backend has no native versions")
+}
+
+// Rename renames the secret id to newKey. Stores that don't implement
+// Renamer get a copy of the latest value under the new key, after which the
+// old key is deleted.
+func Rename(s Store, id SecretId, newKey string) error {
+	if r, ok := s.(Renamer); ok {
+		return r.Rename(id, newKey)
+	}
+
+	newId := SecretId{Service: id.Service, Key: newKey}
+	if _, err := s.Read(newId, -1); err != ErrSecretNotFound {
+		if err == nil {
+			return fmt.Errorf("secret %s/%s already exists", newId.Service, newId.Key)
+		}
+		return err
+	}
+	current, err := s.Read(id, -1)
+	if err != nil {
+		return err
+	}
+	if err := s.Write(newId, *current.Value); err != nil {
+		return err
+	}
+	return s.Delete(id)
+}
@@ -0,0 +1,129 @@
+package store
+
+import (
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// ListStreamer is implemented by stores that can hand out listings page by
+// page as the backend returns them, so callers can start on the first page
+// before the last is fetched and needn't hold all of a huge account at once.
+type ListStreamer interface {
+	StreamList(service string, includeValues bool, fn func([]Secret) error) error
+	StreamServices(service string, includeSecretName bool, fn func([]string) error) error
+}
+
+// StreamList calls fn with the secrets of service, a page at a time, like
+// List. Stores that don't implement ListStreamer list everything as one
+// page. An error of fn stops the listing and is returned.
+func StreamList(s Store, service string, includeValues bool, fn func([]Secret) error) error {
+	if l, ok := s.(ListStreamer); ok {
+		return l.StreamList(service, includeValues, fn)
+	}
+	secrets, err := s.List(service, includeValues)
+	if err != nil {
+		return err
+	}
+	return fn(secrets)
+}
+
+// StreamServices calls fn with the services, or secret names, below service,
+// a page at a time, like ListServices. Every name is handed out once, but
+// unlike ListServices they aren't sorted. Stores that don't implement
+// ListStreamer list everything as one page. An error of fn stops the listing
+// and is returned.
+func StreamServices(s Store, service string, includeSecretName bool, fn func([]string) error) error {
+	if l, ok := s.(ListStreamer); ok {
+		return l.StreamServices(service, includeSecretName, fn)
+	}
+	names, err := s.ListServices(service, includeSecretName)
+	if err != nil {
+		return err
+	}
+	return fn(names)
+}
+
+var _ ListStreamer = &SSMStore{}
+
+// StreamList hands out a page of DescribeParameters at a time, with values
+// read for each page before it's handed out
+func (s *SSMStore) StreamList(serviceName string, includeValues bool, fn func([]Secret) error) error {
+	service, _ := parseServiceLabel(serviceName)
+	if s.layout != nil {
+		secrets, err := s.listWithLayout(service, includeValues)
+		if err != nil {
+			return err
+		}
+		return fn(secrets)
+	}
+
+	var fnErr error
+	err := s.svc.DescribeParametersPages(s.listInput(service), func(resp *ssm.DescribeParametersOutput, lastPage bool) bool {
+		secrets := map[string]Secret{}
+		for _, meta := range resp.Parameters {
+			if !s.validateName(*meta.Name) {
+				continue
+			}
+			secretMeta := parameterMetaToSecretMeta(meta)
+			secrets[secretMeta.Key] = Secret{Meta: secretMeta}
+		}
+		if len(secrets) == 0 {
+			return true
+		}
+		if includeValues {
+			if fnErr = s.readValues(secrets); fnErr != nil {
+				return false
+			}
+		}
+		fnErr = fn(values(secrets))
+		return fnErr == nil
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	return err
+}
+
+// StreamServices pages through DescribeParameters one page after the other,
+// rather than in parallel like ListServices, handing out the services not
+// seen on earlier pages
+func (s *SSMStore) StreamServices(service string, includeSecretName bool, fn func([]string) error) error {
+	if s.layout != nil {
+		names, err := s.listServicesWithLayout(service, includeSecretName)
+		if err != nil {
+			return err
+		}
+		return fn(names)
+	}
+	prefix := service + "."
+	if s.usePaths {
+		prefix = "/" + service
+	}
+
+	seen := map[string]bool{}
+	var fnErr error
+	err := s.svc.DescribeParametersPages(s.describeParametersInput(prefix), func(resp *ssm.DescribeParametersOutput, lastPage bool) bool {
+		var names []string
+		for _, meta := range resp.Parameters {
+			if !s.validateName(*meta.Name) {
+				continue
+			}
+			name := parameterMetaToSecretMeta(meta).Key
+			if !includeSecretName {
+				name = serviceName(name)
+			}
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			return true
+		}
+		fnErr = fn(names)
+		return fnErr == nil
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	return err
+}
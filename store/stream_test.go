@@ -0,0 +1,95 @@
+package store
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/stretchr/testify/assert"
+)
+
+// onePerPageSSMClient returns one parameter per page of DescribeParameters
+type onePerPageSSMClient struct {
+	*mockSSMClient
+}
+
+func (m *onePerPageSSMClient) DescribeParametersPages(i *ssm.DescribeParametersInput, fn func(*ssm.DescribeParametersOutput, bool) bool) error {
+	o, err := m.DescribeParameters(i)
+	if err != nil {
+		return err
+	}
+	sort.Slice(o.Parameters, func(i, j int) bool { return *o.Parameters[i].Name < *o.Parameters[j].Name })
+	for n, param := range o.Parameters {
+		if !fn(&ssm.DescribeParametersOutput{Parameters: []*ssm.ParameterMetadata{param}}, n == len(o.Parameters)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func TestStreamList(t *testing.T) {
+	mock := &mockSSMClient{parameters: map[string]mockParameter{}}
+	s := NewTestSSMStoreWithPaths(&onePerPageSSMClient{mock})
+	for _, key := range []string{"a", "b", "c"} {
+		assert.Nil(t, s.Write(SecretId{Service: "app", Key: key}, "value-"+key))
+	}
+	assert.Nil(t, s.Write(SecretId{Service: "other/prod", Key: "d"}, "value-d"))
+
+	t.Run("Should hand out secrets with values page by page", func(t *testing.T) {
+		var pages [][]Secret
+		err := StreamList(s, "app", true, func(secrets []Secret) error {
+			pages = append(pages, secrets)
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.Len(t, pages, 3)
+		assert.Equal(t, "/app/a", pages[0][0].Meta.Key)
+		assert.Equal(t, "value-a", *pages[0][0].Value)
+	})
+
+	t.Run("Should stop at the first error of the callback", func(t *testing.T) {
+		stop := errors.New("stop")
+		calls := 0
+		err := StreamList(s, "app", false, func(secrets []Secret) error {
+			calls++
+			return stop
+		})
+		assert.Equal(t, stop, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Should hand out each service once", func(t *testing.T) {
+		var services []string
+		err := StreamServices(s, "", false, func(names []string) error {
+			services = append(services, names...)
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"app", "other/prod"}, services)
+	})
+
+	t.Run("Should strip namespaces from each page", func(t *testing.T) {
+		var keys []string
+		err := StreamList(NewNamespacedStore(s, "other"), "prod", false, func(secrets []Secret) error {
+			for _, secret := range secrets {
+				keys = append(keys, secret.Meta.Key)
+			}
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"/prod/d"}, keys)
+	})
+
+	t.Run("Should list stores that don't stream as one page", func(t *testing.T) {
+		static := NewStaticStore(map[string]map[string]string{"app": {"a": "1", "b": "2"}})
+		pages := 0
+		err := StreamList(static, "app", true, func(secrets []Secret) error {
+			pages++
+			assert.Len(t, secrets, 2)
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, pages)
+	})
+}
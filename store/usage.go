@@ -0,0 +1,113 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// UsageRecord lists the keys of a service that one workload consumed
+type UsageRecord struct {
+	Service string    `json:"service"`
+	Keys    []string  `json:"keys"`
+	Time    time.Time `json:"time"`
+	Host    string    `json:"host"`
+}
+
+// S3UsageSink keeps UsageRecords as small objects in S3, one prefix per day,
+// which is cheap to write and to scan for the last few months.
+type S3UsageSink struct {
+	svc    s3iface.S3API
+	bucket string
+	prefix string
+}
+
+// NewS3UsageSink returns a sink for a location like s3://bucket/prefix
+func NewS3UsageSink(numRetries int, location string) (*S3UsageSink, error) {
+	u, err := url.Parse(location)
+	if err != nil || u.Scheme != "s3" || u.Host == "" {
+		return nil, fmt.Errorf("invalid usage sink %q; expected s3://bucket/prefix", location)
+	}
+
+	session, region, err := getSession(numRetries)
+	if err != nil {
+		return nil, err
+	}
+	svc := s3.New(session, &aws.Config{
+		MaxRetries: aws.Int(numRetries),
+		Region:     region,
+	})
+	return &S3UsageSink{svc: svc, bucket: u.Host, prefix: strings.Trim(u.Path, "/")}, nil
+}
+
+func (s *S3UsageSink) dayPrefix(day time.Time) string {
+	p := day.UTC().Format("2006-01-02") + "/"
+	if s.prefix != "" {
+		p = s.prefix + "/" + p
+	}
+	return p
+}
+
+// Record stores r
+func (s *S3UsageSink) Record(r UsageRecord) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s%s/%d-%d.json", s.dayPrefix(r.Time), strings.Replace(r.Service, "/", ".", -1), r.Time.UnixNano(), os.Getpid())
+	_, err = s.svc.PutObject(&s3.PutObjectInput{
+		Bucket:               aws.String(s.bucket),
+		Key:                  aws.String(name),
+		Body:                 bytes.NewReader(body),
+		ServerSideEncryption: aws.String(s3.ServerSideEncryptionAes256),
+	})
+	return err
+}
+
+// Since returns the records stored on or after the day of since
+func (s *S3UsageSink) Since(since time.Time, now time.Time) ([]UsageRecord, error) {
+	var records []UsageRecord
+	for day := since.UTC().Truncate(24 * time.Hour); !day.After(now); day = day.Add(24 * time.Hour) {
+		var names []string
+		err := s.svc.ListObjectsPages(&s3.ListObjectsInput{
+			Bucket: aws.String(s.bucket),
+			Prefix: aws.String(s.dayPrefix(day)),
+		}, func(page *s3.ListObjectsOutput, lastPage bool) bool {
+			for _, obj := range page.Contents {
+				names = append(names, aws.StringValue(obj.Key))
+			}
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range names {
+			resp, err := s.svc.GetObject(&s3.GetObjectInput{
+				Bucket: aws.String(s.bucket),
+				Key:    aws.String(name),
+			})
+			if err != nil {
+				return nil, err
+			}
+			var r UsageRecord
+			err = json.NewDecoder(resp.Body).Decode(&r)
+			resp.Body.Close()
+			if err != nil {
+				return nil, fmt.Errorf("invalid usage record %s: %s", name, err)
+			}
+			if !r.Time.Before(since) {
+				records = append(records, r)
+			}
+		}
+	}
+	return records, nil
+}
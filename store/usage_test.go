@@ -0,0 +1,82 @@
+package store
+
+import (
+	"bytes"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockS3Client keeps objects in memory
+type mockS3Client struct {
+	s3iface.S3API
+	objects map[string][]byte
+}
+
+func (m *mockS3Client) PutObject(i *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	body, err := ioutil.ReadAll(i.Body)
+	if err != nil {
+		return nil, err
+	}
+	m.objects[*i.Key] = body
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (m *mockS3Client) GetObject(i *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	return &s3.GetObjectOutput{Body: ioutil.NopCloser(bytes.NewReader(m.objects[*i.Key]))}, nil
+}
+
+func (m *mockS3Client) ListObjectsPages(i *s3.ListObjectsInput, fn func(*s3.ListObjectsOutput, bool) bool) error {
+	var names []string
+	for name := range m.objects {
+		if strings.HasPrefix(name, *i.Prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	out := &s3.ListObjectsOutput{}
+	for _, name := range names {
+		out.Contents = append(out.Contents, &s3.Object{Key: aws.String(name)})
+	}
+	fn(out, true)
+	return nil
+}
+
+func TestS3UsageSink(t *testing.T) {
+	mock := &mockS3Client{objects: map[string][]byte{}}
+	sink := &S3UsageSink{svc: mock, bucket: "usage", prefix: "chamber"}
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+
+	assert.Nil(t, sink.Record(UsageRecord{Service: "app/prod", Keys: []string{"db_url"}, Time: now.Add(-48 * time.Hour)}))
+	assert.Nil(t, sink.Record(UsageRecord{Service: "app/prod", Keys: []string{"api_token"}, Time: now.Add(-10 * 24 * time.Hour)}))
+
+	t.Run("Records should be filed by day", func(t *testing.T) {
+		names := keysOf(mock.objects)
+		assert.Len(t, names, 2)
+		assert.True(t, strings.HasPrefix(names[0], "chamber/2024-05-31/app.prod/"), names[0])
+		assert.True(t, strings.HasPrefix(names[1], "chamber/2024-06-08/app.prod/"), names[1])
+	})
+
+	t.Run("Only records since the given time should be returned", func(t *testing.T) {
+		records, err := sink.Since(now.Add(-7*24*time.Hour), now)
+		assert.Nil(t, err)
+		assert.Len(t, records, 1)
+		assert.Equal(t, []string{"db_url"}, records[0].Keys)
+	})
+}
+
+func keysOf(m map[string][]byte) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
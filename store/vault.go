@@ -0,0 +1,181 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VaultVersion is one version of a secret in a Vault KV v2 engine
+type VaultVersion struct {
+	Version int
+	Created time.Time
+	Data    map[string]string
+}
+
+// VaultKVClient reads the history of secrets in a Vault KV v2 engine through
+// Vault's HTTP API
+type VaultKVClient struct {
+	client    *http.Client
+	address   string
+	token     string
+	namespace string
+}
+
+// NewVaultKVClient returns a client configured like the vault CLI, from
+// VAULT_ADDR, VAULT_TOKEN (or ~/.vault-token) and VAULT_NAMESPACE
+func NewVaultKVClient() (*VaultKVClient, error) {
+	address := os.Getenv("VAULT_ADDR")
+	if address == "" {
+		return nil, fmt.Errorf("VAULT_ADDR must be set")
+	}
+	token := os.Getenv("VAULT_TOKEN")
+	if token == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			if data, err := ioutil.ReadFile(filepath.Join(home, ".vault-token")); err == nil {
+				token = strings.TrimSpace(string(data))
+			}
+		}
+	}
+	if token == "" {
+		return nil, fmt.Errorf("VAULT_TOKEN must be set, or ~/.vault-token exist")
+	}
+	client, err := newHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	return &VaultKVClient{
+		client:    client,
+		address:   strings.TrimRight(address, "/"),
+		token:     token,
+		namespace: os.Getenv("VAULT_NAMESPACE"),
+	}, nil
+}
+
+// splitVaultPath splits a path like secret/app into the engine's mount and
+// the path of the secret within it
+func splitVaultPath(path string) (string, string, error) {
+	parts := strings.SplitN(strings.Trim(path, "/"), "/", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", "", fmt.Errorf("vault path %q must be <mount>/<path>", path)
+	}
+	return parts[0], parts[1], nil
+}
+
+func (c *VaultKVClient) get(path string, query url.Values, out interface{}) error {
+	return c.do(http.MethodGet, path, query, nil, out)
+}
+
+// do makes a request of Vault's HTTP API with in, if any, as its JSON body,
+// and decodes the response into out, if any
+func (c *VaultKVClient) do(method, path string, query url.Values, in, out interface{}) error {
+	u := c.address + "/v1/" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", c.token)
+	if c.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.namespace)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrSecretNotFound
+	}
+	if resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("vault returned %s for %s", resp.Status, path)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// History returns the readable versions of the secret at path, oldest
+// first. Deleted and destroyed versions are skipped.
+func (c *VaultKVClient) History(path string) ([]VaultVersion, error) {
+	mount, secretPath, err := splitVaultPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var metadata struct {
+		Data struct {
+			Versions map[string]struct {
+				CreatedTime  time.Time `json:"created_time"`
+				DeletionTime string    `json:"deletion_time"`
+				Destroyed    bool      `json:"destroyed"`
+			} `json:"versions"`
+		} `json:"data"`
+	}
+	if err := c.get(mount+"/metadata/"+secretPath, nil, &metadata); err != nil {
+		return nil, err
+	}
+
+	var versions []VaultVersion
+	for v, m := range metadata.Data.Versions {
+		if m.Destroyed || m.DeletionTime != "" {
+			continue
+		}
+		version, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vault version %q", v)
+		}
+
+		var data struct {
+			Data struct {
+				Data map[string]interface{} `json:"data"`
+			} `json:"data"`
+		}
+		query := url.Values{"version": []string{v}}
+		if err := c.get(mount+"/data/"+secretPath, query, &data); err != nil {
+			return nil, err
+		}
+
+		values := make(map[string]string, len(data.Data.Data))
+		for key, value := range data.Data.Data {
+			switch value := value.(type) {
+			case string:
+				values[key] = value
+			default:
+				encoded, err := json.Marshal(value)
+				if err != nil {
+					return nil, err
+				}
+				values[key] = string(encoded)
+			}
+		}
+		versions = append(versions, VaultVersion{Version: version, Created: m.CreatedTime, Data: values})
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+	return versions, nil
+}
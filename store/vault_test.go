@@ -0,0 +1,52 @@
+package store
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVaultKVClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token", r.Header.Get("X-Vault-Token"))
+		switch {
+		case r.URL.Path == "/v1/secret/metadata/app":
+			w.Write([]byte(`{"data": {"versions": {
+				"1": {"created_time": "2023-01-02T15:04:05Z", "deletion_time": "", "destroyed": false},
+				"2": {"created_time": "2023-02-02T15:04:05Z", "deletion_time": "", "destroyed": true},
+				"3": {"created_time": "2023-03-02T15:04:05Z", "deletion_time": "", "destroyed": false}
+			}}}`))
+		case r.URL.Path == "/v1/secret/data/app":
+			data := map[string]interface{}{"password": "v" + r.URL.Query().Get("version"), "port": 5432}
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"data": data}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	c := &VaultKVClient{client: server.Client(), address: server.URL, token: "token"}
+
+	t.Run("Should read the versions that still exist, oldest first", func(t *testing.T) {
+		versions, err := c.History("secret/app")
+		assert.Nil(t, err)
+		assert.Len(t, versions, 2)
+		assert.Equal(t, 1, versions[0].Version)
+		assert.Equal(t, "v1", versions[0].Data["password"])
+		assert.Equal(t, "5432", versions[0].Data["port"])
+		assert.Equal(t, 3, versions[1].Version)
+		assert.Equal(t, 2023, versions[1].Created.Year())
+	})
+
+	t.Run("Should report missing secrets", func(t *testing.T) {
+		_, err := c.History("secret/other")
+		assert.Equal(t, ErrSecretNotFound, err)
+	})
+
+	t.Run("Should require a mount", func(t *testing.T) {
+		_, err := c.History("app")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,344 @@
+package store
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultVaultMount is the mount of the KV v2 engine the Vault backend
+	// keeps secrets in unless configured otherwise
+	DefaultVaultMount = "secret"
+
+	// vaultValueField is the field of each Vault secret holding its value
+	vaultValueField = "value"
+
+	// vaultCreatedByPrefix prefixes the custom metadata recording who wrote
+	// a version, e.g. created_by_3
+	vaultCreatedByPrefix = "created_by_"
+
+	// vaultMaxCreatedBy is how many versions' writers are kept in the custom
+	// metadata, of which Vault allows 64 keys
+	vaultMaxCreatedBy = 32
+)
+
+// ensure VaultStore confirms to Store interface
+var _ Store = &VaultStore{}
+
+// VaultStore implements the Store interface for storing secrets in a Vault KV
+// v2 engine. Each key is a Vault secret at <mount>/<service>/<key> holding
+// the value in its value field, and chamber's versions are the secret's KV
+// versions. Vault records when each version was created; who created it is
+// recorded in the secret's custom metadata.
+type VaultStore struct {
+	client *VaultKVClient
+	mount  string
+
+	// user is the display name of the Vault token, looked up on first write
+	user string
+}
+
+// NewVaultStore returns a store keeping secrets in the KV v2 engine at
+// mount, or DefaultVaultMount, of the Vault configured like the vault CLI
+func NewVaultStore(mount string) (*VaultStore, error) {
+	client, err := NewVaultKVClient()
+	if err != nil {
+		return nil, err
+	}
+	if mount == "" {
+		mount = DefaultVaultMount
+	}
+	return &VaultStore{client: client, mount: strings.Trim(mount, "/")}, nil
+}
+
+// vaultMetadata is the metadata of a Vault secret and its versions
+type vaultMetadata struct {
+	CurrentVersion int                             `json:"current_version"`
+	CustomMetadata map[string]string               `json:"custom_metadata"`
+	Versions       map[string]vaultVersionMetadata `json:"versions"`
+}
+
+type vaultVersionMetadata struct {
+	CreatedTime  time.Time `json:"created_time"`
+	DeletionTime string    `json:"deletion_time"`
+	Destroyed    bool      `json:"destroyed"`
+}
+
+func (s *VaultStore) path(id SecretId) string {
+	return id.Service + "/" + id.Key
+}
+
+func (s *VaultStore) readMetadata(id SecretId) (vaultMetadata, error) {
+	var resp struct {
+		Data vaultMetadata `json:"data"`
+	}
+	err := s.client.get(s.mount+"/metadata/"+s.path(id), nil, &resp)
+	return resp.Data, err
+}
+
+func (s *VaultStore) currentUser() (string, error) {
+	if s.user != "" {
+		return s.user, nil
+	}
+	var resp struct {
+		Data struct {
+			DisplayName string `json:"display_name"`
+		} `json:"data"`
+	}
+	if err := s.client.get("auth/token/lookup-self", nil, &resp); err != nil {
+		return "", fmt.Errorf("unable to look up the vault token: %s", err)
+	}
+	s.user = resp.Data.DisplayName
+	return s.user, nil
+}
+
+// withCreatedBy returns custom with user recorded as the writer of version,
+// forgetting the writers of versions vaultMaxCreatedBy or more older
+func withCreatedBy(custom map[string]string, version int, user string) map[string]string {
+	result := map[string]string{}
+	for k, v := range custom {
+		if strings.HasPrefix(k, vaultCreatedByPrefix) {
+			if n, err := strconv.Atoi(strings.TrimPrefix(k, vaultCreatedByPrefix)); err == nil && n <= version-vaultMaxCreatedBy {
+				continue
+			}
+		}
+		result[k] = v
+	}
+	result[vaultCreatedByPrefix+strconv.Itoa(version)] = user
+	return result
+}
+
+// Write writes a new version of the secret id, and records who wrote it
+func (s *VaultStore) Write(id SecretId, value string) error {
+	if err := ValidateValue(value); err != nil {
+		return err
+	}
+	user, err := s.currentUser()
+	if err != nil {
+		return err
+	}
+	metadata, err := s.readMetadata(id)
+	if err != nil && err != ErrSecretNotFound {
+		return err
+	}
+
+	var written struct {
+		Data struct {
+			Version int `json:"version"`
+		} `json:"data"`
+	}
+	data := map[string]interface{}{"data": map[string]string{vaultValueField: value}}
+	if err := s.client.do(http.MethodPost, s.mount+"/data/"+s.path(id), nil, data, &written); err != nil {
+		return err
+	}
+
+	custom := map[string]interface{}{"custom_metadata": withCreatedBy(metadata.CustomMetadata, written.Data.Version, user)}
+	return s.client.do(http.MethodPost, s.mount+"/metadata/"+s.path(id), nil, custom, nil)
+}
+
+// Read reads version of the secret id, or its current version if version
+// is -1. Deleted and destroyed versions aren't found.
+func (s *VaultStore) Read(id SecretId, version int) (Secret, error) {
+	var query url.Values
+	if version != -1 {
+		query = url.Values{"version": []string{strconv.Itoa(version)}}
+	}
+	var resp struct {
+		Data struct {
+			Data     map[string]interface{} `json:"data"`
+			Metadata struct {
+				CreatedTime    time.Time         `json:"created_time"`
+				CustomMetadata map[string]string `json:"custom_metadata"`
+				Version        int               `json:"version"`
+			} `json:"metadata"`
+		} `json:"data"`
+	}
+	if err := s.client.get(s.mount+"/data/"+s.path(id), query, &resp); err != nil {
+		return Secret{}, err
+	}
+	if resp.Data.Data == nil {
+		return Secret{}, ErrSecretNotFound
+	}
+	value, ok := resp.Data.Data[vaultValueField].(string)
+	if !ok {
+		return Secret{}, fmt.Errorf("vault secret %s has no string %s field", s.path(id), vaultValueField)
+	}
+
+	meta := resp.Data.Metadata
+	return Secret{
+		Value: &value,
+		Meta: SecretMetadata{
+			Created:   meta.CreatedTime,
+			CreatedBy: meta.CustomMetadata[vaultCreatedByPrefix+strconv.Itoa(meta.Version)],
+			Version:   meta.Version,
+			Key:       "/" + s.path(id),
+		},
+	}, nil
+}
+
+// ReadNativeVersion reads the KV version version, which is chamber's too
+func (s *VaultStore) ReadNativeVersion(id SecretId, version int64) (Secret, error) {
+	return s.Read(id, int(version))
+}
+
+// listFolder returns the secrets and the folders, ending in /, of folder
+func (s *VaultStore) listFolder(folder string) ([]string, error) {
+	var resp struct {
+		Data struct {
+			Keys []string `json:"keys"`
+		} `json:"data"`
+	}
+	err := s.client.get(s.mount+"/metadata/"+folder, url.Values{"list": []string{"true"}}, &resp)
+	if err == ErrSecretNotFound {
+		return nil, nil
+	}
+	return resp.Data.Keys, err
+}
+
+// walk calls fn with the service and key of every secret below folder
+func (s *VaultStore) walk(folder string, fn func(service, key string)) error {
+	entries, err := s.listFolder(folder)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry, "/") {
+			if err := s.walk(folder+entry, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if service := strings.TrimSuffix(folder, "/"); service != "" {
+			fn(service, entry)
+		}
+	}
+	return nil
+}
+
+// ListServices lists the services, or the /service/key names of the secrets,
+// whose names begin with service, walking the folders of the engine from the
+// deepest one containing them all
+func (s *VaultStore) ListServices(service string, includeSecretName bool) ([]string, error) {
+	folder := ""
+	if i := strings.LastIndex(service, "/"); i >= 0 {
+		folder = service[:i+1]
+	}
+	var names []string
+	err := s.walk(folder, func(secretService, key string) {
+		name := "/" + secretService + "/" + key
+		if !strings.HasPrefix(name, "/"+service) {
+			return
+		}
+		if includeSecretName {
+			names = append(names, name)
+		} else {
+			names = append(names, secretService)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return uniqueStringSlice(names), nil
+}
+
+// List lists the secrets of service, reading each one's metadata, or its
+// latest version if includeValues is set. Secrets whose current version is
+// deleted are left out.
+func (s *VaultStore) List(service string, includeValues bool) ([]Secret, error) {
+	entries, err := s.listFolder(service + "/")
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := []Secret{}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry, "/") {
+			continue
+		}
+		id := SecretId{Service: service, Key: entry}
+		if includeValues {
+			secret, err := s.Read(id, -1)
+			if err == ErrSecretNotFound {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			secrets = append(secrets, secret)
+			continue
+		}
+
+		metadata, err := s.readMetadata(id)
+		if err == ErrSecretNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		current, ok := metadata.Versions[strconv.Itoa(metadata.CurrentVersion)]
+		if !ok || current.Destroyed || current.DeletionTime != "" {
+			continue
+		}
+		secrets = append(secrets, Secret{
+			Meta: SecretMetadata{
+				Created:   current.CreatedTime,
+				CreatedBy: metadata.CustomMetadata[vaultCreatedByPrefix+strconv.Itoa(metadata.CurrentVersion)],
+				Version:   metadata.CurrentVersion,
+				Key:       "/" + s.path(id),
+			},
+		})
+	}
+	return secrets, nil
+}
+
+// ListRaw lists the keys and values of the secrets of service
+func (s *VaultStore) ListRaw(service string) ([]RawSecret, error) {
+	secrets, err := s.List(service, true)
+	if err != nil {
+		return nil, err
+	}
+	rawSecrets := make([]RawSecret, 0, len(secrets))
+	for _, secret := range secrets {
+		rawSecrets = append(rawSecrets, RawSecret{Key: secret.Meta.Key, Value: *secret.Value})
+	}
+	return rawSecrets, nil
+}
+
+// History returns an event for every version Vault still knows of, oldest
+// first, including deleted ones
+func (s *VaultStore) History(id SecretId) ([]ChangeEvent, error) {
+	metadata, err := s.readMetadata(id)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []ChangeEvent{}
+	for v, m := range metadata.Versions {
+		version, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vault version %q", v)
+		}
+		events = append(events, ChangeEvent{
+			Type:          getChangeType(version),
+			Time:          m.CreatedTime,
+			User:          metadata.CustomMetadata[vaultCreatedByPrefix+v],
+			Version:       version,
+			NativeVersion: int64(version),
+		})
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Version < events[j].Version })
+	return events, nil
+}
+
+// Delete removes the secret id with all of its versions and metadata
+func (s *VaultStore) Delete(id SecretId) error {
+	if _, err := s.readMetadata(id); err != nil {
+		return err
+	}
+	return s.client.do(http.MethodDelete, s.mount+"/metadata/"+s.path(id), nil, nil, nil)
+}
@@ -0,0 +1,225 @@
+package store
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeVault serves the parts of a KV v2 engine mounted at secret that
+// VaultStore uses
+type fakeVault struct {
+	secrets map[string]*fakeVaultSecret
+	created time.Time
+}
+
+type fakeVaultSecret struct {
+	values  []string
+	deleted map[int]bool
+	custom  map[string]string
+}
+
+func (v *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	reply := func(data interface{}) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	switch {
+	case path == "auth/token/lookup-self":
+		reply(map[string]string{"display_name": "token-alice"})
+
+	case strings.HasPrefix(path, "secret/data/"):
+		name := strings.TrimPrefix(path, "secret/data/")
+		secret, ok := v.secrets[name]
+		if r.Method == http.MethodPost {
+			var body struct {
+				Data map[string]string `json:"data"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			if !ok {
+				secret = &fakeVaultSecret{deleted: map[int]bool{}}
+				v.secrets[name] = secret
+			}
+			secret.values = append(secret.values, body.Data["value"])
+			reply(map[string]int{"version": len(secret.values)})
+			return
+		}
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		version := len(secret.values)
+		if q := r.URL.Query().Get("version"); q != "" {
+			version, _ = strconv.Atoi(q)
+		}
+		if version < 1 || version > len(secret.values) || secret.deleted[version] {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		reply(map[string]interface{}{
+			"data": map[string]string{"value": secret.values[version-1]},
+			"metadata": map[string]interface{}{
+				"created_time":    v.created.Add(time.Duration(version) * time.Hour),
+				"custom_metadata": secret.custom,
+				"version":         version,
+			},
+		})
+
+	case strings.HasPrefix(path, "secret/metadata"):
+		name := strings.TrimPrefix(strings.TrimPrefix(path, "secret/metadata"), "/")
+		if r.URL.Query().Get("list") == "true" {
+			entries := map[string]bool{}
+			for secretName := range v.secrets {
+				if strings.HasPrefix(secretName, name) {
+					rest := strings.TrimPrefix(secretName, name)
+					if i := strings.Index(rest, "/"); i >= 0 {
+						rest = rest[:i+1]
+					}
+					entries[rest] = true
+				}
+			}
+			if len(entries) == 0 {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			keys := []string{}
+			for entry := range entries {
+				keys = append(keys, entry)
+			}
+			sort.Strings(keys)
+			reply(map[string][]string{"keys": keys})
+			return
+		}
+		secret, ok := v.secrets[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case http.MethodPost:
+			var body struct {
+				CustomMetadata map[string]string `json:"custom_metadata"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			secret.custom = body.CustomMetadata
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodDelete:
+			delete(v.secrets, name)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			versions := map[string]interface{}{}
+			for i := range secret.values {
+				deletion := ""
+				if secret.deleted[i+1] {
+					deletion = v.created.Format(time.RFC3339)
+				}
+				versions[strconv.Itoa(i+1)] = map[string]interface{}{
+					"created_time":  v.created.Add(time.Duration(i+1) * time.Hour),
+					"deletion_time": deletion,
+					"destroyed":     false,
+				}
+			}
+			reply(map[string]interface{}{
+				"current_version": len(secret.values),
+				"custom_metadata": secret.custom,
+				"versions":        versions,
+			})
+		}
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestVaultStore(t *testing.T) {
+	vault := &fakeVault{secrets: map[string]*fakeVaultSecret{}, created: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}
+	server := httptest.NewServer(vault)
+	defer server.Close()
+	s := &VaultStore{client: &VaultKVClient{client: server.Client(), address: server.URL, token: "token"}, mount: "secret"}
+
+	password := SecretId{Service: "app/prod", Key: "db_password"}
+	assert.Nil(t, s.Write(password, "one"))
+	assert.Nil(t, s.Write(password, "two"))
+	assert.Nil(t, s.Write(SecretId{Service: "app/prod", Key: "db_user"}, "app"))
+	assert.Nil(t, s.Write(SecretId{Service: "app/prod/worker", Key: "queue"}, "jobs"))
+	assert.Nil(t, s.Write(SecretId{Service: "other", Key: "key"}, "value"))
+
+	t.Run("Should read versions with who created them", func(t *testing.T) {
+		secret, err := s.Read(password, -1)
+		assert.Nil(t, err)
+		assert.Equal(t, "two", *secret.Value)
+		assert.Equal(t, 2, secret.Meta.Version)
+		assert.Equal(t, "token-alice", secret.Meta.CreatedBy)
+		assert.Equal(t, "/app/prod/db_password", secret.Meta.Key)
+
+		secret, err = s.Read(password, 1)
+		assert.Nil(t, err)
+		assert.Equal(t, "one", *secret.Value)
+		assert.Equal(t, vault.created.Add(time.Hour), secret.Meta.Created)
+
+		_, err = s.Read(password, 3)
+		assert.Equal(t, ErrSecretNotFound, err)
+	})
+
+	t.Run("Should list the secrets of a service but not of nested services", func(t *testing.T) {
+		secrets, err := s.ListRaw("app/prod")
+		assert.Nil(t, err)
+		assert.Equal(t, []RawSecret{{Key: "/app/prod/db_password", Value: "two"}, {Key: "/app/prod/db_user", Value: "app"}}, secrets)
+
+		listed, err := s.List("app/prod", false)
+		assert.Nil(t, err)
+		assert.Len(t, listed, 2)
+		assert.Nil(t, listed[0].Value)
+		assert.Equal(t, 2, listed[0].Meta.Version)
+	})
+
+	t.Run("Should leave out secrets whose current version is deleted", func(t *testing.T) {
+		vault.secrets["app/prod/db_user"].deleted[1] = true
+		defer delete(vault.secrets["app/prod/db_user"].deleted, 1)
+		secrets, err := s.List("app/prod", false)
+		assert.Nil(t, err)
+		assert.Len(t, secrets, 1)
+	})
+
+	t.Run("Should list services below a prefix", func(t *testing.T) {
+		services, err := s.ListServices("app", false)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"app/prod", "app/prod/worker"}, services)
+
+		names, err := s.ListServices("app/prod/w", true)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"/app/prod/worker/queue"}, names)
+	})
+
+	t.Run("Should report the history of every version", func(t *testing.T) {
+		events, err := s.History(password)
+		assert.Nil(t, err)
+		assert.Len(t, events, 2)
+		assert.Equal(t, Created, events[0].Type)
+		assert.Equal(t, Updated, events[1].Type)
+		assert.Equal(t, 2, events[1].Version)
+		assert.Equal(t, "token-alice", events[1].User)
+	})
+
+	t.Run("Should delete secrets with all their versions", func(t *testing.T) {
+		id := SecretId{Service: "other", Key: "key"}
+		assert.Nil(t, s.Delete(id))
+		_, err := s.Read(id, -1)
+		assert.Equal(t, ErrSecretNotFound, err)
+		assert.Equal(t, ErrSecretNotFound, s.Delete(id))
+	})
+}
+
+func TestWithCreatedBy(t *testing.T) {
+	t.Run("Should forget the writers of old versions but keep other metadata", func(t *testing.T) {
+		custom := map[string]string{"owner": "team", "created_by_1": "alice", "created_by_20": "bob"}
+		assert.Equal(t, map[string]string{"owner": "team", "created_by_20": "bob", "created_by_33": "carol"}, withCreatedBy(custom, 33, "carol"))
+	})
+}
@@ -0,0 +1,54 @@
+package store
+
+import "fmt"
+
+// VerifyError is returned when a secret doesn't read back as it was written
+type VerifyError struct {
+	Id     SecretId
+	Reason string
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("%s/%s did not read back as written: %s", e.Id.Service, e.Id.Key, e.Reason)
+}
+
+// VerifyWrite reads back the secret id just written with value, both as the
+// latest version and by its version number, which backends look up with
+// different calls, and compares both with value. Written references are
+// compared with the value they refer to.
+func VerifyWrite(s Store, id SecretId, value string) error {
+	expected := value
+	if ref, ok, err := ParseReference(value); ok {
+		if err != nil {
+			return err
+		}
+		referenced, err := s.Read(ref, -1)
+		if err != nil {
+			return err
+		}
+		expected = *referenced.Value
+	}
+
+	latest, err := s.Read(id, -1)
+	if err == ErrSecretNotFound {
+		return &VerifyError{Id: id, Reason: "not found"}
+	}
+	if err != nil {
+		return err
+	}
+	if latest.Value == nil || *latest.Value != expected {
+		return &VerifyError{Id: id, Reason: "the latest version holds another value"}
+	}
+
+	versioned, err := s.Read(id, latest.Meta.Version)
+	if err == ErrSecretNotFound {
+		return &VerifyError{Id: id, Reason: fmt.Sprintf("version %d not found", latest.Meta.Version)}
+	}
+	if err != nil {
+		return err
+	}
+	if versioned.Value == nil || *versioned.Value != expected {
+		return &VerifyError{Id: id, Reason: fmt.Sprintf("version %d holds another value", latest.Meta.Version)}
+	}
+	return nil
+}
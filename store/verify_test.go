@@ -0,0 +1,44 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyWrite(t *testing.T) {
+	mock := &mockSSMClient{parameters: map[string]mockParameter{}}
+	s := NewReferenceStore(NewTestSSMStoreWithPaths(mock))
+	secretId := SecretId{Service: "app", Key: "key"}
+
+	t.Run("Should accept values that read back as written", func(t *testing.T) {
+		assert.Nil(t, s.Write(secretId, "value"))
+		assert.Nil(t, VerifyWrite(s, secretId, "value"))
+	})
+
+	t.Run("Should compare references with the value they refer to", func(t *testing.T) {
+		refId := SecretId{Service: "app", Key: "ref"}
+		assert.Nil(t, s.Write(refId, "chamber-ref://app/key"))
+		assert.Nil(t, VerifyWrite(s, refId, "chamber-ref://app/key"))
+	})
+
+	t.Run("Should fail when the value read back differs", func(t *testing.T) {
+		err := VerifyWrite(s, secretId, "other")
+		assert.IsType(t, &VerifyError{}, err)
+	})
+
+	t.Run("Should fail when the version read back differs", func(t *testing.T) {
+		for _, h := range mock.parameters["/app/key"].history {
+			h.Value = aws.String("stale")
+		}
+		err := VerifyWrite(s, secretId, "value")
+		assert.IsType(t, &VerifyError{}, err)
+		assert.Contains(t, err.Error(), "version 1")
+	})
+
+	t.Run("Should fail when the secret is missing", func(t *testing.T) {
+		err := VerifyWrite(s, SecretId{Service: "app", Key: "missing"}, "value")
+		assert.IsType(t, &VerifyError{}, err)
+	})
+}
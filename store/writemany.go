@@ -0,0 +1,37 @@
+package store
+
+import (
+	"sort"
+)
+
+// BatchWriter is implemented by stores that can write several keys of a
+// service for less than the cost of writing them one at a time
+type BatchWriter interface {
+	// WriteMany writes values, by key, to the keys of service, as Write
+	// would, in key order. When a write fails, the keys before it may have
+	// been written.
+	WriteMany(service string, values map[string]string) error
+}
+
+// WriteMany writes values, by key, to the keys of service, at once if s is a
+// BatchWriter, and otherwise one key at a time in key order
+func WriteMany(s Store, service string, values map[string]string) error {
+	if w, ok := s.(BatchWriter); ok {
+		return w.WriteMany(service, values)
+	}
+	for _, key := range sortedKeys(values) {
+		if err := s.Write(SecretId{Service: service, Key: key}, values[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,41 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// batchStore records the batches written to it
+type batchStore struct {
+	NullStore
+	batches map[string]map[string]string
+}
+
+func (s *batchStore) WriteMany(service string, values map[string]string) error {
+	s.batches[service] = values
+	return nil
+}
+
+func TestWriteMany(t *testing.T) {
+	values := map[string]string{"db_password": "hunter22", "db_username": "app"}
+
+	t.Run("Should write batches at once to stores that can", func(t *testing.T) {
+		s := &batchStore{batches: map[string]map[string]string{}}
+		assert.Nil(t, WriteMany(s, "app", values))
+		assert.Equal(t, map[string]map[string]string{"app": values}, s.batches)
+	})
+
+	t.Run("Should write batches through wrappers", func(t *testing.T) {
+		s := &batchStore{batches: map[string]map[string]string{}}
+		wrapped := NewScrubbingStore(NewProfileStore(NewNamespacedStore(s, "tenants/acme"), "dev"))
+		assert.Nil(t, WriteMany(wrapped, "app", values))
+		assert.Equal(t, map[string]map[string]string{"tenants/acme/app/_profile/dev": values}, s.batches)
+	})
+
+	t.Run("Should write one key at a time, in order, to other stores", func(t *testing.T) {
+		s := &recordingStore{}
+		assert.Nil(t, WriteMany(s, "app", values))
+		assert.Equal(t, []string{"app/db_password=hunter22", "app/db_username=app"}, s.writes)
+	})
+}
@@ -0,0 +1,215 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// DefaultWriterConcurrency is the number of services a Writer writes to
+	// at once unless configured otherwise
+	DefaultWriterConcurrency = 1
+
+	// DefaultWriterMaxPending is the number of queued writes at which Writer.Write
+	// blocks unless configured otherwise
+	DefaultWriterMaxPending = 100
+)
+
+// ErrWriterClosed is returned when writing to a closed Writer
+var ErrWriterClosed = errors.New("writer is closed")
+
+// WriterOptions configures a Writer
+type WriterOptions struct {
+	// Concurrency is the number of services written to at once; writes to
+	// one service are always made one at a time
+	Concurrency int
+
+	// MaxPending is the number of queued writes at which Write blocks until
+	// some of them are made
+	MaxPending int
+}
+
+// WriteError is returned by Writer.Flush for the writes that failed
+type WriteError struct {
+	Failed map[SecretId]error
+}
+
+func (e *WriteError) Error() string {
+	failed := make([]string, 0, len(e.Failed))
+	for id, err := range e.Failed {
+		failed = append(failed, fmt.Sprintf("%s/%s: %s", id.Service, id.Key, err))
+	}
+	sort.Strings(failed)
+	return fmt.Sprintf("failed to write %d secrets: %s", len(failed), strings.Join(failed, "; "))
+}
+
+// Writer queues writes to a store from any number of goroutines, so library
+// users making many writes don't have to throttle them by hand. Writes to a
+// service are made one at a time, in the order they were queued, and a write
+// to a key that is still queued replaces the queued value instead of being
+// made as well, so only the last of several quick writes becomes a version.
+// Write blocks while MaxPending writes are queued.
+type Writer struct {
+	store Store
+	opts  WriterOptions
+
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	// queued are the writes not yet started, by service; ready lists the
+	// services with queued writes that no worker is writing to, in the
+	// order their writes were queued
+	queued  map[string]*serviceWrites
+	ready   []string
+	busy    map[string]bool
+	pending int
+	started int
+	failed  map[SecretId]error
+	closed  bool
+
+	workers sync.WaitGroup
+}
+
+// serviceWrites are the queued writes of a service, in the order their keys
+// were first queued
+type serviceWrites struct {
+	keys   []string
+	values map[string]string
+}
+
+// NewWriter returns a Writer writing to s
+func NewWriter(s Store, opts WriterOptions) *Writer {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultWriterConcurrency
+	}
+	if opts.MaxPending <= 0 {
+		opts.MaxPending = DefaultWriterMaxPending
+	}
+	w := &Writer{
+		store:  s,
+		opts:   opts,
+		queued: map[string]*serviceWrites{},
+		busy:   map[string]bool{},
+		failed: map[SecretId]error{},
+	}
+	w.cond = sync.NewCond(&w.mu)
+	for i := 0; i < opts.Concurrency; i++ {
+		w.workers.Add(1)
+		go w.work()
+	}
+	return w
+}
+
+// Write queues writing value to id. Errors of the write itself are returned
+// by Flush or Close.
+func (w *Writer) Write(id SecretId, value string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for {
+		if w.closed {
+			return ErrWriterClosed
+		}
+		if sw, ok := w.queued[id.Service]; ok {
+			if _, ok := sw.values[id.Key]; ok {
+				sw.values[id.Key] = value
+				return nil
+			}
+		}
+		if w.pending < w.opts.MaxPending {
+			break
+		}
+		w.cond.Wait()
+	}
+
+	sw, ok := w.queued[id.Service]
+	if !ok {
+		sw = &serviceWrites{values: map[string]string{}}
+		w.queued[id.Service] = sw
+		if !w.busy[id.Service] {
+			w.ready = append(w.ready, id.Service)
+		}
+	}
+	sw.keys = append(sw.keys, id.Key)
+	sw.values[id.Key] = value
+	w.pending++
+	w.cond.Broadcast()
+	return nil
+}
+
+// Flush waits until all queued writes are made, and returns a *WriteError
+// for those that failed since the last Flush
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for w.pending > 0 || w.started > 0 {
+		w.cond.Wait()
+	}
+	if len(w.failed) == 0 {
+		return nil
+	}
+	err := &WriteError{Failed: w.failed}
+	w.failed = map[SecretId]error{}
+	return err
+}
+
+// Close flushes the writer and stops its workers. Writes after Close fail.
+func (w *Writer) Close() error {
+	err := w.Flush()
+
+	w.mu.Lock()
+	w.closed = true
+	w.cond.Broadcast()
+	w.mu.Unlock()
+
+	w.workers.Wait()
+	return err
+}
+
+func (w *Writer) work() {
+	defer w.workers.Done()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for {
+		for len(w.ready) == 0 && !w.closed {
+			w.cond.Wait()
+		}
+		if len(w.ready) == 0 {
+			return
+		}
+
+		service := w.ready[0]
+		w.ready = w.ready[1:]
+		sw := w.queued[service]
+		delete(w.queued, service)
+		w.busy[service] = true
+		w.pending -= len(sw.keys)
+		w.started += len(sw.keys)
+		w.cond.Broadcast()
+
+		w.mu.Unlock()
+		failed := map[string]error{}
+		for _, key := range sw.keys {
+			if err := w.store.Write(SecretId{Service: service, Key: key}, sw.values[key]); err != nil {
+				failed[key] = err
+			}
+		}
+		w.mu.Lock()
+
+		for key, err := range failed {
+			w.failed[SecretId{Service: service, Key: key}] = err
+		}
+		w.busy[service] = false
+		w.started -= len(sw.keys)
+		// writes queued meanwhile waited for these to keep their order
+		if _, ok := w.queued[service]; ok {
+			w.ready = append(w.ready, service)
+		}
+		w.cond.Broadcast()
+	}
+}
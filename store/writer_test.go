@@ -0,0 +1,88 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingStore records the writes made to it, failing those of failKey and
+// holding each write until gate lets it through, if set
+type recordingStore struct {
+	NullStore
+	mu      sync.Mutex
+	writes  []string
+	failKey string
+	gate    chan struct{}
+}
+
+func (s *recordingStore) Write(id SecretId, value string) error {
+	if s.gate != nil {
+		<-s.gate
+	}
+	if id.Key == s.failKey {
+		return errors.New("write failed")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writes = append(s.writes, fmt.Sprintf("%s/%s=%s", id.Service, id.Key, value))
+	return nil
+}
+
+func TestWriter(t *testing.T) {
+	t.Run("Should write in order per service", func(t *testing.T) {
+		s := &recordingStore{}
+		w := NewWriter(s, WriterOptions{})
+		for i := 0; i < 3; i++ {
+			assert.Nil(t, w.Write(SecretId{Service: "app", Key: fmt.Sprintf("k%d", i)}, "v"))
+		}
+		assert.Nil(t, w.Close())
+		assert.Equal(t, []string{"app/k0=v", "app/k1=v", "app/k2=v"}, s.writes)
+	})
+
+	t.Run("Should coalesce queued writes to a key", func(t *testing.T) {
+		s := &recordingStore{gate: make(chan struct{})}
+		w := NewWriter(s, WriterOptions{})
+		assert.Nil(t, w.Write(SecretId{Service: "app", Key: "a"}, "1"))
+		// wait for the worker to start writing a=1
+		s.gate <- struct{}{}
+		for _, v := range []string{"2", "3", "4"} {
+			assert.Nil(t, w.Write(SecretId{Service: "app", Key: "a"}, v))
+		}
+		close(s.gate)
+		assert.Nil(t, w.Close())
+		assert.Equal(t, []string{"app/a=1", "app/a=4"}, s.writes)
+	})
+
+	t.Run("Should report failed writes on flush", func(t *testing.T) {
+		s := &recordingStore{failKey: "bad"}
+		w := NewWriter(s, WriterOptions{Concurrency: 4})
+		assert.Nil(t, w.Write(SecretId{Service: "app", Key: "bad"}, "v"))
+		assert.Nil(t, w.Write(SecretId{Service: "other", Key: "good"}, "v"))
+		err := w.Flush()
+		assert.IsType(t, &WriteError{}, err)
+		assert.Contains(t, err.Error(), "app/bad")
+		assert.Equal(t, []string{"other/good=v"}, s.writes)
+		assert.Nil(t, w.Close())
+	})
+
+	t.Run("Should make the writes of concurrent writers", func(t *testing.T) {
+		s := &recordingStore{}
+		w := NewWriter(s, WriterOptions{Concurrency: 2, MaxPending: 3})
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				assert.Nil(t, w.Write(SecretId{Service: fmt.Sprintf("svc%d", i%3), Key: fmt.Sprintf("k%d", i)}, "v"))
+			}(i)
+		}
+		wg.Wait()
+		assert.Nil(t, w.Close())
+		assert.Len(t, s.writes, 10)
+		assert.Equal(t, ErrWriterClosed, w.Write(SecretId{Service: "app", Key: "late"}, "v"))
+	})
+}